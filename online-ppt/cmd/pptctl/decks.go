@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"online-ppt/internal/records"
+)
+
+const exportPageSize = 100
+
+// exportDecks writes every deck the user owns to <dir>/<name>.zip, in the
+// format the export endpoint produces.
+func exportDecks(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("decks export", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the deck owner")
+	dir := fs.String("dir", "", "directory receiving the archives")
+	if err := env.parse(ctx, fs, args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return errors.New("-dir is required")
+	}
+
+	user, err := env.lookupUser(ctx, *email)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return fmt.Errorf("create export dir: %w", err)
+	}
+
+	exported := 0
+	for offset := 0; ; offset += exportPageSize {
+		page, err := env.records.ListRecords(ctx, records.ListParams{
+			UserID: user.ID,
+			Filters: records.ListFilters{
+				Scope:  records.ListScopeOwned,
+				Limit:  exportPageSize,
+				Offset: offset,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, view := range page.Records {
+			target := records.SlideTarget{UserID: user.ID, UserUUID: user.UUID, RecordID: view.Record.ID}
+			file, err := exportDeck(ctx, env, target, *dir)
+			if err != nil {
+				return fmt.Errorf("export record %d: %w", view.Record.ID, err)
+			}
+			fmt.Println(file)
+			exported++
+		}
+
+		if len(page.Records) < exportPageSize {
+			break
+		}
+	}
+
+	fmt.Printf("exported %d decks\n", exported)
+	return nil
+}
+
+func exportDeck(ctx context.Context, env *env, target records.SlideTarget, dir string) (string, error) {
+	export, err := env.records.PrepareExport(ctx, target, records.ExportFormatZip)
+	if err != nil {
+		return "", err
+	}
+
+	file := filepath.Join(dir, fmt.Sprintf("%d-%s.zip", target.RecordID, export.Name))
+	out, err := os.Create(file)
+	if err != nil {
+		return "", err
+	}
+	if err := export.WriteZip(out); err != nil {
+		out.Close()
+		return "", err
+	}
+	return file, out.Close()
+}
+
+// importDecks creates a deck for the user from every *.zip file in the
+// directory. Each deck is named after the folder inside its archive.
+func importDecks(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("decks import", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the new deck owner")
+	dir := fs.String("dir", "", "directory holding the archives")
+	if err := env.parse(ctx, fs, args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return errors.New("-dir is required")
+	}
+
+	user, err := env.lookupUser(ctx, *email)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(*dir)
+	if err != nil {
+		return fmt.Errorf("read import dir: %w", err)
+	}
+
+	imported := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".zip") {
+			continue
+		}
+		file := filepath.Join(*dir, entry.Name())
+		view, err := importDeck(ctx, env, records.CreateParams{UserID: user.ID, UserUUID: user.UUID}, file)
+		if err != nil {
+			return fmt.Errorf("import %s: %w", entry.Name(), err)
+		}
+		fmt.Printf("%s -> record %d (%s)\n", entry.Name(), view.Record.ID, view.Record.GroupName)
+		imported++
+	}
+
+	fmt.Printf("imported %d decks\n", imported)
+	return nil
+}
+
+func importDeck(ctx context.Context, env *env, params records.CreateParams, file string) (records.RecordView, error) {
+	archive, err := os.Open(file)
+	if err != nil {
+		return records.RecordView{}, err
+	}
+	defer archive.Close()
+
+	info, err := archive.Stat()
+	if err != nil {
+		return records.RecordView{}, err
+	}
+	return env.records.ImportRecord(ctx, params, archive, info.Size())
+}
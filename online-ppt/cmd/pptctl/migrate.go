@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"online-ppt/internal/storage"
+)
+
+// migrateUp applies the pending migrations, as the server does on start.
+func migrateUp(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print the pending migrations without applying them")
+	if err := env.parse(ctx, fs, args); err != nil {
+		return err
+	}
+
+	migrator := storage.Migrator{Dialect: env.dialect, DryRun: *dryRun}
+	applied, err := migrator.Apply(ctx, env.db)
+	printMigrations("apply", applied, *dryRun, func(m storage.Migration) []string { return m.Up })
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d migrations %s\n", len(applied), pastTense("applied", *dryRun))
+	return nil
+}
+
+// migrateDown rolls back the most recent migrations.
+func migrateDown(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+	steps := fs.Int("steps", 1, "number of migrations to roll back")
+	dryRun := fs.Bool("dry-run", false, "print the migrations to roll back without running them")
+	if err := env.parse(ctx, fs, args); err != nil {
+		return err
+	}
+
+	migrator := storage.Migrator{Dialect: env.dialect, DryRun: *dryRun}
+	reverted, err := migrator.Rollback(ctx, env.db, *steps)
+	printMigrations("roll back", reverted, *dryRun, func(m storage.Migration) []string { return m.Down })
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d migrations %s\n", len(reverted), pastTense("rolled back", *dryRun))
+	return nil
+}
+
+// migrateBaseline marks the migrations up to -version as applied without
+// running them, for databases created before the schema_migrations ledger.
+func migrateBaseline(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("migrate baseline", flag.ContinueOnError)
+	version := fs.Int64("version", 0, "last migration already present in the database")
+	dryRun := fs.Bool("dry-run", false, "print the migrations to mark without recording them")
+	if err := env.parse(ctx, fs, args); err != nil {
+		return err
+	}
+	if *version <= 0 {
+		return fmt.Errorf("-version is required")
+	}
+
+	migrator := storage.Migrator{Dialect: env.dialect, DryRun: *dryRun}
+	marked, err := migrator.Baseline(ctx, env.db, *version)
+	printMigrations("mark", marked, *dryRun, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d migrations %s\n", len(marked), pastTense("marked as applied", *dryRun))
+	return nil
+}
+
+// migrateStatus lists every migration and when it was applied.
+func migrateStatus(ctx context.Context, env *env, args []string) error {
+	if err := env.parse(ctx, flag.NewFlagSet("migrate status", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+
+	statuses, err := storage.Migrator{Dialect: env.dialect}.Status(ctx, env.db)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt.Valid {
+			applied = status.AppliedAt.Time.Local().Format(timeLayout)
+		}
+		if status.Modified {
+			applied += " (modified since)"
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\n", status.Version, status.Name, applied)
+	}
+	return w.Flush()
+}
+
+// printMigrations lists the migrations an action touched. In dry-run mode the
+// statements are printed too, when stmts is given.
+func printMigrations(action string, migrations []storage.Migration, dryRun bool, stmts func(storage.Migration) []string) {
+	for _, migration := range migrations {
+		if !dryRun {
+			fmt.Printf("%03d_%s\n", migration.Version, migration.Name)
+			continue
+		}
+		fmt.Printf("-- would %s %03d_%s\n", action, migration.Version, migration.Name)
+		if stmts == nil {
+			continue
+		}
+		for _, stmt := range stmts(migration) {
+			fmt.Println(strings.TrimSpace(stmt) + ";")
+		}
+	}
+}
+
+func pastTense(done string, dryRun bool) string {
+	if dryRun {
+		return "would be " + done
+	}
+	return done
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"online-ppt/internal/records"
+)
+
+// verifyPaths reports decks whose stored location is wrong.
+func verifyPaths(ctx context.Context, env *env, args []string) error {
+	if err := env.parse(ctx, flag.NewFlagSet("paths verify", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+
+	issues, err := env.records.VerifyPaths(ctx)
+	if err != nil {
+		return err
+	}
+	return printPathIssues(issues)
+}
+
+// reindexPaths repairs the decks verifyPaths reports.
+func reindexPaths(ctx context.Context, env *env, args []string) error {
+	if err := env.parse(ctx, flag.NewFlagSet("paths reindex", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+
+	issues, err := env.records.ReindexPaths(ctx)
+	if err != nil {
+		return err
+	}
+	if err := printPathIssues(issues); err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		if !issue.Fixed {
+			return fmt.Errorf("some decks could not be repaired")
+		}
+	}
+	return nil
+}
+
+func printPathIssues(issues []records.PathIssue) error {
+	if len(issues) == 0 {
+		fmt.Println("all deck paths are consistent")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RECORD\tUSER\tPROBLEM\tSTORED\tEXPECTED\tFIXED\tERROR")
+	for _, issue := range issues {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%t\t%s\n",
+			issue.RecordID,
+			issue.UserID,
+			issue.Problem,
+			issue.Stored.Relative,
+			issue.Expected.Relative,
+			issue.Fixed,
+			issue.Error,
+		)
+	}
+	return w.Flush()
+}
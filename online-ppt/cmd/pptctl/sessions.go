@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// listSessions prints a user's active sessions, newest first.
+func listSessions(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("sessions list", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the account")
+	if err := env.parse(ctx, fs, args); err != nil {
+		return err
+	}
+
+	user, err := env.lookupUser(ctx, *email)
+	if err != nil {
+		return err
+	}
+	sessions, err := env.auth.ListSessions(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tISSUED\tEXPIRES\tIP\tUSER AGENT")
+	for _, session := range sessions {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+			session.ID,
+			session.IssuedAt.Local().Format(timeLayout),
+			session.ExpiresAt.Local().Format(timeLayout),
+			session.IPAddress.String,
+			session.UserAgent.String,
+		)
+	}
+	return w.Flush()
+}
+
+// revokeSessions signs out one session, or every session of the user when
+// -session is omitted.
+func revokeSessions(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("sessions revoke", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the account")
+	sessionID := fs.Int64("session", 0, "session id to revoke; all sessions when omitted")
+	if err := env.parse(ctx, fs, args); err != nil {
+		return err
+	}
+
+	user, err := env.lookupUser(ctx, *email)
+	if err != nil {
+		return err
+	}
+
+	if *sessionID != 0 {
+		if err := env.auth.RevokeUserSession(ctx, user.ID, *sessionID); err != nil {
+			return err
+		}
+		fmt.Printf("revoked session %d of user %d\n", *sessionID, user.ID)
+		return nil
+	}
+
+	revoked, err := env.auth.RevokeAllSessions(ctx, cliAdminID, user.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("revoked %d sessions of user %d\n", revoked, user.ID)
+	return nil
+}
+
+// purgeSessions deletes session rows that expired more than -grace ago.
+func purgeSessions(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("sessions purge", flag.ContinueOnError)
+	grace := fs.Duration("grace", 0, "keep sessions that expired less than this long ago")
+	if err := env.parse(ctx, fs, args); err != nil {
+		return err
+	}
+	if *grace < 0 {
+		return fmt.Errorf("-grace must not be negative")
+	}
+
+	purged, err := env.authRepo.PurgeExpiredSessions(ctx, time.Now().Add(-*grace))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("purged %d expired sessions\n", purged)
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"online-ppt/internal/auth"
+)
+
+// createUser adds an active account. Without -password the password is read
+// from the first line of stdin so it stays out of the shell history.
+func createUser(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("users create", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the new account")
+	password := fs.String("password", "", "password; read from stdin when omitted")
+	role := fs.String("role", auth.RoleUser, "account role: user or admin")
+	if err := env.parse(ctx, fs, args); err != nil {
+		return err
+	}
+	if *role != auth.RoleUser && *role != auth.RoleAdmin {
+		return auth.ErrInvalidUserRole
+	}
+
+	if *password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("read password from stdin: %w", err)
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	user, err := env.auth.Register(ctx, *email, *password)
+	if err != nil {
+		return err
+	}
+	if *role != auth.RoleUser {
+		if user, err = env.auth.SetUserRole(ctx, cliAdminID, user.ID, *role); err != nil {
+			return fmt.Errorf("user %d created but role not set: %w", user.ID, err)
+		}
+	}
+
+	fmt.Printf("created user %d (%s) role=%s uuid=%s\n", user.ID, user.Email, user.Role, user.UUID)
+	return nil
+}
+
+// setUserRole grants or removes administrator rights.
+func setUserRole(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("users set-role", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the account")
+	role := fs.String("role", "", "account role: user or admin")
+	if err := env.parse(ctx, fs, args); err != nil {
+		return err
+	}
+	if *role == "" {
+		return errors.New("-role is required")
+	}
+
+	user, err := env.lookupUser(ctx, *email)
+	if err != nil {
+		return err
+	}
+	if user, err = env.auth.SetUserRole(ctx, cliAdminID, user.ID, *role); err != nil {
+		return err
+	}
+
+	fmt.Printf("user %d (%s) role=%s\n", user.ID, user.Email, user.Role)
+	return nil
+}
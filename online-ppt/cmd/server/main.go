@@ -7,6 +7,7 @@ import (
 	"log"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 
@@ -30,23 +31,32 @@ func main() {
 		log.Fatalf("load config: %v", err)
 	}
 
-	db, err := storage.MySQLFactory{DSN: cfg.Storage.DSN}.Open(ctx)
+	db, dialect, err := storage.Open(ctx, cfg.Storage.Driver, cfg.Storage.DSN)
 	if err != nil {
-		log.Fatalf("connect mysql: %v", err)
+		log.Fatalf("connect database: %v", err)
 	}
 	defer db.Close()
 
-	migrator := storage.Migrator{}
-	if err := migrator.Apply(ctx, db); err != nil {
+	applied, err := storage.Migrator{Dialect: dialect}.Apply(ctx, db)
+	if err != nil {
 		log.Fatalf("apply migrations: %v", err)
 	}
+	for _, migration := range applied {
+		log.Printf("applied migration %03d_%s", migration.Version, migration.Name)
+	}
 
 	authRepo, err := auth.NewRepository(db)
 	if err != nil {
 		log.Fatalf("init auth repository: %v", err)
 	}
+	authRepo.WithDialect(dialect)
+
+	signingKeys, err := auth.LoadSigningKeys(cfg.Security)
+	if err != nil {
+		log.Fatalf("load signing keys: %v", err)
+	}
 
-	tokenManager, err := auth.NewTokenManager(cfg.Security.JWTSecret, cfg.Security.AccessTokenTTL, cfg.Security.RefreshTokenTTL)
+	tokenManager, err := auth.NewTokenManagerWithKeys(signingKeys, cfg.Security.AccessTokenTTL, cfg.Security.RefreshTokenTTL)
 	if err != nil {
 		log.Fatalf("init token manager: %v", err)
 	}
@@ -88,22 +98,58 @@ func main() {
 		log.Fatalf("init auth service: %v", err)
 	}
 
+	if err := authService.ConfigurePasswordReset(cfg.Server.PublicURL+"/reset-password", 0); err != nil {
+		log.Fatalf("configure password reset: %v", err)
+	}
+
+	if err := authService.ConfigureLoginThrottle(cfg.Server.PublicURL+"/unlock-account", auth.LoginThrottle{}); err != nil {
+		log.Fatalf("configure login throttle: %v", err)
+	}
+
+	identityProviders := make([]auth.IdentityProvider, 0, len(cfg.OIDC))
+	for _, providerCfg := range cfg.OIDC {
+		provider, err := auth.NewOIDCProvider(auth.OIDCProviderOptions{
+			Name:         providerCfg.Name,
+			Issuer:       providerCfg.Issuer,
+			ClientID:     providerCfg.ClientID,
+			ClientSecret: providerCfg.ClientSecret,
+			Scopes:       providerCfg.Scopes,
+		})
+		if err != nil {
+			log.Fatalf("init oidc provider: %v", err)
+		}
+		identityProviders = append(identityProviders, provider)
+	}
+	if err := authService.ConfigureIdentityProviders(cfg.Server.PublicURL+"/api/v1/auth/oidc", identityProviders...); err != nil {
+		log.Fatalf("configure identity providers: %v", err)
+	}
+
 	authHandler := handlers.NewAuthHandler(authService, cfg)
 
 	recordsRepo, err := records.NewRepository(db)
 	if err != nil {
 		log.Fatalf("init records repository: %v", err)
 	}
+	recordsRepo.WithDialect(dialect)
 
 	recordsService, err := records.NewService(recordsRepo, cfg.Paths.PresentationsRoot, auditLogger)
 	if err != nil {
 		log.Fatalf("init records service: %v", err)
 	}
 
-	recordsHandler := handlers.NewRecordsHandler(recordsService, tokenManager)
+	if err := recordsService.ConfigureTrash(cfg.Paths.TrashRoot, cfg.Paths.TrashRetention); err != nil {
+		log.Fatalf("configure records trash: %v", err)
+	}
+	if err := recordsService.ConfigureShareThrottle(cacheService, records.ShareThrottle{}); err != nil {
+		log.Fatalf("configure share throttle: %v", err)
+	}
+	go recordsService.RunTrashPurger(ctx, time.Hour)
+
+	recordsHandler := handlers.NewRecordsHandler(recordsService, tokenManager).WithAPITokens(authService)
 	router := internalhttp.NewRouter(cfg)
 	internalhttp.RegisterAuthRoutes(router, authHandler)
 	internalhttp.RegisterRecordRoutes(router, recordsHandler)
+	internalhttp.RegisterAdminRoutes(router, handlers.NewAdminHandler(authService, recordsService))
 
 	if err := internalhttp.RunServer(ctx, cfg, router); err != nil {
 		if errors.Is(err, context.Canceled) {
@@ -0,0 +1,269 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Authenticate validates an access token and returns its claims.
+func (s *Service) Authenticate(accessToken string) (*Claims, error) {
+	return s.tokens.ParseAccessToken(accessToken)
+}
+
+// JWKS returns the public keys that verify our access tokens.
+func (s *Service) JWKS() JSONWebKeySet {
+	return s.tokens.JWKS()
+}
+
+// ChangePassword replaces the password of a signed-in user after checking the
+// current one. Every other session is revoked; the session owning refreshToken
+// (if any) stays signed in.
+func (s *Service) ChangePassword(ctx context.Context, userID int64, currentPassword, newPassword, refreshToken string) error {
+	if err := validatePassword(newPassword); err != nil {
+		s.audit.Log("account.password.change", map[string]any{
+			"status": "validation_failed",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return err
+	}
+
+	user, err := s.verifyCurrentPassword(ctx, "account.password.change", userID, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	hash, err := HashPassword(newPassword)
+	if err != nil {
+		s.audit.Log("account.password.change", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return err
+	}
+
+	keepSessionID := s.currentSessionID(ctx, user.ID, refreshToken)
+	var revoked int64
+	err = s.repo.WithTx(ctx, func(tx *sql.Tx) error {
+		rt := NewRepositoryTx(tx, s.repo.dialect)
+		if err := rt.UpdatePasswordTx(ctx, user.ID, hash); err != nil {
+			return err
+		}
+		count, err := rt.RevokeOtherSessionsTx(ctx, user.ID, keepSessionID, s.clockFn())
+		if err != nil {
+			return err
+		}
+		revoked = count
+		return nil
+	})
+	if err != nil {
+		s.audit.Log("account.password.change", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return err
+	}
+
+	s.audit.Log("account.password.change", map[string]any{
+		"status":          "success",
+		"userId":          user.ID,
+		"revokedSessions": revoked,
+	})
+	return nil
+}
+
+// RequestEmailChange checks the current password and sends a verification code
+// to the new address through SendVerificationCode. The email is only swapped
+// by ConfirmEmailChange.
+func (s *Service) RequestEmailChange(ctx context.Context, userID int64, password, newEmail, captchaID, captchaCode string) (int, error) {
+	normalized, err := normalizeEmail(newEmail)
+	if err != nil {
+		s.audit.Log("account.email.request", map[string]any{
+			"status": "validation_failed",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return 0, err
+	}
+
+	user, err := s.verifyCurrentPassword(ctx, "account.email.request", userID, password)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.ensureEmailAvailable(ctx, "account.email.request", user, normalized); err != nil {
+		return 0, err
+	}
+
+	expiresIn, err := s.SendVerificationCode(ctx, normalized, captchaID, captchaCode)
+	if err != nil {
+		s.audit.Log("account.email.request", map[string]any{
+			"status": "failed",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return 0, err
+	}
+
+	s.audit.Log("account.email.request", map[string]any{
+		"status": "success",
+		"userId": user.ID,
+		"email":  normalized,
+	})
+	return expiresIn, nil
+}
+
+// ConfirmEmailChange swaps the user's email once the code sent to the new
+// address is confirmed, then revokes every other session.
+func (s *Service) ConfirmEmailChange(ctx context.Context, userID int64, newEmail, emailCode, refreshToken string) (UserAccount, error) {
+	normalized, err := normalizeEmail(newEmail)
+	if err != nil {
+		s.audit.Log("account.email.change", map[string]any{
+			"status": "validation_failed",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return UserAccount{}, err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		s.audit.Log("account.email.change", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return UserAccount{}, err
+	}
+	if user.Email == normalized {
+		s.audit.Log("account.email.change", map[string]any{
+			"status": "validation_failed",
+			"userId": user.ID,
+			"reason": ErrEmailUnchanged.Error(),
+		})
+		return UserAccount{}, ErrEmailUnchanged
+	}
+
+	if err := s.consumeEmailCode(ctx, "account.email.change", normalized, emailCode); err != nil {
+		return UserAccount{}, err
+	}
+
+	keepSessionID := s.currentSessionID(ctx, user.ID, refreshToken)
+	var revoked int64
+	err = s.repo.WithTx(ctx, func(tx *sql.Tx) error {
+		rt := NewRepositoryTx(tx, s.repo.dialect)
+		if err := rt.UpdateEmailTx(ctx, user.ID, normalized); err != nil {
+			return err
+		}
+		count, err := rt.RevokeOtherSessionsTx(ctx, user.ID, keepSessionID, s.clockFn())
+		if err != nil {
+			return err
+		}
+		revoked = count
+		return nil
+	})
+	if err != nil {
+		if s.repo.dialect.IsUniqueViolation(err) {
+			s.audit.Log("account.email.change", map[string]any{
+				"status": "conflict",
+				"userId": user.ID,
+				"reason": ErrEmailAlreadyRegistered.Error(),
+			})
+			return UserAccount{}, ErrEmailAlreadyRegistered
+		}
+		s.audit.Log("account.email.change", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return UserAccount{}, err
+	}
+
+	s.audit.Log("account.email.change", map[string]any{
+		"status":          "success",
+		"userId":          user.ID,
+		"previousEmail":   user.Email,
+		"email":           normalized,
+		"revokedSessions": revoked,
+	})
+
+	user.Email = normalized
+	return user, nil
+}
+
+func (s *Service) verifyCurrentPassword(ctx context.Context, event string, userID int64, password string) (UserAccount, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		s.audit.Log(event, map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return UserAccount{}, err
+	}
+
+	match, err := VerifyPassword(user.PasswordHash, password)
+	if err != nil {
+		s.audit.Log(event, map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return UserAccount{}, err
+	}
+	if !match {
+		s.audit.Log(event, map[string]any{
+			"status": "invalid_credentials",
+			"userId": user.ID,
+		})
+		return UserAccount{}, ErrIncorrectPassword
+	}
+	return user, nil
+}
+
+func (s *Service) ensureEmailAvailable(ctx context.Context, event string, user UserAccount, email string) error {
+	if user.Email == email {
+		s.audit.Log(event, map[string]any{
+			"status": "validation_failed",
+			"userId": user.ID,
+			"reason": ErrEmailUnchanged.Error(),
+		})
+		return ErrEmailUnchanged
+	}
+
+	_, err := s.repo.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		s.audit.Log(event, map[string]any{
+			"status": "conflict",
+			"userId": user.ID,
+			"reason": ErrEmailAlreadyRegistered.Error(),
+		})
+		return ErrEmailAlreadyRegistered
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	default:
+		s.audit.Log(event, map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return fmt.Errorf("check email availability: %w", err)
+	}
+}
+
+// currentSessionID resolves the caller's own session from its refresh token so
+// it can survive a "revoke other sessions" sweep. It returns 0 when unknown.
+func (s *Service) currentSessionID(ctx context.Context, userID int64, refreshToken string) int64 {
+	if refreshToken == "" {
+		return 0
+	}
+	session, err := s.repo.FindActiveSession(ctx, HashRefreshToken(refreshToken))
+	if err != nil || session.UserID != userID {
+		return 0
+	}
+	return session.ID
+}
@@ -0,0 +1,433 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultUserListLimit = 50
+	maxUserListLimit     = 100
+)
+
+// UserFilter narrows an admin user listing. Query matches part of the email.
+type UserFilter struct {
+	Query  string
+	Status string
+	Role   string
+	Limit  int
+	Offset int
+}
+
+// UserList is one page of an admin user listing.
+type UserList struct {
+	Users  []UserAccount
+	Total  int
+	Limit  int
+	Offset int
+}
+
+// ListUsers searches user accounts for an administrator.
+func (s *Service) ListUsers(ctx context.Context, adminID int64, filter UserFilter) (UserList, error) {
+	normalized, err := normalizeUserFilter(filter)
+	if err != nil {
+		s.audit.Log("auth.admin.users.list", map[string]any{
+			"status":  "validation_failed",
+			"adminId": adminID,
+			"reason":  err.Error(),
+		})
+		return UserList{}, err
+	}
+
+	users, total, err := s.repo.ListUsers(ctx, normalized)
+	if err != nil {
+		s.audit.Log("auth.admin.users.list", map[string]any{
+			"status":  "error",
+			"adminId": adminID,
+			"reason":  err.Error(),
+		})
+		return UserList{}, err
+	}
+
+	s.audit.Log("auth.admin.users.list", map[string]any{
+		"status":  "success",
+		"adminId": adminID,
+		"query":   normalized.Query,
+		"total":   total,
+	})
+	return UserList{Users: users, Total: total, Limit: normalized.Limit, Offset: normalized.Offset}, nil
+}
+
+// GetUser loads a single account for an administrator.
+func (s *Service) GetUser(ctx context.Context, adminID, userID int64) (UserAccount, error) {
+	return s.adminLoadUser(ctx, "auth.admin.users.get", adminID, userID)
+}
+
+// FindUser looks up an account by email for an administrator.
+func (s *Service) FindUser(ctx context.Context, adminID int64, email string) (UserAccount, error) {
+	normalized, err := normalizeEmail(email)
+	if err != nil {
+		return UserAccount{}, err
+	}
+	user, err := s.repo.GetUserByEmail(ctx, normalized)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("auth.admin.users.get", map[string]any{
+				"status":  "not_found",
+				"adminId": adminID,
+				"email":   normalized,
+			})
+			return UserAccount{}, ErrUserNotFound
+		}
+		return UserAccount{}, err
+	}
+	return user, nil
+}
+
+// SetUserRole grants or removes administrator rights. Access tokens already
+// issued keep their role until they expire.
+func (s *Service) SetUserRole(ctx context.Context, adminID, userID int64, role string) (UserAccount, error) {
+	const event = "auth.admin.users.role"
+
+	if role != RoleUser && role != RoleAdmin {
+		s.audit.Log(event, map[string]any{
+			"status":  "validation_failed",
+			"adminId": adminID,
+			"userId":  userID,
+			"reason":  ErrInvalidUserRole.Error(),
+		})
+		return UserAccount{}, ErrInvalidUserRole
+	}
+	if role != RoleAdmin {
+		if err := s.ensureNotSelf(event, adminID, userID); err != nil {
+			return UserAccount{}, err
+		}
+	}
+
+	user, err := s.adminLoadUser(ctx, event, adminID, userID)
+	if err != nil {
+		return UserAccount{}, err
+	}
+	if err := s.repo.SetUserRole(ctx, user.ID, role); err != nil {
+		s.audit.Log(event, map[string]any{
+			"status":  "error",
+			"adminId": adminID,
+			"userId":  user.ID,
+			"reason":  err.Error(),
+		})
+		return UserAccount{}, err
+	}
+
+	s.audit.Log(event, map[string]any{
+		"status":  "success",
+		"adminId": adminID,
+		"userId":  user.ID,
+		"from":    user.Role,
+		"to":      role,
+	})
+	user.Role = role
+	return user, nil
+}
+
+// SetUserStatus activates, locks or resets an account to pending. A lock set
+// here has no expiry; locking or resetting to pending signs the user out of
+// every session.
+func (s *Service) SetUserStatus(ctx context.Context, adminID, userID int64, status string) (UserAccount, error) {
+	const event = "auth.admin.users.status"
+
+	switch status {
+	case userStatusActive, userStatusLocked, userStatusPending:
+	default:
+		s.audit.Log(event, map[string]any{
+			"status":  "validation_failed",
+			"adminId": adminID,
+			"userId":  userID,
+			"reason":  ErrInvalidUserStatus.Error(),
+		})
+		return UserAccount{}, ErrInvalidUserStatus
+	}
+	if err := s.ensureNotSelf(event, adminID, userID); err != nil {
+		return UserAccount{}, err
+	}
+
+	user, err := s.adminLoadUser(ctx, event, adminID, userID)
+	if err != nil {
+		return UserAccount{}, err
+	}
+
+	var revoked int64
+	err = s.repo.WithTx(ctx, func(tx *sql.Tx) error {
+		rt := NewRepositoryTx(tx, s.repo.dialect)
+		if err := rt.SetUserStatusTx(ctx, user.ID, status); err != nil {
+			return err
+		}
+		if status == userStatusActive {
+			return nil
+		}
+		count, err := rt.RevokeUserSessionsTx(ctx, user.ID, s.clockFn())
+		if err != nil {
+			return err
+		}
+		revoked = count
+		return nil
+	})
+	if err != nil {
+		s.audit.Log(event, map[string]any{
+			"status":  "error",
+			"adminId": adminID,
+			"userId":  user.ID,
+			"reason":  err.Error(),
+		})
+		return UserAccount{}, err
+	}
+
+	// 解锁时一并清除失败计数，避免用户下次登录立即被再次锁定
+	if status == userStatusActive {
+		s.clearLoginFailures(ctx, fmt.Sprintf(loginAccountKey, user.Email))
+	}
+
+	s.audit.Log(event, map[string]any{
+		"status":          "success",
+		"adminId":         adminID,
+		"userId":          user.ID,
+		"from":            user.Status,
+		"to":              status,
+		"revokedSessions": revoked,
+	})
+	user.Status = status
+	user.LockedUntil = sql.NullTime{}
+	return user, nil
+}
+
+// ForcePasswordReset invalidates the user's password, signs them out
+// everywhere and emails a reset link. It returns the link lifetime in seconds.
+func (s *Service) ForcePasswordReset(ctx context.Context, adminID, userID int64) (int, error) {
+	const event = "auth.admin.users.password_reset"
+
+	user, err := s.adminLoadUser(ctx, event, adminID, userID)
+	if err != nil {
+		return 0, err
+	}
+	// 重置链接只对正常状态的账号有效
+	if user.Status != resetEligibleStatus {
+		s.audit.Log(event, map[string]any{
+			"status":  "ineligible",
+			"adminId": adminID,
+			"userId":  user.ID,
+			"reason":  "account " + user.Status,
+		})
+		if user.Status == userStatusPending {
+			return 0, ErrAccountPending
+		}
+		return 0, ErrAccountLocked
+	}
+
+	// 旧密码立即失效：替换为无人知晓的随机密码
+	secret, err := randomTokens(1)
+	if err != nil {
+		return 0, err
+	}
+	hash, err := HashPassword(secret[0])
+	if err != nil {
+		return 0, err
+	}
+
+	var revoked int64
+	err = s.repo.WithTx(ctx, func(tx *sql.Tx) error {
+		rt := NewRepositoryTx(tx, s.repo.dialect)
+		if err := rt.UpdatePasswordTx(ctx, user.ID, hash); err != nil {
+			return err
+		}
+		count, err := rt.RevokeUserSessionsTx(ctx, user.ID, s.clockFn())
+		if err != nil {
+			return err
+		}
+		revoked = count
+		return nil
+	})
+	if err != nil {
+		s.audit.Log(event, map[string]any{
+			"status":  "error",
+			"adminId": adminID,
+			"userId":  user.ID,
+			"reason":  err.Error(),
+		})
+		return 0, err
+	}
+
+	if err := s.sendPasswordResetLink(ctx, user); err != nil {
+		s.audit.Log(event, map[string]any{
+			"status":  "error",
+			"adminId": adminID,
+			"userId":  user.ID,
+			"reason":  err.Error(),
+		})
+		return 0, err
+	}
+
+	s.audit.Log(event, map[string]any{
+		"status":          "success",
+		"adminId":         adminID,
+		"userId":          user.ID,
+		"revokedSessions": revoked,
+	})
+	return int(s.resetTokenTTL.Seconds()), nil
+}
+
+// RevokeAllSessions signs the user out of every device and returns how many
+// sessions were revoked. Access tokens already issued stay valid until expiry.
+func (s *Service) RevokeAllSessions(ctx context.Context, adminID, userID int64) (int, error) {
+	const event = "auth.admin.users.revoke_sessions"
+
+	user, err := s.adminLoadUser(ctx, event, adminID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var revoked int64
+	err = s.repo.WithTx(ctx, func(tx *sql.Tx) error {
+		count, err := NewRepositoryTx(tx, s.repo.dialect).RevokeUserSessionsTx(ctx, user.ID, s.clockFn())
+		revoked = count
+		return err
+	})
+	if err != nil {
+		s.audit.Log(event, map[string]any{
+			"status":  "error",
+			"adminId": adminID,
+			"userId":  user.ID,
+			"reason":  err.Error(),
+		})
+		return 0, err
+	}
+
+	s.audit.Log(event, map[string]any{
+		"status":          "success",
+		"adminId":         adminID,
+		"userId":          user.ID,
+		"revokedSessions": revoked,
+	})
+	return int(revoked), nil
+}
+
+// DeleteUser permanently removes an account and everything stored for it in
+// the database. cleanup, when set, runs once the deletion has committed to
+// remove data kept elsewhere (deck directories); a cleanup failure is audited
+// but does not bring the account back.
+func (s *Service) DeleteUser(ctx context.Context, adminID, userID int64, cleanup func(ctx context.Context, user UserAccount) error) error {
+	const event = "auth.admin.users.delete"
+
+	if err := s.ensureNotSelf(event, adminID, userID); err != nil {
+		return err
+	}
+
+	user, err := s.adminLoadUser(ctx, event, adminID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteUser(ctx, user.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log(event, map[string]any{
+				"status":  "not_found",
+				"adminId": adminID,
+				"userId":  user.ID,
+			})
+			return ErrUserNotFound
+		}
+		s.audit.Log(event, map[string]any{
+			"status":  "error",
+			"adminId": adminID,
+			"userId":  user.ID,
+			"reason":  err.Error(),
+		})
+		return err
+	}
+
+	s.audit.Log(event, map[string]any{
+		"status":  "success",
+		"adminId": adminID,
+		"userId":  user.ID,
+		"email":   user.Email,
+	})
+
+	// 账号已删除，清理失败只留审计记录，残留目录不再被任何记录引用
+	if cleanup != nil {
+		if err := cleanup(ctx, user); err != nil {
+			s.audit.Log(event, map[string]any{
+				"status":  "cleanup_failed",
+				"adminId": adminID,
+				"userId":  user.ID,
+				"reason":  err.Error(),
+			})
+		}
+	}
+	return nil
+}
+
+func (s *Service) adminLoadUser(ctx context.Context, event string, adminID, userID int64) (UserAccount, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log(event, map[string]any{
+				"status":  "not_found",
+				"adminId": adminID,
+				"userId":  userID,
+			})
+			return UserAccount{}, ErrUserNotFound
+		}
+		s.audit.Log(event, map[string]any{
+			"status":  "error",
+			"adminId": adminID,
+			"userId":  userID,
+			"reason":  err.Error(),
+		})
+		return UserAccount{}, err
+	}
+	return user, nil
+}
+
+// ensureNotSelf stops an administrator from locking, demoting or deleting
+// their own account, which could leave nobody able to undo it.
+func (s *Service) ensureNotSelf(event string, adminID, userID int64) error {
+	if adminID != userID {
+		return nil
+	}
+	s.audit.Log(event, map[string]any{
+		"status":  "forbidden",
+		"adminId": adminID,
+		"userId":  userID,
+		"reason":  ErrAdminSelfAction.Error(),
+	})
+	return ErrAdminSelfAction
+}
+
+func normalizeUserFilter(filter UserFilter) (UserFilter, error) {
+	normalized := UserFilter{
+		Query:  strings.ToLower(strings.TrimSpace(filter.Query)),
+		Status: strings.TrimSpace(filter.Status),
+		Role:   strings.TrimSpace(filter.Role),
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+
+	switch normalized.Status {
+	case "", userStatusActive, userStatusLocked, userStatusPending:
+	default:
+		return UserFilter{}, ErrInvalidUserStatus
+	}
+	switch normalized.Role {
+	case "", RoleUser, RoleAdmin:
+	default:
+		return UserFilter{}, ErrInvalidUserRole
+	}
+
+	if normalized.Limit <= 0 || normalized.Limit > maxUserListLimit {
+		normalized.Limit = defaultUserListLimit
+	}
+	if normalized.Offset < 0 {
+		normalized.Offset = 0
+	}
+	return normalized, nil
+}
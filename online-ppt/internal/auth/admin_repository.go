@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+const userAccountColumns = `id, uuid, email, password_hash, status, role, locked_until, last_login_at, created_at, updated_at`
+
+// likeEscaper quotes LIKE wildcards in a search term. "!" is the escape
+// character because backslash literals differ between MySQL and PostgreSQL.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// ListUsers returns one page of users matching filter, newest first, and the
+// total number of matches. filter must already be normalized.
+func (r *Repository) ListUsers(ctx context.Context, filter UserFilter) ([]UserAccount, int, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if filter.Query != "" {
+		where = append(where, "email LIKE ? ESCAPE '!'")
+		args = append(args, "%"+likeEscaper.Replace(filter.Query)+"%")
+	}
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Role != "" {
+		where = append(where, "role = ?")
+		args = append(args, filter.Role)
+	}
+	clause := ""
+	if len(where) > 0 {
+		clause = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_accounts`+clause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count users: %w", err)
+	}
+
+	stmt := `SELECT ` + userAccountColumns + ` FROM user_accounts` + clause + ` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`
+	rows, err := r.db.QueryContext(ctx, stmt, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []UserAccount
+	for rows.Next() {
+		user, err := scanUserAccount(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate users: %w", err)
+	}
+	return users, total, nil
+}
+
+// DeleteUser removes a user together with their personal decks; sessions,
+// tokens and identities go with it through ON DELETE CASCADE, while workspace
+// decks the user created stay with the workspace and lose their creator. It
+// returns sql.ErrNoRows when no row matched.
+func (r *Repository) DeleteUser(ctx context.Context, userID int64) error {
+	return r.WithTx(ctx, func(tx *sql.Tx) error {
+		// ppt_records.user_id 是 ON DELETE SET NULL，个人 deck 需要显式删除
+		if _, err := tx.ExecContext(ctx, `DELETE FROM ppt_records WHERE user_id = ? AND workspace_id IS NULL`, userID); err != nil {
+			return fmt.Errorf("delete personal records: %w", err)
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM user_accounts WHERE id = ?`, userID)
+		if err != nil {
+			return fmt.Errorf("delete user: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+		if affected == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+}
+
+// SetUserStatusTx sets a user's status inside a transaction. Any automatic
+// lock expiry is cleared, so a lock set this way lasts until lifted.
+func (rt RepositoryTx) SetUserStatusTx(ctx context.Context, userID int64, status string) error {
+	stmt := `UPDATE user_accounts SET status = ?, locked_until = NULL, updated_at = NOW() WHERE id = ?`
+	res, err := rt.tx.ExecContext(ctx, stmt, status, userID)
+	if err != nil {
+		return fmt.Errorf("set user status tx: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetUserRole changes a user's role. It returns sql.ErrNoRows when no row matched.
+func (r *Repository) SetUserRole(ctx context.Context, userID int64, role string) error {
+	stmt := `UPDATE user_accounts SET role = ?, updated_at = NOW() WHERE id = ?`
+	res, err := r.db.ExecContext(ctx, stmt, role, userID)
+	if err != nil {
+		return fmt.Errorf("set user role: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
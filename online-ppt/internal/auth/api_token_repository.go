@@ -0,0 +1,85 @@
+package auth
+
+import (
+	context "context"
+	databaseSql "database/sql"
+	fmt "fmt"
+	strings "strings"
+	time "time"
+)
+
+// CreateAPIToken inserts a personal access token and returns the stored row.
+func (r *Repository) CreateAPIToken(ctx context.Context, token APIToken) (APIToken, error) {
+	stmt := `INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at) VALUES (?, ?, ?, ?, ?, ?)`
+	id, err := r.dialect.InsertID(ctx, r.db, stmt,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		token.TokenPrefix,
+		strings.Join(token.Scopes, ","),
+		token.ExpiresAt,
+	)
+	if err != nil {
+		return APIToken{}, fmt.Errorf("insert api token: %w", err)
+	}
+
+	stmt = `SELECT id, user_id, name, token_hash, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_tokens WHERE id = ? LIMIT 1`
+	return scanAPIToken(r.db.QueryRowContext(ctx, stmt, id))
+}
+
+// FindActiveAPIToken locates an unrevoked token by hash.
+func (r *Repository) FindActiveAPIToken(ctx context.Context, hash string) (APIToken, error) {
+	stmt := `SELECT id, user_id, name, token_hash, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_tokens WHERE token_hash = ? AND revoked_at IS NULL LIMIT 1`
+	return scanAPIToken(r.db.QueryRowContext(ctx, stmt, hash))
+}
+
+// ListAPITokens returns the unrevoked tokens of a user, newest first.
+func (r *Repository) ListAPITokens(ctx context.Context, userID int64) ([]APIToken, error) {
+	stmt := `SELECT id, user_id, name, token_hash, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_tokens WHERE user_id = ? AND revoked_at IS NULL ORDER BY created_at DESC, id DESC`
+	rows, err := r.db.QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list api tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate api tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken revokes a token owned by userID. It returns sql.ErrNoRows when
+// the token does not exist, belongs to someone else or is already revoked.
+func (r *Repository) RevokeAPIToken(ctx context.Context, userID, tokenID int64, revokedAt time.Time) error {
+	stmt := `UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`
+	res, err := r.db.ExecContext(ctx, stmt, revokedAt, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("revoke api token: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return databaseSql.ErrNoRows
+	}
+	return nil
+}
+
+// TouchAPIToken records a use of the token, skipping the write when the last
+// recorded use is newer than staleBefore.
+func (r *Repository) TouchAPIToken(ctx context.Context, tokenID int64, usedAt, staleBefore time.Time) error {
+	stmt := `UPDATE api_tokens SET last_used_at = ? WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)`
+	if _, err := r.db.ExecContext(ctx, stmt, usedAt, tokenID, staleBefore); err != nil {
+		return fmt.Errorf("touch api token: %w", err)
+	}
+	return nil
+}
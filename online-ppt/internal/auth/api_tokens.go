@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Personal access token scopes. Each scope includes the ones listed before it.
+const (
+	ScopeRead         = "read"
+	ScopeRecordsWrite = "records:write"
+	ScopeAdmin        = "admin"
+)
+
+const (
+	// APITokenPrefix marks personal access tokens so they can be told apart from JWTs.
+	APITokenPrefix = "ppt_"
+
+	apiTokenSize          = 32
+	apiTokenDisplayLength = 12
+	maxAPITokenNameLength = 100
+	maxAPITokenLifetime   = 366 * 24 * time.Hour
+	// apiTokenTouchInterval limits last-used bookkeeping to one write per token per minute.
+	apiTokenTouchInterval = time.Minute
+)
+
+var scopeRank = map[string]int{
+	ScopeRead:         1,
+	ScopeRecordsWrite: 2,
+	ScopeAdmin:        3,
+}
+
+// HasScope reports whether the caller may act with the given scope. Access
+// tokens from an interactive login carry no scopes and are allowed everything.
+func (c *Claims) HasScope(scope string) bool {
+	if c == nil {
+		return false
+	}
+	if c.TokenID == 0 {
+		return true
+	}
+	required := scopeRank[scope]
+	for _, granted := range c.Scopes {
+		if rank, ok := scopeRank[granted]; ok && rank >= required {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatedAPIToken carries the only copy of a new token's secret.
+type CreatedAPIToken struct {
+	APIToken
+	Token string
+}
+
+// CreateAPIToken issues a personal access token. A zero lifetime never expires.
+func (s *Service) CreateAPIToken(ctx context.Context, userID int64, name string, scopes []string, lifetime time.Duration) (CreatedAPIToken, error) {
+	name = strings.TrimSpace(name)
+	normalized, err := normalizeScopes(scopes)
+	if err == nil && (name == "" || len([]rune(name)) > maxAPITokenNameLength) {
+		err = fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidAPITokenRequest, maxAPITokenNameLength)
+	}
+	if err == nil && (lifetime < 0 || lifetime > maxAPITokenLifetime) {
+		err = fmt.Errorf("%w: lifetime must not exceed %d days", ErrInvalidAPITokenRequest, int(maxAPITokenLifetime.Hours()/24))
+	}
+	if err != nil {
+		s.audit.Log("account.api_token.create", map[string]any{
+			"status": "validation_failed",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return CreatedAPIToken{}, err
+	}
+
+	buf := make([]byte, apiTokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return CreatedAPIToken{}, fmt.Errorf("generate api token: %w", err)
+	}
+	secret := APITokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	token := APIToken{
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   hashSecretToken(secret),
+		TokenPrefix: secret[:apiTokenDisplayLength],
+		Scopes:      normalized,
+	}
+	if lifetime > 0 {
+		token.ExpiresAt = sql.NullTime{Time: s.clockFn().Add(lifetime), Valid: true}
+	}
+
+	created, err := s.repo.CreateAPIToken(ctx, token)
+	if err != nil {
+		s.audit.Log("account.api_token.create", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return CreatedAPIToken{}, err
+	}
+
+	s.audit.Log("account.api_token.create", map[string]any{
+		"status":  "success",
+		"userId":  userID,
+		"tokenId": created.ID,
+		"scopes":  created.Scopes,
+	})
+	return CreatedAPIToken{APIToken: created, Token: secret}, nil
+}
+
+// ListAPITokens returns the user's unrevoked tokens, including expired ones.
+func (s *Service) ListAPITokens(ctx context.Context, userID int64) ([]APIToken, error) {
+	tokens, err := s.repo.ListAPITokens(ctx, userID)
+	if err != nil {
+		s.audit.Log("account.api_token.list", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken revokes one of the user's tokens.
+func (s *Service) RevokeAPIToken(ctx context.Context, userID, tokenID int64) error {
+	if err := s.repo.RevokeAPIToken(ctx, userID, tokenID, s.clockFn()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("account.api_token.revoke", map[string]any{
+				"status":  "not_found",
+				"userId":  userID,
+				"tokenId": tokenID,
+			})
+			return ErrAPITokenNotFound
+		}
+		s.audit.Log("account.api_token.revoke", map[string]any{
+			"status":  "error",
+			"userId":  userID,
+			"tokenId": tokenID,
+			"reason":  err.Error(),
+		})
+		return err
+	}
+
+	s.audit.Log("account.api_token.revoke", map[string]any{
+		"status":  "success",
+		"userId":  userID,
+		"tokenId": tokenID,
+	})
+	return nil
+}
+
+// AuthenticateAPIToken validates a personal access token and returns claims
+// carrying its scopes. Tokens of users that are no longer active are rejected.
+func (s *Service) AuthenticateAPIToken(ctx context.Context, secret string) (*Claims, error) {
+	if !IsAPIToken(secret) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	token, err := s.repo.FindActiveAPIToken(ctx, hashSecretToken(secret))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidAPIToken
+		}
+		s.audit.Log("auth.api_token", map[string]any{
+			"status": "error",
+			"reason": err.Error(),
+		})
+		return nil, err
+	}
+
+	now := s.clockFn()
+	if token.ExpiresAt.Valid && !token.ExpiresAt.Time.After(now) {
+		s.audit.Log("auth.api_token", map[string]any{
+			"status":  "expired",
+			"userId":  token.UserID,
+			"tokenId": token.ID,
+		})
+		return nil, ErrInvalidAPIToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidAPIToken
+		}
+		return nil, err
+	}
+	if user.Status != "active" {
+		s.audit.Log("auth.api_token", map[string]any{
+			"status":  "inactive_user",
+			"userId":  user.ID,
+			"tokenId": token.ID,
+		})
+		return nil, ErrInvalidAPIToken
+	}
+
+	// 最近使用时间仅用于展示，写入失败不影响本次请求
+	if err := s.repo.TouchAPIToken(ctx, token.ID, now, now.Add(-apiTokenTouchInterval)); err != nil {
+		s.audit.Log("auth.api_token.touch", map[string]any{
+			"status":  "error",
+			"tokenId": token.ID,
+			"error":   err.Error(),
+		})
+	}
+
+	return &Claims{
+		UserID:   user.ID,
+		UserUUID: user.UUID,
+		Role:     user.Role,
+		TokenID:  token.ID,
+		Scopes:   token.Scopes,
+	}, nil
+}
+
+// IsAPIToken reports whether a bearer credential looks like a personal access token.
+func IsAPIToken(secret string) bool {
+	return strings.HasPrefix(secret, APITokenPrefix)
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPITokenRequest)
+	}
+	seen := make(map[string]bool, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if _, ok := scopeRank[scope]; !ok {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPITokenRequest, scope)
+		}
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		normalized = append(normalized, scope)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimsHasScope(t *testing.T) {
+	// 登录签发的访问令牌不受权限范围限制
+	session := &Claims{UserID: 1}
+	assert.True(t, session.HasScope(ScopeAdmin))
+
+	read := &Claims{UserID: 1, TokenID: 2, Scopes: []string{ScopeRead}}
+	assert.True(t, read.HasScope(ScopeRead))
+	assert.False(t, read.HasScope(ScopeRecordsWrite))
+	assert.False(t, read.HasScope(ScopeAdmin))
+
+	write := &Claims{UserID: 1, TokenID: 2, Scopes: []string{ScopeRecordsWrite}}
+	assert.True(t, write.HasScope(ScopeRead))
+	assert.True(t, write.HasScope(ScopeRecordsWrite))
+	assert.False(t, write.HasScope(ScopeAdmin))
+
+	admin := &Claims{UserID: 1, TokenID: 2, Scopes: []string{ScopeAdmin}}
+	assert.True(t, admin.HasScope(ScopeRecordsWrite))
+
+	var missing *Claims
+	assert.False(t, missing.HasScope(ScopeRead))
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	scopes, err := normalizeScopes([]string{" READ ", "records:write", "read"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{ScopeRead, ScopeRecordsWrite}, scopes)
+
+	_, err = normalizeScopes(nil)
+	require.ErrorIs(t, err, ErrInvalidAPITokenRequest)
+
+	_, err = normalizeScopes([]string{"records:delete"})
+	require.ErrorIs(t, err, ErrInvalidAPITokenRequest)
+}
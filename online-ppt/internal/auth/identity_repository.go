@@ -0,0 +1,55 @@
+package auth
+
+import (
+	context "context"
+	databaseSql "database/sql"
+	fmt "fmt"
+	time "time"
+)
+
+// FindIdentity locates the account linked to a subject at an external provider.
+func (r *Repository) FindIdentity(ctx context.Context, provider, subject string) (UserIdentity, error) {
+	stmt := `SELECT id, user_id, provider, subject, email, last_login_at, created_at FROM user_identities WHERE provider = ? AND subject = ? LIMIT 1`
+	return scanUserIdentity(r.db.QueryRowContext(ctx, stmt, provider, subject))
+}
+
+// CreateIdentity links an existing account to an external subject.
+func (r *Repository) CreateIdentity(ctx context.Context, identity UserIdentity) error {
+	stmt := `INSERT INTO user_identities (user_id, provider, subject, email, last_login_at) VALUES (?, ?, ?, ?, ?)`
+	if _, err := r.db.ExecContext(ctx, stmt, identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.LastLoginAt); err != nil {
+		return fmt.Errorf("insert identity: %w", err)
+	}
+	return nil
+}
+
+// CreateUserWithIdentity provisions an account for a first-time external
+// sign-in and links it to the subject in one transaction.
+func (r *Repository) CreateUserWithIdentity(ctx context.Context, email, passwordHash, uuid string, identity UserIdentity) (UserAccount, error) {
+	var userID int64
+	err := r.WithTx(ctx, func(tx *databaseSql.Tx) error {
+		var err error
+		userID, err = r.dialect.InsertID(ctx, tx, `INSERT INTO user_accounts (email, password_hash, uuid) VALUES (?, ?, ?)`, email, passwordHash, uuid)
+		if err != nil {
+			return fmt.Errorf("insert user: %w", err)
+		}
+		stmt := `INSERT INTO user_identities (user_id, provider, subject, email, last_login_at) VALUES (?, ?, ?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, stmt, userID, identity.Provider, identity.Subject, identity.Email, identity.LastLoginAt); err != nil {
+			return fmt.Errorf("insert identity: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return UserAccount{}, err
+	}
+	return r.GetUserByID(ctx, userID)
+}
+
+// TouchIdentity records a sign-in through an existing link and refreshes the
+// email the provider reported.
+func (r *Repository) TouchIdentity(ctx context.Context, identityID int64, email databaseSql.NullString, ts time.Time) error {
+	stmt := `UPDATE user_identities SET email = ?, last_login_at = ? WHERE id = ?`
+	if _, err := r.db.ExecContext(ctx, stmt, email, ts, identityID); err != nil {
+		return fmt.Errorf("touch identity: %w", err)
+	}
+	return nil
+}
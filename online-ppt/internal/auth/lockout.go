@@ -0,0 +1,373 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"online-ppt/internal/cache"
+)
+
+const (
+	defaultUnlockLinkBase = "/unlock-account"
+	loginAccountKey       = "account:%s"
+	loginIPKey            = "ip:%s"
+	loginBackoffKey       = "login_backoff:%s"
+	userStatusActive      = "active"
+	userStatusLocked      = "locked"
+	userStatusPending     = "pending"
+)
+
+// LoginThrottle tunes how repeated failed password logins are slowed down.
+// Failures are counted per account (by email) and per client IP.
+type LoginThrottle struct {
+	// CaptchaAfter is the number of failures after which every further attempt
+	// must solve a captcha and waits out an exponential backoff.
+	CaptchaAfter int
+	// LockAfter is the number of account failures after which the account is locked.
+	LockAfter int
+	// LockDuration is how long an automatic lock lasts unless the emailed
+	// unlock link is used first.
+	LockDuration time.Duration
+	// BackoffBase is the wait imposed once CaptchaAfter is reached; it doubles
+	// with every further failure up to BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Window is how long failures are remembered, counted from the first one.
+	Window time.Duration
+}
+
+var defaultLoginThrottle = LoginThrottle{
+	CaptchaAfter: 3,
+	LockAfter:    10,
+	LockDuration: 30 * time.Minute,
+	BackoffBase:  time.Second,
+	BackoffMax:   5 * time.Minute,
+	Window:       time.Hour,
+}
+
+// backoff returns how long a client must wait after its count-th failure.
+func (t LoginThrottle) backoff(count int) time.Duration {
+	if count < t.CaptchaAfter || t.BackoffBase <= 0 {
+		return 0
+	}
+	delay := t.BackoffBase
+	for i := t.CaptchaAfter; i < count && delay < t.BackoffMax; i++ {
+		delay *= 2
+	}
+	if t.BackoffMax > 0 && delay > t.BackoffMax {
+		delay = t.BackoffMax
+	}
+	return delay
+}
+
+// ConfigureLoginThrottle sets the link embedded in account unlock emails and the
+// failed-login policy. Zero fields of policy keep their defaults. The unlock
+// token is appended as the "token" query parameter.
+func (s *Service) ConfigureLoginThrottle(unlockLinkBase string, policy LoginThrottle) error {
+	if unlockLinkBase != "" {
+		if _, err := url.Parse(unlockLinkBase); err != nil {
+			return fmt.Errorf("parse account unlock link: %w", err)
+		}
+		s.unlockLinkBase = unlockLinkBase
+	}
+	if policy.CaptchaAfter < 0 || policy.LockAfter < 0 || policy.LockDuration < 0 ||
+		policy.BackoffBase < 0 || policy.BackoffMax < 0 || policy.Window < 0 {
+		return fmt.Errorf("login throttle settings must not be negative")
+	}
+
+	merged := defaultLoginThrottle
+	if policy.CaptchaAfter > 0 {
+		merged.CaptchaAfter = policy.CaptchaAfter
+	}
+	if policy.LockAfter > 0 {
+		merged.LockAfter = policy.LockAfter
+	}
+	if policy.LockDuration > 0 {
+		merged.LockDuration = policy.LockDuration
+	}
+	if policy.BackoffBase > 0 {
+		merged.BackoffBase = policy.BackoffBase
+	}
+	if policy.BackoffMax > 0 {
+		merged.BackoffMax = policy.BackoffMax
+	}
+	if policy.Window > 0 {
+		merged.Window = policy.Window
+	}
+	s.throttle = merged
+	return nil
+}
+
+// UnlockAccount 使用邮件中的解锁令牌解除自动锁定
+func (s *Service) UnlockAccount(ctx context.Context, token string) error {
+	if token == "" {
+		s.audit.Log("auth.account.unlock", map[string]any{
+			"status": "validation_failed",
+			"reason": "unlock token required",
+		})
+		return ErrInvalidUnlockToken
+	}
+
+	// 取出即删除，令牌只能使用一次
+	data, err := s.cache.ConsumeAccountUnlockToken(ctx, hashSecretToken(token))
+	if err != nil {
+		s.audit.Log("auth.account.unlock", map[string]any{
+			"status": "invalid_token",
+			"reason": "token not found or expired",
+		})
+		return ErrInvalidUnlockToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, data.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("auth.account.unlock", map[string]any{
+				"status": "invalid_token",
+				"userId": data.UserID,
+				"reason": "user not found",
+			})
+			return ErrInvalidUnlockToken
+		}
+		s.audit.Log("auth.account.unlock", map[string]any{
+			"status": "error",
+			"userId": data.UserID,
+			"reason": err.Error(),
+		})
+		return err
+	}
+	// 邮箱已修改，或账号被人工锁定（没有到期时间）时令牌失效
+	if user.Email != data.Email || user.Status != userStatusLocked || !user.LockedUntil.Valid {
+		s.audit.Log("auth.account.unlock", map[string]any{
+			"status": "invalid_token",
+			"userId": user.ID,
+			"reason": "account changed since lock",
+		})
+		return ErrInvalidUnlockToken
+	}
+
+	if err := s.repo.UnlockUser(ctx, user.ID); err != nil {
+		s.audit.Log("auth.account.unlock", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return err
+	}
+	s.clearLoginFailures(ctx, fmt.Sprintf(loginAccountKey, user.Email))
+
+	s.audit.Log("auth.account.unlock", map[string]any{
+		"status": "success",
+		"userId": user.ID,
+	})
+	return nil
+}
+
+// loginThrottleKeys returns the failure counter keys of a login attempt, the
+// account key first.
+func loginThrottleKeys(email, ipAddress string) []string {
+	keys := []string{fmt.Sprintf(loginAccountKey, email)}
+	if ipAddress != "" {
+		keys = append(keys, fmt.Sprintf(loginIPKey, ipAddress))
+	}
+	return keys
+}
+
+// checkLoginThrottle rejects attempts made during a backoff and demands a
+// captcha once either counter reached CaptchaAfter.
+func (s *Service) checkLoginThrottle(ctx context.Context, email string, keys []string, req LoginRequest) error {
+	failures := 0
+	for _, key := range keys {
+		limited, err := s.cache.CheckRateLimit(ctx, fmt.Sprintf(loginBackoffKey, key))
+		if err != nil {
+			s.audit.Log("auth.login", map[string]any{
+				"status": "error",
+				"email":  email,
+				"reason": err.Error(),
+			})
+			return err
+		}
+		if limited {
+			s.audit.Log("auth.login", map[string]any{
+				"status": "throttled",
+				"email":  email,
+			})
+			return ErrRateLimited
+		}
+
+		count, err := s.cache.GetLoginFailures(ctx, key)
+		if err != nil {
+			s.audit.Log("auth.login", map[string]any{
+				"status": "error",
+				"email":  email,
+				"reason": err.Error(),
+			})
+			return err
+		}
+		failures = max(failures, count)
+	}
+	if failures < s.throttle.CaptchaAfter {
+		return nil
+	}
+
+	if req.CaptchaID == "" || req.CaptchaCode == "" {
+		s.audit.Log("auth.login", map[string]any{
+			"status":   "captcha_required",
+			"email":    email,
+			"failures": failures,
+		})
+		return ErrCaptchaRequired
+	}
+	valid, err := s.captcha.Verify(ctx, req.CaptchaID, req.CaptchaCode)
+	if err != nil || !valid {
+		s.audit.Log("auth.login", map[string]any{
+			"status": "invalid_captcha",
+			"email":  email,
+		})
+		return ErrInvalidCaptcha
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed attempt and starts the backoff. It locks
+// the account once it reached LockAfter and reports whether it did.
+func (s *Service) recordLoginFailure(ctx context.Context, keys []string, user *UserAccount) bool {
+	counts := make([]int, len(keys))
+	for i, key := range keys {
+		count, err := s.cache.IncrementLoginFailures(ctx, key, s.throttle.Window)
+		if err != nil {
+			s.audit.Log("auth.login.record_failure", map[string]any{
+				"status": "error",
+				"error":  err.Error(),
+			})
+			continue
+		}
+		counts[i] = count
+	}
+
+	locked := false
+	if user != nil && user.Status == userStatusActive && counts[0] >= s.throttle.LockAfter {
+		locked = s.lockAccount(ctx, *user)
+	}
+
+	for i, key := range keys {
+		// 账号已锁定时不再叠加退避，解锁后可以立即登录
+		if locked && i == 0 {
+			continue
+		}
+		if delay := s.throttle.backoff(counts[i]); delay > 0 {
+			_ = s.cache.SetRateLimit(ctx, fmt.Sprintf(loginBackoffKey, key), delay)
+		}
+	}
+	return locked
+}
+
+func (s *Service) clearLoginFailures(ctx context.Context, key string) {
+	if err := s.cache.ResetLoginFailures(ctx, key); err != nil {
+		s.audit.Log("auth.login.clear_failures", map[string]any{
+			"status": "error",
+			"error":  err.Error(),
+		})
+	}
+}
+
+// lockAccount locks the account for LockDuration, revokes its sessions so
+// existing refresh tokens stop working, and emails an unlock link.
+func (s *Service) lockAccount(ctx context.Context, user UserAccount) bool {
+	until := s.clockFn().Add(s.throttle.LockDuration)
+	var (
+		locked  bool
+		revoked int64
+	)
+	err := s.repo.WithTx(ctx, func(tx *sql.Tx) error {
+		rt := NewRepositoryTx(tx, s.repo.dialect)
+		var err error
+		if locked, err = rt.LockUserTx(ctx, user.ID, until); err != nil || !locked {
+			return err
+		}
+		revoked, err = rt.RevokeUserSessionsTx(ctx, user.ID, s.clockFn())
+		return err
+	})
+	if err != nil {
+		s.audit.Log("auth.account.lock", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return false
+	}
+	if !locked {
+		return false
+	}
+	s.audit.Log("auth.account.lock", map[string]any{
+		"status":          "locked",
+		"userId":          user.ID,
+		"lockedUntil":     until,
+		"revokedSessions": revoked,
+	})
+
+	if err := s.sendUnlockLink(ctx, user, until); err != nil {
+		s.audit.Log("auth.account.lock.notify", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"error":  err.Error(),
+		})
+	}
+	return true
+}
+
+// sendUnlockLink emails a one-time unlock link that expires with the lock.
+func (s *Service) sendUnlockLink(ctx context.Context, user UserAccount, until time.Time) error {
+	tokens, err := randomTokens(1)
+	if err != nil {
+		return err
+	}
+	data := &cache.AccountUnlockData{
+		UserID:    user.ID,
+		Email:     user.Email,
+		CreatedAt: s.clockFn(),
+	}
+	if err := s.cache.SetAccountUnlockToken(ctx, hashSecretToken(tokens[0]), data, until.Sub(s.clockFn())); err != nil {
+		return err
+	}
+	link, err := buildTokenLink(s.unlockLinkBase, tokens[0])
+	if err != nil {
+		return err
+	}
+	return s.mail.SendAccountUnlock(user.Email, link, until)
+}
+
+// ensureUnlocked refuses locked accounts. An automatic lock whose time is up is
+// lifted on the spot and user is updated accordingly.
+func (s *Service) ensureUnlocked(ctx context.Context, user *UserAccount, event string) error {
+	if user.Status != userStatusLocked {
+		return nil
+	}
+	if !user.LockedUntil.Valid || s.clockFn().Before(user.LockedUntil.Time) {
+		s.audit.Log(event, map[string]any{
+			"status": "locked",
+			"userId": user.ID,
+		})
+		return ErrAccountLocked
+	}
+
+	if err := s.repo.UnlockUser(ctx, user.ID); err != nil {
+		s.audit.Log(event, map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return err
+	}
+	s.clearLoginFailures(ctx, fmt.Sprintf(loginAccountKey, user.Email))
+	s.audit.Log("auth.account.unlock", map[string]any{
+		"status": "expired",
+		"userId": user.ID,
+	})
+	user.Status = userStatusActive
+	user.LockedUntil = sql.NullTime{}
+	return nil
+}
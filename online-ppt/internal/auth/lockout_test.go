@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	throttle := LoginThrottle{CaptchaAfter: 3, BackoffBase: time.Second, BackoffMax: 10 * time.Second}
+
+	cases := map[int]time.Duration{
+		1:  0,
+		2:  0,
+		3:  time.Second,
+		4:  2 * time.Second,
+		6:  8 * time.Second,
+		7:  10 * time.Second,
+		50: 10 * time.Second,
+	}
+	for count, want := range cases {
+		require.Equal(t, want, throttle.backoff(count), "failure %d", count)
+	}
+}
+
+func TestConfigureLoginThrottleKeepsDefaults(t *testing.T) {
+	s := &Service{throttle: defaultLoginThrottle}
+
+	require.NoError(t, s.ConfigureLoginThrottle("https://ppt.example.com/unlock-account", LoginThrottle{LockAfter: 5}))
+	require.Equal(t, 5, s.throttle.LockAfter)
+	require.Equal(t, defaultLoginThrottle.CaptchaAfter, s.throttle.CaptchaAfter)
+	require.Equal(t, defaultLoginThrottle.LockDuration, s.throttle.LockDuration)
+	require.Equal(t, "https://ppt.example.com/unlock-account", s.unlockLinkBase)
+
+	require.Error(t, s.ConfigureLoginThrottle("", LoginThrottle{LockDuration: -time.Minute}))
+}
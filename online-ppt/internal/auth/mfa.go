@@ -0,0 +1,421 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"online-ppt/internal/cache"
+)
+
+const (
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaChallengeMaxAttempts = 5
+)
+
+// MFAStatus summarizes the two-factor settings of an account.
+type MFAStatus struct {
+	Enabled                bool
+	RecoveryCodesRemaining int
+}
+
+// MFAEnrollment carries the secret a user adds to an authenticator app.
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// MFAStatus reports whether two-factor login is enabled for a user.
+func (s *Service) MFAStatus(ctx context.Context, userID int64) (MFAStatus, error) {
+	mfa, err := s.repo.GetMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return MFAStatus{}, nil
+		}
+		return MFAStatus{}, err
+	}
+	if !mfa.Enabled() {
+		return MFAStatus{}, nil
+	}
+	remaining, err := s.repo.CountUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return MFAStatus{}, err
+	}
+	return MFAStatus{Enabled: true, RecoveryCodesRemaining: remaining}, nil
+}
+
+// BeginMFAEnrollment generates a new TOTP secret after checking the password.
+// The secret stays pending until ConfirmMFAEnrollment verifies a first code.
+func (s *Service) BeginMFAEnrollment(ctx context.Context, userID int64, password string) (MFAEnrollment, error) {
+	user, err := s.verifyCurrentPassword(ctx, "account.mfa.enroll", userID, password)
+	if err != nil {
+		return MFAEnrollment{}, err
+	}
+
+	mfa, err := s.repo.GetMFA(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.audit.Log("account.mfa.enroll", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return MFAEnrollment{}, err
+	}
+	if err == nil && mfa.Enabled() {
+		s.audit.Log("account.mfa.enroll", map[string]any{
+			"status": "conflict",
+			"userId": user.ID,
+			"reason": ErrMFAAlreadyEnabled.Error(),
+		})
+		return MFAEnrollment{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		s.audit.Log("account.mfa.enroll", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return MFAEnrollment{}, err
+	}
+	if err := s.repo.SavePendingMFA(ctx, user.ID, secret); err != nil {
+		s.audit.Log("account.mfa.enroll", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return MFAEnrollment{}, err
+	}
+
+	s.audit.Log("account.mfa.enroll", map[string]any{
+		"status": "pending",
+		"userId": user.ID,
+	})
+	return MFAEnrollment{Secret: secret, URI: totpURI(secret, user.Email)}, nil
+}
+
+// ConfirmMFAEnrollment enables two-factor login once the first authenticator
+// code checks out and returns the plaintext recovery codes, shown only once.
+func (s *Service) ConfirmMFAEnrollment(ctx context.Context, userID int64, code string) ([]string, error) {
+	mfa, err := s.repo.GetMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("account.mfa.confirm", map[string]any{
+				"status": "not_found",
+				"userId": userID,
+				"reason": ErrMFANotEnabled.Error(),
+			})
+			return nil, ErrMFANotEnabled
+		}
+		s.audit.Log("account.mfa.confirm", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return nil, err
+	}
+	if mfa.Enabled() {
+		s.audit.Log("account.mfa.confirm", map[string]any{
+			"status": "conflict",
+			"userId": userID,
+			"reason": ErrMFAAlreadyEnabled.Error(),
+		})
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok := verifyTOTP(mfa.TOTPSecret, code, s.clockFn())
+	if !ok {
+		s.audit.Log("account.mfa.confirm", map[string]any{
+			"status": "invalid_code",
+			"userId": userID,
+		})
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		s.audit.Log("account.mfa.confirm", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return nil, err
+	}
+	if err := s.repo.EnableMFA(ctx, userID, step, hashes, s.clockFn()); err != nil {
+		s.audit.Log("account.mfa.confirm", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return nil, err
+	}
+
+	s.audit.Log("account.mfa.confirm", map[string]any{
+		"status": "success",
+		"userId": userID,
+	})
+	return codes, nil
+}
+
+// DisableMFA turns two-factor login off. It needs both the password and a
+// current authenticator or recovery code.
+func (s *Service) DisableMFA(ctx context.Context, userID int64, password, code string) error {
+	mfa, err := s.requireEnabledMFA(ctx, "account.mfa.disable", userID, password, code)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DisableMFA(ctx, mfa.UserID); err != nil {
+		s.audit.Log("account.mfa.disable", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return err
+	}
+
+	s.audit.Log("account.mfa.disable", map[string]any{
+		"status": "success",
+		"userId": userID,
+	})
+	return nil
+}
+
+// RegenerateRecoveryCodes invalidates every existing recovery code and returns a new set.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int64, password, code string) ([]string, error) {
+	mfa, err := s.requireEnabledMFA(ctx, "account.mfa.recovery_codes", userID, password, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		s.audit.Log("account.mfa.recovery_codes", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, mfa.UserID, hashes); err != nil {
+		s.audit.Log("account.mfa.recovery_codes", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return nil, err
+	}
+
+	s.audit.Log("account.mfa.recovery_codes", map[string]any{
+		"status": "success",
+		"userId": userID,
+	})
+	return codes, nil
+}
+
+// CompleteMFALogin finishes a two-step login with the challenge token returned
+// by Login and an authenticator or recovery code.
+func (s *Service) CompleteMFALogin(ctx context.Context, mfaToken, code string) (AuthResult, error) {
+	tokenHash := hashSecretToken(mfaToken)
+	challenge, err := s.cache.GetMFAChallenge(ctx, tokenHash)
+	if err != nil || mfaToken == "" {
+		s.audit.Log("auth.login.mfa", map[string]any{
+			"status": "not_found",
+			"reason": ErrMFAChallengeNotFound.Error(),
+		})
+		return AuthResult{}, ErrMFAChallengeNotFound
+	}
+	if challenge.Attempts >= mfaChallengeMaxAttempts {
+		_ = s.cache.DeleteMFAChallenge(ctx, tokenHash)
+		s.audit.Log("auth.login.mfa", map[string]any{
+			"status": "too_many_attempts",
+			"userId": challenge.UserID,
+		})
+		return AuthResult{}, ErrTooManyAttempts
+	}
+
+	user, err := s.repo.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		s.audit.Log("auth.login.mfa", map[string]any{
+			"status": "error",
+			"userId": challenge.UserID,
+			"reason": err.Error(),
+		})
+		return AuthResult{}, err
+	}
+	// 挑战期间账号可能被锁定或改为待激活，签发会话前重新检查
+	if err := s.ensureUnlocked(ctx, &user, "auth.login.mfa"); err != nil {
+		_ = s.cache.DeleteMFAChallenge(ctx, tokenHash)
+		return AuthResult{}, err
+	}
+	if user.Status == userStatusPending {
+		_ = s.cache.DeleteMFAChallenge(ctx, tokenHash)
+		s.audit.Log("auth.login.mfa", map[string]any{
+			"status": "pending",
+			"userId": user.ID,
+		})
+		return AuthResult{}, ErrAccountPending
+	}
+	mfa, err := s.repo.GetMFA(ctx, user.ID)
+	if err != nil || !mfa.Enabled() {
+		// 两步验证在挑战期间被关闭，挑战作废
+		_ = s.cache.DeleteMFAChallenge(ctx, tokenHash)
+		s.audit.Log("auth.login.mfa", map[string]any{
+			"status": "not_found",
+			"userId": user.ID,
+			"reason": ErrMFAChallengeNotFound.Error(),
+		})
+		return AuthResult{}, ErrMFAChallengeNotFound
+	}
+
+	method, err := s.verifySecondFactor(ctx, mfa, code)
+	if err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			_ = s.cache.IncrementMFAChallengeAttempts(ctx, tokenHash)
+			if s.recordLoginFailure(ctx, loginThrottleKeys(user.Email, challenge.IPAddress), &user) {
+				_ = s.cache.DeleteMFAChallenge(ctx, tokenHash)
+				return AuthResult{}, ErrAccountLocked
+			}
+			s.audit.Log("auth.login.mfa", map[string]any{
+				"status":   "invalid_code",
+				"userId":   user.ID,
+				"attempts": challenge.Attempts + 1,
+			})
+			return AuthResult{}, err
+		}
+		s.audit.Log("auth.login.mfa", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return AuthResult{}, err
+	}
+
+	// 挑战令牌只能使用一次
+	_ = s.cache.DeleteMFAChallenge(ctx, tokenHash)
+	s.clearLoginFailures(ctx, fmt.Sprintf(loginAccountKey, user.Email))
+	s.audit.Log("auth.login.mfa", map[string]any{
+		"status": "verified",
+		"userId": user.ID,
+		"method": method,
+	})
+	return s.issueSession(ctx, user, ClientInfo{
+		Fingerprint: challenge.Fingerprint,
+		IPAddress:   challenge.IPAddress,
+		UserAgent:   challenge.UserAgent,
+	}, nil, "auth.login.mfa")
+}
+
+// startMFAChallenge stores a short-lived challenge for a user whose password
+// checked out but who still has to present a second factor.
+func (s *Service) startMFAChallenge(ctx context.Context, user UserAccount, client ClientInfo) (AuthResult, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return AuthResult{}, fmt.Errorf("generate mfa token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := s.clockFn()
+	data := &cache.MFAChallengeData{
+		UserID:      user.ID,
+		Fingerprint: client.Fingerprint,
+		IPAddress:   client.IPAddress,
+		UserAgent:   client.UserAgent,
+		CreatedAt:   now,
+	}
+	if err := s.cache.SetMFAChallenge(ctx, hashSecretToken(token), data, mfaChallengeTTL); err != nil {
+		return AuthResult{}, err
+	}
+
+	s.audit.Log("auth.login", map[string]any{
+		"status": "mfa_required",
+		"userId": user.ID,
+	})
+	return AuthResult{
+		User:         user,
+		MFAToken:     token,
+		MFAExpiresAt: now.Add(mfaChallengeTTL),
+	}, nil
+}
+
+// mfaEnabled reports whether a user must pass a second factor at login.
+func (s *Service) mfaEnabled(ctx context.Context, userID int64) (bool, error) {
+	mfa, err := s.repo.GetMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return mfa.Enabled(), nil
+}
+
+func (s *Service) requireEnabledMFA(ctx context.Context, event string, userID int64, password, code string) (UserMFA, error) {
+	if _, err := s.verifyCurrentPassword(ctx, event, userID, password); err != nil {
+		return UserMFA{}, err
+	}
+
+	mfa, err := s.repo.GetMFA(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.audit.Log(event, map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return UserMFA{}, err
+	}
+	if err != nil || !mfa.Enabled() {
+		s.audit.Log(event, map[string]any{
+			"status": "not_found",
+			"userId": userID,
+			"reason": ErrMFANotEnabled.Error(),
+		})
+		return UserMFA{}, ErrMFANotEnabled
+	}
+
+	if _, err := s.verifySecondFactor(ctx, mfa, code); err != nil {
+		s.audit.Log(event, map[string]any{
+			"status": "invalid_code",
+			"userId": userID,
+		})
+		return UserMFA{}, err
+	}
+	return mfa, nil
+}
+
+// verifySecondFactor accepts either a TOTP code (rejecting replays of an
+// already used time step) or an unused recovery code, which is burnt.
+func (s *Service) verifySecondFactor(ctx context.Context, mfa UserMFA, code string) (string, error) {
+	now := s.clockFn()
+	if isTOTPCode(code) {
+		step, ok := verifyTOTP(mfa.TOTPSecret, code, now)
+		if !ok {
+			return "", ErrInvalidMFACode
+		}
+		fresh, err := s.repo.MarkTOTPStepUsed(ctx, mfa.UserID, step)
+		if err != nil {
+			return "", err
+		}
+		if !fresh {
+			return "", ErrInvalidMFACode
+		}
+		return "totp", nil
+	}
+
+	if code == "" {
+		return "", ErrInvalidMFACode
+	}
+	used, err := s.repo.UseRecoveryCode(ctx, mfa.UserID, hashRecoveryCode(code), now)
+	if err != nil {
+		return "", err
+	}
+	if !used {
+		return "", ErrInvalidMFACode
+	}
+	return "recovery_code", nil
+}
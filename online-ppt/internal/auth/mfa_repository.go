@@ -0,0 +1,124 @@
+package auth
+
+import (
+	context "context"
+	databaseSql "database/sql"
+	fmt "fmt"
+	strings "strings"
+	time "time"
+)
+
+// GetMFA fetches the two-factor settings of a user.
+func (r *Repository) GetMFA(ctx context.Context, userID int64) (UserMFA, error) {
+	stmt := `SELECT user_id, totp_secret, enabled_at, last_used_step, created_at, updated_at FROM user_mfa WHERE user_id = ? LIMIT 1`
+	return scanUserMFA(r.db.QueryRowContext(ctx, stmt, userID))
+}
+
+// SavePendingMFA stores a not yet confirmed TOTP secret, replacing any earlier pending one.
+func (r *Repository) SavePendingMFA(ctx context.Context, userID int64, secret string) error {
+	stmt := `INSERT INTO user_mfa (user_id, totp_secret, enabled_at, last_used_step) VALUES (?, ?, NULL, NULL) ` +
+		r.dialect.Upsert("user_id", "totp_secret = "+r.dialect.Excluded("totp_secret"), "enabled_at = NULL", "last_used_step = NULL", "updated_at = NOW()")
+	if _, err := r.db.ExecContext(ctx, stmt, userID, secret); err != nil {
+		return fmt.Errorf("save pending mfa: %w", err)
+	}
+	return nil
+}
+
+// EnableMFA confirms a pending enrollment and stores its first recovery codes.
+func (r *Repository) EnableMFA(ctx context.Context, userID, step int64, codeHashes []string, enabledAt time.Time) error {
+	return r.WithTx(ctx, func(tx *databaseSql.Tx) error {
+		stmt := `UPDATE user_mfa SET enabled_at = ?, last_used_step = ?, updated_at = NOW() WHERE user_id = ? AND enabled_at IS NULL`
+		res, err := tx.ExecContext(ctx, stmt, enabledAt, step, userID)
+		if err != nil {
+			return fmt.Errorf("enable mfa: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected: %w", err)
+		}
+		if affected == 0 {
+			return databaseSql.ErrNoRows
+		}
+		return replaceRecoveryCodesTx(ctx, tx, userID, codeHashes)
+	})
+}
+
+// DisableMFA removes the TOTP secret and every recovery code of a user.
+func (r *Repository) DisableMFA(ctx context.Context, userID int64) error {
+	return r.WithTx(ctx, func(tx *databaseSql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("delete recovery codes: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = ?`, userID); err != nil {
+			return fmt.Errorf("delete mfa: %w", err)
+		}
+		return nil
+	})
+}
+
+// ReplaceRecoveryCodes swaps every recovery code of a user for a new set.
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	return r.WithTx(ctx, func(tx *databaseSql.Tx) error {
+		return replaceRecoveryCodesTx(ctx, tx, userID, codeHashes)
+	})
+}
+
+// MarkTOTPStepUsed records the latest accepted time step. It reports false when
+// the step is not newer than the last one, i.e. the code is being replayed.
+func (r *Repository) MarkTOTPStepUsed(ctx context.Context, userID, step int64) (bool, error) {
+	stmt := `UPDATE user_mfa SET last_used_step = ?, updated_at = NOW() WHERE user_id = ? AND (last_used_step IS NULL OR last_used_step < ?)`
+	res, err := r.db.ExecContext(ctx, stmt, step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("mark totp step: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// UseRecoveryCode burns an unused recovery code. It reports false when no such code exists.
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string, usedAt time.Time) (bool, error) {
+	stmt := `UPDATE user_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`
+	res, err := r.db.ExecContext(ctx, stmt, usedAt, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("use recovery code: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes a user has left.
+func (r *Repository) CountUnusedRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	var count int
+	stmt := `SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = ? AND used_at IS NULL`
+	if err := r.db.QueryRowContext(ctx, stmt, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+func replaceRecoveryCodesTx(ctx context.Context, tx *databaseSql.Tx, userID int64, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(codeHashes))
+	args := make([]any, 0, len(codeHashes)*2)
+	for _, hash := range codeHashes {
+		placeholders = append(placeholders, "(?, ?)")
+		args = append(args, userID, hash)
+	}
+	stmt := `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ` + strings.Join(placeholders, ", ")
+	if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+		return fmt.Errorf("insert recovery codes: %w", err)
+	}
+	return nil
+}
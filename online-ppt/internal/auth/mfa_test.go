@@ -0,0 +1,439 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/cache"
+	"online-ppt/internal/storage"
+)
+
+const (
+	mfaTestUserID = int64(1)
+	mfaTestEmail  = "user@example.com"
+	mfaTestPass   = "CorrectHorse123"
+
+	selectMFAQuery  = "SELECT user_id, totp_secret, enabled_at, last_used_step, created_at, updated_at FROM user_mfa WHERE user_id = \\?"
+	selectUserQuery = "SELECT id, uuid, email, password_hash, status, role, locked_until, last_login_at, created_at, updated_at FROM user_accounts WHERE "
+)
+
+var (
+	mfaColumns  = []string{"user_id", "totp_secret", "enabled_at", "last_used_step", "created_at", "updated_at"}
+	userColumns = []string{"id", "uuid", "email", "password_hash", "status", "role", "locked_until", "last_login_at", "created_at", "updated_at"}
+)
+
+// stubCache keeps MFA challenges and login failure counts in memory; other
+// operations are unused here.
+type stubCache struct {
+	challenges map[string]cache.MFAChallengeData
+	failures   map[string]int
+}
+
+func (c *stubCache) SetCaptcha(ctx context.Context, captchaID, code string) error { return nil }
+func (c *stubCache) GetCaptcha(ctx context.Context, captchaID string) (string, error) {
+	return "", errors.New("not found")
+}
+func (c *stubCache) DeleteCaptcha(ctx context.Context, captchaID string) error { return nil }
+func (c *stubCache) SetEmailCode(ctx context.Context, email string, data *cache.EmailCodeData) error {
+	return nil
+}
+func (c *stubCache) GetEmailCode(ctx context.Context, email string) (*cache.EmailCodeData, error) {
+	return nil, errors.New("not found")
+}
+func (c *stubCache) DeleteEmailCode(ctx context.Context, email string) error            { return nil }
+func (c *stubCache) IncrementEmailCodeAttempts(ctx context.Context, email string) error { return nil }
+func (c *stubCache) SetRateLimit(ctx context.Context, email string, ttl time.Duration) error {
+	return nil
+}
+func (c *stubCache) CheckRateLimit(ctx context.Context, email string) (bool, error) {
+	return false, nil
+}
+func (c *stubCache) SetPasswordResetToken(ctx context.Context, tokenHash string, data *cache.PasswordResetData, ttl time.Duration) error {
+	return nil
+}
+func (c *stubCache) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (*cache.PasswordResetData, error) {
+	return nil, errors.New("not found")
+}
+
+func (c *stubCache) SetMFAChallenge(ctx context.Context, tokenHash string, data *cache.MFAChallengeData, ttl time.Duration) error {
+	c.challenges[tokenHash] = *data
+	return nil
+}
+
+func (c *stubCache) GetMFAChallenge(ctx context.Context, tokenHash string) (*cache.MFAChallengeData, error) {
+	data, ok := c.challenges[tokenHash]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &data, nil
+}
+
+func (c *stubCache) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	delete(c.challenges, tokenHash)
+	return nil
+}
+
+func (c *stubCache) IncrementMFAChallengeAttempts(ctx context.Context, tokenHash string) error {
+	data, ok := c.challenges[tokenHash]
+	if !ok {
+		return errors.New("not found")
+	}
+	data.Attempts++
+	c.challenges[tokenHash] = data
+	return nil
+}
+
+func (c *stubCache) SetOIDCState(ctx context.Context, stateHash string, data *cache.OIDCStateData, ttl time.Duration) error {
+	return nil
+}
+func (c *stubCache) ConsumeOIDCState(ctx context.Context, stateHash string) (*cache.OIDCStateData, error) {
+	return nil, errors.New("not found")
+}
+func (c *stubCache) IncrementLoginFailures(ctx context.Context, key string, window time.Duration) (int, error) {
+	c.failures[key]++
+	return c.failures[key], nil
+}
+func (c *stubCache) GetLoginFailures(ctx context.Context, key string) (int, error) {
+	return c.failures[key], nil
+}
+func (c *stubCache) ResetLoginFailures(ctx context.Context, key string) error {
+	delete(c.failures, key)
+	return nil
+}
+func (c *stubCache) SetAccountUnlockToken(ctx context.Context, tokenHash string, data *cache.AccountUnlockData, ttl time.Duration) error {
+	return nil
+}
+func (c *stubCache) ConsumeAccountUnlockToken(ctx context.Context, tokenHash string) (*cache.AccountUnlockData, error) {
+	return nil, errors.New("not found")
+}
+
+type stubCaptcha struct{}
+
+func (stubCaptcha) Generate(ctx context.Context) (string, string, error) { return "", "", nil }
+func (stubCaptcha) Verify(ctx context.Context, captchaID, code string) (bool, error) {
+	return true, nil
+}
+
+type stubMailer struct{}
+
+func (stubMailer) SendVerificationCode(to, code string) error { return nil }
+func (stubMailer) SendPasswordReset(to, link string, expiresIn time.Duration) error {
+	return nil
+}
+func (stubMailer) SendAccountUnlock(to, link string, lockedUntil time.Time) error {
+	return nil
+}
+
+type mfaTestContext struct {
+	t       *testing.T
+	service *Service
+	mock    sqlmock.Sqlmock
+	cache   *stubCache
+	now     time.Time
+	pwdHash string
+}
+
+// newMFATestContext builds a Service whose clock is frozen so TOTP codes can
+// be computed offline.
+func newMFATestContext(t *testing.T) *mfaTestContext {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	repo, err := NewRepository(db)
+	require.NoError(t, err)
+	tokens, err := NewTokenManager("test-secret", 5*time.Minute, 24*time.Hour)
+	require.NoError(t, err)
+	stub := &stubCache{challenges: make(map[string]cache.MFAChallengeData), failures: make(map[string]int)}
+	service, err := NewService(repo, tokens, storage.NewAuditLogger(log.New(io.Discard, "", 0)), stub, stubCaptcha{}, stubMailer{})
+	require.NoError(t, err)
+
+	hash, err := HashPassword(mfaTestPass)
+	require.NoError(t, err)
+
+	ctx := &mfaTestContext{
+		t:       t,
+		service: service,
+		mock:    mock,
+		cache:   stub,
+		now:     time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC),
+		pwdHash: hash,
+	}
+	service.clockFn = func() time.Time { return ctx.now }
+	return ctx
+}
+
+func (ctx *mfaTestContext) code(secret string, offset time.Duration) string {
+	key, err := totpEncoding.DecodeString(secret)
+	require.NoError(ctx.t, err)
+	return totpCode(key, totpStep(ctx.now.Add(offset)))
+}
+
+func (ctx *mfaTestContext) userRow() *sqlmock.Rows {
+	return ctx.userRowWithStatus("active", sql.NullTime{})
+}
+
+func (ctx *mfaTestContext) userRowWithStatus(status string, lockedUntil sql.NullTime) *sqlmock.Rows {
+	return sqlmock.NewRows(userColumns).
+		AddRow(mfaTestUserID, "123e4567-e89b-12d3-a456-426614174000", mfaTestEmail, ctx.pwdHash, status, "user", lockedUntil, sql.NullTime{}, ctx.now, ctx.now)
+}
+
+func (ctx *mfaTestContext) expectUserByID() {
+	ctx.mock.ExpectQuery(selectUserQuery + "id = \\?").
+		WithArgs(mfaTestUserID).
+		WillReturnRows(ctx.userRow())
+}
+
+func (ctx *mfaTestContext) expectMFA(secret string, enabled bool, lastStep sql.NullInt64) {
+	enabledAt := sql.NullTime{}
+	if enabled {
+		enabledAt = sql.NullTime{Time: ctx.now.Add(-time.Hour), Valid: true}
+	}
+	ctx.mock.ExpectQuery(selectMFAQuery).
+		WithArgs(mfaTestUserID).
+		WillReturnRows(sqlmock.NewRows(mfaColumns).AddRow(mfaTestUserID, secret, enabledAt, lastStep, ctx.now, ctx.now))
+}
+
+// stringCapture records the string argument it is matched against.
+type stringCapture struct {
+	value *string
+}
+
+func (c stringCapture) Match(v driver.Value) bool {
+	if s, ok := v.(string); ok {
+		*c.value = s
+	}
+	return true
+}
+
+func TestMFAEnrollmentAndTwoStepLogin(t *testing.T) {
+	ctx := newMFATestContext(t)
+	bg := context.Background()
+
+	// 错误的密码不能开始绑定
+	ctx.expectUserByID()
+	_, err := ctx.service.BeginMFAEnrollment(bg, mfaTestUserID, "wrong-password")
+	require.ErrorIs(t, err, ErrIncorrectPassword)
+
+	// 开始绑定，生成待确认的密钥
+	var storedSecret string
+	ctx.expectUserByID()
+	ctx.mock.ExpectQuery(selectMFAQuery).WithArgs(mfaTestUserID).WillReturnError(sql.ErrNoRows)
+	ctx.mock.ExpectExec("INSERT INTO user_mfa").
+		WithArgs(mfaTestUserID, stringCapture{value: &storedSecret}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	enrollment, err := ctx.service.BeginMFAEnrollment(bg, mfaTestUserID, mfaTestPass)
+	require.NoError(t, err)
+	require.Equal(t, enrollment.Secret, storedSecret)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Online%20PPT:user@example.com?")
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	// 错误的首个验证码不会开启两步验证
+	ctx.expectMFA(storedSecret, false, sql.NullInt64{})
+	_, err = ctx.service.ConfirmMFAEnrollment(bg, mfaTestUserID, "000000")
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+
+	// 确认绑定并保存恢复码摘要
+	insertArgs := make([]driver.Value, 0, recoveryCodeCount*2)
+	for i := 0; i < recoveryCodeCount; i++ {
+		insertArgs = append(insertArgs, mfaTestUserID, sqlmock.AnyArg())
+	}
+	ctx.expectMFA(storedSecret, false, sql.NullInt64{})
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("UPDATE user_mfa SET enabled_at = \\?, last_used_step = \\?").
+		WithArgs(sqlmock.AnyArg(), totpStep(ctx.now), mfaTestUserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectExec("DELETE FROM user_recovery_codes WHERE user_id = \\?").
+		WithArgs(mfaTestUserID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	ctx.mock.ExpectExec("INSERT INTO user_recovery_codes \\(user_id, code_hash\\) VALUES").
+		WithArgs(insertArgs...).
+		WillReturnResult(sqlmock.NewResult(1, recoveryCodeCount))
+	ctx.mock.ExpectCommit()
+	codes, err := ctx.service.ConfirmMFAEnrollment(bg, mfaTestUserID, ctx.code(storedSecret, 0))
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+
+	// 密码正确后登录只返回挑战令牌
+	ctx.mock.ExpectQuery(selectUserQuery + "email = \\?").
+		WithArgs(mfaTestEmail).
+		WillReturnRows(ctx.userRow())
+	ctx.expectMFA(storedSecret, true, sql.NullInt64{Int64: totpStep(ctx.now), Valid: true})
+	result, err := ctx.service.Login(bg, LoginRequest{Email: mfaTestEmail, Password: mfaTestPass}, ClientInfo{
+		Fingerprint: "device-1",
+		IPAddress:   "203.0.113.7",
+		UserAgent:   "TestAgent/1.0",
+	})
+	require.NoError(t, err)
+	require.True(t, result.MFARequired())
+	require.Empty(t, result.AccessToken)
+	require.Empty(t, result.RefreshToken)
+	require.Equal(t, ctx.now.Add(mfaChallengeTTL), result.MFAExpiresAt)
+
+	// 错误的验证码计入尝试次数
+	ctx.expectUserByID()
+	ctx.expectMFA(storedSecret, true, sql.NullInt64{Int64: totpStep(ctx.now), Valid: true})
+	_, err = ctx.service.CompleteMFALogin(bg, result.MFAToken, "000000")
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+	require.Equal(t, 1, ctx.cache.challenges[hashSecretToken(result.MFAToken)].Attempts)
+	require.Equal(t, 1, ctx.cache.failures["account:"+mfaTestEmail])
+
+	// 下一个时间步的验证码完成登录
+	ctx.now = ctx.now.Add(totpPeriod * time.Second)
+	nextStep := totpStep(ctx.now)
+	ctx.expectUserByID()
+	ctx.expectMFA(storedSecret, true, sql.NullInt64{Int64: nextStep - 1, Valid: true})
+	ctx.mock.ExpectExec("UPDATE user_mfa SET last_used_step = \\?").
+		WithArgs(nextStep, mfaTestUserID, nextStep).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectExec("INSERT INTO user_sessions").
+		WithArgs(mfaTestUserID, sql.NullInt64{}, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sql.NullString{String: "device-1", Valid: true},
+			sql.NullString{String: "203.0.113.7", Valid: true}, sql.NullString{String: "TestAgent/1.0", Valid: true}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(9, 1))
+	ctx.mock.ExpectQuery("SELECT id, user_id, parent_id, family_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, ip_address, user_agent, revoked_at, created_at FROM user_sessions WHERE id = \\?").
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "parent_id", "family_id", "refresh_token_hash", "expires_at", "issued_at", "client_fingerprint", "ip_address", "user_agent", "revoked_at", "created_at"}).
+			AddRow(int64(9), mfaTestUserID, sql.NullInt64{}, "family-1", "hash", ctx.now.Add(time.Hour), ctx.now, sql.NullString{}, sql.NullString{}, sql.NullString{}, sql.NullTime{}, ctx.now))
+	ctx.mock.ExpectExec("UPDATE user_accounts SET last_login_at = \\?").
+		WithArgs(sqlmock.AnyArg(), mfaTestUserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	full, err := ctx.service.CompleteMFALogin(bg, result.MFAToken, ctx.code(storedSecret, 0))
+	require.NoError(t, err)
+	require.NotEmpty(t, full.AccessToken)
+	require.NotEmpty(t, full.RefreshToken)
+	claims, err := ctx.service.Authenticate(full.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, int64(9), claims.SessionID)
+	require.NotContains(t, ctx.cache.failures, "account:"+mfaTestEmail)
+
+	// 挑战令牌只能使用一次
+	_, err = ctx.service.CompleteMFALogin(bg, result.MFAToken, ctx.code(storedSecret, 0))
+	require.ErrorIs(t, err, ErrMFAChallengeNotFound)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestMFARejectsReplayedCodeAndAcceptsRecoveryCode(t *testing.T) {
+	ctx := newMFATestContext(t)
+	bg := context.Background()
+	secret := rfc6238Secret
+	step := totpStep(ctx.now)
+
+	// 已使用过的时间步不能再次使用
+	ctx.expectUserByID()
+	ctx.expectMFA(secret, true, sql.NullInt64{Int64: step, Valid: true})
+	ctx.mock.ExpectExec("UPDATE user_mfa SET last_used_step = \\?").
+		WithArgs(step, mfaTestUserID, step).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	err := ctx.service.DisableMFA(bg, mfaTestUserID, mfaTestPass, ctx.code(secret, 0))
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+
+	// 未知恢复码
+	ctx.expectUserByID()
+	ctx.expectMFA(secret, true, sql.NullInt64{Int64: step, Valid: true})
+	ctx.mock.ExpectExec("UPDATE user_recovery_codes SET used_at = \\?").
+		WithArgs(sqlmock.AnyArg(), mfaTestUserID, hashRecoveryCode("zzzzz-zzzzz")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	err = ctx.service.DisableMFA(bg, mfaTestUserID, mfaTestPass, "zzzzz-zzzzz")
+	require.ErrorIs(t, err, ErrInvalidMFACode)
+
+	// 恢复码（忽略大小写与分隔符）可用于关闭两步验证
+	ctx.expectUserByID()
+	ctx.expectMFA(secret, true, sql.NullInt64{Int64: step, Valid: true})
+	ctx.mock.ExpectExec("UPDATE user_recovery_codes SET used_at = \\?").
+		WithArgs(sqlmock.AnyArg(), mfaTestUserID, hashRecoveryCode("abcde-fghjk")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("DELETE FROM user_recovery_codes WHERE user_id = \\?").
+		WithArgs(mfaTestUserID).
+		WillReturnResult(sqlmock.NewResult(0, 9))
+	ctx.mock.ExpectExec("DELETE FROM user_mfa WHERE user_id = \\?").
+		WithArgs(mfaTestUserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectCommit()
+	require.NoError(t, ctx.service.DisableMFA(bg, mfaTestUserID, mfaTestPass, "ABCDEFGHJK"))
+
+	// 未开启时不能重新生成恢复码
+	ctx.expectUserByID()
+	ctx.mock.ExpectQuery(selectMFAQuery).WithArgs(mfaTestUserID).WillReturnError(sql.ErrNoRows)
+	_, err = ctx.service.RegenerateRecoveryCodes(bg, mfaTestUserID, mfaTestPass, "123456")
+	require.ErrorIs(t, err, ErrMFANotEnabled)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestMFAChallengeAttemptLimit(t *testing.T) {
+	ctx := newMFATestContext(t)
+
+	token := "challenge-token"
+	ctx.cache.challenges[hashSecretToken(token)] = cache.MFAChallengeData{
+		UserID:   mfaTestUserID,
+		Attempts: mfaChallengeMaxAttempts,
+	}
+
+	_, err := ctx.service.CompleteMFALogin(context.Background(), token, "123456")
+	require.ErrorIs(t, err, ErrTooManyAttempts)
+	require.Empty(t, ctx.cache.challenges)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestMFAFailuresCountTowardsAccountLockout(t *testing.T) {
+	ctx := newMFATestContext(t)
+	bg := context.Background()
+	secret := rfc6238Secret
+	step := totpStep(ctx.now)
+
+	// 新的挑战不会重置账号失败计数，错误的动态码同样会锁定账号
+	token := "challenge-token"
+	ctx.cache.challenges[hashSecretToken(token)] = cache.MFAChallengeData{UserID: mfaTestUserID, IPAddress: "203.0.113.7"}
+	ctx.cache.failures["account:"+mfaTestEmail] = ctx.service.throttle.LockAfter - 1
+
+	ctx.expectUserByID()
+	ctx.expectMFA(secret, true, sql.NullInt64{Int64: step, Valid: true})
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("UPDATE user_accounts SET status = 'locked'").
+		WithArgs(sqlmock.AnyArg(), mfaTestUserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectExec("UPDATE user_sessions SET revoked_at = \\?").
+		WithArgs(sqlmock.AnyArg(), mfaTestUserID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	ctx.mock.ExpectCommit()
+	_, err := ctx.service.CompleteMFALogin(bg, token, "000000")
+	require.ErrorIs(t, err, ErrAccountLocked)
+	require.Empty(t, ctx.cache.challenges)
+	require.Equal(t, 1, ctx.cache.failures["ip:203.0.113.7"])
+
+	// 挑战期间被锁定或改为待激活的账号不能完成登录
+	ctx.cache.challenges[hashSecretToken(token)] = cache.MFAChallengeData{UserID: mfaTestUserID}
+	ctx.mock.ExpectQuery(selectUserQuery + "id = \\?").
+		WithArgs(mfaTestUserID).
+		WillReturnRows(ctx.userRowWithStatus("locked", sql.NullTime{Time: ctx.now.Add(time.Hour), Valid: true}))
+	_, err = ctx.service.CompleteMFALogin(bg, token, ctx.code(secret, 0))
+	require.ErrorIs(t, err, ErrAccountLocked)
+	require.Empty(t, ctx.cache.challenges)
+
+	ctx.cache.challenges[hashSecretToken(token)] = cache.MFAChallengeData{UserID: mfaTestUserID}
+	ctx.mock.ExpectQuery(selectUserQuery + "id = \\?").
+		WithArgs(mfaTestUserID).
+		WillReturnRows(ctx.userRowWithStatus("pending", sql.NullTime{}))
+	_, err = ctx.service.CompleteMFALogin(bg, token, ctx.code(secret, 0))
+	require.ErrorIs(t, err, ErrAccountPending)
+	require.Empty(t, ctx.cache.challenges)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
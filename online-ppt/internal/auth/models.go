@@ -2,6 +2,7 @@ package auth
 
 import (
 	databaseSql "database/sql"
+	strings "strings"
 	time "time"
 )
 
@@ -12,6 +13,8 @@ type UserAccount struct {
 	Email        string
 	PasswordHash string
 	Status       string
+	Role         string
+	LockedUntil  databaseSql.NullTime
 	LastLoginAt  databaseSql.NullTime
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
@@ -21,10 +24,14 @@ type UserAccount struct {
 type UserSession struct {
 	ID                int64
 	UserID            int64
+	ParentID          databaseSql.NullInt64
+	FamilyID          databaseSql.NullString
 	RefreshTokenHash  string
 	ExpiresAt         time.Time
 	IssuedAt          time.Time
 	ClientFingerprint databaseSql.NullString
+	IPAddress         databaseSql.NullString
+	UserAgent         databaseSql.NullString
 	RevokedAt         databaseSql.NullTime
 	CreatedAt         time.Time
 }
@@ -42,6 +49,8 @@ func scanUserAccount(row scanner) (UserAccount, error) {
 		&ua.Email,
 		&ua.PasswordHash,
 		&ua.Status,
+		&ua.Role,
+		&ua.LockedUntil,
 		&ua.LastLoginAt,
 		&ua.CreatedAt,
 		&ua.UpdatedAt,
@@ -57,10 +66,14 @@ func scanUserSession(row scanner) (UserSession, error) {
 	if err := row.Scan(
 		&us.ID,
 		&us.UserID,
+		&us.ParentID,
+		&us.FamilyID,
 		&us.RefreshTokenHash,
 		&us.ExpiresAt,
 		&us.IssuedAt,
 		&us.ClientFingerprint,
+		&us.IPAddress,
+		&us.UserAgent,
 		&us.RevokedAt,
 		&us.CreatedAt,
 	); err != nil {
@@ -68,3 +81,100 @@ func scanUserSession(row scanner) (UserSession, error) {
 	}
 	return us, nil
 }
+
+// UserMFA mirrors the user_mfa table.
+type UserMFA struct {
+	UserID       int64
+	TOTPSecret   string
+	EnabledAt    databaseSql.NullTime
+	LastUsedStep databaseSql.NullInt64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Enabled reports whether enrollment was confirmed.
+func (m UserMFA) Enabled() bool {
+	return m.EnabledAt.Valid
+}
+
+// scanUserMFA builds a UserMFA from the current row.
+func scanUserMFA(row scanner) (UserMFA, error) {
+	var m UserMFA
+	if err := row.Scan(
+		&m.UserID,
+		&m.TOTPSecret,
+		&m.EnabledAt,
+		&m.LastUsedStep,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+	); err != nil {
+		return UserMFA{}, err
+	}
+	return m, nil
+}
+
+// APIToken mirrors the api_tokens table.
+type APIToken struct {
+	ID          int64
+	UserID      int64
+	Name        string
+	TokenHash   string
+	TokenPrefix string
+	Scopes      []string
+	ExpiresAt   databaseSql.NullTime
+	LastUsedAt  databaseSql.NullTime
+	RevokedAt   databaseSql.NullTime
+	CreatedAt   time.Time
+}
+
+// scanAPIToken builds an APIToken from the current row.
+func scanAPIToken(row scanner) (APIToken, error) {
+	var (
+		t      APIToken
+		scopes string
+	)
+	if err := row.Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Name,
+		&t.TokenHash,
+		&t.TokenPrefix,
+		&scopes,
+		&t.ExpiresAt,
+		&t.LastUsedAt,
+		&t.RevokedAt,
+		&t.CreatedAt,
+	); err != nil {
+		return APIToken{}, err
+	}
+	t.Scopes = strings.Split(scopes, ",")
+	return t, nil
+}
+
+// UserIdentity mirrors the user_identities table.
+type UserIdentity struct {
+	ID          int64
+	UserID      int64
+	Provider    string
+	Subject     string
+	Email       databaseSql.NullString
+	LastLoginAt databaseSql.NullTime
+	CreatedAt   time.Time
+}
+
+// scanUserIdentity builds a UserIdentity from the current row.
+func scanUserIdentity(row scanner) (UserIdentity, error) {
+	var ui UserIdentity
+	if err := row.Scan(
+		&ui.ID,
+		&ui.UserID,
+		&ui.Provider,
+		&ui.Subject,
+		&ui.Email,
+		&ui.LastLoginAt,
+		&ui.CreatedAt,
+	); err != nil {
+		return UserIdentity{}, err
+	}
+	return ui, nil
+}
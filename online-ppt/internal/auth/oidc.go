@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"online-ppt/internal/cache"
+)
+
+const (
+	oidcStateTTL            = 10 * time.Minute
+	oidcRandomBytes         = 32
+	defaultOIDCCallbackBase = "/api/v1/auth/oidc"
+)
+
+// ConfigureIdentityProviders registers the external identity providers users
+// may sign in with. callbackBase is the public URL under which the callback
+// endpoints live; the redirect URI of a provider is <callbackBase>/<name>/callback.
+func (s *Service) ConfigureIdentityProviders(callbackBase string, providers ...IdentityProvider) error {
+	if callbackBase == "" {
+		callbackBase = defaultOIDCCallbackBase
+	}
+	if _, err := url.Parse(callbackBase); err != nil {
+		return fmt.Errorf("parse oidc callback base: %w", err)
+	}
+
+	registered := make(map[string]IdentityProvider, len(providers))
+	for _, provider := range providers {
+		if provider == nil || provider.Name() == "" {
+			return fmt.Errorf("identity provider requires name")
+		}
+		if _, exists := registered[provider.Name()]; exists {
+			return fmt.Errorf("identity provider %s registered twice", provider.Name())
+		}
+		registered[provider.Name()] = provider
+	}
+
+	s.identityProviders = registered
+	s.oidcCallbackBase = strings.TrimRight(callbackBase, "/")
+	return nil
+}
+
+// IdentityProviderNames lists the configured external identity providers.
+func (s *Service) IdentityProviderNames() []string {
+	names := make([]string, 0, len(s.identityProviders))
+	for name := range s.identityProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginOIDCLogin starts an authorization code + PKCE sign-in and returns the
+// provider URL the browser should be sent to.
+func (s *Service) BeginOIDCLogin(ctx context.Context, providerName string) (string, error) {
+	provider, ok := s.identityProviders[providerName]
+	if !ok {
+		s.audit.Log("auth.oidc.begin", map[string]any{
+			"status":   "not_found",
+			"provider": providerName,
+		})
+		return "", ErrIdentityProviderNotFound
+	}
+
+	values, err := randomTokens(3)
+	if err != nil {
+		s.audit.Log("auth.oidc.begin", map[string]any{
+			"status":   "error",
+			"provider": providerName,
+			"reason":   err.Error(),
+		})
+		return "", err
+	}
+	req := AuthorizationRequest{
+		State:        values[0],
+		Nonce:        values[1],
+		CodeVerifier: values[2],
+		RedirectURI:  s.oidcRedirectURI(providerName),
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, req)
+	if err != nil {
+		s.audit.Log("auth.oidc.begin", map[string]any{
+			"status":   "error",
+			"provider": providerName,
+			"reason":   err.Error(),
+		})
+		return "", fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+
+	// 缓存中只保存 state 摘要，校验器与 nonce 仅在服务端保留
+	data := &cache.OIDCStateData{
+		Provider:     providerName,
+		CodeVerifier: req.CodeVerifier,
+		Nonce:        req.Nonce,
+		CreatedAt:    s.clockFn(),
+	}
+	if err := s.cache.SetOIDCState(ctx, hashSecretToken(req.State), data, oidcStateTTL); err != nil {
+		s.audit.Log("auth.oidc.begin", map[string]any{
+			"status":   "error",
+			"provider": providerName,
+			"reason":   err.Error(),
+		})
+		return "", err
+	}
+
+	s.audit.Log("auth.oidc.begin", map[string]any{
+		"status":   "redirect",
+		"provider": providerName,
+	})
+	return authURL, nil
+}
+
+// CompleteOIDCLogin handles the provider callback: it redeems the code, then
+// signs in the linked account, links an existing account with the same
+// verified email, or provisions a new one.
+func (s *Service) CompleteOIDCLogin(ctx context.Context, providerName, state, code string, client ClientInfo) (AuthResult, error) {
+	provider, ok := s.identityProviders[providerName]
+	if !ok {
+		s.audit.Log("auth.login.oidc", map[string]any{
+			"status":   "not_found",
+			"provider": providerName,
+		})
+		return AuthResult{}, ErrIdentityProviderNotFound
+	}
+
+	// state 只能使用一次，且必须属于同一个身份提供方
+	data, err := s.cache.ConsumeOIDCState(ctx, hashSecretToken(state))
+	if err != nil || state == "" || data.Provider != providerName {
+		s.audit.Log("auth.login.oidc", map[string]any{
+			"status":   "invalid_state",
+			"provider": providerName,
+		})
+		return AuthResult{}, ErrInvalidOIDCState
+	}
+
+	identity, err := provider.Exchange(ctx, code, AuthorizationRequest{
+		State:        state,
+		Nonce:        data.Nonce,
+		CodeVerifier: data.CodeVerifier,
+		RedirectURI:  s.oidcRedirectURI(providerName),
+	})
+	if err != nil {
+		s.audit.Log("auth.login.oidc", map[string]any{
+			"status":   "exchange_failed",
+			"provider": providerName,
+			"reason":   err.Error(),
+		})
+		return AuthResult{}, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	identity.Provider = providerName
+
+	user, err := s.resolveIdentity(ctx, identity)
+	if err != nil {
+		return AuthResult{}, err
+	}
+	if err := s.ensureUnlocked(ctx, &user, "auth.login.oidc"); err != nil {
+		return AuthResult{}, err
+	}
+	if user.Status == userStatusPending {
+		s.audit.Log("auth.login.oidc", map[string]any{
+			"status": "pending",
+			"userId": user.ID,
+		})
+		return AuthResult{}, ErrAccountPending
+	}
+
+	mfaRequired, err := s.mfaEnabled(ctx, user.ID)
+	if err != nil {
+		s.audit.Log("auth.login.oidc", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return AuthResult{}, err
+	}
+	if mfaRequired {
+		return s.startMFAChallenge(ctx, user, client)
+	}
+	return s.issueSession(ctx, user, client, nil, "auth.login.oidc")
+}
+
+// resolveIdentity maps an external identity onto a local account.
+func (s *Service) resolveIdentity(ctx context.Context, identity ExternalIdentity) (UserAccount, error) {
+	now := s.clockFn()
+	email := sql.NullString{String: identity.Email, Valid: identity.Email != ""}
+
+	linked, err := s.repo.FindIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		if err := s.repo.TouchIdentity(ctx, linked.ID, email, now); err != nil {
+			s.audit.Log("auth.login.oidc.touch_identity", map[string]any{
+				"status": "error",
+				"userId": linked.UserID,
+				"error":  err.Error(),
+			})
+		}
+		user, err := s.repo.GetUserByID(ctx, linked.UserID)
+		if err != nil {
+			s.audit.Log("auth.login.oidc", map[string]any{
+				"status": "error",
+				"userId": linked.UserID,
+				"reason": err.Error(),
+			})
+			return UserAccount{}, err
+		}
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		s.audit.Log("auth.login.oidc", map[string]any{
+			"status":   "error",
+			"provider": identity.Provider,
+			"reason":   err.Error(),
+		})
+		return UserAccount{}, err
+	}
+
+	// 未关联的身份只能凭已验证邮箱关联或创建账号
+	normalized, err := normalizeEmail(identity.Email)
+	if err != nil || !identity.EmailVerified {
+		s.audit.Log("auth.login.oidc", map[string]any{
+			"status":   "email_unverified",
+			"provider": identity.Provider,
+		})
+		return UserAccount{}, ErrOIDCEmailUnverified
+	}
+	link := UserIdentity{
+		Provider:    identity.Provider,
+		Subject:     identity.Subject,
+		Email:       email,
+		LastLoginAt: sql.NullTime{Time: now, Valid: true},
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, normalized)
+	if err == nil {
+		link.UserID = user.ID
+		if err := s.repo.CreateIdentity(ctx, link); err != nil {
+			s.audit.Log("auth.login.oidc", map[string]any{
+				"status": "error",
+				"userId": user.ID,
+				"reason": err.Error(),
+			})
+			return UserAccount{}, err
+		}
+		s.audit.Log("auth.oidc.link", map[string]any{
+			"status":   "success",
+			"userId":   user.ID,
+			"provider": identity.Provider,
+		})
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		s.audit.Log("auth.login.oidc", map[string]any{
+			"status": "error",
+			"email":  normalized,
+			"reason": err.Error(),
+		})
+		return UserAccount{}, err
+	}
+
+	// 新账号使用随机密码，用户可通过找回密码设置本地密码
+	secret, err := randomTokens(1)
+	if err != nil {
+		return UserAccount{}, err
+	}
+	hash, err := HashPassword(secret[0])
+	if err != nil {
+		return UserAccount{}, err
+	}
+	user, err = s.repo.CreateUserWithIdentity(ctx, normalized, hash, uuid.NewString(), link)
+	if err != nil {
+		s.audit.Log("auth.oidc.provision", map[string]any{
+			"status":   "error",
+			"email":    normalized,
+			"provider": identity.Provider,
+			"reason":   err.Error(),
+		})
+		return UserAccount{}, err
+	}
+	s.audit.Log("auth.oidc.provision", map[string]any{
+		"status":   "success",
+		"userId":   user.ID,
+		"provider": identity.Provider,
+	})
+	return user, nil
+}
+
+func (s *Service) oidcRedirectURI(providerName string) string {
+	return s.oidcCallbackBase + "/" + url.PathEscape(providerName) + "/callback"
+}
+
+// randomTokens returns n independent URL-safe random strings.
+func randomTokens(n int) ([]string, error) {
+	tokens := make([]string, n)
+	buf := make([]byte, oidcRandomBytes)
+	for i := range tokens {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("generate random token: %w", err)
+		}
+		tokens[i] = base64.RawURLEncoding.EncodeToString(buf)
+	}
+	return tokens, nil
+}
@@ -0,0 +1,374 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	oidcHTTPTimeout     = 10 * time.Second
+	oidcMaxResponseSize = 1 << 20
+	oidcClockSkew       = time.Minute
+)
+
+var defaultOIDCScopes = []string{"openid", "email", "profile"}
+
+// IdentityProvider signs users in through an external authorization server.
+type IdentityProvider interface {
+	// Name identifies the provider in URLs and in user_identities rows.
+	Name() string
+	// AuthCodeURL returns the URL the browser is sent to for consent.
+	AuthCodeURL(ctx context.Context, req AuthorizationRequest) (string, error)
+	// Exchange redeems the authorization code and returns the verified identity.
+	Exchange(ctx context.Context, code string, req AuthorizationRequest) (ExternalIdentity, error)
+}
+
+// AuthorizationRequest carries the per-login values of an authorization code + PKCE flow.
+type AuthorizationRequest struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+	RedirectURI  string
+}
+
+// ExternalIdentity is the user as asserted by an identity provider.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// OIDCProviderOptions configures an OpenID Connect provider.
+type OIDCProviderOptions struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// HTTPClient defaults to a client with a short timeout.
+	HTTPClient *http.Client
+}
+
+// OIDCProvider implements IdentityProvider for an OpenID Connect issuer. The
+// discovery document and signing keys are fetched lazily and cached.
+type OIDCProvider struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	httpClient   *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type idTokenClaims struct {
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"`
+	jwt.RegisteredClaims
+}
+
+// NewOIDCProvider validates the options and builds a provider.
+func NewOIDCProvider(opts OIDCProviderOptions) (*OIDCProvider, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("oidc provider requires name")
+	}
+	issuer, err := url.Parse(opts.Issuer)
+	if err != nil || issuer.Host == "" || (issuer.Scheme != "https" && issuer.Scheme != "http") {
+		return nil, fmt.Errorf("oidc provider %s requires an absolute issuer url", opts.Name)
+	}
+	if opts.ClientID == "" {
+		return nil, fmt.Errorf("oidc provider %s requires client id", opts.Name)
+	}
+	scopes := opts.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultOIDCScopes
+	}
+	hasOpenID := false
+	for _, scope := range scopes {
+		hasOpenID = hasOpenID || scope == "openid"
+	}
+	if !hasOpenID {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: oidcHTTPTimeout}
+	}
+	return &OIDCProvider{
+		name:         opts.Name,
+		issuer:       strings.TrimRight(opts.Issuer, "/"),
+		clientID:     opts.ClientID,
+		clientSecret: opts.ClientSecret,
+		scopes:       scopes,
+		httpClient:   client,
+	}, nil
+}
+
+// Name implements IdentityProvider.
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL implements IdentityProvider.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, req AuthorizationRequest) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("parse authorization endpoint: %w", err)
+	}
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.clientID)
+	query.Set("redirect_uri", req.RedirectURI)
+	query.Set("scope", strings.Join(p.scopes, " "))
+	query.Set("state", req.State)
+	query.Set("nonce", req.Nonce)
+	query.Set("code_challenge", pkceChallenge(req.CodeVerifier))
+	query.Set("code_challenge_method", "S256")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Exchange implements IdentityProvider.
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, req AuthorizationRequest) (ExternalIdentity, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return ExternalIdentity{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {req.RedirectURI},
+		"client_id":     {p.clientID},
+		"code_verifier": {req.CodeVerifier},
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return ExternalIdentity{}, fmt.Errorf("build token request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+	if p.clientSecret != "" {
+		httpReq.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	var tokenResp struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := p.doJSON(httpReq, &tokenResp); err != nil {
+		return ExternalIdentity{}, fmt.Errorf("token request: %w", err)
+	}
+	if tokenResp.IDToken == "" {
+		return ExternalIdentity{}, fmt.Errorf("token response has no id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, tokenResp.IDToken)
+	if err != nil {
+		return ExternalIdentity{}, err
+	}
+	if claims.Nonce == "" || claims.Nonce != req.Nonce {
+		return ExternalIdentity{}, fmt.Errorf("id token nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return ExternalIdentity{}, fmt.Errorf("id token has no subject")
+	}
+
+	return ExternalIdentity{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified == true || claims.EmailVerified == "true",
+	}, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience and expiry of an ID token.
+// iss must equal the issuer exactly as the discovery document reports it, which
+// may differ from the configured one by a trailing slash.
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, raw string) (*idTokenClaims, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := jwt.ParseWithClaims(raw, &idTokenClaims{}, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.signingKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(oidcClockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("verify id token: %w", err)
+	}
+	claims, ok := parsed.Claims.(*idTokenClaims)
+	if !ok || !parsed.Valid {
+		return nil, fmt.Errorf("invalid id token claims")
+	}
+	return claims, nil
+}
+
+// signingKey returns the key with the given id, refetching the key set once
+// when it is unknown so that provider key rotation is picked up.
+func (p *OIDCProvider) signingKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	keys := p.keys
+	p.mu.Unlock()
+
+	if key := pickKey(keys, kid); key != nil {
+		return key, nil
+	}
+
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.JWKSURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build jwks request: %w", err)
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.doJSON(req, &set); err != nil {
+		return nil, fmt.Errorf("jwks request: %w", err)
+	}
+
+	keys = make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key := pickKey(keys, kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// pickKey finds a key by id; a token without kid is accepted only when the set has a single key.
+func pickKey(keys map[string]*rsa.PublicKey, kid string) *rsa.PublicKey {
+	if key, ok := keys[kid]; ok {
+		return key
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return nil
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	cached := p.discovery
+	p.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("build discovery request: %w", err)
+	}
+	var doc oidcDiscovery
+	if err := p.doJSON(req, &doc); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimRight(doc.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", doc.Issuer, p.issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery: missing endpoints")
+	}
+
+	p.mu.Lock()
+	p.discovery = &doc
+	p.mu.Unlock()
+	return &doc, nil
+}
+
+func (p *OIDCProvider) doJSON(req *http.Request, dest any) error {
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, oidcMaxResponseSize))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			return fmt.Errorf("status %d: %s %s", resp.StatusCode, oauthErr.Error, oauthErr.Description)
+		}
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, dest); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// pkceChallenge derives the S256 code challenge of RFC 7636.
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+var _ IdentityProvider = (*OIDCProvider)(nil)
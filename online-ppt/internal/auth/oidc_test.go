@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPKCEChallenge(t *testing.T) {
+	// RFC 7636 Appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", pkceChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func TestNewOIDCProvider(t *testing.T) {
+	_, err := NewOIDCProvider(OIDCProviderOptions{Name: "corp", Issuer: "idp.example.com", ClientID: "client"})
+	assert.Error(t, err)
+
+	_, err = NewOIDCProvider(OIDCProviderOptions{Name: "corp", Issuer: "https://idp.example.com"})
+	assert.Error(t, err)
+
+	provider, err := NewOIDCProvider(OIDCProviderOptions{Name: "corp", Issuer: "https://idp.example.com/", ClientID: "client", Scopes: []string{"email"}})
+	require.NoError(t, err)
+	assert.Equal(t, "https://idp.example.com", provider.issuer)
+	assert.Equal(t, []string{"openid", "email"}, provider.scopes)
+}
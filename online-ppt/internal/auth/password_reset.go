@@ -0,0 +1,327 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"online-ppt/internal/cache"
+)
+
+const (
+	defaultResetTokenTTL = 30 * time.Minute
+	defaultResetLinkBase = "/reset-password"
+	resetRequestCooldown = 60 * time.Second
+	resetConfirmCooldown = 10 * time.Second
+	resetRequestLimitKey = "password_reset:%s"
+	resetConfirmLimitKey = "password_reset_confirm:%s"
+	resetTokenBytes      = 32
+	resetEligibleStatus  = "active"
+)
+
+// PasswordResetConfirmation carries the inputs of a reset confirmation.
+type PasswordResetConfirmation struct {
+	Token       string
+	Password    string
+	CaptchaID   string
+	CaptchaCode string
+	// ClientKey identifies the caller (usually the client IP) for throttling.
+	ClientKey string
+}
+
+// ConfigurePasswordReset sets the link embedded in reset emails and how long
+// a reset token stays valid. The token is appended as the "token" query parameter.
+func (s *Service) ConfigurePasswordReset(linkBase string, ttl time.Duration) error {
+	if linkBase != "" {
+		if _, err := url.Parse(linkBase); err != nil {
+			return fmt.Errorf("parse password reset link: %w", err)
+		}
+		s.resetLinkBase = linkBase
+	}
+	if ttl < 0 {
+		return fmt.Errorf("password reset ttl must not be negative")
+	}
+	if ttl > 0 {
+		s.resetTokenTTL = ttl
+	}
+	return nil
+}
+
+// RequestPasswordReset 发送密码重置链接邮件
+//
+// 为避免泄露账号是否存在，未注册或不可用的邮箱同样返回成功，但不会发送邮件。
+func (s *Service) RequestPasswordReset(ctx context.Context, email, captchaID, captchaCode string) (int, error) {
+	// 验证邮箱格式
+	normalized, err := normalizeEmail(email)
+	if err != nil {
+		s.audit.Log("auth.password_reset.request", map[string]any{
+			"status": "validation_failed",
+			"reason": err.Error(),
+		})
+		return 0, err
+	}
+
+	// 验证图形验证码
+	valid, err := s.captcha.Verify(ctx, captchaID, captchaCode)
+	if err != nil || !valid {
+		s.audit.Log("auth.password_reset.request", map[string]any{
+			"status": "invalid_captcha",
+			"email":  normalized,
+		})
+		return 0, ErrInvalidCaptcha
+	}
+
+	// 检查频率限制
+	limitKey := fmt.Sprintf(resetRequestLimitKey, normalized)
+	limited, err := s.cache.CheckRateLimit(ctx, limitKey)
+	if err != nil {
+		s.audit.Log("auth.password_reset.request", map[string]any{
+			"status": "error",
+			"email":  normalized,
+			"reason": err.Error(),
+		})
+		return 0, err
+	}
+	if limited {
+		s.audit.Log("auth.password_reset.request", map[string]any{
+			"status": "rate_limited",
+			"email":  normalized,
+		})
+		return 0, ErrRateLimited
+	}
+	if err := s.cache.SetRateLimit(ctx, limitKey, resetRequestCooldown); err != nil {
+		s.audit.Log("auth.password_reset.request", map[string]any{
+			"status": "error",
+			"email":  normalized,
+			"reason": err.Error(),
+		})
+		return 0, err
+	}
+
+	expiresIn := int(s.resetTokenTTL.Seconds())
+
+	user, err := s.repo.GetUserByEmail(ctx, normalized)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("auth.password_reset.request", map[string]any{
+				"status": "not_found",
+				"email":  normalized,
+			})
+			return expiresIn, nil
+		}
+		s.audit.Log("auth.password_reset.request", map[string]any{
+			"status": "error",
+			"email":  normalized,
+			"reason": err.Error(),
+		})
+		return 0, err
+	}
+	if user.Status != resetEligibleStatus {
+		s.audit.Log("auth.password_reset.request", map[string]any{
+			"status": "ineligible",
+			"userId": user.ID,
+			"reason": "account " + user.Status,
+		})
+		return expiresIn, nil
+	}
+
+	if err := s.sendPasswordResetLink(ctx, user); err != nil {
+		s.audit.Log("auth.password_reset.request", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return 0, err
+	}
+
+	s.audit.Log("auth.password_reset.request", map[string]any{
+		"status": "success",
+		"userId": user.ID,
+	})
+	return expiresIn, nil
+}
+
+// ConfirmPasswordReset 使用重置令牌设置新密码，并吊销该用户的全部会话
+func (s *Service) ConfirmPasswordReset(ctx context.Context, req PasswordResetConfirmation) error {
+	if req.Token == "" {
+		s.audit.Log("auth.password_reset.confirm", map[string]any{
+			"status": "validation_failed",
+			"reason": "reset token required",
+		})
+		return ErrInvalidResetToken
+	}
+	// 先校验密码，避免弱密码白白消耗一次性令牌
+	if err := validatePassword(req.Password); err != nil {
+		s.audit.Log("auth.password_reset.confirm", map[string]any{
+			"status": "validation_failed",
+			"reason": err.Error(),
+		})
+		return err
+	}
+
+	// 验证图形验证码
+	valid, err := s.captcha.Verify(ctx, req.CaptchaID, req.CaptchaCode)
+	if err != nil || !valid {
+		s.audit.Log("auth.password_reset.confirm", map[string]any{
+			"status": "invalid_captcha",
+		})
+		return ErrInvalidCaptcha
+	}
+
+	// 检查频率限制：猜错令牌后短时间内拒绝同一客户端继续尝试
+	limitKey := ""
+	if req.ClientKey != "" {
+		limitKey = fmt.Sprintf(resetConfirmLimitKey, req.ClientKey)
+		limited, err := s.cache.CheckRateLimit(ctx, limitKey)
+		if err != nil {
+			s.audit.Log("auth.password_reset.confirm", map[string]any{
+				"status": "error",
+				"reason": err.Error(),
+			})
+			return err
+		}
+		if limited {
+			s.audit.Log("auth.password_reset.confirm", map[string]any{
+				"status": "rate_limited",
+			})
+			return ErrRateLimited
+		}
+	}
+
+	// 取出即删除，令牌只能使用一次
+	data, err := s.cache.ConsumePasswordResetToken(ctx, hashSecretToken(req.Token))
+	if err != nil {
+		if limitKey != "" {
+			_ = s.cache.SetRateLimit(ctx, limitKey, resetConfirmCooldown)
+		}
+		s.audit.Log("auth.password_reset.confirm", map[string]any{
+			"status": "invalid_token",
+			"reason": "token not found or expired",
+		})
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, data.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("auth.password_reset.confirm", map[string]any{
+				"status": "invalid_token",
+				"userId": data.UserID,
+				"reason": "user not found",
+			})
+			return ErrInvalidResetToken
+		}
+		s.audit.Log("auth.password_reset.confirm", map[string]any{
+			"status": "error",
+			"userId": data.UserID,
+			"reason": err.Error(),
+		})
+		return err
+	}
+	// 申请后邮箱被修改或账号被锁定，令牌随之失效
+	if user.Email != data.Email || user.Status != resetEligibleStatus {
+		s.audit.Log("auth.password_reset.confirm", map[string]any{
+			"status": "invalid_token",
+			"userId": user.ID,
+			"reason": "account changed since request",
+		})
+		return ErrInvalidResetToken
+	}
+
+	hash, err := HashPassword(req.Password)
+	if err != nil {
+		s.audit.Log("auth.password_reset.confirm", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return err
+	}
+
+	var revoked int64
+	err = s.repo.WithTx(ctx, func(tx *sql.Tx) error {
+		rt := NewRepositoryTx(tx, s.repo.dialect)
+		if err := rt.UpdatePasswordTx(ctx, user.ID, hash); err != nil {
+			return err
+		}
+		count, err := rt.RevokeUserSessionsTx(ctx, user.ID, s.clockFn())
+		if err != nil {
+			return err
+		}
+		revoked = count
+		return nil
+	})
+	if err != nil {
+		s.audit.Log("auth.password_reset.confirm", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return err
+	}
+
+	s.audit.Log("auth.password_reset.confirm", map[string]any{
+		"status":          "success",
+		"userId":          user.ID,
+		"revokedSessions": revoked,
+	})
+	return nil
+}
+
+// sendPasswordResetLink 生成重置令牌并发送重置链接邮件，缓存中只保存令牌摘要
+func (s *Service) sendPasswordResetLink(ctx context.Context, user UserAccount) error {
+	token, err := generateResetToken()
+	if err != nil {
+		return err
+	}
+	data := &cache.PasswordResetData{
+		UserID:    user.ID,
+		Email:     user.Email,
+		CreatedAt: s.clockFn(),
+	}
+	if err := s.cache.SetPasswordResetToken(ctx, hashSecretToken(token), data, s.resetTokenTTL); err != nil {
+		return err
+	}
+
+	link, err := buildTokenLink(s.resetLinkBase, token)
+	if err != nil {
+		return err
+	}
+	if err := s.mail.SendPasswordReset(user.Email, link, s.resetTokenTTL); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// generateResetToken 生成 URL 安全的随机重置令牌
+func generateResetToken() (string, error) {
+	buf := make([]byte, resetTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate reset token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashSecretToken 计算一次性令牌（重置令牌、两步登录挑战）的摘要，用作缓存键
+func hashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// buildTokenLink appends a one-time token to an emailed link as the "token" query parameter.
+func buildTokenLink(base, token string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("parse token link: %w", err)
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
@@ -5,11 +5,14 @@ import (
 	databaseSql "database/sql"
 	fmt "fmt"
 	time "time"
+
+	storage "online-ppt/internal/storage"
 )
 
 // Repository provides persistence operations for users and sessions.
 type Repository struct {
-	db *databaseSql.DB
+	db      *databaseSql.DB
+	dialect storage.Dialect
 }
 
 // NewRepository creates a new Repository instance.
@@ -17,28 +20,29 @@ func NewRepository(db *databaseSql.DB) (*Repository, error) {
 	if db == nil {
 		return nil, fmt.Errorf("auth repository requires db handle")
 	}
-	return &Repository{db: db}, nil
+	return &Repository{db: db, dialect: storage.MySQL}, nil
+}
+
+// WithDialect selects the SQL dialect of the database; the default is MySQL.
+func (r *Repository) WithDialect(dialect storage.Dialect) *Repository {
+	r.dialect = dialect
+	return r
 }
 
 // CreateUser inserts a new user row and returns the hydrated entity.
 func (r *Repository) CreateUser(ctx context.Context, email, passwordHash, uuid string) (UserAccount, error) {
 	stmt := `INSERT INTO user_accounts (email, password_hash, uuid) VALUES (?, ?, ?)`
-	res, err := r.db.ExecContext(ctx, stmt, email, passwordHash, uuid)
+	id, err := r.dialect.InsertID(ctx, r.db, stmt, email, passwordHash, uuid)
 	if err != nil {
 		return UserAccount{}, fmt.Errorf("insert user: %w", err)
 	}
 
-	id, err := res.LastInsertId()
-	if err != nil {
-		return UserAccount{}, fmt.Errorf("derive user id: %w", err)
-	}
-
 	return r.GetUserByID(ctx, id)
 }
 
 // GetUserByEmail fetches a user by normalized email address.
 func (r *Repository) GetUserByEmail(ctx context.Context, email string) (UserAccount, error) {
-	stmt := `SELECT id, uuid, email, password_hash, status, last_login_at, created_at, updated_at FROM user_accounts WHERE email = ? LIMIT 1`
+	stmt := `SELECT ` + userAccountColumns + ` FROM user_accounts WHERE email = ? LIMIT 1`
 	row := r.db.QueryRowContext(ctx, stmt, email)
 	user, err := scanUserAccount(row)
 	if err != nil {
@@ -49,7 +53,7 @@ func (r *Repository) GetUserByEmail(ctx context.Context, email string) (UserAcco
 
 // GetUserByID fetches a user by primary key.
 func (r *Repository) GetUserByID(ctx context.Context, id int64) (UserAccount, error) {
-	stmt := `SELECT id, uuid, email, password_hash, status, last_login_at, created_at, updated_at FROM user_accounts WHERE id = ? LIMIT 1`
+	stmt := `SELECT ` + userAccountColumns + ` FROM user_accounts WHERE id = ? LIMIT 1`
 	row := r.db.QueryRowContext(ctx, stmt, id)
 	user, err := scanUserAccount(row)
 	if err != nil {
@@ -67,43 +71,91 @@ func (r *Repository) UpdateLastLogin(ctx context.Context, userID int64, ts time.
 	return nil
 }
 
+// UnlockUser reactivates a locked account and clears its lock expiry.
+func (r *Repository) UnlockUser(ctx context.Context, userID int64) error {
+	stmt := `UPDATE user_accounts SET status = 'active', locked_until = NULL, updated_at = NOW() WHERE id = ? AND status = 'locked'`
+	if _, err := r.db.ExecContext(ctx, stmt, userID); err != nil {
+		return fmt.Errorf("unlock user: %w", err)
+	}
+	return nil
+}
+
 // CreateSession persists a refresh token entry.
 func (r *Repository) CreateSession(ctx context.Context, session UserSession) (UserSession, error) {
-	stmt := `INSERT INTO user_sessions (user_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, revoked_at) VALUES (?, ?, ?, ?, ?, ?)`
-	res, err := r.db.ExecContext(ctx, stmt,
+	stmt := `INSERT INTO user_sessions (user_id, parent_id, family_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, ip_address, user_agent, revoked_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	id, err := r.dialect.InsertID(ctx, r.db, stmt,
 		session.UserID,
+		session.ParentID,
+		session.FamilyID,
 		session.RefreshTokenHash,
 		session.ExpiresAt,
 		session.IssuedAt,
 		session.ClientFingerprint,
+		session.IPAddress,
+		session.UserAgent,
 		session.RevokedAt,
 	)
 	if err != nil {
 		return UserSession{}, fmt.Errorf("insert session: %w", err)
 	}
 
-	id, err := res.LastInsertId()
-	if err != nil {
-		return UserSession{}, fmt.Errorf("derive session id: %w", err)
-	}
-
 	return r.GetSessionByID(ctx, id)
 }
 
 // GetSessionByID fetches a session row.
 func (r *Repository) GetSessionByID(ctx context.Context, id int64) (UserSession, error) {
-	stmt := `SELECT id, user_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, revoked_at, created_at FROM user_sessions WHERE id = ? LIMIT 1`
+	stmt := `SELECT id, user_id, parent_id, family_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, ip_address, user_agent, revoked_at, created_at FROM user_sessions WHERE id = ? LIMIT 1`
 	row := r.db.QueryRowContext(ctx, stmt, id)
 	return scanUserSession(row)
 }
 
 // FindActiveSession locates an active session by hash.
 func (r *Repository) FindActiveSession(ctx context.Context, hash string) (UserSession, error) {
-	stmt := `SELECT id, user_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, revoked_at, created_at FROM user_sessions WHERE refresh_token_hash = ? AND revoked_at IS NULL LIMIT 1`
+	stmt := `SELECT id, user_id, parent_id, family_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, ip_address, user_agent, revoked_at, created_at FROM user_sessions WHERE refresh_token_hash = ? AND revoked_at IS NULL LIMIT 1`
+	row := r.db.QueryRowContext(ctx, stmt, hash)
+	return scanUserSession(row)
+}
+
+// FindSessionByHash locates a session by refresh token hash, including revoked ones.
+func (r *Repository) FindSessionByHash(ctx context.Context, hash string) (UserSession, error) {
+	stmt := `SELECT id, user_id, parent_id, family_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, ip_address, user_agent, revoked_at, created_at FROM user_sessions WHERE refresh_token_hash = ? LIMIT 1`
 	row := r.db.QueryRowContext(ctx, stmt, hash)
 	return scanUserSession(row)
 }
 
+// HasChildSession reports whether a session has already been rotated into a successor.
+func (r *Repository) HasChildSession(ctx context.Context, sessionID int64) (bool, error) {
+	var exists bool
+	stmt := `SELECT EXISTS(SELECT 1 FROM user_sessions WHERE parent_id = ?)`
+	if err := r.db.QueryRowContext(ctx, stmt, sessionID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("check child session: %w", err)
+	}
+	return exists, nil
+}
+
+// ListActiveSessions returns the unrevoked, unexpired sessions of a user, newest first.
+func (r *Repository) ListActiveSessions(ctx context.Context, userID int64, now time.Time) ([]UserSession, error) {
+	stmt := `SELECT id, user_id, parent_id, family_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, ip_address, user_agent, revoked_at, created_at FROM user_sessions WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ? ORDER BY issued_at DESC, id DESC`
+	rows, err := r.db.QueryContext(ctx, stmt, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []UserSession
+	for rows.Next() {
+		session, err := scanUserSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sessions: %w", err)
+	}
+	return sessions, nil
+}
+
 // RevokeSession marks a session as revoked.
 func (r *Repository) RevokeSession(ctx context.Context, sessionID int64, revokedAt time.Time) error {
 	stmt := `UPDATE user_sessions SET revoked_at = ? WHERE id = ?`
@@ -113,6 +165,50 @@ func (r *Repository) RevokeSession(ctx context.Context, sessionID int64, revoked
 	return nil
 }
 
+// RevokeActiveSession revokes a session only if it is still active. It reports
+// false when another request revoked it first.
+func (r *Repository) RevokeActiveSession(ctx context.Context, sessionID int64, revokedAt time.Time) (bool, error) {
+	stmt := `UPDATE user_sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`
+	res, err := r.db.ExecContext(ctx, stmt, revokedAt, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("revoke active session: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// RevokeSessionFamily revokes every active session descending from the same login.
+func (r *Repository) RevokeSessionFamily(ctx context.Context, familyID string, revokedAt time.Time) (int64, error) {
+	stmt := `UPDATE user_sessions SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`
+	res, err := r.db.ExecContext(ctx, stmt, revokedAt, familyID)
+	if err != nil {
+		return 0, fmt.Errorf("revoke session family: %w", err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	return count, nil
+}
+
+// RevokeOtherSessions revokes every active session of a user except
+// keepSessionID in a single statement.
+func (r *Repository) RevokeOtherSessions(ctx context.Context, userID, keepSessionID int64, revokedAt time.Time) (int64, error) {
+	stmt := `UPDATE user_sessions SET revoked_at = ? WHERE user_id = ? AND id <> ? AND revoked_at IS NULL`
+	res, err := r.db.ExecContext(ctx, stmt, revokedAt, userID, keepSessionID)
+	if err != nil {
+		return 0, fmt.Errorf("revoke other sessions: %w", err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	return count, nil
+}
+
 // PurgeExpiredSessions removes sessions whose expiry is in the past.
 func (r *Repository) PurgeExpiredSessions(ctx context.Context, cutoff time.Time) (int64, error) {
 	stmt := `DELETE FROM user_sessions WHERE expires_at < ?`
@@ -153,29 +249,34 @@ func (r *Repository) WithTx(ctx context.Context, fn func(tx *databaseSql.Tx) err
 
 // RepositoryTx exposes helpers when running inside a transaction.
 type RepositoryTx struct {
-	tx *databaseSql.Tx
+	tx      *databaseSql.Tx
+	dialect storage.Dialect
 }
 
 // NewRepositoryTx converts a sql.Tx into a transactional repository helper.
-func NewRepositoryTx(tx *databaseSql.Tx) RepositoryTx {
-	return RepositoryTx{tx: tx}
+func NewRepositoryTx(tx *databaseSql.Tx, dialect storage.Dialect) RepositoryTx {
+	return RepositoryTx{tx: tx, dialect: dialect}
 }
 
 // CreateSessionTx inserts a session when inside an existing transaction.
 func (rt RepositoryTx) CreateSessionTx(ctx context.Context, session UserSession) (int64, error) {
-	stmt := `INSERT INTO user_sessions (user_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, revoked_at) VALUES (?, ?, ?, ?, ?, ?)`
-	res, err := rt.tx.ExecContext(ctx, stmt,
+	stmt := `INSERT INTO user_sessions (user_id, parent_id, family_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, ip_address, user_agent, revoked_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	id, err := rt.dialect.InsertID(ctx, rt.tx, stmt,
 		session.UserID,
+		session.ParentID,
+		session.FamilyID,
 		session.RefreshTokenHash,
 		session.ExpiresAt,
 		session.IssuedAt,
 		session.ClientFingerprint,
+		session.IPAddress,
+		session.UserAgent,
 		session.RevokedAt,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("insert session tx: %w", err)
 	}
-	return res.LastInsertId()
+	return id, nil
 }
 
 // UpdateLastLoginTx updates last login timestamp inside a transaction.
@@ -186,3 +287,82 @@ func (rt RepositoryTx) UpdateLastLoginTx(ctx context.Context, userID int64, ts t
 	}
 	return nil
 }
+
+// UpdatePasswordTx replaces a user's password hash inside a transaction.
+func (rt RepositoryTx) UpdatePasswordTx(ctx context.Context, userID int64, passwordHash string) error {
+	stmt := `UPDATE user_accounts SET password_hash = ?, updated_at = NOW() WHERE id = ?`
+	res, err := rt.tx.ExecContext(ctx, stmt, passwordHash, userID)
+	if err != nil {
+		return fmt.Errorf("update password tx: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return databaseSql.ErrNoRows
+	}
+	return nil
+}
+
+// LockUserTx locks an active account until the given time inside a
+// transaction. It reports false when the account was not active, e.g. another
+// request locked it first.
+func (rt RepositoryTx) LockUserTx(ctx context.Context, userID int64, until time.Time) (bool, error) {
+	stmt := `UPDATE user_accounts SET status = 'locked', locked_until = ?, updated_at = NOW() WHERE id = ? AND status = 'active'`
+	res, err := rt.tx.ExecContext(ctx, stmt, until, userID)
+	if err != nil {
+		return false, fmt.Errorf("lock user tx: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// RevokeUserSessionsTx revokes every active session of a user inside a transaction.
+func (rt RepositoryTx) RevokeUserSessionsTx(ctx context.Context, userID int64, revokedAt time.Time) (int64, error) {
+	stmt := `UPDATE user_sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`
+	res, err := rt.tx.ExecContext(ctx, stmt, revokedAt, userID)
+	if err != nil {
+		return 0, fmt.Errorf("revoke user sessions tx: %w", err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	return count, nil
+}
+
+// UpdateEmailTx replaces a user's email address inside a transaction.
+func (rt RepositoryTx) UpdateEmailTx(ctx context.Context, userID int64, email string) error {
+	stmt := `UPDATE user_accounts SET email = ?, updated_at = NOW() WHERE id = ?`
+	res, err := rt.tx.ExecContext(ctx, stmt, email, userID)
+	if err != nil {
+		return fmt.Errorf("update email tx: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return databaseSql.ErrNoRows
+	}
+	return nil
+}
+
+// RevokeOtherSessionsTx revokes every active session of a user except keepSessionID
+// inside a transaction. A zero keepSessionID revokes them all.
+func (rt RepositoryTx) RevokeOtherSessionsTx(ctx context.Context, userID, keepSessionID int64, revokedAt time.Time) (int64, error) {
+	stmt := `UPDATE user_sessions SET revoked_at = ? WHERE user_id = ? AND id <> ? AND revoked_at IS NULL`
+	res, err := rt.tx.ExecContext(ctx, stmt, revokedAt, userID, keepSessionID)
+	if err != nil {
+		return 0, fmt.Errorf("revoke other sessions tx: %w", err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+	return count, nil
+}
@@ -11,7 +11,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
 
 	"online-ppt/internal/cache"
@@ -35,6 +34,56 @@ var (
 	ErrInvalidVerificationCode = errors.New("invalid verification code")
 	// ErrTooManyAttempts indicates too many verification attempts.
 	ErrTooManyAttempts = errors.New("too many verification attempts")
+	// ErrWeakPassword indicates the password does not meet the length requirement.
+	ErrWeakPassword = errors.New("password must be at least 10 characters")
+	// ErrInvalidResetToken indicates the password reset token is unknown, used or expired.
+	ErrInvalidResetToken = errors.New("invalid password reset token")
+	// ErrIncorrectPassword indicates the current password supplied by a signed-in user is wrong.
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	// ErrEmailUnchanged indicates the requested email equals the current one.
+	ErrEmailUnchanged = errors.New("new email must differ from the current email")
+	// ErrInvalidMFACode indicates the authenticator or recovery code is wrong or already used.
+	ErrInvalidMFACode = errors.New("invalid two-factor code")
+	// ErrMFAAlreadyEnabled indicates two-factor login is already active.
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	// ErrMFANotEnabled indicates two-factor login is not active or enrollment was not started.
+	ErrMFANotEnabled = errors.New("two-factor authentication not enabled")
+	// ErrMFAChallengeNotFound indicates the login challenge token is unknown or expired.
+	ErrMFAChallengeNotFound = errors.New("mfa challenge not found")
+	// ErrCurrentSessionUnknown indicates the caller's own session could not be identified.
+	ErrCurrentSessionUnknown = errors.New("current session unknown")
+	// ErrRefreshTokenReused indicates an already rotated refresh token was presented again.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+	// ErrInvalidAPIToken indicates a personal access token is unknown, revoked or expired.
+	ErrInvalidAPIToken = errors.New("invalid api token")
+	// ErrInvalidAPITokenRequest indicates a token name, scope or lifetime is not acceptable.
+	ErrInvalidAPITokenRequest = errors.New("invalid api token request")
+	// ErrAPITokenNotFound indicates the token does not exist or belongs to another user.
+	ErrAPITokenNotFound = errors.New("api token not found")
+	// ErrIdentityProviderNotFound indicates no external identity provider has the requested name.
+	ErrIdentityProviderNotFound = errors.New("identity provider not found")
+	// ErrInvalidOIDCState indicates the sign-in callback carries an unknown, used or expired state.
+	ErrInvalidOIDCState = errors.New("invalid oidc state")
+	// ErrOIDCLoginFailed indicates the identity provider rejected the code or returned an invalid ID token.
+	ErrOIDCLoginFailed = errors.New("external sign-in failed")
+	// ErrOIDCEmailUnverified indicates an unlinked identity has no verified email to link or provision by.
+	ErrOIDCEmailUnverified = errors.New("identity provider did not assert a verified email")
+	// ErrAccountLocked indicates the account is locked, automatically after repeated failures or by an operator.
+	ErrAccountLocked = errors.New("account locked")
+	// ErrAccountPending indicates the account has not been activated yet.
+	ErrAccountPending = errors.New("account pending activation")
+	// ErrCaptchaRequired indicates repeated failed logins require a captcha before the next attempt.
+	ErrCaptchaRequired = errors.New("captcha required")
+	// ErrInvalidUnlockToken indicates the account unlock token is unknown, used or expired.
+	ErrInvalidUnlockToken = errors.New("invalid account unlock token")
+	// ErrUserNotFound indicates an administrator addressed an account that does not exist.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrInvalidUserStatus indicates a status other than active, locked or pending.
+	ErrInvalidUserStatus = errors.New("status must be active, locked or pending")
+	// ErrInvalidUserRole indicates a role other than user or admin.
+	ErrInvalidUserRole = errors.New("role must be user or admin")
+	// ErrAdminSelfAction indicates an administrator tried to lock, demote or delete their own account.
+	ErrAdminSelfAction = errors.New("administrators cannot lock, demote or delete their own account")
 )
 
 // Service coordinates authentication workflows.
@@ -46,6 +95,15 @@ type Service struct {
 	captcha captcha.Service
 	mail    mailpkg.Service
 	clockFn func() time.Time
+
+	resetLinkBase string
+	resetTokenTTL time.Duration
+
+	throttle       LoginThrottle
+	unlockLinkBase string
+
+	identityProviders map[string]IdentityProvider
+	oidcCallbackBase  string
 }
 
 // AuthResult represents the outcome of a login or refresh invocation.
@@ -55,6 +113,16 @@ type AuthResult struct {
 	AccessExpiresAt  time.Time
 	RefreshToken     string
 	RefreshExpiresAt time.Time
+
+	// MFAToken is set instead of the tokens above when the password was
+	// accepted but a second factor is still required (see CompleteMFALogin).
+	MFAToken     string
+	MFAExpiresAt time.Time
+}
+
+// MFARequired reports whether the login still awaits a second factor.
+func (r AuthResult) MFARequired() bool {
+	return r.MFAToken != ""
 }
 
 // NewService builds a Service with sane defaults.
@@ -92,6 +160,12 @@ func NewService(
 		captcha: captchaService,
 		mail:    mailService,
 		clockFn: time.Now,
+
+		resetLinkBase: defaultResetLinkBase,
+		resetTokenTTL: defaultResetTokenTTL,
+
+		throttle:       defaultLoginThrottle,
+		unlockLinkBase: defaultUnlockLinkBase,
 	}, nil
 }
 
@@ -126,8 +200,7 @@ func (s *Service) Register(ctx context.Context, email, password string) (UserAcc
 
 	user, err := s.repo.CreateUser(ctx, normalized, hash, uuidValue)
 	if err != nil {
-		var mysqlErr *mysql.MySQLError
-		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		if s.repo.dialect.IsUniqueViolation(err) {
 			s.audit.Log("auth.register", map[string]any{
 				"status": "conflict",
 				"reason": ErrEmailAlreadyRegistered.Error(),
@@ -150,9 +223,18 @@ func (s *Service) Register(ctx context.Context, email, password string) (UserAcc
 	return user, nil
 }
 
+// LoginRequest carries the inputs of a password login.
+type LoginRequest struct {
+	Email    string
+	Password string
+	// CaptchaID and CaptchaCode answer the captcha demanded after repeated failures.
+	CaptchaID   string
+	CaptchaCode string
+}
+
 // Login authenticates a user and provisions tokens plus session state.
-func (s *Service) Login(ctx context.Context, email, password, fingerprint string) (AuthResult, error) {
-	normalized, err := normalizeEmail(email)
+func (s *Service) Login(ctx context.Context, req LoginRequest, client ClientInfo) (AuthResult, error) {
+	normalized, err := normalizeEmail(req.Email)
 	if err != nil {
 		s.audit.Log("auth.login", map[string]any{
 			"status": "validation_failed",
@@ -161,9 +243,16 @@ func (s *Service) Login(ctx context.Context, email, password, fingerprint string
 		return AuthResult{}, err
 	}
 
+	// 失败计数按邮箱与客户端 IP 分别统计，未注册的邮箱同样计数
+	keys := loginThrottleKeys(normalized, client.IPAddress)
+	if err := s.checkLoginThrottle(ctx, normalized, keys, req); err != nil {
+		return AuthResult{}, err
+	}
+
 	user, err := s.repo.GetUserByEmail(ctx, normalized)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
+			s.recordLoginFailure(ctx, keys, nil)
 			s.audit.Log("auth.login", map[string]any{
 				"status": "invalid_credentials",
 				"email":  normalized,
@@ -178,7 +267,12 @@ func (s *Service) Login(ctx context.Context, email, password, fingerprint string
 		return AuthResult{}, err
 	}
 
-	match, err := VerifyPassword(user.PasswordHash, password)
+	// 锁定状态先于密码校验判断，避免锁定期间继续猜测密码
+	if err := s.ensureUnlocked(ctx, &user, "auth.login"); err != nil {
+		return AuthResult{}, err
+	}
+
+	match, err := VerifyPassword(user.PasswordHash, req.Password)
 	if err != nil {
 		s.audit.Log("auth.login", map[string]any{
 			"status": "error",
@@ -188,14 +282,48 @@ func (s *Service) Login(ctx context.Context, email, password, fingerprint string
 		return AuthResult{}, err
 	}
 	if !match {
+		if s.recordLoginFailure(ctx, keys, &user) {
+			return AuthResult{}, ErrAccountLocked
+		}
 		s.audit.Log("auth.login", map[string]any{
 			"status": "invalid_credentials",
 			"userId": user.ID,
 		})
 		return AuthResult{}, ErrInvalidCredentials
 	}
+	if user.Status == userStatusPending {
+		s.audit.Log("auth.login", map[string]any{
+			"status": "pending",
+			"userId": user.ID,
+		})
+		return AuthResult{}, ErrAccountPending
+	}
 
-	result, err := s.issueSession(ctx, user, fingerprint, "auth.login")
+	mfaRequired, err := s.mfaEnabled(ctx, user.ID)
+	if err != nil {
+		s.audit.Log("auth.login", map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return AuthResult{}, err
+	}
+	if mfaRequired {
+		// 失败计数保留到第二步验证通过，动态码错误同样计入账号锁定
+		result, err := s.startMFAChallenge(ctx, user, client)
+		if err != nil {
+			s.audit.Log("auth.login", map[string]any{
+				"status": "error",
+				"userId": user.ID,
+				"reason": err.Error(),
+			})
+			return AuthResult{}, err
+		}
+		return result, nil
+	}
+	s.clearLoginFailures(ctx, keys[0])
+
+	result, err := s.issueSession(ctx, user, client, nil, "auth.login")
 	if err != nil {
 		s.audit.Log("auth.login", map[string]any{
 			"status": "error",
@@ -208,7 +336,7 @@ func (s *Service) Login(ctx context.Context, email, password, fingerprint string
 }
 
 // Refresh exchanges a valid refresh token for new credentials.
-func (s *Service) Refresh(ctx context.Context, refreshToken, fingerprint string) (AuthResult, error) {
+func (s *Service) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (AuthResult, error) {
 	if refreshToken == "" {
 		s.audit.Log("auth.refresh", map[string]any{
 			"status": "validation_failed",
@@ -218,7 +346,7 @@ func (s *Service) Refresh(ctx context.Context, refreshToken, fingerprint string)
 	}
 
 	hash := HashRefreshToken(refreshToken)
-	session, err := s.repo.FindActiveSession(ctx, hash)
+	session, err := s.repo.FindSessionByHash(ctx, hash)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			s.audit.Log("auth.refresh", map[string]any{
@@ -233,6 +361,9 @@ func (s *Service) Refresh(ctx context.Context, refreshToken, fingerprint string)
 		})
 		return AuthResult{}, err
 	}
+	if session.RevokedAt.Valid {
+		return AuthResult{}, s.handleRevokedRefresh(ctx, session)
+	}
 	if session.ExpiresAt.Before(s.clockFn()) {
 		s.audit.Log("auth.refresh", map[string]any{
 			"status":    "expired",
@@ -250,8 +381,22 @@ func (s *Service) Refresh(ctx context.Context, refreshToken, fingerprint string)
 		})
 		return AuthResult{}, err
 	}
+	// 锁定或待激活的账号不能靠刷新令牌续期
+	if err := s.ensureUnlocked(ctx, &user, "auth.refresh"); err != nil {
+		return AuthResult{}, err
+	}
+	if user.Status == userStatusPending {
+		s.audit.Log("auth.refresh", map[string]any{
+			"status":    "pending",
+			"userId":    user.ID,
+			"sessionId": session.ID,
+		})
+		return AuthResult{}, ErrAccountPending
+	}
 
-	if err := s.repo.RevokeSession(ctx, session.ID, s.clockFn()); err != nil {
+	// 条件吊销旧会话：并发请求抢先轮换时视为令牌被重复使用
+	rotated, err := s.repo.RevokeActiveSession(ctx, session.ID, s.clockFn())
+	if err != nil {
 		s.audit.Log("auth.refresh", map[string]any{
 			"status":    "error",
 			"sessionId": session.ID,
@@ -259,8 +404,11 @@ func (s *Service) Refresh(ctx context.Context, refreshToken, fingerprint string)
 		})
 		return AuthResult{}, err
 	}
+	if !rotated {
+		return AuthResult{}, s.revokeReusedFamily(ctx, session)
+	}
 
-	result, err := s.issueSession(ctx, user, fingerprint, "auth.refresh")
+	result, err := s.issueSession(ctx, user, client, &session, "auth.refresh")
 	if err != nil {
 		s.audit.Log("auth.refresh", map[string]any{
 			"status": "error",
@@ -313,17 +461,9 @@ func (s *Service) Logout(ctx context.Context, refreshToken string) error {
 	return nil
 }
 
-func (s *Service) issueSession(ctx context.Context, user UserAccount, fingerprint string, event string) (AuthResult, error) {
-	accessToken, accessExpiry, err := s.tokens.IssueAccessToken(user.ID, user.UUID)
-	if err != nil {
-		s.audit.Log(event, map[string]any{
-			"status": "error",
-			"userId": user.ID,
-			"reason": err.Error(),
-		})
-		return AuthResult{}, err
-	}
-
+// issueSession creates a session and its tokens. A non-nil parent is the session
+// being rotated; the new one joins its family.
+func (s *Service) issueSession(ctx context.Context, user UserAccount, client ClientInfo, parent *UserSession, event string) (AuthResult, error) {
 	refreshToken, refreshExpiry, err := s.tokens.GenerateRefreshToken()
 	if err != nil {
 		s.audit.Log(event, map[string]any{
@@ -340,8 +480,13 @@ func (s *Service) issueSession(ctx context.Context, user UserAccount, fingerprin
 		ExpiresAt:        refreshExpiry,
 		IssuedAt:         s.clockFn(),
 	}
-	if fingerprint != "" {
-		session.ClientFingerprint = sql.NullString{String: fingerprint, Valid: true}
+	client.apply(&session)
+	if parent != nil {
+		session.ParentID = sql.NullInt64{Int64: parent.ID, Valid: true}
+		session.FamilyID = parent.FamilyID
+	}
+	if !session.FamilyID.Valid {
+		session.FamilyID = sql.NullString{String: uuid.NewString(), Valid: true}
 	}
 
 	created, err := s.repo.CreateSession(ctx, session)
@@ -354,6 +499,17 @@ func (s *Service) issueSession(ctx context.Context, user UserAccount, fingerprin
 		return AuthResult{}, err
 	}
 
+	// 访问令牌携带会话 ID，便于会话列表标记当前设备
+	accessToken, accessExpiry, err := s.tokens.IssueSessionAccessToken(user.ID, user.UUID, user.Role, created.ID)
+	if err != nil {
+		s.audit.Log(event, map[string]any{
+			"status": "error",
+			"userId": user.ID,
+			"reason": err.Error(),
+		})
+		return AuthResult{}, err
+	}
+
 	if err := s.repo.UpdateLastLogin(ctx, user.ID, s.clockFn()); err != nil {
 		s.audit.Log("auth.login.update_last_login", map[string]any{
 			"status": "error",
@@ -390,7 +546,7 @@ func normalizeEmail(email string) (string, error) {
 
 func validatePassword(password string) error {
 	if len(password) < 10 {
-		return fmt.Errorf("password must be at least 10 characters")
+		return ErrWeakPassword
 	}
 	return nil
 }
@@ -529,40 +685,10 @@ func (s *Service) RegisterWithEmailCode(ctx context.Context, email, password, em
 	}
 
 	// 验证邮箱验证码
-	codeData, err := s.cache.GetEmailCode(ctx, normalized)
-	if err != nil {
-		s.audit.Log("auth.register", map[string]any{
-			"status": "invalid_code",
-			"email":  normalized,
-			"reason": "code not found or expired",
-		})
-		return UserAccount{}, ErrInvalidVerificationCode
-	}
-
-	// 检查尝试次数
-	if codeData.Attempts >= 5 {
-		s.audit.Log("auth.register", map[string]any{
-			"status": "too_many_attempts",
-			"email":  normalized,
-		})
-		return UserAccount{}, ErrTooManyAttempts
-	}
-
-	// 验证码是否匹配
-	if codeData.Code != emailCode {
-		// 增加尝试次数
-		_ = s.cache.IncrementEmailCodeAttempts(ctx, normalized)
-		s.audit.Log("auth.register", map[string]any{
-			"status":   "invalid_code",
-			"email":    normalized,
-			"attempts": codeData.Attempts + 1,
-		})
-		return UserAccount{}, ErrInvalidVerificationCode
+	if err := s.consumeEmailCode(ctx, "auth.register", normalized, emailCode); err != nil {
+		return UserAccount{}, err
 	}
 
-	// 删除验证码
-	_ = s.cache.DeleteEmailCode(ctx, normalized)
-
 	// 创建用户账号
 	hash, err := HashPassword(password)
 	if err != nil {
@@ -577,8 +703,7 @@ func (s *Service) RegisterWithEmailCode(ctx context.Context, email, password, em
 
 	user, err := s.repo.CreateUser(ctx, normalized, hash, uuidValue)
 	if err != nil {
-		var mysqlErr *mysql.MySQLError
-		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		if s.repo.dialect.IsUniqueViolation(err) {
 			s.audit.Log("auth.register", map[string]any{
 				"status": "conflict",
 				"reason": ErrEmailAlreadyRegistered.Error(),
@@ -601,6 +726,44 @@ func (s *Service) RegisterWithEmailCode(ctx context.Context, email, password, em
 	return user, nil
 }
 
+// consumeEmailCode 校验邮箱验证码，成功后删除，失败时累加尝试次数
+func (s *Service) consumeEmailCode(ctx context.Context, event, email, emailCode string) error {
+	codeData, err := s.cache.GetEmailCode(ctx, email)
+	if err != nil {
+		s.audit.Log(event, map[string]any{
+			"status": "invalid_code",
+			"email":  email,
+			"reason": "code not found or expired",
+		})
+		return ErrInvalidVerificationCode
+	}
+
+	// 检查尝试次数
+	if codeData.Attempts >= 5 {
+		s.audit.Log(event, map[string]any{
+			"status": "too_many_attempts",
+			"email":  email,
+		})
+		return ErrTooManyAttempts
+	}
+
+	// 验证码是否匹配
+	if codeData.Code != emailCode {
+		// 增加尝试次数
+		_ = s.cache.IncrementEmailCodeAttempts(ctx, email)
+		s.audit.Log(event, map[string]any{
+			"status":   "invalid_code",
+			"email":    email,
+			"attempts": codeData.Attempts + 1,
+		})
+		return ErrInvalidVerificationCode
+	}
+
+	// 删除验证码
+	_ = s.cache.DeleteEmailCode(ctx, email)
+	return nil
+}
+
 // generateEmailCode 生成6位数字验证码
 func generateEmailCode() (string, error) {
 	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+const (
+	maxIPAddressLength = 45
+	maxUserAgentLength = 512
+)
+
+// ClientInfo describes the device a session is being issued to.
+type ClientInfo struct {
+	Fingerprint string
+	IPAddress   string
+	UserAgent   string
+}
+
+// apply copies the client details onto a new session row, trimming values to the column sizes.
+func (ci ClientInfo) apply(session *UserSession) {
+	session.ClientFingerprint = nullString(truncate(ci.Fingerprint, 255))
+	session.IPAddress = nullString(truncate(ci.IPAddress, maxIPAddressLength))
+	session.UserAgent = nullString(truncate(ci.UserAgent, maxUserAgentLength))
+}
+
+// ListSessions returns the active sessions of a user, newest first.
+func (s *Service) ListSessions(ctx context.Context, userID int64) ([]UserSession, error) {
+	sessions, err := s.repo.ListActiveSessions(ctx, userID, s.clockFn())
+	if err != nil {
+		s.audit.Log("auth.sessions.list", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// CurrentSessionID resolves the session the caller is using: the `sid` claim of
+// its access token, or the session owning refreshToken for older tokens. It
+// returns 0 when unknown.
+func (s *Service) CurrentSessionID(ctx context.Context, claims *Claims, refreshToken string) int64 {
+	if claims == nil {
+		return 0
+	}
+	if claims.SessionID != 0 {
+		return claims.SessionID
+	}
+	return s.currentSessionID(ctx, claims.UserID, refreshToken)
+}
+
+// RevokeUserSession signs one of the user's own sessions out. Sessions of other
+// users are reported as not found.
+func (s *Service) RevokeUserSession(ctx context.Context, userID, sessionID int64) error {
+	session, err := s.repo.GetSessionByID(ctx, sessionID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.audit.Log("auth.sessions.revoke", map[string]any{
+			"status":    "error",
+			"userId":    userID,
+			"sessionId": sessionID,
+			"reason":    err.Error(),
+		})
+		return err
+	}
+	if err != nil || session.UserID != userID || session.RevokedAt.Valid {
+		s.audit.Log("auth.sessions.revoke", map[string]any{
+			"status":    "not_found",
+			"userId":    userID,
+			"sessionId": sessionID,
+		})
+		return ErrSessionNotFound
+	}
+
+	if err := s.repo.RevokeSession(ctx, session.ID, s.clockFn()); err != nil {
+		s.audit.Log("auth.sessions.revoke", map[string]any{
+			"status":    "error",
+			"userId":    userID,
+			"sessionId": session.ID,
+			"reason":    err.Error(),
+		})
+		return err
+	}
+
+	s.audit.Log("auth.sessions.revoke", map[string]any{
+		"status":    "success",
+		"userId":    userID,
+		"sessionId": session.ID,
+	})
+	return nil
+}
+
+// RevokeOtherSessions signs the user out everywhere except currentSessionID and
+// returns how many sessions were revoked.
+func (s *Service) RevokeOtherSessions(ctx context.Context, userID, currentSessionID int64) (int, error) {
+	if currentSessionID == 0 {
+		s.audit.Log("auth.sessions.revoke_others", map[string]any{
+			"status": "validation_failed",
+			"userId": userID,
+			"reason": ErrCurrentSessionUnknown.Error(),
+		})
+		return 0, ErrCurrentSessionUnknown
+	}
+
+	revoked, err := s.repo.RevokeOtherSessions(ctx, userID, currentSessionID, s.clockFn())
+	if err != nil {
+		s.audit.Log("auth.sessions.revoke_others", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return 0, err
+	}
+
+	s.audit.Log("auth.sessions.revoke_others", map[string]any{
+		"status":           "success",
+		"userId":           userID,
+		"currentSessionId": currentSessionID,
+		"revokedSessions":  revoked,
+	})
+	return int(revoked), nil
+}
+
+// handleRevokedRefresh decides what a revoked refresh token means. A token that
+// was rotated has a successor, so seeing it again means it leaked; a token
+// revoked by logout is simply not found.
+func (s *Service) handleRevokedRefresh(ctx context.Context, session UserSession) error {
+	rotated, err := s.repo.HasChildSession(ctx, session.ID)
+	if err != nil {
+		s.audit.Log("auth.refresh", map[string]any{
+			"status":    "error",
+			"sessionId": session.ID,
+			"reason":    err.Error(),
+		})
+		return err
+	}
+	if !rotated {
+		s.audit.Log("auth.refresh", map[string]any{
+			"status":    "revoked",
+			"sessionId": session.ID,
+			"reason":    ErrSessionNotFound.Error(),
+		})
+		return ErrSessionNotFound
+	}
+	return s.revokeReusedFamily(ctx, session)
+}
+
+// revokeReusedFamily signs out every session descending from the same login as
+// a reused refresh token, since either the attacker or the user holds a copy.
+func (s *Service) revokeReusedFamily(ctx context.Context, session UserSession) error {
+	var revoked int64
+	if session.FamilyID.Valid {
+		count, err := s.repo.RevokeSessionFamily(ctx, session.FamilyID.String, s.clockFn())
+		if err != nil {
+			s.audit.Log("security.refresh_token_reuse", map[string]any{
+				"status":    "error",
+				"userId":    session.UserID,
+				"sessionId": session.ID,
+				"familyId":  session.FamilyID.String,
+				"reason":    err.Error(),
+			})
+			return err
+		}
+		revoked = count
+	}
+
+	s.audit.Log("security.refresh_token_reuse", map[string]any{
+		"status":          "family_revoked",
+		"userId":          session.UserID,
+		"sessionId":       session.ID,
+		"familyId":        session.FamilyID.String,
+		"revokedSessions": revoked,
+	})
+	return ErrRefreshTokenReused
+}
+
+func nullString(value string) sql.NullString {
+	if value == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: value, Valid: true}
+}
+
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	// 去掉被截断的多字节字符
+	return strings.ToValidUTF8(value[:max], "")
+}
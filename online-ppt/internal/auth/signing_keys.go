@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"online-ppt/internal/config"
+)
+
+// Supported access token signing algorithms.
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+const minRSAKeyBits = 2048
+
+// SigningKey is a key access tokens are signed or verified with. Keys without
+// private material only verify tokens, e.g. a retired key whose private half
+// has already been destroyed.
+type SigningKey struct {
+	// ID is written to the "kid" header. Only the legacy HS256 secret may omit it.
+	ID        string
+	Algorithm string
+	// Private is a []byte secret (HS256), *rsa.PrivateKey (RS256) or
+	// ed25519.PrivateKey (EdDSA).
+	Private any
+	// Public is the matching []byte secret, *rsa.PublicKey or ed25519.PublicKey.
+	Public any
+	// ActivateAt is when the key starts signing; zero means immediately. The
+	// most recently activated key signs, so scheduling a key rotates to it.
+	ActivateAt time.Time
+	// RetireAt is when the key stops signing; zero means never. Tokens it
+	// signed stay valid for one access token lifetime afterwards.
+	RetireAt time.Time
+}
+
+// canSign reports whether the key may sign new tokens at now.
+func (k SigningKey) canSign(now time.Time) bool {
+	if k.Private == nil || now.Before(k.ActivateAt) {
+		return false
+	}
+	return k.RetireAt.IsZero() || now.Before(k.RetireAt)
+}
+
+// canVerify reports whether tokens signed with the key are accepted at now.
+func (k SigningKey) canVerify(now time.Time, grace time.Duration) bool {
+	return k.RetireAt.IsZero() || now.Before(k.RetireAt.Add(grace))
+}
+
+// ParseSigningKeyPEM decodes a PEM encoded RSA or Ed25519 key. A private key
+// (PKCS#8 or PKCS#1) can sign; a public key (PKIX) only verifies.
+func ParseSigningKeyPEM(id, algorithm string, data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, fmt.Errorf("signing key %s: no PEM block found", id)
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return SigningKey{}, fmt.Errorf("signing key %s: unsupported PEM type %q", id, block.Type)
+	}
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("signing key %s: %w", id, err)
+	}
+
+	key := SigningKey{ID: id, Algorithm: algorithm}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Private, key.Public = k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Public = k
+	case ed25519.PrivateKey:
+		key.Private, key.Public = k, k.Public()
+	case ed25519.PublicKey:
+		key.Public = k
+	default:
+		return SigningKey{}, fmt.Errorf("signing key %s: unsupported key type %T", id, parsed)
+	}
+	if err := key.validate(); err != nil {
+		return SigningKey{}, err
+	}
+	return key, nil
+}
+
+// validate checks that the key material matches the algorithm.
+func (k SigningKey) validate() error {
+	switch k.Algorithm {
+	case AlgorithmHS256:
+		secret, ok := k.Public.([]byte)
+		if !ok || len(secret) == 0 {
+			return fmt.Errorf("signing key %s: HS256 requires a secret", k.ID)
+		}
+	case AlgorithmRS256:
+		pub, ok := k.Public.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("signing key %s: RS256 requires an RSA key", k.ID)
+		}
+		if pub.N.BitLen() < minRSAKeyBits {
+			return fmt.Errorf("signing key %s: RSA key must be at least %d bits", k.ID, minRSAKeyBits)
+		}
+	case AlgorithmEdDSA:
+		if _, ok := k.Public.(ed25519.PublicKey); !ok {
+			return fmt.Errorf("signing key %s: EdDSA requires an Ed25519 key", k.ID)
+		}
+	default:
+		return fmt.Errorf("signing key %s: unsupported algorithm %q", k.ID, k.Algorithm)
+	}
+	if k.Algorithm != AlgorithmHS256 && k.ID == "" {
+		return fmt.Errorf("%s signing key requires an id", k.Algorithm)
+	}
+	if !k.RetireAt.IsZero() && !k.RetireAt.After(k.ActivateAt) {
+		return fmt.Errorf("signing key %s: retireAt must be after activateAt", k.ID)
+	}
+	return nil
+}
+
+// JSONWebKey is the public half of a signing key as published in a JWKS.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet is the document served at /.well-known/jwks.json.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// jwk converts an asymmetric key to its public JWK form; HS256 secrets are never published.
+func (k SigningKey) jwk() (JSONWebKey, bool) {
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		return JSONWebKey{
+			Kty: "RSA",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: k.Algorithm,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JSONWebKey{
+			Kty: "OKP",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: k.Algorithm,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, true
+	default:
+		return JSONWebKey{}, false
+	}
+}
+
+// LoadSigningKeys reads the configured access token keys. Once asymmetric keys
+// are configured the HS256 secret retires at load time, so it only verifies
+// tokens issued before the switch for one more access token lifetime.
+func LoadSigningKeys(sec config.SecurityConfig) ([]SigningKey, error) {
+	keys := make([]SigningKey, 0, len(sec.SigningKeys)+1)
+	for _, keyCfg := range sec.SigningKeys {
+		data, err := os.ReadFile(keyCfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read signing key %s: %w", keyCfg.ID, err)
+		}
+		key, err := ParseSigningKeyPEM(keyCfg.ID, keyCfg.Algorithm, data)
+		if err != nil {
+			return nil, err
+		}
+		key.ActivateAt = keyCfg.ActivateAt
+		key.RetireAt = keyCfg.RetireAt
+		keys = append(keys, key)
+	}
+
+	if sec.JWTSecret != "" {
+		legacy := LegacySecretKey(sec.JWTSecret)
+		if len(keys) > 0 {
+			legacy.Private = nil
+			legacy.RetireAt = time.Now()
+		}
+		keys = append(keys, legacy)
+	}
+	return keys, nil
+}
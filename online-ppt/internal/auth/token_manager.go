@@ -13,58 +13,195 @@ import (
 
 // TokenManager handles JWT issuing and refresh token hashing.
 type TokenManager struct {
-	secret          []byte
+	keys            []SigningKey
 	accessTokenTTL  time.Duration
 	refreshTokenTTL time.Duration
+	clockFn         func() time.Time
 }
 
-var errTokenManagerNil = errors.New("token manager is nil")
+var (
+	errTokenManagerNil    = errors.New("token manager is nil")
+	errNoActiveSigningKey = errors.New("no active signing key")
+)
+
+// Account roles stored in user_accounts.role and embedded in access tokens.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
 
 // Claims describes the custom payload embedded in access tokens.
 type Claims struct {
-	UserID   int64  `json:"userId"`
-	UserUUID string `json:"userUuid"`
+	UserID    int64  `json:"userId"`
+	UserUUID  string `json:"userUuid"`
+	SessionID int64  `json:"sid,omitempty"`
+	Role      string `json:"role,omitempty"`
+	// TokenID and Scopes are set only for personal access tokens; they are
+	// never part of a signed JWT.
+	TokenID int64    `json:"-"`
+	Scopes  []string `json:"-"`
 	jwt.RegisteredClaims
 }
 
-// NewTokenManager creates a TokenManager with validated configuration.
+// HasRole reports whether the caller's account holds role. Admins hold every
+// role, and tokens issued before roles existed count as plain users.
+func (c *Claims) HasRole(role string) bool {
+	if c == nil {
+		return false
+	}
+	granted := c.Role
+	if granted == "" {
+		granted = RoleUser
+	}
+	return granted == RoleAdmin || granted == role
+}
+
+// NewTokenManager creates a TokenManager signing with a single HS256 secret.
 func NewTokenManager(secret string, accessTTL, refreshTTL time.Duration) (*TokenManager, error) {
 	if secret == "" {
 		return nil, fmt.Errorf("token secret cannot be empty")
 	}
+	return NewTokenManagerWithKeys([]SigningKey{LegacySecretKey(secret)}, accessTTL, refreshTTL)
+}
+
+// NewTokenManagerWithKeys creates a TokenManager from a set of signing keys.
+// Every key verifies tokens carrying its kid; the most recently activated key
+// that can sign issues new tokens.
+func NewTokenManagerWithKeys(keys []SigningKey, accessTTL, refreshTTL time.Duration) (*TokenManager, error) {
 	if accessTTL <= 0 {
 		return nil, fmt.Errorf("access token ttl must be positive")
 	}
 	if refreshTTL <= 0 {
 		return nil, fmt.Errorf("refresh token ttl must be positive")
 	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("token manager requires at least one signing key")
+	}
+
+	seen := make(map[string]bool, len(keys))
+	canSign := false
+	for _, key := range keys {
+		if err := key.validate(); err != nil {
+			return nil, err
+		}
+		if seen[key.ID] {
+			return nil, fmt.Errorf("signing key id %q is duplicated", key.ID)
+		}
+		seen[key.ID] = true
+		canSign = canSign || key.Private != nil
+	}
+	if !canSign {
+		return nil, fmt.Errorf("token manager requires a key with private material")
+	}
 
 	return &TokenManager{
-		secret:          []byte(secret),
+		keys:            append([]SigningKey(nil), keys...),
 		accessTokenTTL:  accessTTL,
 		refreshTokenTTL: refreshTTL,
+		clockFn:         time.Now,
 	}, nil
 }
 
+// LegacySecretKey wraps the configured HS256 secret. It carries no kid so
+// tokens issued before key rotation was introduced keep verifying.
+func LegacySecretKey(secret string) SigningKey {
+	return SigningKey{Algorithm: AlgorithmHS256, Private: []byte(secret), Public: []byte(secret)}
+}
+
+// JWKS returns the public keys other services use to verify access tokens,
+// including scheduled keys that have not started signing yet.
+func (m *TokenManager) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	if m == nil {
+		return set
+	}
+	now := m.clockFn()
+	for _, key := range m.keys {
+		if !key.canVerify(now, m.accessTokenTTL) {
+			continue
+		}
+		if jwk, ok := key.jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+// signingKey picks the most recently activated key that may sign at now.
+func (m *TokenManager) signingKey(now time.Time) (SigningKey, error) {
+	var (
+		current SigningKey
+		found   bool
+	)
+	for _, key := range m.keys {
+		if !key.canSign(now) {
+			continue
+		}
+		if !found || !key.ActivateAt.Before(current.ActivateAt) {
+			current, found = key, true
+		}
+	}
+	if !found {
+		return SigningKey{}, errNoActiveSigningKey
+	}
+	return current, nil
+}
+
+// verificationKey resolves the key named by a token's kid header.
+func (m *TokenManager) verificationKey(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	now := m.clockFn()
+	for _, key := range m.keys {
+		if key.ID != kid {
+			continue
+		}
+		if !key.canVerify(now, m.accessTokenTTL) {
+			return nil, fmt.Errorf("signing key %q retired", kid)
+		}
+		if t.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %s", t.Method.Alg())
+		}
+		return key.Public, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
 // IssueAccessToken builds a signed JWT for the provided principal.
 func (m *TokenManager) IssueAccessToken(userID int64, userUUID string) (string, time.Time, error) {
+	return m.IssueSessionAccessToken(userID, userUUID, RoleUser, 0)
+}
+
+// IssueSessionAccessToken builds a signed JWT carrying the account role and
+// bound to the session it was issued for.
+func (m *TokenManager) IssueSessionAccessToken(userID int64, userUUID, role string, sessionID int64) (string, time.Time, error) {
 	if m == nil {
 		return "", time.Time{}, errTokenManagerNil
 	}
 
-	expiresAt := time.Now().Add(m.accessTokenTTL)
+	now := m.clockFn()
+	key, err := m.signingKey(now)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := now.Add(m.accessTokenTTL)
 	claims := Claims{
-		UserID:   userID,
-		UserUUID: userUUID,
+		UserID:    userID,
+		UserUUID:  userUUID,
+		SessionID: sessionID,
+		Role:      role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			Subject:   fmt.Sprintf("user:%d", userID),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString(m.secret)
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	signed, err := token.SignedString(key.Private)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("sign jwt: %w", err)
 	}
@@ -78,12 +215,10 @@ func (m *TokenManager) ParseAccessToken(token string) (*Claims, error) {
 		return nil, errTokenManagerNil
 	}
 
-	parsed, err := jwt.ParseWithClaims(token, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %T", t.Method)
-		}
-		return m.secret, nil
-	})
+	parsed, err := jwt.ParseWithClaims(token, &Claims{}, m.verificationKey,
+		jwt.WithValidMethods([]string{AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA}),
+		jwt.WithTimeFunc(m.clockFn),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("parse jwt: %w", err)
 	}
@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/config"
+)
+
+func TestLegacySecretTokensHaveNoKeyID(t *testing.T) {
+	tm, err := NewTokenManager("test-secret", 5*time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	token, _, err := tm.IssueSessionAccessToken(1, "uuid-1", RoleAdmin, 7)
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmHS256, parsed.Method.Alg())
+	assert.NotContains(t, parsed.Header, "kid")
+
+	claims, err := tm.ParseAccessToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), claims.SessionID)
+	assert.Equal(t, RoleAdmin, claims.Role)
+	assert.Empty(t, tm.JWKS().Keys)
+}
+
+func TestClaimsHasRole(t *testing.T) {
+	legacy := &Claims{UserID: 1}
+	assert.True(t, legacy.HasRole(RoleUser))
+	assert.False(t, legacy.HasRole(RoleAdmin))
+
+	user := &Claims{UserID: 1, Role: RoleUser}
+	assert.False(t, user.HasRole(RoleAdmin))
+
+	admin := &Claims{UserID: 1, Role: RoleAdmin}
+	assert.True(t, admin.HasRole(RoleAdmin))
+	assert.True(t, admin.HasRole(RoleUser))
+
+	var missing *Claims
+	assert.False(t, missing.HasRole(RoleUser))
+}
+
+func TestScheduledKeyRotation(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys := []SigningKey{
+		{ID: "2026-10", Algorithm: AlgorithmRS256, Private: rsaKey, Public: &rsaKey.PublicKey, ActivateAt: start, RetireAt: start.Add(2 * time.Hour)},
+		{ID: "2026-11", Algorithm: AlgorithmEdDSA, Private: edKey, Public: edKey.Public(), ActivateAt: start.Add(time.Hour)},
+		{Algorithm: AlgorithmHS256, Public: []byte("old-secret")},
+	}
+	tm, err := NewTokenManagerWithKeys(keys, 5*time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	now := start.Add(59 * time.Minute)
+	tm.clockFn = func() time.Time { return now }
+
+	// 新密钥启用前仍由旧密钥签名，但已对外发布
+	first, _, err := tm.IssueAccessToken(1, "uuid-1")
+	require.NoError(t, err)
+	assertKeyID(t, first, "2026-10", AlgorithmRS256)
+	assert.Len(t, tm.JWKS().Keys, 2)
+
+	// 到达计划时间后自动切换，旧令牌继续有效
+	now = start.Add(time.Hour + time.Minute)
+	second, _, err := tm.IssueAccessToken(1, "uuid-1")
+	require.NoError(t, err)
+	assertKeyID(t, second, "2026-11", AlgorithmEdDSA)
+	_, err = tm.ParseAccessToken(first)
+	require.NoError(t, err)
+
+	// 退役超过一个访问令牌有效期后不再接受也不再发布
+	now = start.Add(2*time.Hour + 6*time.Minute)
+	_, err = tm.ParseAccessToken(first)
+	assert.Error(t, err)
+	jwks := tm.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "2026-11", jwks.Keys[0].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+}
+
+func TestLegacySecretRetiresWhenAsymmetricKeysLoad(t *testing.T) {
+	// 旧令牌的有效期长于新配置，拒绝只能来自密钥退役
+	legacyTM, err := NewTokenManager("old-secret", time.Hour, 2*time.Hour)
+	require.NoError(t, err)
+	old, _, err := legacyTM.IssueAccessToken(1, "uuid-1")
+	require.NoError(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "ed.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	keys, err := LoadSigningKeys(config.SecurityConfig{
+		JWTSecret:   "old-secret",
+		SigningKeys: []config.SigningKeyConfig{{ID: "ed", Algorithm: AlgorithmEdDSA, KeyFile: keyFile}},
+	})
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	legacy := keys[1]
+	assert.Nil(t, legacy.Private)
+	require.False(t, legacy.RetireAt.IsZero())
+
+	tm, err := NewTokenManagerWithKeys(keys, 5*time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	// 切换后的一个访问令牌有效期内旧令牌仍可用
+	now := legacy.RetireAt.Add(4 * time.Minute)
+	tm.clockFn = func() time.Time { return now }
+	_, err = tm.ParseAccessToken(old)
+	require.NoError(t, err)
+
+	now = legacy.RetireAt.Add(6 * time.Minute)
+	_, err = tm.ParseAccessToken(old)
+	assert.Error(t, err)
+}
+
+func TestParseAccessTokenRejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tm, err := NewTokenManagerWithKeys([]SigningKey{
+		{ID: "rsa", Algorithm: AlgorithmRS256, Private: rsaKey, Public: &rsaKey.PublicKey},
+	}, 5*time.Minute, time.Hour)
+	require.NoError(t, err)
+
+	// 使用公钥作为 HMAC 密钥伪造的令牌
+	pubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{UserID: 1})
+	forged.Header["kid"] = "rsa"
+	signed, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	require.NoError(t, err)
+	_, err = tm.ParseAccessToken(signed)
+	assert.Error(t, err)
+
+	// 未知 kid
+	unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, Claims{UserID: 1})
+	unknown.Header["kid"] = "other"
+	signed, err = unknown.SignedString(rsaKey)
+	require.NoError(t, err)
+	_, err = tm.ParseAccessToken(signed)
+	assert.Error(t, err)
+}
+
+func TestParseSigningKeyPEM(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	key, err := ParseSigningKeyPEM("ed", AlgorithmEdDSA, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.NotNil(t, key.Private)
+
+	pubDER, err := x509.MarshalPKIXPublicKey(edKey.Public())
+	require.NoError(t, err)
+	key, err = ParseSigningKeyPEM("ed", AlgorithmEdDSA, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	require.NoError(t, err)
+	assert.Nil(t, key.Private)
+
+	// 算法与密钥类型不符
+	_, err = ParseSigningKeyPEM("ed", AlgorithmRS256, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	assert.Error(t, err)
+}
+
+func assertKeyID(t *testing.T, token, kid, alg string) {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, kid, parsed.Header["kid"])
+	assert.Equal(t, alg, parsed.Method.Alg())
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters follow the RFC 6238 defaults understood by common
+// authenticator apps: HMAC-SHA1, 6 digits, 30 second steps.
+const (
+	totpPeriod     = 30
+	totpDigits     = 6
+	totpSkewSteps  = 1
+	totpSecretSize = 20
+	totpIssuer     = "Online PPT"
+
+	recoveryCodeCount = 10
+	recoveryCodeSize  = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// recoveryAlphabet omits characters that are easily confused (0/o, 1/l/i).
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// generateTOTPSecret returns a random base32 encoded shared secret.
+func generateTOTPSecret() (string, error) {
+	buf := make([]byte, totpSecretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate totp secret: %w", err)
+	}
+	return totpEncoding.EncodeToString(buf), nil
+}
+
+// totpURI builds the otpauth:// URI rendered as a QR code during enrollment.
+func totpURI(secret, account string) string {
+	label := url.PathEscape(totpIssuer) + ":" + url.PathEscape(account)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", totpIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// totpStep returns the RFC 6238 time step counter for ts.
+func totpStep(ts time.Time) int64 {
+	return ts.Unix() / totpPeriod
+}
+
+// totpCode computes the HOTP value (RFC 4226) for the given counter.
+func totpCode(secret []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// verifyTOTP checks code against the steps around now and returns the step
+// that matched so callers can reject replays of the same code.
+func verifyTOTP(secret, code string, now time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+	current := totpStep(now)
+	for delta := int64(-totpSkewSteps); delta <= totpSkewSteps; delta++ {
+		step := current + delta
+		if hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// isTOTPCode reports whether input looks like an authenticator code rather
+// than a recovery code.
+func isTOTPCode(input string) bool {
+	if len(input) != totpDigits {
+		return false
+	}
+	for _, r := range input {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// generateRecoveryCodes returns fresh single-use recovery codes formatted as
+// "xxxxx-xxxxx" together with the hashes that get persisted.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	alphabetSize := big.NewInt(int64(len(recoveryAlphabet)))
+	for i := 0; i < recoveryCodeCount; i++ {
+		var b strings.Builder
+		for j := 0; j < recoveryCodeSize; j++ {
+			if j == recoveryCodeSize/2 {
+				b.WriteByte('-')
+			}
+			n, err := rand.Int(rand.Reader, alphabetSize)
+			if err != nil {
+				return nil, nil, fmt.Errorf("generate recovery code: %w", err)
+			}
+			b.WriteByte(recoveryAlphabet[n.Int64()])
+		}
+		code := b.String()
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normalizes user input (case, dashes, spaces) before hashing.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA1 seed from RFC 6238 Appendix B.
+var rfc6238Secret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// TestTOTPCodeRFC6238Vectors 使用 RFC 6238 附录 B 的测试向量（取后 6 位）
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, v := range vectors {
+		now := time.Unix(v.unix, 0).UTC()
+		step, ok := verifyTOTP(rfc6238Secret, v.code, now)
+		require.True(t, ok, "code for T=%d", v.unix)
+		assert.Equal(t, totpStep(now), step)
+	}
+}
+
+// TestVerifyTOTPSkew 测试仅接受前后各一个时间步
+func TestVerifyTOTPSkew(t *testing.T) {
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	require.NoError(t, err)
+
+	now := time.Unix(1111111111, 0)
+	current := totpStep(now)
+
+	for _, delta := range []int64{-1, 0, 1} {
+		step, ok := verifyTOTP(rfc6238Secret, totpCode(key, current+delta), now)
+		assert.True(t, ok, "delta %d", delta)
+		assert.Equal(t, current+delta, step)
+	}
+	for _, delta := range []int64{-2, 2} {
+		_, ok := verifyTOTP(rfc6238Secret, totpCode(key, current+delta), now)
+		assert.False(t, ok, "delta %d", delta)
+	}
+
+	_, ok := verifyTOTP(rfc6238Secret, "12345", now)
+	assert.False(t, ok)
+	_, ok = verifyTOTP("not base32!", "123456", now)
+	assert.False(t, ok)
+}
+
+// TestTOTPURI 测试 otpauth URI 格式
+func TestTOTPURI(t *testing.T) {
+	uri := totpURI("JBSWY3DPEHPK3PXP", "user@example.com")
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", parsed.Scheme)
+	assert.Equal(t, "totp", parsed.Host)
+	assert.Equal(t, "/Online PPT:user@example.com", parsed.Path)
+
+	query := parsed.Query()
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", query.Get("secret"))
+	assert.Equal(t, "Online PPT", query.Get("issuer"))
+	assert.Equal(t, "6", query.Get("digits"))
+	assert.Equal(t, "30", query.Get("period"))
+}
+
+// TestGenerateRecoveryCodes 测试恢复码格式、唯一性与摘要规范化
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, hashes, recoveryCodeCount)
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		require.Len(t, code, recoveryCodeSize+1)
+		assert.Equal(t, byte('-'), code[recoveryCodeSize/2])
+		assert.False(t, isTOTPCode(code))
+		assert.False(t, seen[code], "duplicate recovery code")
+		seen[code] = true
+
+		assert.Equal(t, hashes[i], hashRecoveryCode(code))
+		assert.Equal(t, hashes[i], hashRecoveryCode(" "+strings.ToUpper(strings.ReplaceAll(code, "-", ""))+" "))
+	}
+}
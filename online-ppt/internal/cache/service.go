@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,9 +11,14 @@ import (
 )
 
 const (
-	captchaKeyFormat   = "captcha:%s"
-	emailCodeKeyFormat = "email_code:%s"
-	rateLimitKeyFormat = "rate_limit:%s"
+	captchaKeyFormat      = "captcha:%s"
+	emailCodeKeyFormat    = "email_code:%s"
+	rateLimitKeyFormat    = "rate_limit:%s"
+	resetTokenKeyFormat   = "password_reset:%s"
+	mfaChallengeKeyFormat = "mfa_challenge:%s"
+	oidcStateKeyFormat    = "oidc_state:%s"
+	loginFailureKeyFormat = "login_failures:%s"
+	unlockTokenKeyFormat  = "account_unlock:%s"
 )
 
 // EmailCodeData 邮箱验证码缓存数据结构
@@ -22,6 +28,38 @@ type EmailCodeData struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// PasswordResetData 密码重置令牌缓存数据结构，键为令牌摘要
+type PasswordResetData struct {
+	UserID    int64     `json:"user_id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MFAChallengeData 两步登录挑战缓存数据结构，键为挑战令牌摘要
+type MFAChallengeData struct {
+	UserID      int64     `json:"user_id"`
+	Fingerprint string    `json:"fingerprint"`
+	IPAddress   string    `json:"ip_address"`
+	UserAgent   string    `json:"user_agent"`
+	Attempts    int       `json:"attempts"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// OIDCStateData 第三方登录授权请求缓存数据结构，键为 state 摘要
+type OIDCStateData struct {
+	Provider     string    `json:"provider"`
+	CodeVerifier string    `json:"code_verifier"`
+	Nonce        string    `json:"nonce"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AccountUnlockData 账号解锁令牌缓存数据结构，键为令牌摘要
+type AccountUnlockData struct {
+	UserID    int64     `json:"user_id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // Service Redis 缓存服务接口
 type Service interface {
 	// Captcha operations
@@ -38,6 +76,29 @@ type Service interface {
 	// Rate limiting
 	SetRateLimit(ctx context.Context, email string, ttl time.Duration) error
 	CheckRateLimit(ctx context.Context, email string) (bool, error)
+
+	// Password reset tokens
+	SetPasswordResetToken(ctx context.Context, tokenHash string, data *PasswordResetData, ttl time.Duration) error
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (*PasswordResetData, error)
+
+	// MFA login challenges
+	SetMFAChallenge(ctx context.Context, tokenHash string, data *MFAChallengeData, ttl time.Duration) error
+	GetMFAChallenge(ctx context.Context, tokenHash string) (*MFAChallengeData, error)
+	DeleteMFAChallenge(ctx context.Context, tokenHash string) error
+	IncrementMFAChallengeAttempts(ctx context.Context, tokenHash string) error
+
+	// OIDC authorization requests
+	SetOIDCState(ctx context.Context, stateHash string, data *OIDCStateData, ttl time.Duration) error
+	ConsumeOIDCState(ctx context.Context, stateHash string) (*OIDCStateData, error)
+
+	// Failed login counters, keyed by account or client IP
+	IncrementLoginFailures(ctx context.Context, key string, window time.Duration) (int, error)
+	GetLoginFailures(ctx context.Context, key string) (int, error)
+	ResetLoginFailures(ctx context.Context, key string) error
+
+	// Account unlock tokens
+	SetAccountUnlockToken(ctx context.Context, tokenHash string, data *AccountUnlockData, ttl time.Duration) error
+	ConsumeAccountUnlockToken(ctx context.Context, tokenHash string) (*AccountUnlockData, error)
 }
 
 // RedisService Redis 缓存服务实现
@@ -141,3 +202,162 @@ func (s *RedisService) CheckRateLimit(ctx context.Context, email string) (bool,
 	}
 	return exists > 0, nil
 }
+
+// Password reset operations
+
+func (s *RedisService) SetPasswordResetToken(ctx context.Context, tokenHash string, data *PasswordResetData, ttl time.Duration) error {
+	key := fmt.Sprintf(resetTokenKeyFormat, tokenHash)
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal password reset data: %w", err)
+	}
+	return s.client.Set(ctx, key, jsonData, ttl).Err()
+}
+
+// ConsumePasswordResetToken 读取并删除令牌，保证令牌只能使用一次
+func (s *RedisService) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (*PasswordResetData, error) {
+	key := fmt.Sprintf(resetTokenKeyFormat, tokenHash)
+	jsonData, err := s.client.GetDel(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var data PasswordResetData
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal password reset data: %w", err)
+	}
+	return &data, nil
+}
+
+// MFA challenge operations
+
+func (s *RedisService) SetMFAChallenge(ctx context.Context, tokenHash string, data *MFAChallengeData, ttl time.Duration) error {
+	key := fmt.Sprintf(mfaChallengeKeyFormat, tokenHash)
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mfa challenge data: %w", err)
+	}
+	return s.client.Set(ctx, key, jsonData, ttl).Err()
+}
+
+func (s *RedisService) GetMFAChallenge(ctx context.Context, tokenHash string) (*MFAChallengeData, error) {
+	key := fmt.Sprintf(mfaChallengeKeyFormat, tokenHash)
+	jsonData, err := s.client.Get(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var data MFAChallengeData
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mfa challenge data: %w", err)
+	}
+	return &data, nil
+}
+
+func (s *RedisService) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	key := fmt.Sprintf(mfaChallengeKeyFormat, tokenHash)
+	return s.client.Del(ctx, key).Err()
+}
+
+func (s *RedisService) IncrementMFAChallengeAttempts(ctx context.Context, tokenHash string) error {
+	data, err := s.GetMFAChallenge(ctx, tokenHash)
+	if err != nil {
+		return err
+	}
+	data.Attempts++
+
+	key := fmt.Sprintf(mfaChallengeKeyFormat, tokenHash)
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mfa challenge data: %w", err)
+	}
+	// 保持原有的 TTL
+	return s.client.Set(ctx, key, jsonData, redis.KeepTTL).Err()
+}
+
+// OIDC state operations
+
+func (s *RedisService) SetOIDCState(ctx context.Context, stateHash string, data *OIDCStateData, ttl time.Duration) error {
+	key := fmt.Sprintf(oidcStateKeyFormat, stateHash)
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal oidc state data: %w", err)
+	}
+	return s.client.Set(ctx, key, jsonData, ttl).Err()
+}
+
+// ConsumeOIDCState 读取并删除授权请求，保证 state 只能使用一次
+func (s *RedisService) ConsumeOIDCState(ctx context.Context, stateHash string) (*OIDCStateData, error) {
+	key := fmt.Sprintf(oidcStateKeyFormat, stateHash)
+	jsonData, err := s.client.GetDel(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var data OIDCStateData
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal oidc state data: %w", err)
+	}
+	return &data, nil
+}
+
+// Login failure operations
+
+// IncrementLoginFailures 累加失败次数并返回新值，计数在首次失败 window 后清零
+func (s *RedisService) IncrementLoginFailures(ctx context.Context, key string, window time.Duration) (int, error) {
+	redisKey := fmt.Sprintf(loginFailureKeyFormat, key)
+	count, err := s.client.Incr(ctx, redisKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := s.client.Expire(ctx, redisKey, window).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return int(count), nil
+}
+
+// GetLoginFailures 返回当前失败次数，没有记录时为 0
+func (s *RedisService) GetLoginFailures(ctx context.Context, key string) (int, error) {
+	redisKey := fmt.Sprintf(loginFailureKeyFormat, key)
+	count, err := s.client.Get(ctx, redisKey).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *RedisService) ResetLoginFailures(ctx context.Context, key string) error {
+	redisKey := fmt.Sprintf(loginFailureKeyFormat, key)
+	return s.client.Del(ctx, redisKey).Err()
+}
+
+// Account unlock operations
+
+func (s *RedisService) SetAccountUnlockToken(ctx context.Context, tokenHash string, data *AccountUnlockData, ttl time.Duration) error {
+	key := fmt.Sprintf(unlockTokenKeyFormat, tokenHash)
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal account unlock data: %w", err)
+	}
+	return s.client.Set(ctx, key, jsonData, ttl).Err()
+}
+
+// ConsumeAccountUnlockToken 读取并删除令牌，保证令牌只能使用一次
+func (s *RedisService) ConsumeAccountUnlockToken(ctx context.Context, tokenHash string) (*AccountUnlockData, error) {
+	key := fmt.Sprintf(unlockTokenKeyFormat, tokenHash)
+	jsonData, err := s.client.GetDel(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var data AccountUnlockData
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal account unlock data: %w", err)
+	}
+	return &data, nil
+}
@@ -445,3 +445,114 @@ func BenchmarkGetCaptcha(b *testing.B) {
 		_, _ = service.GetCaptcha(ctx, "bench-captcha")
 	}
 }
+
+// TestConsumePasswordResetToken 测试密码重置令牌只能使用一次
+func TestConsumePasswordResetToken(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "127.0.0.1:6379",
+		DB:   2,
+	})
+	defer client.Close()
+
+	_ = client.FlushDB(context.Background())
+	defer client.FlushDB(context.Background())
+
+	service := NewRedisService(client)
+	ctx := context.Background()
+
+	data := &PasswordResetData{UserID: 7, Email: "test@example.com", CreatedAt: time.Now()}
+	require.NoError(t, service.SetPasswordResetToken(ctx, "token-hash", data, time.Minute))
+
+	consumed, err := service.ConsumePasswordResetToken(ctx, "token-hash")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), consumed.UserID)
+	assert.Equal(t, "test@example.com", consumed.Email)
+
+	_, err = service.ConsumePasswordResetToken(ctx, "token-hash")
+	assert.Equal(t, redis.Nil, err)
+}
+
+func TestConsumeOIDCState(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "127.0.0.1:6379",
+		DB:   2,
+	})
+	defer client.Close()
+
+	_ = client.FlushDB(context.Background())
+	defer client.FlushDB(context.Background())
+
+	service := NewRedisService(client)
+	ctx := context.Background()
+
+	data := &OIDCStateData{Provider: "corp", CodeVerifier: "verifier", Nonce: "nonce", CreatedAt: time.Now()}
+	require.NoError(t, service.SetOIDCState(ctx, "state-hash", data, time.Minute))
+
+	consumed, err := service.ConsumeOIDCState(ctx, "state-hash")
+	require.NoError(t, err)
+	assert.Equal(t, "corp", consumed.Provider)
+	assert.Equal(t, "verifier", consumed.CodeVerifier)
+	assert.Equal(t, "nonce", consumed.Nonce)
+
+	_, err = service.ConsumeOIDCState(ctx, "state-hash")
+	assert.Equal(t, redis.Nil, err)
+}
+
+func TestLoginFailures(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "127.0.0.1:6379",
+		DB:   2,
+	})
+	defer client.Close()
+
+	_ = client.FlushDB(context.Background())
+	defer client.FlushDB(context.Background())
+
+	service := NewRedisService(client)
+	ctx := context.Background()
+
+	count, err := service.GetLoginFailures(ctx, "account:user@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	for i := 1; i <= 3; i++ {
+		count, err = service.IncrementLoginFailures(ctx, "account:user@example.com", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, i, count)
+	}
+
+	// 计数窗口从首次失败开始
+	ttl, err := client.TTL(ctx, "login_failures:account:user@example.com").Result()
+	require.NoError(t, err)
+	assert.True(t, ttl > 0 && ttl <= time.Minute)
+
+	require.NoError(t, service.ResetLoginFailures(ctx, "account:user@example.com"))
+	count, err = service.GetLoginFailures(ctx, "account:user@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestConsumeAccountUnlockToken(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "127.0.0.1:6379",
+		DB:   2,
+	})
+	defer client.Close()
+
+	_ = client.FlushDB(context.Background())
+	defer client.FlushDB(context.Background())
+
+	service := NewRedisService(client)
+	ctx := context.Background()
+
+	data := &AccountUnlockData{UserID: 7, Email: "user@example.com", CreatedAt: time.Now()}
+	require.NoError(t, service.SetAccountUnlockToken(ctx, "token-hash", data, time.Minute))
+
+	consumed, err := service.ConsumeAccountUnlockToken(ctx, "token-hash")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), consumed.UserID)
+	assert.Equal(t, "user@example.com", consumed.Email)
+
+	_, err = service.ConsumeAccountUnlockToken(ctx, "token-hash")
+	assert.Equal(t, redis.Nil, err)
+}
@@ -3,8 +3,10 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -20,20 +22,40 @@ type Config struct {
 	Redis    RedisConfig
 	SMTP     SMTPConfig
 	Paths    PathConfig
+	OIDC     []OIDCProviderConfig
 }
 
 // ServerConfig wraps HTTP server settings.
 type ServerConfig struct {
 	Addr string
+	// PublicURL is the externally reachable base URL used in emailed links.
+	PublicURL string
+	// TrustedProxies lists the reverse proxy IPs or CIDRs whose
+	// X-Forwarded-For header names the client. Empty trusts no proxy, so the
+	// client IP is the peer address.
+	TrustedProxies []string
 }
 
 // SecurityConfig covers JWT parameters and secrets.
 type SecurityConfig struct {
+	// JWTSecret signs HS256 tokens. With SigningKeys configured it is optional
+	// and only verifies tokens issued before the switch.
 	JWTSecret       string
+	SigningKeys     []SigningKeyConfig
 	AccessTokenTTL  time.Duration
 	RefreshTokenTTL time.Duration
 }
 
+// SigningKeyConfig describes an asymmetric access token signing key.
+type SigningKeyConfig struct {
+	ID        string
+	Algorithm string
+	// KeyFile is a PEM private key, or a public key for verification only.
+	KeyFile    string
+	ActivateAt time.Time
+	RetireAt   time.Time
+}
+
 // StorageConfig stores database connectivity hints.
 type StorageConfig struct {
 	Driver string
@@ -43,6 +65,8 @@ type StorageConfig struct {
 // PathConfig keeps filesystem root references.
 type PathConfig struct {
 	PresentationsRoot string
+	TrashRoot         string
+	TrashRetention    time.Duration
 }
 
 // RedisConfig stores Redis connectivity settings.
@@ -65,15 +89,34 @@ type SMTPConfig struct {
 	UseTLS   bool   `yaml:"useTLS"`
 }
 
+// OIDCProviderConfig describes an external OpenID Connect identity provider.
+type OIDCProviderConfig struct {
+	// Name identifies the provider in URLs, e.g. /auth/oidc/<name>/login.
+	Name         string   `yaml:"name"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"clientID"`
+	ClientSecret string   `yaml:"clientSecret"`
+	Scopes       []string `yaml:"scopes"`
+}
+
 type securityRaw struct {
 	JWTSecret       string `yaml:"jwtSecret"`
 	AccessTokenTTL  string `yaml:"accessTokenTTL"`
 	RefreshTokenTTL string `yaml:"refreshTokenTTL"`
+	SigningKeys     []struct {
+		ID         string `yaml:"id"`
+		Algorithm  string `yaml:"algorithm"`
+		KeyFile    string `yaml:"keyFile"`
+		ActivateAt string `yaml:"activateAt"`
+		RetireAt   string `yaml:"retireAt"`
+	} `yaml:"signingKeys"`
 }
 
 type rawConfig struct {
 	Server struct {
-		Addr string `yaml:"addr"`
+		Addr           string   `yaml:"addr"`
+		PublicURL      string   `yaml:"publicURL"`
+		TrustedProxies []string `yaml:"trustedProxies"`
 	} `yaml:"server"`
 	Security securityRaw `yaml:"security"`
 	Storage  struct {
@@ -82,7 +125,12 @@ type rawConfig struct {
 	} `yaml:"storage"`
 	Paths struct {
 		PresentationsRoot string `yaml:"presentationsRoot"`
+		TrashRoot         string `yaml:"trashRoot"`
+		TrashRetention    string `yaml:"trashRetention"`
 	} `yaml:"paths"`
+	OIDC struct {
+		Providers []OIDCProviderConfig `yaml:"providers"`
+	} `yaml:"oidc"`
 }
 
 // Load reads configuration from disk using APP_CONFIG_PATH override or default path.
@@ -111,17 +159,29 @@ func LoadFromFile(path string) (*Config, error) {
 	}
 
 	cfg := Config{
-		Server: ServerConfig{Addr: raw.Server.Addr},
+		Server: ServerConfig{
+			Addr:           raw.Server.Addr,
+			PublicURL:      strings.TrimRight(raw.Server.PublicURL, "/"),
+			TrustedProxies: raw.Server.TrustedProxies,
+		},
 		Storage: StorageConfig{
 			Driver: raw.Storage.Driver,
 			DSN:    raw.Storage.DSN,
 		},
-		Paths: PathConfig{PresentationsRoot: raw.Paths.PresentationsRoot},
+		Paths: PathConfig{
+			PresentationsRoot: raw.Paths.PresentationsRoot,
+			TrashRoot:         raw.Paths.TrashRoot,
+		},
 	}
 
 	if cfg.Server.Addr == "" {
 		return nil, errors.New("server.addr is required")
 	}
+	for i, proxy := range cfg.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return nil, fmt.Errorf("server.trustedProxies[%d] must be an IP address or CIDR", i)
+		}
+	}
 	if cfg.Storage.Driver == "" {
 		return nil, errors.New("storage.driver is required")
 	}
@@ -132,16 +192,54 @@ func LoadFromFile(path string) (*Config, error) {
 		return nil, errors.New("paths.presentationsRoot is required")
 	}
 
+	if raw.Paths.TrashRetention != "" {
+		retention, err := time.ParseDuration(raw.Paths.TrashRetention)
+		if err != nil {
+			return nil, fmt.Errorf("parse paths.trashRetention: %w", err)
+		}
+		cfg.Paths.TrashRetention = retention
+	}
+
 	if cfg.Security, err = parseSecurity(raw.Security); err != nil {
 		return nil, err
 	}
 
+	if cfg.OIDC, err = parseOIDCProviders(raw.OIDC.Providers); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
 func parseSecurity(sec securityRaw) (SecurityConfig, error) {
-	if sec.JWTSecret == "" {
-		return SecurityConfig{}, errors.New("security.jwtSecret is required")
+	if sec.JWTSecret == "" && len(sec.SigningKeys) == 0 {
+		return SecurityConfig{}, errors.New("security.jwtSecret or security.signingKeys is required")
+	}
+
+	keys := make([]SigningKeyConfig, 0, len(sec.SigningKeys))
+	for i, raw := range sec.SigningKeys {
+		key := SigningKeyConfig{ID: raw.ID, Algorithm: raw.Algorithm, KeyFile: raw.KeyFile}
+		if key.ID == "" {
+			return SecurityConfig{}, fmt.Errorf("security.signingKeys[%d].id is required", i)
+		}
+		if key.Algorithm != "RS256" && key.Algorithm != "EdDSA" {
+			return SecurityConfig{}, fmt.Errorf("security.signingKeys[%d].algorithm must be RS256 or EdDSA", i)
+		}
+		if key.KeyFile == "" {
+			return SecurityConfig{}, fmt.Errorf("security.signingKeys[%d].keyFile is required", i)
+		}
+		var err error
+		if raw.ActivateAt != "" {
+			if key.ActivateAt, err = time.Parse(time.RFC3339, raw.ActivateAt); err != nil {
+				return SecurityConfig{}, fmt.Errorf("parse security.signingKeys[%d].activateAt: %w", i, err)
+			}
+		}
+		if raw.RetireAt != "" {
+			if key.RetireAt, err = time.Parse(time.RFC3339, raw.RetireAt); err != nil {
+				return SecurityConfig{}, fmt.Errorf("parse security.signingKeys[%d].retireAt: %w", i, err)
+			}
+		}
+		keys = append(keys, key)
 	}
 
 	accessTTL, err := time.ParseDuration(sec.AccessTokenTTL)
@@ -156,7 +254,29 @@ func parseSecurity(sec securityRaw) (SecurityConfig, error) {
 
 	return SecurityConfig{
 		JWTSecret:       sec.JWTSecret,
+		SigningKeys:     keys,
 		AccessTokenTTL:  accessTTL,
 		RefreshTokenTTL: refreshTTL,
 	}, nil
 }
+
+func parseOIDCProviders(providers []OIDCProviderConfig) ([]OIDCProviderConfig, error) {
+	seen := make(map[string]bool, len(providers))
+	for i, provider := range providers {
+		if provider.Name == "" || strings.Trim(provider.Name, "abcdefghijklmnopqrstuvwxyz0123456789-") != "" {
+			return nil, fmt.Errorf("oidc.providers[%d].name must use lowercase letters, digits and dashes", i)
+		}
+		if seen[provider.Name] {
+			return nil, fmt.Errorf("oidc.providers[%d].name %q is duplicated", i, provider.Name)
+		}
+		seen[provider.Name] = true
+		if provider.Issuer == "" {
+			return nil, fmt.Errorf("oidc.providers[%d].issuer is required", i)
+		}
+		if provider.ClientID == "" {
+			return nil, fmt.Errorf("oidc.providers[%d].clientID is required", i)
+		}
+		providers[i].Issuer = strings.TrimRight(provider.Issuer, "/")
+	}
+	return providers, nil
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/auth"
+)
+
+// ChangePassword handles PUT /account/password.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		CurrentPassword string `json:"currentPassword" binding:"required"`
+		NewPassword     string `json:"newPassword" binding:"required"`
+		RefreshToken    string `json:"refreshToken"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	err := h.service.ChangePassword(
+		c.Request.Context(),
+		claims.UserID,
+		req.CurrentPassword,
+		req.NewPassword,
+		currentRefreshToken(c, req.RefreshToken),
+	)
+	if err != nil {
+		handleAccountError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RequestEmailChange handles POST /account/email - 向新邮箱发送验证码
+func (h *AuthHandler) RequestEmailChange(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Email       string `json:"email" binding:"required"`
+		Password    string `json:"password" binding:"required"`
+		CaptchaID   string `json:"captcha_id" binding:"required"`
+		CaptchaCode string `json:"captcha_code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	expiresIn, err := h.service.RequestEmailChange(
+		c.Request.Context(),
+		claims.UserID,
+		req.Password,
+		req.Email,
+		req.CaptchaID,
+		req.CaptchaCode,
+	)
+	if err != nil {
+		handleAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "验证码已发送",
+		"expires_in": expiresIn,
+	})
+}
+
+// ConfirmEmailChange handles POST /account/email/confirm - 校验验证码后更换邮箱
+func (h *AuthHandler) ConfirmEmailChange(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Email        string `json:"email" binding:"required"`
+		EmailCode    string `json:"email_code" binding:"required"`
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	user, err := h.service.ConfirmEmailChange(
+		c.Request.Context(),
+		claims.UserID,
+		req.Email,
+		req.EmailCode,
+		currentRefreshToken(c, req.RefreshToken),
+	)
+	if err != nil {
+		handleAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": gin.H{
+			"id":     user.ID,
+			"email":  user.Email,
+			"status": user.Status,
+		},
+	})
+}
+
+// GetMFA handles GET /account/mfa.
+func (h *AuthHandler) GetMFA(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.MFAStatus(c.Request.Context(), claims.UserID)
+	if err != nil {
+		handleAccountError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":                status.Enabled,
+		"recoveryCodesRemaining": status.RecoveryCodesRemaining,
+	})
+}
+
+// BeginMFAEnrollment handles POST /account/mfa/enroll.
+func (h *AuthHandler) BeginMFAEnrollment(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	enrollment, err := h.service.BeginMFAEnrollment(c.Request.Context(), claims.UserID, req.Password)
+	if err != nil {
+		handleAccountError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"secret":     enrollment.Secret,
+		"otpauthUri": enrollment.URI,
+	})
+}
+
+// ConfirmMFAEnrollment handles POST /account/mfa/enroll/confirm.
+func (h *AuthHandler) ConfirmMFAEnrollment(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	codes, err := h.service.ConfirmMFAEnrollment(c.Request.Context(), claims.UserID, req.Code)
+	if err != nil {
+		handleAccountError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// DisableMFA handles POST /account/mfa/disable.
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	if err := h.service.DisableMFA(c.Request.Context(), claims.UserID, req.Password, req.Code); err != nil {
+		handleAccountError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes handles POST /account/mfa/recovery-codes.
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), claims.UserID, req.Password, req.Code)
+	if err != nil {
+		handleAccountError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// currentRefreshToken prefers the token in the request body and falls back to the cookie.
+func currentRefreshToken(c *gin.Context, fromBody string) string {
+	if fromBody != "" {
+		return fromBody
+	}
+	if token, err := c.Cookie("refresh_token"); err == nil {
+		return token
+	}
+	return ""
+}
+
+// handleAccountError 处理账号设置相关错误
+func handleAccountError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrIncorrectPassword):
+		writeError(c, http.StatusBadRequest, "incorrect_password", "当前密码错误")
+	case errors.Is(err, auth.ErrEmailUnchanged):
+		writeError(c, http.StatusBadRequest, "email_unchanged", "新邮箱与当前邮箱相同")
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		writeError(c, http.StatusBadRequest, "invalid_mfa_code", "动态验证码或恢复码错误")
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled):
+		writeError(c, http.StatusConflict, "mfa_already_enabled", "已开启两步验证")
+	case errors.Is(err, auth.ErrMFANotEnabled):
+		writeError(c, http.StatusConflict, "mfa_not_enabled", "尚未开启两步验证")
+	case errors.Is(err, auth.ErrMFAChallengeNotFound):
+		writeError(c, http.StatusUnauthorized, "mfa_challenge_invalid", "登录验证已失效，请重新登录")
+	case errors.Is(err, auth.ErrAccountLocked):
+		writeError(c, http.StatusLocked, "account_locked", "账号已锁定，请稍后再试或使用邮件中的解锁链接")
+	case errors.Is(err, auth.ErrAccountPending):
+		writeError(c, http.StatusForbidden, "account_pending", "账号尚未激活")
+	default:
+		handleVerificationError(c, err)
+	}
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/auth"
+	"online-ppt/internal/http/middleware"
+	"online-ppt/internal/records"
+)
+
+// AdminHandler exposes operator endpoints for managing user accounts.
+type AdminHandler struct {
+	service *auth.Service
+	records *records.Service
+	auth    *middleware.Auth
+}
+
+// NewAdminHandler constructs an AdminHandler. recordsService is used to clean
+// up the deck directories of deleted users.
+func NewAdminHandler(service *auth.Service, recordsService *records.Service) *AdminHandler {
+	return &AdminHandler{
+		service: service,
+		records: recordsService,
+		auth:    middleware.NewAuth(service.Authenticate).WithAPITokens(service),
+	}
+}
+
+// Auth returns the bearer token middleware guarding the admin routes. Personal
+// access tokens are accepted when they carry the admin scope.
+func (h *AdminHandler) Auth() *middleware.Auth {
+	return h.auth
+}
+
+// ListUsers handles GET /admin/users?q=&status=&role=&limit=&offset=.
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	adminID, ok := currentAdminID(c)
+	if !ok {
+		return
+	}
+
+	filter := auth.UserFilter{
+		Query:  c.Query("q"),
+		Status: c.Query("status"),
+		Role:   c.Query("role"),
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "invalid_limit", "limit must be an integer")
+			return
+		}
+		filter.Limit = limit
+	}
+	if v := c.Query("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "invalid_offset", "offset must be an integer")
+			return
+		}
+		filter.Offset = offset
+	}
+
+	result, err := h.service.ListUsers(c.Request.Context(), adminID, filter)
+	if err != nil {
+		handleAdminError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(result.Users))
+	for _, user := range result.Users {
+		items = append(items, makeAdminUserResponse(user))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"total":  result.Total,
+		"limit":  result.Limit,
+		"offset": result.Offset,
+		"users":  items,
+	})
+}
+
+// GetUser handles GET /admin/users/:userId.
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	adminID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.GetUser(c.Request.Context(), adminID, userID)
+	if err != nil {
+		handleAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": makeAdminUserResponse(user)})
+}
+
+// SetUserStatus handles PUT /admin/users/:userId/status.
+func (h *AdminHandler) SetUserStatus(c *gin.Context) {
+	adminID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	user, err := h.service.SetUserStatus(c.Request.Context(), adminID, userID, req.Status)
+	if err != nil {
+		handleAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": makeAdminUserResponse(user)})
+}
+
+// ForcePasswordReset handles POST /admin/users/:userId/password-reset.
+func (h *AdminHandler) ForcePasswordReset(c *gin.Context) {
+	adminID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	expiresIn, err := h.service.ForcePasswordReset(c.Request.Context(), adminID, userID)
+	if err != nil {
+		handleAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "重置链接已发送",
+		"expires_in": expiresIn,
+	})
+}
+
+// RevokeUserSessions handles DELETE /admin/users/:userId/sessions.
+func (h *AdminHandler) RevokeUserSessions(c *gin.Context) {
+	adminID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.service.RevokeAllSessions(c.Request.Context(), adminID, userID)
+	if err != nil {
+		handleAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+// DeleteUser handles DELETE /admin/users/:userId. The user's personal deck
+// directories are removed after the account row.
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	adminID, userID, ok := adminTarget(c)
+	if !ok {
+		return
+	}
+
+	var cleanup func(ctx context.Context, user auth.UserAccount) error
+	if h.records != nil {
+		cleanup = func(ctx context.Context, user auth.UserAccount) error {
+			_, err := h.records.RemoveUserDecks(ctx, adminID, user.ID, user.UUID)
+			return err
+		}
+	}
+
+	if err := h.service.DeleteUser(c.Request.Context(), adminID, userID, cleanup); err != nil {
+		handleAdminError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// currentAdminID reads the caller stored by the admin route middleware.
+func currentAdminID(c *gin.Context) (int64, bool) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return 0, false
+	}
+	return claims.UserID, true
+}
+
+func adminTarget(c *gin.Context) (int64, int64, bool) {
+	adminID, ok := currentAdminID(c)
+	if !ok {
+		return 0, 0, false
+	}
+	userID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil || userID <= 0 {
+		writeError(c, http.StatusBadRequest, "invalid_id", "user id must be a positive integer")
+		return 0, 0, false
+	}
+	return adminID, userID, true
+}
+
+func makeAdminUserResponse(user auth.UserAccount) gin.H {
+	resp := gin.H{
+		"id":          user.ID,
+		"uuid":        user.UUID,
+		"email":       user.Email,
+		"status":      user.Status,
+		"role":        user.Role,
+		"lockedUntil": nil,
+		"lastLoginAt": nil,
+		"createdAt":   user.CreatedAt.UTC(),
+		"updatedAt":   user.UpdatedAt.UTC(),
+	}
+	if user.LockedUntil.Valid {
+		resp["lockedUntil"] = user.LockedUntil.Time.UTC()
+	}
+	if user.LastLoginAt.Valid {
+		resp["lastLoginAt"] = user.LastLoginAt.Time.UTC()
+	}
+	return resp
+}
+
+func handleAdminError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound):
+		writeError(c, http.StatusNotFound, "user_not_found", "user not found")
+	case errors.Is(err, auth.ErrInvalidUserStatus):
+		writeError(c, http.StatusBadRequest, "invalid_status", err.Error())
+	case errors.Is(err, auth.ErrInvalidUserRole):
+		writeError(c, http.StatusBadRequest, "invalid_role", err.Error())
+	case errors.Is(err, auth.ErrAdminSelfAction):
+		writeError(c, http.StatusConflict, "self_action", err.Error())
+	case errors.Is(err, auth.ErrAccountLocked), errors.Is(err, auth.ErrAccountPending):
+		writeError(c, http.StatusConflict, "account_inactive", "password reset requires an active account")
+	default:
+		writeError(c, http.StatusInternalServerError, "server_error", err.Error())
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/auth"
+)
+
+// ListAPITokens handles GET /account/tokens.
+func (h *AuthHandler) ListAPITokens(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.service.ListAPITokens(c.Request.Context(), claims.UserID)
+	if err != nil {
+		handleAPITokenError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(tokens))
+	for _, token := range tokens {
+		items = append(items, serializeAPIToken(token))
+	}
+	c.JSON(http.StatusOK, gin.H{"tokens": items})
+}
+
+// CreateAPIToken handles POST /account/tokens. The secret is only returned once.
+func (h *AuthHandler) CreateAPIToken(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name          string   `json:"name" binding:"required"`
+		Scopes        []string `json:"scopes" binding:"required"`
+		ExpiresInDays int      `json:"expiresInDays"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	lifetime := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	created, err := h.service.CreateAPIToken(c.Request.Context(), claims.UserID, req.Name, req.Scopes, lifetime)
+	if err != nil {
+		handleAPITokenError(c, err)
+		return
+	}
+
+	body := serializeAPIToken(created.APIToken)
+	body["token"] = created.Token
+	c.JSON(http.StatusCreated, body)
+}
+
+// RevokeAPIToken handles DELETE /account/tokens/:tokenId.
+func (h *AuthHandler) RevokeAPIToken(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	tokenID, err := strconv.ParseInt(c.Param("tokenId"), 10, 64)
+	if err != nil || tokenID <= 0 {
+		writeError(c, http.StatusBadRequest, "invalid_id", "token id must be a positive integer")
+		return
+	}
+
+	if err := h.service.RevokeAPIToken(c.Request.Context(), claims.UserID, tokenID); err != nil {
+		handleAPITokenError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func serializeAPIToken(token auth.APIToken) gin.H {
+	var expiresAt, lastUsedAt *time.Time
+	if token.ExpiresAt.Valid {
+		expiresAt = &token.ExpiresAt.Time
+	}
+	if token.LastUsedAt.Valid {
+		lastUsedAt = &token.LastUsedAt.Time
+	}
+	return gin.H{
+		"id":         token.ID,
+		"name":       token.Name,
+		"prefix":     token.TokenPrefix,
+		"scopes":     token.Scopes,
+		"expiresAt":  expiresAt,
+		"lastUsedAt": lastUsedAt,
+		"createdAt":  token.CreatedAt,
+	}
+}
+
+// handleAPITokenError 处理个人访问令牌相关错误
+func handleAPITokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidAPITokenRequest):
+		writeError(c, http.StatusBadRequest, "invalid_token_request", err.Error())
+	case errors.Is(err, auth.ErrAPITokenNotFound):
+		writeError(c, http.StatusNotFound, "token_not_found", "访问令牌不存在或已吊销")
+	default:
+		writeError(c, http.StatusInternalServerError, "server_error", err.Error())
+	}
+}
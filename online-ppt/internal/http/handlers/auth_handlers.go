@@ -9,17 +9,26 @@ import (
 
 	"online-ppt/internal/auth"
 	"online-ppt/internal/config"
+	"online-ppt/internal/http/middleware"
 )
 
 // AuthHandler exposes HTTP endpoints for authentication flows.
 type AuthHandler struct {
 	service *auth.Service
 	cfg     *config.Config
+	auth    *middleware.Auth
 }
 
 // NewAuthHandler constructs a new AuthHandler instance.
 func NewAuthHandler(service *auth.Service, cfg *config.Config) *AuthHandler {
-	return &AuthHandler{service: service, cfg: cfg}
+	return &AuthHandler{service: service, cfg: cfg, auth: middleware.NewAuth(service.Authenticate)}
+}
+
+// Auth returns the bearer token middleware guarding the session and account
+// routes. Only interactive access tokens are accepted, never personal access
+// tokens.
+func (h *AuthHandler) Auth() *middleware.Auth {
+	return h.auth
 }
 
 // Register handles POST /auth/register.
@@ -38,8 +47,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	fingerprint := clientFingerprint(c)
-	result, err := h.service.Login(c.Request.Context(), req.Email, req.Password, fingerprint)
+	result, err := h.service.Login(c.Request.Context(), auth.LoginRequest{Email: req.Email, Password: req.Password}, clientInfo(c))
 	if err != nil {
 		handleAuthError(c, err)
 		return
@@ -49,23 +57,54 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	c.JSON(http.StatusCreated, serializeAuthResult(result))
 }
 
-// Login handles POST /auth/login.
+// Login handles POST /auth/login. After repeated failures the captcha fields are required.
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email       string `json:"email"`
+		Password    string `json:"password"`
+		CaptchaID   string `json:"captcha_id"`
+		CaptchaCode string `json:"captcha_code"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
 
-	fingerprint := clientFingerprint(c)
-	result, err := h.service.Login(c.Request.Context(), req.Email, req.Password, fingerprint)
+	result, err := h.service.Login(c.Request.Context(), auth.LoginRequest{
+		Email:       req.Email,
+		Password:    req.Password,
+		CaptchaID:   req.CaptchaID,
+		CaptchaCode: req.CaptchaCode,
+	}, clientInfo(c))
 	if err != nil {
 		handleAuthError(c, err)
 		return
 	}
+	if result.MFARequired() {
+		writeMFAChallenge(c, result)
+		return
+	}
+
+	setRefreshCookie(c, result.RefreshToken, result.RefreshExpiresAt, h.cfg)
+	c.JSON(http.StatusOK, serializeAuthResult(result))
+}
+
+// LoginMFA handles POST /auth/login/mfa, the second step of a two-factor login.
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfaToken" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	result, err := h.service.CompleteMFALogin(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		handleAccountError(c, err)
+		return
+	}
 
 	setRefreshCookie(c, result.RefreshToken, result.RefreshExpiresAt, h.cfg)
 	c.JSON(http.StatusOK, serializeAuthResult(result))
@@ -79,9 +118,11 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	fingerprint := clientFingerprint(c)
-	result, err := h.service.Refresh(c.Request.Context(), refreshToken, fingerprint)
+	result, err := h.service.Refresh(c.Request.Context(), refreshToken, clientInfo(c))
 	if err != nil {
+		if errors.Is(err, auth.ErrRefreshTokenReused) {
+			setRefreshCookie(c, "", time.Time{}, h.cfg)
+		}
 		handleAuthError(c, err)
 		return
 	}
@@ -107,6 +148,24 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// JWKS handles GET /.well-known/jwks.json so other services can verify access tokens.
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.service.JWKS())
+}
+
+func writeMFAChallenge(c *gin.Context, result auth.AuthResult) {
+	expiresIn := int(time.Until(result.MFAExpiresAt).Seconds())
+	if expiresIn < 0 {
+		expiresIn = 0
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"mfaRequired": true,
+		"mfaToken":    result.MFAToken,
+		"expiresIn":   expiresIn,
+	})
+}
+
 func serializeAuthResult(result auth.AuthResult) gin.H {
 	expiresIn := int(time.Until(result.AccessExpiresAt).Seconds())
 	if expiresIn < 0 {
@@ -138,11 +197,34 @@ func handleAuthError(c *gin.Context, err error) {
 		writeError(c, http.StatusUnauthorized, "invalid_credentials", "invalid email or password")
 	case errors.Is(err, auth.ErrSessionNotFound):
 		writeError(c, http.StatusUnauthorized, "session_not_found", "refresh token invalid or expired")
+	case errors.Is(err, auth.ErrRefreshTokenReused):
+		writeError(c, http.StatusUnauthorized, "refresh_token_reused", "refresh token already used; please sign in again")
+	case errors.Is(err, auth.ErrAccountLocked):
+		writeError(c, http.StatusLocked, "account_locked", "account locked; try again later or use the unlock link sent by email")
+	case errors.Is(err, auth.ErrAccountPending):
+		writeError(c, http.StatusForbidden, "account_pending", "account has not been activated")
+	case errors.Is(err, auth.ErrCaptchaRequired):
+		writeError(c, http.StatusBadRequest, "captcha_required", "too many failed logins; captcha required")
+	case errors.Is(err, auth.ErrInvalidCaptcha):
+		writeError(c, http.StatusBadRequest, "invalid_captcha", "captcha incorrect or expired")
+	case errors.Is(err, auth.ErrRateLimited):
+		writeError(c, http.StatusTooManyRequests, "rate_limited", "too many failed logins; try again later")
 	default:
 		writeError(c, http.StatusInternalServerError, "server_error", err.Error())
 	}
 }
 
+// currentClaims returns the caller authenticated by the route's Auth
+// middleware, answering 401 when it is missing.
+func currentClaims(c *gin.Context) (*auth.Claims, bool) {
+	claims, ok := middleware.ClaimsFrom(c)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, "unauthorized", middleware.ErrMissingBearer.Error())
+		return nil, false
+	}
+	return claims, true
+}
+
 func writeError(c *gin.Context, status int, code, message string) {
 	c.JSON(status, gin.H{
 		"code":    code,
@@ -158,6 +240,15 @@ func clientFingerprint(c *gin.Context) string {
 	return fingerprint
 }
 
+// clientInfo collects the device details recorded on a new session.
+func clientInfo(c *gin.Context) auth.ClientInfo {
+	return auth.ClientInfo{
+		Fingerprint: clientFingerprint(c),
+		IPAddress:   c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+	}
+}
+
 func readRefreshTokenFromRequest(c *gin.Context) string {
 	var body struct {
 		RefreshToken string `json:"refreshToken"`
@@ -259,8 +350,7 @@ func (h *AuthHandler) RegisterWithCode(c *gin.Context) {
 	}
 
 	// 注册成功后自动登录
-	fingerprint := clientFingerprint(c)
-	result, err := h.service.Login(c.Request.Context(), req.Email, req.Password, fingerprint)
+	result, err := h.service.Login(c.Request.Context(), auth.LoginRequest{Email: req.Email, Password: req.Password}, clientInfo(c))
 	if err != nil {
 		handleAuthError(c, err)
 		return
@@ -270,6 +360,88 @@ func (h *AuthHandler) RegisterWithCode(c *gin.Context) {
 	c.JSON(http.StatusCreated, serializeAuthResult(result))
 }
 
+// RequestPasswordReset handles POST /auth/password/reset - 发送密码重置链接
+func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
+	var req struct {
+		Email       string `json:"email" binding:"required"`
+		CaptchaID   string `json:"captcha_id" binding:"required"`
+		CaptchaCode string `json:"captcha_code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	expiresIn, err := h.service.RequestPasswordReset(
+		c.Request.Context(),
+		req.Email,
+		req.CaptchaID,
+		req.CaptchaCode,
+	)
+	if err != nil {
+		handleVerificationError(c, err)
+		return
+	}
+
+	// 无论邮箱是否注册都返回相同响应，避免泄露账号信息
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "如果该邮箱已注册，重置链接已发送",
+		"expires_in": expiresIn,
+	})
+}
+
+// ConfirmPasswordReset handles POST /auth/password/reset/confirm - 使用重置令牌设置新密码
+func (h *AuthHandler) ConfirmPasswordReset(c *gin.Context) {
+	var req struct {
+		Token       string `json:"token" binding:"required"`
+		Password    string `json:"password" binding:"required"`
+		CaptchaID   string `json:"captcha_id" binding:"required"`
+		CaptchaCode string `json:"captcha_code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	err := h.service.ConfirmPasswordReset(c.Request.Context(), auth.PasswordResetConfirmation{
+		Token:       req.Token,
+		Password:    req.Password,
+		CaptchaID:   req.CaptchaID,
+		CaptchaCode: req.CaptchaCode,
+		ClientKey:   c.ClientIP(),
+	})
+	if err != nil {
+		handleVerificationError(c, err)
+		return
+	}
+
+	// 所有会话已被吊销，同时清除当前客户端的刷新令牌
+	setRefreshCookie(c, "", time.Time{}, h.cfg)
+	c.Status(http.StatusNoContent)
+}
+
+// UnlockAccount handles POST /auth/unlock - 使用邮件中的链接解除账号锁定
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	if err := h.service.UnlockAccount(c.Request.Context(), req.Token); err != nil {
+		if errors.Is(err, auth.ErrInvalidUnlockToken) {
+			writeError(c, http.StatusBadRequest, "invalid_unlock_token", "解锁链接无效、已使用或已过期")
+			return
+		}
+		writeError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // handleVerificationError 处理验证码相关错误
 func handleVerificationError(c *gin.Context, err error) {
 	switch {
@@ -283,6 +455,10 @@ func handleVerificationError(c *gin.Context, err error) {
 		writeError(c, http.StatusTooManyRequests, "too_many_attempts", "验证失败次数过多，请重新获取验证码")
 	case errors.Is(err, auth.ErrEmailAlreadyRegistered):
 		writeError(c, http.StatusConflict, "email_exists", "邮箱已注册")
+	case errors.Is(err, auth.ErrInvalidResetToken):
+		writeError(c, http.StatusBadRequest, "invalid_reset_token", "重置链接无效、已使用或已过期")
+	case errors.Is(err, auth.ErrWeakPassword):
+		writeError(c, http.StatusBadRequest, "weak_password", "密码长度至少为10个字符")
 	default:
 		writeError(c, http.StatusInternalServerError, "server_error", err.Error())
 	}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/auth"
+)
+
+// ListIdentityProviders handles GET /auth/oidc/providers.
+func (h *AuthHandler) ListIdentityProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.service.IdentityProviderNames()})
+}
+
+// BeginOIDCLogin handles GET /auth/oidc/:provider/login - 跳转到第三方身份提供方
+func (h *AuthHandler) BeginOIDCLogin(c *gin.Context) {
+	authURL, err := h.service.BeginOIDCLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		handleOIDCError(c, err)
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// CompleteOIDCLogin handles GET /auth/oidc/:provider/callback - 第三方登录回调
+func (h *AuthHandler) CompleteOIDCLogin(c *gin.Context) {
+	if errCode := c.Query("error"); errCode != "" {
+		writeError(c, http.StatusUnauthorized, "oidc_denied", "身份提供方拒绝了登录请求："+errCode)
+		return
+	}
+	state := c.Query("state")
+	code := c.Query("code")
+	if state == "" || code == "" {
+		writeError(c, http.StatusBadRequest, "invalid_request", "state and code are required")
+		return
+	}
+
+	result, err := h.service.CompleteOIDCLogin(c.Request.Context(), c.Param("provider"), state, code, clientInfo(c))
+	if err != nil {
+		handleOIDCError(c, err)
+		return
+	}
+	if result.MFARequired() {
+		writeMFAChallenge(c, result)
+		return
+	}
+
+	setRefreshCookie(c, result.RefreshToken, result.RefreshExpiresAt, h.cfg)
+	c.JSON(http.StatusOK, serializeAuthResult(result))
+}
+
+// handleOIDCError 处理第三方登录相关错误
+func handleOIDCError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrIdentityProviderNotFound):
+		writeError(c, http.StatusNotFound, "provider_not_found", "未配置该身份提供方")
+	case errors.Is(err, auth.ErrInvalidOIDCState):
+		writeError(c, http.StatusBadRequest, "invalid_state", "登录请求无效或已过期，请重新登录")
+	case errors.Is(err, auth.ErrOIDCEmailUnverified):
+		writeError(c, http.StatusForbidden, "email_unverified", "身份提供方未提供已验证的邮箱")
+	case errors.Is(err, auth.ErrOIDCLoginFailed):
+		writeError(c, http.StatusBadGateway, "oidc_login_failed", "第三方登录失败，请稍后再试")
+	default:
+		handleAuthError(c, err)
+	}
+}
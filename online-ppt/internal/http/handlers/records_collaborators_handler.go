@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/records"
+)
+
+// ListCollaborators handles GET /ppts/{id}/collaborators.
+func (h *RecordsHandler) ListCollaborators(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	collaborators, err := h.service.ListCollaborators(c.Request.Context(), slideTarget(claims, recordID))
+	if err != nil {
+		writeCollaboratorError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(collaborators))
+	for _, collaborator := range collaborators {
+		items = append(items, makeCollaboratorResponse(collaborator))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// InviteCollaborator handles POST /ppts/{id}/collaborators.
+func (h *RecordsHandler) InviteCollaborator(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Email string `json:"email" binding:"required"`
+		Role  string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	collaborator, err := h.service.InviteCollaborator(c.Request.Context(), slideTarget(claims, recordID), req.Email, records.Role(req.Role))
+	if err != nil {
+		writeCollaboratorError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, makeCollaboratorResponse(collaborator))
+}
+
+// UpdateCollaborator handles PATCH /ppts/{id}/collaborators/{userId}.
+func (h *RecordsHandler) UpdateCollaborator(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	memberID, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	if err := h.service.UpdateCollaborator(c.Request.Context(), slideTarget(claims, recordID), memberID, records.Role(req.Role)); err != nil {
+		writeCollaboratorError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveCollaborator handles DELETE /ppts/{id}/collaborators/{userId}.
+func (h *RecordsHandler) RemoveCollaborator(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	memberID, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveCollaborator(c.Request.Context(), slideTarget(claims, recordID), memberID); err != nil {
+		writeCollaboratorError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func parseMemberID(c *gin.Context) (int64, bool) {
+	memberID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil || memberID <= 0 {
+		writeError(c, http.StatusBadRequest, "invalid_id", "user id must be a positive integer")
+		return 0, false
+	}
+	return memberID, true
+}
+
+func makeCollaboratorResponse(collaborator records.Collaborator) gin.H {
+	var invitedBy any
+	if collaborator.InvitedBy.Valid {
+		invitedBy = collaborator.InvitedBy.Int64
+	}
+
+	return gin.H{
+		"userId":    collaborator.UserID,
+		"recordId":  collaborator.RecordID,
+		"email":     collaborator.Email,
+		"role":      collaborator.Role,
+		"invitedBy": invitedBy,
+		"createdAt": collaborator.CreatedAt,
+		"updatedAt": collaborator.UpdatedAt,
+	}
+}
+
+func writeCollaboratorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, records.ErrInvalidCollaborator):
+		writeError(c, http.StatusBadRequest, "invalid_collaborator", err.Error())
+	case errors.Is(err, records.ErrInviteeNotFound):
+		writeError(c, http.StatusNotFound, "user_not_found", err.Error())
+	case errors.Is(err, records.ErrCollaboratorNotFound):
+		writeError(c, http.StatusNotFound, "collaborator_not_found", err.Error())
+	case errors.Is(err, records.ErrCollaboratorExists):
+		writeError(c, http.StatusConflict, "collaborator_exists", err.Error())
+	default:
+		writeSlideError(c, err)
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/records"
+)
+
+// maxConfigBodyBytes caps slides.config.json request bodies.
+const maxConfigBodyBytes = 1 << 20
+
+// GetConfig handles GET /ppts/{id}/config.
+func (h *RecordsHandler) GetConfig(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	cfg, err := h.service.GetConfig(c.Request.Context(), slideTarget(claims, recordID))
+	if err != nil {
+		writeConfigError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cfg)
+}
+
+// ReplaceConfig handles PUT /ppts/{id}/config.
+func (h *RecordsHandler) ReplaceConfig(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	raw, ok := readConfigBody(c)
+	if !ok {
+		return
+	}
+
+	cfg, err := h.service.ReplaceConfig(c.Request.Context(), slideTarget(claims, recordID), raw)
+	if err != nil {
+		writeConfigError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cfg)
+}
+
+// PatchConfig handles PATCH /ppts/{id}/config using JSON merge patch semantics.
+func (h *RecordsHandler) PatchConfig(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	raw, ok := readConfigBody(c)
+	if !ok {
+		return
+	}
+
+	cfg, err := h.service.PatchConfig(c.Request.Context(), slideTarget(claims, recordID), raw)
+	if err != nil {
+		writeConfigError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cfg)
+}
+
+func readConfigBody(c *gin.Context) ([]byte, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxConfigBodyBytes)
+	raw, err := c.GetRawData()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return nil, false
+	}
+	if len(raw) == 0 {
+		writeError(c, http.StatusBadRequest, "invalid_request", "request body required")
+		return nil, false
+	}
+	return raw, true
+}
+
+func writeConfigError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, records.ErrInvalidSlidesConfig):
+		writeError(c, http.StatusBadRequest, "invalid_config", err.Error())
+	default:
+		writeSlideError(c, err)
+	}
+}
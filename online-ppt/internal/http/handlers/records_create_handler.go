@@ -3,25 +3,38 @@ package handlers
 import (
 	"errors"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"online-ppt/internal/auth"
+	"online-ppt/internal/http/middleware"
 	"online-ppt/internal/records"
 )
 
 // RecordsHandler exposes record-related HTTP endpoints.
 type RecordsHandler struct {
 	service *records.Service
-	tokens  *auth.TokenManager
+	auth    *middleware.Auth
 }
 
-var errMissingBearer = errors.New("missing or invalid authorization header")
-
 // NewRecordsHandler constructs a handler for PPT record operations.
 func NewRecordsHandler(service *records.Service, tokens *auth.TokenManager) *RecordsHandler {
-	return &RecordsHandler{service: service, tokens: tokens}
+	var parse middleware.TokenParser
+	if tokens != nil {
+		parse = tokens.ParseAccessToken
+	}
+	return &RecordsHandler{service: service, auth: middleware.NewAuth(parse)}
+}
+
+// WithAPITokens lets the handler accept personal access tokens alongside JWTs.
+func (h *RecordsHandler) WithAPITokens(authenticator middleware.APITokenAuthenticator) *RecordsHandler {
+	h.auth.WithAPITokens(authenticator)
+	return h
+}
+
+// Auth returns the bearer token middleware matching the tokens this handler accepts.
+func (h *RecordsHandler) Auth() *middleware.Auth {
+	return h.auth
 }
 
 // Create handles POST /ppts.
@@ -31,9 +44,8 @@ func (h *RecordsHandler) Create(c *gin.Context) {
 		return
 	}
 
-	claims, err := h.authorize(c)
-	if err != nil {
-		writeError(c, http.StatusUnauthorized, "unauthorized", err.Error())
+	claims, ok := currentClaims(c)
+	if !ok {
 		return
 	}
 
@@ -42,6 +54,7 @@ func (h *RecordsHandler) Create(c *gin.Context) {
 		Title       string   `json:"title"`
 		Description string   `json:"description"`
 		Tags        []string `json:"tags"`
+		WorkspaceID int64    `json:"workspaceId"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
@@ -51,6 +64,7 @@ func (h *RecordsHandler) Create(c *gin.Context) {
 	view, err := h.service.CreateRecord(c.Request.Context(), records.CreateParams{
 		UserID:      claims.UserID,
 		UserUUID:    claims.UserUUID,
+		WorkspaceID: req.WorkspaceID,
 		Name:        req.Name,
 		Title:       req.Title,
 		Description: req.Description,
@@ -62,6 +76,8 @@ func (h *RecordsHandler) Create(c *gin.Context) {
 			writeError(c, http.StatusBadRequest, "invalid_name", err.Error())
 		case err == records.ErrDuplicateRecord:
 			writeError(c, http.StatusConflict, "record_exists", err.Error())
+		case errors.Is(err, records.ErrWorkspaceNotFound), errors.Is(err, records.ErrInvalidWorkspace), errors.Is(err, records.ErrForbidden):
+			writeWorkspaceError(c, err)
 		default:
 			writeError(c, http.StatusInternalServerError, "server_error", err.Error())
 		}
@@ -89,38 +105,25 @@ func makeRecordResponse(view records.RecordView) gin.H {
 		tags = []string{}
 	}
 
-	return gin.H{
-		"id":            record.ID,
-		"name":          record.Name,
-		"title":         title,
-		"groupName":     record.GroupName,
-		"description":   description,
-		"relativePath":  record.RelativePath,
-		"canonicalPath": record.CanonicalPath,
-		"tags":          tags,
-		"pathStatus":    view.PathStatus,
-		"createdAt":     record.CreatedAt,
-		"updatedAt":     record.UpdatedAt,
-	}
-}
-
-func (h *RecordsHandler) authorize(c *gin.Context) (*auth.Claims, error) {
-	if h.tokens == nil {
-		return nil, errMissingBearer
-	}
-
-	header := c.GetHeader("Authorization")
-	if header == "" {
-		return nil, errMissingBearer
-	}
-	parts := strings.SplitN(header, " ", 2)
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || parts[1] == "" {
-		return nil, errMissingBearer
+	var workspaceID any
+	if record.WorkspaceID.Valid {
+		workspaceID = record.WorkspaceID.Int64
 	}
 
-	claims, err := h.tokens.ParseAccessToken(parts[1])
-	if err != nil {
-		return nil, errMissingBearer
+	return gin.H{
+		"id":             record.ID,
+		"name":           record.Name,
+		"title":          title,
+		"groupName":      record.GroupName,
+		"workspaceId":    workspaceID,
+		"description":    description,
+		"relativePath":   record.RelativePath,
+		"canonicalPath":  record.CanonicalPath,
+		"tags":           tags,
+		"pathStatus":     view.PathStatus,
+		"currentVersion": view.CurrentVersion,
+		"role":           view.Role,
+		"createdAt":      record.CreatedAt,
+		"updatedAt":      record.UpdatedAt,
 	}
-	return claims, nil
 }
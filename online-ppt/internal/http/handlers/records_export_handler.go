@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/records"
+)
+
+// Export handles GET /ppts/{id}/export?format=zip.
+func (h *RecordsHandler) Export(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.service.PrepareExport(c.Request.Context(), slideTarget(claims, recordID), c.DefaultQuery("format", records.ExportFormatZip))
+	if err != nil {
+		if errors.Is(err, records.ErrUnsupportedExportFormat) {
+			writeError(c, http.StatusBadRequest, "unsupported_format", err.Error())
+			return
+		}
+		writeSlideError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName()))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	if err := export.WriteZip(c.Writer); err != nil {
+		// Headers are already on the wire; record the failure and let the
+		// client see a truncated archive.
+		_ = c.Error(err)
+	}
+}
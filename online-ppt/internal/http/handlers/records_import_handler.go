@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/records"
+)
+
+// importFormOverhead leaves room for multipart boundaries and form fields on
+// top of the archive itself.
+const importFormOverhead = 1 << 20
+
+// Import handles POST /ppts/import with a multipart "file" field holding a ZIP.
+func (h *RecordsHandler) Import(c *gin.Context) {
+	if h.service == nil {
+		writeError(c, http.StatusInternalServerError, "server_error", "records service unavailable")
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, records.MaxImportArchiveBytes+importFormOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(c, http.StatusRequestEntityTooLarge, "archive_too_large", records.ErrArchiveTooLarge.Error())
+			return
+		}
+		writeError(c, http.StatusBadRequest, "invalid_request", "multipart field \"file\" is required")
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	defer file.Close()
+
+	var workspaceID int64
+	if v := c.PostForm("workspaceId"); v != "" {
+		if workspaceID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid_request", "workspaceId must be an integer")
+			return
+		}
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(c.PostForm("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	view, err := h.service.ImportRecord(c.Request.Context(), records.CreateParams{
+		UserID:      claims.UserID,
+		UserUUID:    claims.UserUUID,
+		WorkspaceID: workspaceID,
+		Name:        c.PostForm("name"),
+		Title:       c.PostForm("title"),
+		Description: c.PostForm("description"),
+		Tags:        tags,
+	}, file, header.Size)
+	if err != nil {
+		switch {
+		case errors.Is(err, records.ErrArchiveTooLarge):
+			writeError(c, http.StatusRequestEntityTooLarge, "archive_too_large", err.Error())
+		case errors.Is(err, records.ErrInvalidArchive):
+			writeError(c, http.StatusBadRequest, "invalid_archive", err.Error())
+		case errors.Is(err, records.ErrInvalidSlidesConfig):
+			writeError(c, http.StatusBadRequest, "invalid_config", err.Error())
+		case errors.Is(err, records.ErrInvalidRecordName):
+			writeError(c, http.StatusBadRequest, "invalid_name", err.Error())
+		case errors.Is(err, records.ErrDuplicateRecord):
+			writeError(c, http.StatusConflict, "record_exists", err.Error())
+		case errors.Is(err, records.ErrDeckDirectoryExists):
+			writeError(c, http.StatusConflict, "path_conflict", err.Error())
+		case errors.Is(err, records.ErrWorkspaceNotFound), errors.Is(err, records.ErrInvalidWorkspace), errors.Is(err, records.ErrForbidden):
+			writeWorkspaceError(c, err)
+		default:
+			writeError(c, http.StatusInternalServerError, "server_error", err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, makeRecordResponse(view))
+}
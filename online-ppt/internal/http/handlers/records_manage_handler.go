@@ -20,9 +20,8 @@ func (h *RecordsHandler) List(c *gin.Context) {
 		return
 	}
 
-	claims, err := h.authorize(c)
-	if err != nil {
-		writeError(c, http.StatusUnauthorized, "unauthorized", err.Error())
+	claims, ok := currentClaims(c)
+	if !ok {
 		return
 	}
 
@@ -36,7 +35,7 @@ func (h *RecordsHandler) List(c *gin.Context) {
 		Filters: filters,
 	})
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, "server_error", err.Error())
+		writeWorkspaceError(c, err)
 		return
 	}
 
@@ -65,6 +64,8 @@ func (h *RecordsHandler) Get(c *gin.Context) {
 		switch {
 		case errors.Is(err, records.ErrRecordNotFound):
 			writeError(c, http.StatusNotFound, "not_found", recordNotFoundMsg)
+		case errors.Is(err, records.ErrForbidden):
+			writeError(c, http.StatusForbidden, "forbidden", err.Error())
 		default:
 			writeError(c, http.StatusInternalServerError, "server_error", err.Error())
 		}
@@ -107,8 +108,12 @@ func (h *RecordsHandler) Update(c *gin.Context) {
 			writeError(c, http.StatusBadRequest, "invalid_name", err.Error())
 		case errors.Is(err, records.ErrDuplicateRecord):
 			writeError(c, http.StatusConflict, "record_exists", err.Error())
+		case errors.Is(err, records.ErrDeckDirectoryExists):
+			writeError(c, http.StatusConflict, "path_conflict", err.Error())
 		case errors.Is(err, records.ErrRecordNotFound):
 			writeError(c, http.StatusNotFound, "not_found", recordNotFoundMsg)
+		case errors.Is(err, records.ErrForbidden):
+			writeError(c, http.StatusForbidden, "forbidden", err.Error())
 		default:
 			writeError(c, http.StatusInternalServerError, "server_error", err.Error())
 		}
@@ -129,6 +134,8 @@ func (h *RecordsHandler) Delete(c *gin.Context) {
 		switch {
 		case errors.Is(err, records.ErrRecordNotFound):
 			writeError(c, http.StatusNotFound, "not_found", recordNotFoundMsg)
+		case errors.Is(err, records.ErrForbidden):
+			writeError(c, http.StatusForbidden, "forbidden", err.Error())
 		default:
 			writeError(c, http.StatusInternalServerError, "server_error", err.Error())
 		}
@@ -144,9 +151,8 @@ func (h *RecordsHandler) authenticateWithID(c *gin.Context) (*auth.Claims, int64
 		return nil, 0, false
 	}
 
-	claims, err := h.authorize(c)
-	if err != nil {
-		writeError(c, http.StatusUnauthorized, "unauthorized", err.Error())
+	claims, ok := currentClaims(c)
+	if !ok {
 		return nil, 0, false
 	}
 
@@ -172,6 +178,23 @@ func parseListFilters(c *gin.Context) (records.ListFilters, bool) {
 		Sort:  c.Query("sort"),
 	}
 
+	switch scope := records.ListScope(c.Query("scope")); scope {
+	case "", records.ListScopeAll, records.ListScopeOwned, records.ListScopeShared:
+		filters.Scope = scope
+	default:
+		writeError(c, http.StatusBadRequest, "invalid_scope", "scope must be all, owned or shared")
+		return records.ListFilters{}, false
+	}
+
+	if v := c.Query("workspaceId"); v != "" {
+		workspaceID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || workspaceID <= 0 {
+			writeError(c, http.StatusBadRequest, "invalid_workspace", "workspaceId must be a positive integer")
+			return records.ListFilters{}, false
+		}
+		filters.WorkspaceID = workspaceID
+	}
+
 	if v := c.Query("limit"); v != "" {
 		limit, err := strconv.Atoi(v)
 		if err != nil {
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/records"
+)
+
+// sharePasswordHeader carries the password of a protected share link.
+const sharePasswordHeader = "X-Share-Password"
+
+// ListShares handles GET /ppts/{id}/shares.
+func (h *RecordsHandler) ListShares(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	shares, err := h.service.ListShares(c.Request.Context(), slideTarget(claims, recordID))
+	if err != nil {
+		writeShareError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(shares))
+	for _, share := range shares {
+		items = append(items, makeShareResponse(share))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// CreateShare handles POST /ppts/{id}/shares.
+func (h *RecordsHandler) CreateShare(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ExpiresAt *time.Time `json:"expiresAt"`
+		Password  string     `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	share, err := h.service.CreateShare(c.Request.Context(), slideTarget(claims, recordID), records.ShareParams{
+		ExpiresAt: req.ExpiresAt,
+		Password:  req.Password,
+	})
+	if err != nil {
+		writeShareError(c, err)
+		return
+	}
+
+	resp := makeShareResponse(share)
+	resp["token"] = share.Token
+	c.JSON(http.StatusCreated, resp)
+}
+
+// RevokeShare handles DELETE /ppts/{id}/shares/{shareId}.
+func (h *RecordsHandler) RevokeShare(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	shareID, err := strconv.ParseInt(c.Param("shareId"), 10, 64)
+	if err != nil || shareID <= 0 {
+		writeError(c, http.StatusBadRequest, "invalid_id", "share id must be a positive integer")
+		return
+	}
+
+	if err := h.service.RevokeShare(c.Request.Context(), slideTarget(claims, recordID), shareID); err != nil {
+		writeShareError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// OpenShare handles GET /public/{shareToken}. It needs no bearer token; a
+// password-protected link expects the password in the X-Share-Password header.
+func (h *RecordsHandler) OpenShare(c *gin.Context) {
+	if h.service == nil {
+		writeError(c, http.StatusInternalServerError, "server_error", "records service unavailable")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex")
+
+	deck, err := h.service.OpenShare(c.Request.Context(), c.Param("shareToken"), c.GetHeader(sharePasswordHeader), c.ClientIP())
+	if err != nil {
+		writeShareError(c, err)
+		return
+	}
+
+	slides := make([]gin.H, 0, len(deck.Slides))
+	for _, slide := range deck.Slides {
+		slides = append(slides, gin.H{
+			"file":    slide.File,
+			"content": slide.Content,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"config":    deck.Config,
+		"slides":    slides,
+		"expiresAt": deck.ExpiresAt,
+	})
+}
+
+func makeShareResponse(view records.ShareView) gin.H {
+	share := view.Share
+
+	var lastViewedAt, revokedAt any
+	if share.LastViewedAt.Valid {
+		lastViewedAt = share.LastViewedAt.Time
+	}
+	if share.RevokedAt.Valid {
+		revokedAt = share.RevokedAt.Time
+	}
+
+	status := "active"
+	switch {
+	case share.RevokedAt.Valid:
+		status = "revoked"
+	case view.Expired:
+		status = "expired"
+	}
+
+	return gin.H{
+		"id":                share.ID,
+		"recordId":          share.RecordID,
+		"status":            status,
+		"passwordProtected": share.PasswordHash.Valid,
+		"expiresAt":         share.ExpiresAt,
+		"viewCount":         share.ViewCount,
+		"lastViewedAt":      lastViewedAt,
+		"revokedAt":         revokedAt,
+		"createdAt":         share.CreatedAt,
+	}
+}
+
+func writeShareError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, records.ErrShareNotFound):
+		writeError(c, http.StatusNotFound, "share_not_found", err.Error())
+	case errors.Is(err, records.ErrShareExpired):
+		writeError(c, http.StatusGone, "share_expired", err.Error())
+	case errors.Is(err, records.ErrSharePasswordRequired):
+		writeError(c, http.StatusUnauthorized, "password_required", err.Error())
+	case errors.Is(err, records.ErrInvalidSharePassword):
+		writeError(c, http.StatusForbidden, "invalid_password", err.Error())
+	case errors.Is(err, records.ErrShareThrottled):
+		writeError(c, http.StatusTooManyRequests, "rate_limited", err.Error())
+	case errors.Is(err, records.ErrInvalidShareParams):
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+	default:
+		writeSlideError(c, err)
+	}
+}
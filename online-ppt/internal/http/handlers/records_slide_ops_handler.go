@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/records"
+)
+
+// MoveSlide handles POST /ppts/{id}/config/slides/{slideId}/move.
+func (h *RecordsHandler) MoveSlide(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Index *int `json:"index"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if req.Index == nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", "index required")
+		return
+	}
+
+	cfg, err := h.service.MoveSlide(c.Request.Context(), slideTarget(claims, recordID), c.Param("slideId"), *req.Index)
+	if err != nil {
+		writeSlideOperationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cfg)
+}
+
+// DuplicateSlide handles POST /ppts/{id}/config/slides/{slideId}/duplicate.
+func (h *RecordsHandler) DuplicateSlide(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	cfg, err := h.service.DuplicateSlide(c.Request.Context(), slideTarget(claims, recordID), c.Param("slideId"))
+	if err != nil {
+		writeSlideOperationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, cfg)
+}
+
+// HideSlide handles POST /ppts/{id}/config/slides/{slideId}/hide.
+func (h *RecordsHandler) HideSlide(c *gin.Context) {
+	h.setSlideVisibility(c, false)
+}
+
+// UnhideSlide handles POST /ppts/{id}/config/slides/{slideId}/unhide.
+func (h *RecordsHandler) UnhideSlide(c *gin.Context) {
+	h.setSlideVisibility(c, true)
+}
+
+// BatchSlides handles POST /ppts/{id}/config/slides/batch.
+func (h *RecordsHandler) BatchSlides(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Action   string   `json:"action"`
+		SlideIDs []string `json:"slideIds"`
+		Index    int      `json:"index"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	cfg, err := h.service.ApplySlideBatch(c.Request.Context(), slideTarget(claims, recordID), records.SlideBatch{
+		Action:   records.SlideAction(req.Action),
+		SlideIDs: req.SlideIDs,
+		Index:    req.Index,
+	})
+	if err != nil {
+		writeSlideOperationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cfg)
+}
+
+func (h *RecordsHandler) setSlideVisibility(c *gin.Context, visible bool) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	cfg, err := h.service.SetSlideVisibility(c.Request.Context(), slideTarget(claims, recordID), c.Param("slideId"), visible)
+	if err != nil {
+		writeSlideOperationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cfg)
+}
+
+func writeSlideOperationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, records.ErrInvalidSlideOperation):
+		writeError(c, http.StatusBadRequest, "invalid_operation", err.Error())
+	case errors.Is(err, records.ErrSlideEntryNotFound):
+		writeError(c, http.StatusNotFound, "slide_not_found", err.Error())
+	default:
+		writeConfigError(c, err)
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/auth"
+	"online-ppt/internal/records"
+)
+
+// slideBodyOverhead leaves room for multipart framing around the slide payload.
+const slideBodyOverhead = 64 << 10
+
+// ListSlides handles GET /ppts/{id}/slides.
+func (h *RecordsHandler) ListSlides(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	slides, err := h.service.ListSlides(c.Request.Context(), slideTarget(claims, recordID))
+	if err != nil {
+		writeSlideError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(slides))
+	for _, slide := range slides {
+		items = append(items, makeSlideResponse(slide))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// CreateSlide handles POST /ppts/{id}/slides.
+func (h *RecordsHandler) CreateSlide(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	name, content, ok := readSlideUpload(c)
+	if !ok {
+		return
+	}
+
+	slide, err := h.service.CreateSlide(c.Request.Context(), slideTarget(claims, recordID), name, content)
+	if err != nil {
+		writeSlideError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, makeSlideResponse(slide))
+}
+
+// GetSlide handles GET /ppts/{id}/slides/{slide}.
+func (h *RecordsHandler) GetSlide(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	_, content, err := h.service.ReadSlide(c.Request.Context(), slideTarget(claims, recordID), c.Param("slide"))
+	if err != nil {
+		writeSlideError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", content)
+}
+
+// ReplaceSlide handles PUT /ppts/{id}/slides/{slide}.
+func (h *RecordsHandler) ReplaceSlide(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	_, content, ok := readSlideUpload(c)
+	if !ok {
+		return
+	}
+
+	slide, err := h.service.ReplaceSlide(c.Request.Context(), slideTarget(claims, recordID), c.Param("slide"), content)
+	if err != nil {
+		writeSlideError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, makeSlideResponse(slide))
+}
+
+// DeleteSlide handles DELETE /ppts/{id}/slides/{slide}.
+func (h *RecordsHandler) DeleteSlide(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSlide(c.Request.Context(), slideTarget(claims, recordID), c.Param("slide")); err != nil {
+		writeSlideError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func slideTarget(claims *auth.Claims, recordID int64) records.SlideTarget {
+	return records.SlideTarget{
+		UserID:   claims.UserID,
+		UserUUID: claims.UserUUID,
+		RecordID: recordID,
+	}
+}
+
+// readSlideUpload accepts either a multipart form with a "file" field or a
+// JSON body of the form {"name": "...", "content": "<html>"}.
+func readSlideUpload(c *gin.Context) (string, []byte, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, records.MaxSlideBytes+slideBodyOverhead)
+
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "invalid_request", "multipart field \"file\" required")
+			return "", nil, false
+		}
+		if fileHeader.Size > records.MaxSlideBytes {
+			writeError(c, http.StatusRequestEntityTooLarge, "slide_too_large", records.ErrInvalidSlideContent.Error())
+			return "", nil, false
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+			return "", nil, false
+		}
+		defer file.Close()
+
+		content, err := io.ReadAll(file)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+			return "", nil, false
+		}
+		return c.PostForm("name"), content, true
+	}
+
+	var req struct {
+		Name    string `json:"name"`
+		Content string `json:"content"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return "", nil, false
+	}
+	return req.Name, []byte(req.Content), true
+}
+
+func makeSlideResponse(slide records.SlideFile) gin.H {
+	return gin.H{
+		"name":      slide.Name,
+		"index":     slide.Index,
+		"size":      slide.Size,
+		"updatedAt": slide.ModifiedAt,
+	}
+}
+
+func writeSlideError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, records.ErrRecordNotFound):
+		writeError(c, http.StatusNotFound, "not_found", recordNotFoundMsg)
+	case errors.Is(err, records.ErrForbidden):
+		writeError(c, http.StatusForbidden, "forbidden", err.Error())
+	case errors.Is(err, records.ErrSlideNotFound):
+		writeError(c, http.StatusNotFound, "slide_not_found", err.Error())
+	case errors.Is(err, records.ErrInvalidSlideName):
+		writeError(c, http.StatusBadRequest, "invalid_slide_name", "slide name must match slide-N.html")
+	case errors.Is(err, records.ErrInvalidSlideContent):
+		writeError(c, http.StatusBadRequest, "invalid_slide_content", err.Error())
+	case errors.Is(err, records.ErrSlideExists):
+		writeError(c, http.StatusConflict, "slide_exists", err.Error())
+	case errors.Is(err, records.ErrSlideLimitReached):
+		writeError(c, http.StatusConflict, "slide_limit_reached", err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "server_error", err.Error())
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/records"
+)
+
+// ListTrash handles GET /ppts/trash.
+func (h *RecordsHandler) ListTrash(c *gin.Context) {
+	if h.service == nil {
+		writeError(c, http.StatusInternalServerError, "server_error", "records service unavailable")
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	filters, ok := parseListFilters(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.ListTrash(c.Request.Context(), records.ListParams{
+		UserID:  claims.UserID,
+		Filters: filters,
+	})
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	items := make([]gin.H, 0, len(result.Records))
+	for _, view := range result.Records {
+		items = append(items, makeTrashResponse(view))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":  result.Total,
+		"limit":  result.Limit,
+		"offset": result.Offset,
+		"items":  items,
+	})
+}
+
+// Restore handles POST /ppts/{id}/restore.
+func (h *RecordsHandler) Restore(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	view, err := h.service.RestoreRecord(c.Request.Context(), claims.UserID, recordID)
+	if err != nil {
+		switch {
+		case errors.Is(err, records.ErrRecordNotFound):
+			writeError(c, http.StatusNotFound, "not_found", recordNotFoundMsg)
+		case errors.Is(err, records.ErrDuplicateRecord):
+			writeError(c, http.StatusConflict, "record_exists", err.Error())
+		case errors.Is(err, records.ErrDeckDirectoryExists):
+			writeError(c, http.StatusConflict, "path_conflict", err.Error())
+		default:
+			writeError(c, http.StatusInternalServerError, "server_error", err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, makeRecordResponse(view))
+}
+
+func makeTrashResponse(view records.TrashView) gin.H {
+	resp := makeRecordResponse(records.RecordView{Record: view.Record, PathStatus: "trashed"})
+	delete(resp, "canonicalPath")
+	resp["deletedAt"] = view.DeletedAt
+	resp["purgeAt"] = view.PurgeAt
+	return resp
+}
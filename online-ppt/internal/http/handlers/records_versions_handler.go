@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/records"
+)
+
+// ListVersions handles GET /ppts/{id}/versions.
+func (h *RecordsHandler) ListVersions(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	versions, current, err := h.service.ListVersions(c.Request.Context(), slideTarget(claims, recordID))
+	if err != nil {
+		writeVersionError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(versions))
+	for _, version := range versions {
+		items = append(items, makeVersionResponse(version))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"currentVersion": current,
+		"items":          items,
+	})
+}
+
+// SaveVersion handles POST /ppts/{id}/versions.
+func (h *RecordsHandler) SaveVersion(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Label string `json:"label"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	version, err := h.service.SaveVersion(c.Request.Context(), slideTarget(claims, recordID), req.Label)
+	if err != nil {
+		writeVersionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, makeVersionResponse(version))
+}
+
+// DiffVersions handles GET /ppts/{id}/versions/diff?from=N&to=M.
+func (h *RecordsHandler) DiffVersions(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil {
+		writeError(c, http.StatusBadRequest, "invalid_version", "from and to must be version numbers")
+		return
+	}
+
+	diff, err := h.service.DiffVersions(c.Request.Context(), slideTarget(claims, recordID), from, to)
+	if err != nil {
+		writeVersionError(c, err)
+		return
+	}
+
+	files := make([]gin.H, 0, len(diff.Files))
+	for _, file := range diff.Files {
+		files = append(files, gin.H{
+			"name":   file.Name,
+			"status": file.Status,
+			"binary": file.Binary,
+			"patch":  file.Patch,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":          diff.From,
+		"to":            diff.To,
+		"configChanged": diff.ConfigChanged,
+		"configPatch":   diff.ConfigPatch,
+		"files":         files,
+	})
+}
+
+// RestoreVersion handles POST /ppts/{id}/versions/{version}/restore.
+func (h *RecordsHandler) RestoreVersion(c *gin.Context) {
+	claims, recordID, ok := h.authenticateWithID(c)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_version", "version must be an integer")
+		return
+	}
+
+	version, err := h.service.RestoreVersion(c.Request.Context(), slideTarget(claims, recordID), number)
+	if err != nil {
+		writeVersionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, makeVersionResponse(version))
+}
+
+func makeVersionResponse(version records.RecordVersion) gin.H {
+	var label any
+	if version.Label.Valid {
+		label = version.Label.String
+	}
+
+	return gin.H{
+		"version":    version.Version,
+		"label":      label,
+		"reason":     version.Reason,
+		"slideCount": version.Manifest.SlideCount(),
+		"fileCount":  len(version.Manifest.Files),
+		"createdAt":  version.CreatedAt,
+	}
+}
+
+func writeVersionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, records.ErrVersionNotFound):
+		writeError(c, http.StatusNotFound, "version_not_found", err.Error())
+	case errors.Is(err, records.ErrInvalidVersion):
+		writeError(c, http.StatusBadRequest, "invalid_version", err.Error())
+	default:
+		writeSlideError(c, err)
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/auth"
+)
+
+// ListSessions handles GET /auth/sessions.
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.service.ListSessions(c.Request.Context(), claims.UserID)
+	if err != nil {
+		handleSessionError(c, err)
+		return
+	}
+
+	currentID := h.service.CurrentSessionID(c.Request.Context(), claims, currentRefreshToken(c, ""))
+	items := make([]gin.H, 0, len(sessions))
+	for _, session := range sessions {
+		items = append(items, serializeSession(session, session.ID == currentID))
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": items})
+}
+
+// RevokeSession handles DELETE /auth/sessions/:sessionId.
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(c.Param("sessionId"), 10, 64)
+	if err != nil || sessionID <= 0 {
+		writeError(c, http.StatusBadRequest, "invalid_id", "session id must be a positive integer")
+		return
+	}
+
+	if err := h.service.RevokeUserSession(c.Request.Context(), claims.UserID, sessionID); err != nil {
+		handleSessionError(c, err)
+		return
+	}
+
+	// 注销当前设备时一并清除刷新令牌 Cookie
+	if sessionID == h.service.CurrentSessionID(c.Request.Context(), claims, "") {
+		setRefreshCookie(c, "", time.Time{}, h.cfg)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeOtherSessions handles DELETE /auth/sessions - 退出除当前设备外的所有登录
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	currentID := h.service.CurrentSessionID(c.Request.Context(), claims, currentRefreshToken(c, ""))
+	revoked, err := h.service.RevokeOtherSessions(c.Request.Context(), claims.UserID, currentID)
+	if err != nil {
+		handleSessionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+func serializeSession(session auth.UserSession, current bool) gin.H {
+	return gin.H{
+		"id":          session.ID,
+		"fingerprint": nullableString(session.ClientFingerprint.String, session.ClientFingerprint.Valid),
+		"ipAddress":   nullableString(session.IPAddress.String, session.IPAddress.Valid),
+		"userAgent":   nullableString(session.UserAgent.String, session.UserAgent.Valid),
+		"issuedAt":    session.IssuedAt,
+		"expiresAt":   session.ExpiresAt,
+		"current":     current,
+	}
+}
+
+func nullableString(value string, valid bool) *string {
+	if !valid {
+		return nil
+	}
+	return &value
+}
+
+// handleSessionError 处理登录设备管理相关错误
+func handleSessionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrSessionNotFound):
+		writeError(c, http.StatusNotFound, "session_not_found", "会话不存在或已退出")
+	case errors.Is(err, auth.ErrCurrentSessionUnknown):
+		writeError(c, http.StatusBadRequest, "current_session_unknown", "无法识别当前会话，请重新登录")
+	default:
+		writeError(c, http.StatusInternalServerError, "server_error", err.Error())
+	}
+}
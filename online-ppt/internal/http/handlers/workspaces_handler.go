@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/auth"
+	"online-ppt/internal/records"
+)
+
+// ListWorkspaces handles GET /workspaces.
+func (h *RecordsHandler) ListWorkspaces(c *gin.Context) {
+	if h.service == nil {
+		writeError(c, http.StatusInternalServerError, "server_error", "records service unavailable")
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	memberships, err := h.service.ListWorkspaces(c.Request.Context(), claims.UserID)
+	if err != nil {
+		writeWorkspaceError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(memberships))
+	for _, membership := range memberships {
+		items = append(items, makeWorkspaceResponse(membership))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// CreateWorkspace handles POST /workspaces.
+func (h *RecordsHandler) CreateWorkspace(c *gin.Context) {
+	if h.service == nil {
+		writeError(c, http.StatusInternalServerError, "server_error", "records service unavailable")
+		return
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	membership, err := h.service.CreateWorkspace(c.Request.Context(), claims.UserID, req.Name)
+	if err != nil {
+		writeWorkspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, makeWorkspaceResponse(membership))
+}
+
+// ListWorkspaceMembers handles GET /workspaces/{workspaceId}/members.
+func (h *RecordsHandler) ListWorkspaceMembers(c *gin.Context) {
+	claims, workspaceID, ok := h.authenticateWithWorkspaceID(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.service.ListWorkspaceMembers(c.Request.Context(), claims.UserID, workspaceID)
+	if err != nil {
+		writeWorkspaceError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(members))
+	for _, member := range members {
+		items = append(items, makeWorkspaceMemberResponse(member))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// AddWorkspaceMember handles POST /workspaces/{workspaceId}/members.
+func (h *RecordsHandler) AddWorkspaceMember(c *gin.Context) {
+	claims, workspaceID, ok := h.authenticateWithWorkspaceID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Email string `json:"email" binding:"required"`
+		Role  string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	member, err := h.service.AddWorkspaceMember(c.Request.Context(), claims.UserID, workspaceID, req.Email, records.WorkspaceRole(req.Role))
+	if err != nil {
+		writeWorkspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, makeWorkspaceMemberResponse(member))
+}
+
+// UpdateWorkspaceMember handles PATCH /workspaces/{workspaceId}/members/{userId}.
+func (h *RecordsHandler) UpdateWorkspaceMember(c *gin.Context) {
+	claims, workspaceID, ok := h.authenticateWithWorkspaceID(c)
+	if !ok {
+		return
+	}
+
+	memberID, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	if err := h.service.UpdateWorkspaceMember(c.Request.Context(), claims.UserID, workspaceID, memberID, records.WorkspaceRole(req.Role)); err != nil {
+		writeWorkspaceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveWorkspaceMember handles DELETE /workspaces/{workspaceId}/members/{userId}.
+func (h *RecordsHandler) RemoveWorkspaceMember(c *gin.Context) {
+	claims, workspaceID, ok := h.authenticateWithWorkspaceID(c)
+	if !ok {
+		return
+	}
+
+	memberID, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveWorkspaceMember(c.Request.Context(), claims.UserID, workspaceID, memberID); err != nil {
+		writeWorkspaceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *RecordsHandler) authenticateWithWorkspaceID(c *gin.Context) (*auth.Claims, int64, bool) {
+	if h.service == nil {
+		writeError(c, http.StatusInternalServerError, "server_error", "records service unavailable")
+		return nil, 0, false
+	}
+
+	claims, ok := currentClaims(c)
+	if !ok {
+		return nil, 0, false
+	}
+
+	workspaceID, err := strconv.ParseInt(c.Param("workspaceId"), 10, 64)
+	if err != nil || workspaceID <= 0 {
+		writeError(c, http.StatusBadRequest, "invalid_id", "workspace id must be a positive integer")
+		return nil, 0, false
+	}
+	return claims, workspaceID, true
+}
+
+func makeWorkspaceResponse(membership records.WorkspaceMembership) gin.H {
+	workspace := membership.Workspace
+	return gin.H{
+		"id":        workspace.ID,
+		"uuid":      workspace.UUID,
+		"name":      workspace.Name,
+		"role":      membership.Role,
+		"createdAt": workspace.CreatedAt,
+		"updatedAt": workspace.UpdatedAt,
+	}
+}
+
+func makeWorkspaceMemberResponse(member records.WorkspaceMember) gin.H {
+	return gin.H{
+		"userId":      member.UserID,
+		"workspaceId": member.WorkspaceID,
+		"email":       member.Email,
+		"role":        member.Role,
+		"createdAt":   member.CreatedAt,
+		"updatedAt":   member.UpdatedAt,
+	}
+}
+
+func writeWorkspaceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, records.ErrWorkspaceNotFound):
+		writeError(c, http.StatusNotFound, "workspace_not_found", err.Error())
+	case errors.Is(err, records.ErrInvalidWorkspace):
+		writeError(c, http.StatusBadRequest, "invalid_workspace", err.Error())
+	case errors.Is(err, records.ErrInviteeNotFound):
+		writeError(c, http.StatusNotFound, "user_not_found", err.Error())
+	case errors.Is(err, records.ErrWorkspaceMemberExists):
+		writeError(c, http.StatusConflict, "member_exists", err.Error())
+	case errors.Is(err, records.ErrWorkspaceMemberNotFound):
+		writeError(c, http.StatusNotFound, "member_not_found", err.Error())
+	case errors.Is(err, records.ErrLastWorkspaceAdmin):
+		writeError(c, http.StatusConflict, "last_admin", err.Error())
+	case errors.Is(err, records.ErrForbidden):
+		writeError(c, http.StatusForbidden, "forbidden", err.Error())
+	default:
+		writeError(c, http.StatusInternalServerError, "server_error", err.Error())
+	}
+}
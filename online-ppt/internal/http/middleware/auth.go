@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"online-ppt/internal/auth"
+)
+
+// claimsKey is the gin context key holding the caller's *auth.Claims.
+const claimsKey = "auth.claims"
+
+var (
+	// ErrMissingBearer indicates the Authorization header is absent, malformed or not accepted.
+	ErrMissingBearer = errors.New("missing or invalid authorization header")
+	// ErrInsufficientScope indicates a valid personal access token lacks the required scope.
+	ErrInsufficientScope = errors.New("api token scope does not allow this operation")
+	// ErrForbiddenRole indicates the caller's account role does not allow the operation.
+	ErrForbiddenRole = errors.New("account role does not allow this operation")
+)
+
+// TokenParser validates a signed access token, e.g. TokenManager.ParseAccessToken.
+type TokenParser func(token string) (*auth.Claims, error)
+
+// APITokenAuthenticator resolves personal access tokens into scoped claims.
+type APITokenAuthenticator interface {
+	AuthenticateAPIToken(ctx context.Context, token string) (*auth.Claims, error)
+}
+
+// Auth resolves "Authorization: Bearer" credentials into auth.Claims stored on
+// the gin context, so handlers behind it read the caller with ClaimsFrom.
+type Auth struct {
+	parse     TokenParser
+	apiTokens APITokenAuthenticator
+}
+
+// NewAuth builds an Auth accepting access tokens validated by parse.
+func NewAuth(parse TokenParser) *Auth {
+	return &Auth{parse: parse}
+}
+
+// WithAPITokens additionally accepts personal access tokens.
+func (a *Auth) WithAPITokens(authenticator APITokenAuthenticator) *Auth {
+	a.apiTokens = authenticator
+	return a
+}
+
+// Authenticate returns the caller's claims. The Authorization header is parsed
+// once per request; later calls reuse the claims stored on the context.
+func (a *Auth) Authenticate(c *gin.Context) (*auth.Claims, error) {
+	if claims, ok := ClaimsFrom(c); ok {
+		return claims, nil
+	}
+
+	token, err := BearerToken(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims *auth.Claims
+	switch {
+	case auth.IsAPIToken(token):
+		if a == nil || a.apiTokens == nil {
+			return nil, ErrMissingBearer
+		}
+		claims, err = a.apiTokens.AuthenticateAPIToken(c.Request.Context(), token)
+	case a != nil && a.parse != nil:
+		claims, err = a.parse(token)
+	default:
+		return nil, ErrMissingBearer
+	}
+	if err != nil || claims == nil {
+		return nil, ErrMissingBearer
+	}
+
+	c.Set(claimsKey, claims)
+	return claims, nil
+}
+
+// Required rejects requests without valid credentials with 401.
+func (a *Auth) Required() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := a.Authenticate(c); err != nil {
+			abortWithError(c, http.StatusUnauthorized, "unauthorized", err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Optional lets anonymous requests through but still rejects invalid
+// credentials, so a client that meant to sign in learns its token is bad.
+func (a *Auth) Optional() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		if _, err := a.Authenticate(c); err != nil {
+			abortWithError(c, http.StatusUnauthorized, "unauthorized", err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole allows only callers whose account holds role. It must run after
+// Required.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFrom(c)
+		if !ok {
+			abortWithError(c, http.StatusUnauthorized, "unauthorized", ErrMissingBearer)
+			return
+		}
+		if !claims.HasRole(role) {
+			abortWithError(c, http.StatusForbidden, "forbidden", ErrForbiddenRole)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope limits personal access tokens to those granted scope; access
+// tokens from an interactive login pass. It must run after Required.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFrom(c)
+		if !ok {
+			abortWithError(c, http.StatusUnauthorized, "unauthorized", ErrMissingBearer)
+			return
+		}
+		if !claims.HasScope(scope) {
+			abortWithError(c, http.StatusForbidden, "insufficient_scope", ErrInsufficientScope)
+			return
+		}
+		c.Next()
+	}
+}
+
+// ClaimsFrom returns the claims stored by Auth, if the caller authenticated.
+func ClaimsFrom(c *gin.Context) (*auth.Claims, bool) {
+	value, ok := c.Get(claimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*auth.Claims)
+	return claims, ok && claims != nil
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer" header.
+func BearerToken(c *gin.Context) (string, error) {
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		return "", ErrMissingBearer
+	}
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || parts[1] == "" {
+		return "", ErrMissingBearer
+	}
+	return parts[1], nil
+}
+
+func abortWithError(c *gin.Context, status int, code string, err error) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"code":    code,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"online-ppt/internal/auth"
+)
+
+type stubAPITokens map[string]*auth.Claims
+
+func (s stubAPITokens) AuthenticateAPIToken(ctx context.Context, token string) (*auth.Claims, error) {
+	if claims, ok := s[token]; ok {
+		return claims, nil
+	}
+	return nil, auth.ErrInvalidAPIToken
+}
+
+func newTestAuth() *Auth {
+	parse := func(token string) (*auth.Claims, error) {
+		switch token {
+		case "user-token":
+			return &auth.Claims{UserID: 1, Role: auth.RoleUser}, nil
+		case "admin-token":
+			return &auth.Claims{UserID: 2, Role: auth.RoleAdmin}, nil
+		}
+		return nil, errors.New("bad token")
+	}
+	return NewAuth(parse).WithAPITokens(stubAPITokens{
+		auth.APITokenPrefix + "read": {UserID: 1, Role: auth.RoleAdmin, TokenID: 9, Scopes: []string{auth.ScopeRead}},
+	})
+}
+
+func serve(router *gin.Engine, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthRequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", newTestAuth().Required(), func(c *gin.Context) {
+		claims, ok := ClaimsFrom(c)
+		assert.True(t, ok)
+		c.JSON(http.StatusOK, gin.H{"userId": claims.UserID})
+	})
+
+	assert.Equal(t, http.StatusUnauthorized, serve(router, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(router, "Bearer nope").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(router, "Basic user-token").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(router, "Bearer "+auth.APITokenPrefix+"unknown").Code)
+	assert.Equal(t, http.StatusOK, serve(router, "Bearer user-token").Code)
+	assert.Equal(t, http.StatusOK, serve(router, "Bearer "+auth.APITokenPrefix+"read").Code)
+}
+
+func TestAuthOptional(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", newTestAuth().Optional(), func(c *gin.Context) {
+		_, ok := ClaimsFrom(c)
+		c.JSON(http.StatusOK, gin.H{"signedIn": ok})
+	})
+
+	rec := serve(router, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"signedIn":false}`, rec.Body.String())
+
+	rec = serve(router, "Bearer user-token")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"signedIn":true}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, serve(router, "Bearer nope").Code)
+}
+
+func TestRequireRoleAndScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", newTestAuth().Required(), RequireRole(auth.RoleAdmin), RequireScope(auth.ScopeAdmin), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	rec := serve(router, "Bearer user-token")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"forbidden"`)
+
+	// 管理员的只读 API 令牌不能调用管理接口
+	rec = serve(router, "Bearer "+auth.APITokenPrefix+"read")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"insufficient_scope"`)
+
+	assert.Equal(t, http.StatusNoContent, serve(router, "Bearer admin-token").Code)
+}
+
+func TestRequireRoleWithoutAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", RequireRole(auth.RoleUser), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	assert.Equal(t, http.StatusUnauthorized, serve(router, "Bearer user-token").Code)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"online-ppt/internal/auth"
 	"online-ppt/internal/config"
 	"online-ppt/internal/http/handlers"
 	"online-ppt/internal/http/middleware"
@@ -21,9 +22,19 @@ const (
 	apiPrefix       = "/api/v1"
 )
 
-// NewRouter builds a Gin engine with baseline middleware.
+// NewRouter builds a Gin engine with baseline middleware. X-Forwarded-For is
+// honoured only from cfg.Server.TrustedProxies; otherwise c.ClientIP() is the
+// peer address, so clients cannot pick the IP that throttles key on.
 func NewRouter(cfg *config.Config, extra ...gin.HandlerFunc) *gin.Engine {
 	engine := gin.New()
+	var proxies []string
+	if cfg != nil {
+		proxies = cfg.Server.TrustedProxies
+	}
+	if err := engine.SetTrustedProxies(proxies); err != nil {
+		// config.Load rejects malformed entries; never fall back to trusting all.
+		_ = engine.SetTrustedProxies(nil)
+	}
 	engine.Use(middleware.RequestLogger(nil))
 	engine.Use(gin.Recovery())
 	for _, fn := range extra {
@@ -101,6 +112,9 @@ func RegisterAuthRoutes(engine *gin.Engine, handler *handlers.AuthHandler) {
 	if engine == nil || handler == nil {
 		return
 	}
+	// 访问令牌公钥，供其他内部服务校验签名
+	engine.GET("/.well-known/jwks.json", handler.JWKS)
+
 	authGroup := engine.Group(apiPrefix + "/auth")
 
 	// 新的注册流程（带验证码）
@@ -108,10 +122,42 @@ func RegisterAuthRoutes(engine *gin.Engine, handler *handlers.AuthHandler) {
 	authGroup.POST("/send-verification-code", handler.SendVerificationCode)
 	authGroup.POST("/register", handler.RegisterWithCode)
 
+	// 找回密码（图形验证码 + 邮件链接）
+	authGroup.POST("/password/reset", handler.RequestPasswordReset)
+	authGroup.POST("/password/reset/confirm", handler.ConfirmPasswordReset)
+
 	// 原有的登录、刷新、登出
 	authGroup.POST("/login", handler.Login)
+	authGroup.POST("/login/mfa", handler.LoginMFA)
 	authGroup.POST("/refresh", handler.Refresh)
 	authGroup.POST("/logout", handler.Logout)
+	authGroup.POST("/unlock", handler.UnlockAccount)
+
+	// 第三方登录（OpenID Connect 授权码 + PKCE）
+	authGroup.GET("/oidc/providers", handler.ListIdentityProviders)
+	authGroup.GET("/oidc/:provider/login", handler.BeginOIDCLogin)
+	authGroup.GET("/oidc/:provider/callback", handler.CompleteOIDCLogin)
+
+	// 登录设备管理（需登录）
+	requireLogin := handler.Auth().Required()
+	sessionGroup := authGroup.Group("/sessions", requireLogin)
+	sessionGroup.GET("", handler.ListSessions)
+	sessionGroup.DELETE("", handler.RevokeOtherSessions)
+	sessionGroup.DELETE("/:sessionId", handler.RevokeSession)
+
+	// 账号设置（需登录，不接受个人访问令牌）
+	accountGroup := engine.Group(apiPrefix+"/account", requireLogin)
+	accountGroup.PUT("/password", handler.ChangePassword)
+	accountGroup.POST("/email", handler.RequestEmailChange)
+	accountGroup.POST("/email/confirm", handler.ConfirmEmailChange)
+	accountGroup.GET("/mfa", handler.GetMFA)
+	accountGroup.POST("/mfa/enroll", handler.BeginMFAEnrollment)
+	accountGroup.POST("/mfa/enroll/confirm", handler.ConfirmMFAEnrollment)
+	accountGroup.POST("/mfa/disable", handler.DisableMFA)
+	accountGroup.POST("/mfa/recovery-codes", handler.RegenerateRecoveryCodes)
+	accountGroup.GET("/tokens", handler.ListAPITokens)
+	accountGroup.POST("/tokens", handler.CreateAPIToken)
+	accountGroup.DELETE("/tokens/:tokenId", handler.RevokeAPIToken)
 }
 
 // RegisterRecordRoutes wires PPT record HTTP handlers under the API prefix.
@@ -119,10 +165,81 @@ func RegisterRecordRoutes(engine *gin.Engine, handler *handlers.RecordsHandler)
 	if engine == nil || handler == nil {
 		return
 	}
-	recordGroup := engine.Group(apiPrefix + "/ppts")
-	recordGroup.GET("", handler.List)
-	recordGroup.POST("", handler.Create)
-	recordGroup.GET("/:id", handler.Get)
-	recordGroup.PATCH("/:id", handler.Update)
-	recordGroup.DELETE("/:id", handler.Delete)
+	// Personal access tokens need read for GETs, records:write for deck edits
+	// and admin for access control changes (shares, collaborators, workspaces).
+	read := middleware.RequireScope(auth.ScopeRead)
+	write := middleware.RequireScope(auth.ScopeRecordsWrite)
+	manage := middleware.RequireScope(auth.ScopeAdmin)
+
+	recordGroup := engine.Group(apiPrefix+"/ppts", handler.Auth().Required())
+	recordGroup.GET("", read, handler.List)
+	recordGroup.POST("", write, handler.Create)
+	recordGroup.POST("/import", write, handler.Import)
+	recordGroup.GET("/trash", read, handler.ListTrash)
+	recordGroup.GET("/:id", read, handler.Get)
+	recordGroup.PATCH("/:id", write, handler.Update)
+	recordGroup.DELETE("/:id", write, handler.Delete)
+	recordGroup.POST("/:id/restore", write, handler.Restore)
+	recordGroup.GET("/:id/export", read, handler.Export)
+
+	recordGroup.GET("/:id/slides", read, handler.ListSlides)
+	recordGroup.POST("/:id/slides", write, handler.CreateSlide)
+	recordGroup.GET("/:id/slides/:slide", read, handler.GetSlide)
+	recordGroup.PUT("/:id/slides/:slide", write, handler.ReplaceSlide)
+	recordGroup.DELETE("/:id/slides/:slide", write, handler.DeleteSlide)
+
+	recordGroup.GET("/:id/config", read, handler.GetConfig)
+	recordGroup.PUT("/:id/config", write, handler.ReplaceConfig)
+	recordGroup.PATCH("/:id/config", write, handler.PatchConfig)
+	recordGroup.POST("/:id/config/slides/batch", write, handler.BatchSlides)
+	recordGroup.POST("/:id/config/slides/:slideId/move", write, handler.MoveSlide)
+	recordGroup.POST("/:id/config/slides/:slideId/duplicate", write, handler.DuplicateSlide)
+	recordGroup.POST("/:id/config/slides/:slideId/hide", write, handler.HideSlide)
+	recordGroup.POST("/:id/config/slides/:slideId/unhide", write, handler.UnhideSlide)
+
+	recordGroup.GET("/:id/versions", read, handler.ListVersions)
+	recordGroup.POST("/:id/versions", write, handler.SaveVersion)
+	recordGroup.GET("/:id/versions/diff", read, handler.DiffVersions)
+	recordGroup.POST("/:id/versions/:version/restore", write, handler.RestoreVersion)
+
+	recordGroup.GET("/:id/shares", read, handler.ListShares)
+	recordGroup.POST("/:id/shares", manage, handler.CreateShare)
+	recordGroup.DELETE("/:id/shares/:shareId", manage, handler.RevokeShare)
+
+	recordGroup.GET("/:id/collaborators", read, handler.ListCollaborators)
+	recordGroup.POST("/:id/collaborators", manage, handler.InviteCollaborator)
+	recordGroup.PATCH("/:id/collaborators/:userId", manage, handler.UpdateCollaborator)
+	recordGroup.DELETE("/:id/collaborators/:userId", manage, handler.RemoveCollaborator)
+
+	workspaceGroup := engine.Group(apiPrefix+"/workspaces", handler.Auth().Required())
+	workspaceGroup.GET("", read, handler.ListWorkspaces)
+	workspaceGroup.POST("", manage, handler.CreateWorkspace)
+	workspaceGroup.GET("/:workspaceId/members", read, handler.ListWorkspaceMembers)
+	workspaceGroup.POST("/:workspaceId/members", manage, handler.AddWorkspaceMember)
+	workspaceGroup.PATCH("/:workspaceId/members/:userId", manage, handler.UpdateWorkspaceMember)
+	workspaceGroup.DELETE("/:workspaceId/members/:userId", manage, handler.RemoveWorkspaceMember)
+
+	// Share links are opened without a bearer token.
+	publicGroup := engine.Group(apiPrefix + "/public")
+	publicGroup.GET("/:shareToken", handler.OpenShare)
+}
+
+// RegisterAdminRoutes wires operator endpoints. Every route requires an admin
+// account; personal access tokens additionally need the admin scope.
+func RegisterAdminRoutes(engine *gin.Engine, handler *handlers.AdminHandler) {
+	if engine == nil || handler == nil {
+		return
+	}
+	adminGroup := engine.Group(apiPrefix+"/admin",
+		handler.Auth().Required(),
+		middleware.RequireRole(auth.RoleAdmin),
+		middleware.RequireScope(auth.ScopeAdmin),
+	)
+
+	adminGroup.GET("/users", handler.ListUsers)
+	adminGroup.GET("/users/:userId", handler.GetUser)
+	adminGroup.PUT("/users/:userId/status", handler.SetUserStatus)
+	adminGroup.POST("/users/:userId/password-reset", handler.ForcePasswordReset)
+	adminGroup.DELETE("/users/:userId/sessions", handler.RevokeUserSessions)
+	adminGroup.DELETE("/users/:userId", handler.DeleteUser)
 }
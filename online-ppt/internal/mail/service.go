@@ -2,6 +2,8 @@ package mail
 
 import (
 	"fmt"
+	"html"
+	"time"
 
 	"gopkg.in/gomail.v2"
 )
@@ -9,6 +11,8 @@ import (
 // Service 邮件服务接口
 type Service interface {
 	SendVerificationCode(to, code string) error
+	SendPasswordReset(to, link string, expiresIn time.Duration) error
+	SendAccountUnlock(to, link string, lockedUntil time.Time) error
 }
 
 // SMTPService SMTP 邮件服务实现
@@ -45,6 +49,21 @@ func NewSMTPService(cfg Config) *SMTPService {
 
 // SendVerificationCode 发送验证码邮件
 func (s *SMTPService) SendVerificationCode(to, code string) error {
+	return s.send(to, "邮箱验证码 - Online PPT", renderVerificationCodeTemplate(code))
+}
+
+// SendPasswordReset 发送密码重置链接邮件
+func (s *SMTPService) SendPasswordReset(to, link string, expiresIn time.Duration) error {
+	return s.send(to, "重置密码 - Online PPT", renderPasswordResetTemplate(link, expiresIn))
+}
+
+// SendAccountUnlock 发送账号锁定通知及解锁链接邮件
+func (s *SMTPService) SendAccountUnlock(to, link string, lockedUntil time.Time) error {
+	return s.send(to, "账号已锁定 - Online PPT", renderAccountUnlockTemplate(link, lockedUntil))
+}
+
+// send 通过 SMTP 发送一封 HTML 邮件
+func (s *SMTPService) send(to, subject, body string) error {
 	m := gomail.NewMessage()
 
 	// 设置发件人
@@ -54,10 +73,9 @@ func (s *SMTPService) SendVerificationCode(to, code string) error {
 	m.SetHeader("To", to)
 
 	// 设置主题
-	m.SetHeader("Subject", "邮箱验证码 - Online PPT")
+	m.SetHeader("Subject", subject)
 
 	// 设置邮件内容
-	body := renderVerificationCodeTemplate(code)
 	m.SetBody("text/html", body)
 
 	// 创建拨号器
@@ -149,3 +167,187 @@ func renderVerificationCodeTemplate(code string) string {
 </html>
 `, code)
 }
+
+// renderPasswordResetTemplate 渲染密码重置邮件模板
+func renderPasswordResetTemplate(link string, expiresIn time.Duration) string {
+	escaped := html.EscapeString(link)
+	minutes := int(expiresIn.Minutes())
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            max-width: 600px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+        .container {
+            background: #f9f9f9;
+            border-radius: 10px;
+            padding: 30px;
+            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
+        }
+        .header {
+            text-align: center;
+            margin-bottom: 30px;
+        }
+        .action {
+            text-align: center;
+            margin: 30px 0;
+        }
+        .button {
+            display: inline-block;
+            background: #4CAF50;
+            color: #ffffff;
+            text-decoration: none;
+            border-radius: 8px;
+            font-size: 18px;
+            font-weight: bold;
+            padding: 14px 32px;
+        }
+        .link {
+            word-break: break-all;
+            color: #666;
+            font-size: 13px;
+        }
+        .notice {
+            color: #666;
+            font-size: 14px;
+            margin-top: 20px;
+            padding-top: 20px;
+            border-top: 1px solid #ddd;
+        }
+        .footer {
+            text-align: center;
+            color: #999;
+            font-size: 12px;
+            margin-top: 30px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h2>Online PPT 重置密码</h2>
+        </div>
+        <p>您好，</p>
+        <p>我们收到了重置您 Online PPT 账号密码的请求，请点击下方按钮设置新密码：</p>
+        <div class="action">
+            <a class="button" href="%[1]s">重置密码</a>
+        </div>
+        <p>如果按钮无法点击，请将以下链接复制到浏览器中打开：</p>
+        <p class="link">%[1]s</p>
+        <div class="notice">
+            <p><strong>重要提示：</strong></p>
+            <ul>
+                <li>链接有效期为 <strong>%[2]d分钟</strong>，且只能使用一次</li>
+                <li>重置成功后，所有已登录的设备都需要重新登录</li>
+                <li>如果这不是您的操作，请忽略此邮件，您的密码不会被修改</li>
+            </ul>
+        </div>
+        <div class="footer">
+            <p>此邮件由系统自动发送，请勿直接回复</p>
+            <p>&copy; 2025 Online PPT. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+`, escaped, minutes)
+}
+
+// renderAccountUnlockTemplate 渲染账号锁定通知邮件模板
+func renderAccountUnlockTemplate(link string, lockedUntil time.Time) string {
+	escaped := html.EscapeString(link)
+	until := lockedUntil.UTC().Format("2006-01-02 15:04 MST")
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            max-width: 600px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+        .container {
+            background: #f9f9f9;
+            border-radius: 10px;
+            padding: 30px;
+            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
+        }
+        .header {
+            text-align: center;
+            margin-bottom: 30px;
+        }
+        .action {
+            text-align: center;
+            margin: 30px 0;
+        }
+        .button {
+            display: inline-block;
+            background: #4CAF50;
+            color: #ffffff;
+            text-decoration: none;
+            border-radius: 8px;
+            font-size: 18px;
+            font-weight: bold;
+            padding: 14px 32px;
+        }
+        .link {
+            word-break: break-all;
+            color: #666;
+            font-size: 13px;
+        }
+        .notice {
+            color: #666;
+            font-size: 14px;
+            margin-top: 20px;
+            padding-top: 20px;
+            border-top: 1px solid #ddd;
+        }
+        .footer {
+            text-align: center;
+            color: #999;
+            font-size: 12px;
+            margin-top: 30px;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h2>Online PPT 账号已锁定</h2>
+        </div>
+        <p>您好，</p>
+        <p>您的 Online PPT 账号连续多次登录失败，为保护账号安全已被临时锁定，将于 <strong>%[2]s</strong> 自动解锁。</p>
+        <p>如果是您本人操作，可以点击下方按钮立即解锁：</p>
+        <div class="action">
+            <a class="button" href="%[1]s">解锁账号</a>
+        </div>
+        <p>如果按钮无法点击，请将以下链接复制到浏览器中打开：</p>
+        <p class="link">%[1]s</p>
+        <div class="notice">
+            <p><strong>重要提示：</strong></p>
+            <ul>
+                <li>链接只能使用一次</li>
+                <li>如果这不是您的操作，说明有人正在尝试登录您的账号，建议解锁后立即修改密码</li>
+            </ul>
+        </div>
+        <div class="footer">
+            <p>此邮件由系统自动发送，请勿直接回复</p>
+            <p>&copy; 2025 Online PPT. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+`, escaped, until)
+}
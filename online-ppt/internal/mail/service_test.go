@@ -3,6 +3,7 @@ package mail
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -302,3 +303,31 @@ func TestVerificationCodeEmailHeaders(t *testing.T) {
 	// 注：gomail.Message 内部字段不容易直接访问，这里主要测试不发生 panic
 	assert.NotNil(t, m)
 }
+
+// TestRenderPasswordResetTemplate 测试密码重置模板渲染
+func TestRenderPasswordResetTemplate(t *testing.T) {
+	link := "https://ppt.example.com/reset-password?token=abc&lang=zh"
+	html := renderPasswordResetTemplate(link, 30*time.Minute)
+
+	assert.Contains(t, html, "<!DOCTYPE html>")
+	assert.Contains(t, html, "Online PPT 重置密码")
+	assert.Contains(t, html, "30分钟")
+
+	// 链接会出现在按钮和纯文本两处，并做 HTML 转义
+	escaped := "https://ppt.example.com/reset-password?token=abc&amp;lang=zh"
+	assert.Equal(t, 2, strings.Count(html, escaped))
+	assert.Contains(t, html, `href="`+escaped+`"`)
+}
+
+// TestRenderAccountUnlockTemplate 测试账号解锁模板渲染
+func TestRenderAccountUnlockTemplate(t *testing.T) {
+	link := "https://ppt.example.com/unlock-account?token=abc&lang=zh"
+	lockedUntil := time.Date(2025, 3, 1, 8, 30, 0, 0, time.UTC)
+	html := renderAccountUnlockTemplate(link, lockedUntil)
+
+	assert.Contains(t, html, "Online PPT 账号已锁定")
+	assert.Contains(t, html, "2025-03-01 08:30 UTC")
+
+	escaped := "https://ppt.example.com/unlock-account?token=abc&amp;lang=zh"
+	assert.Equal(t, 2, strings.Count(html, escaped))
+}
@@ -0,0 +1,150 @@
+package records
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Role is a user's level of access to a deck.
+type Role string
+
+const (
+	// RoleViewer may read slides, config and versions.
+	RoleViewer Role = "viewer"
+	// RoleEditor may additionally change slides, config and metadata.
+	RoleEditor Role = "editor"
+	// RoleOwner may additionally rename, delete, share and manage collaborators.
+	RoleOwner Role = "owner"
+)
+
+// ErrForbidden indicates the caller can see the record but lacks the role the operation needs.
+var ErrForbidden = errors.New("insufficient permissions for record")
+
+// ParseRole validates a role name.
+func ParseRole(value string) (Role, bool) {
+	role := Role(value)
+	return role, role.rank() > 0
+}
+
+// Allows reports whether r grants at least the access of need.
+func (r Role) Allows(need Role) bool {
+	return r.rank() > 0 && r.rank() >= need.rank()
+}
+
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleOwner:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// recordAccess is a live record as seen by a particular caller. ownerUUID
+// names the user or workspace whose directory holds the deck.
+type recordAccess struct {
+	record    PptRecord
+	ownerUUID string
+	role      Role
+}
+
+// loadRecordAccess resolves a record the caller owns, reaches through a
+// workspace or collaborates on, and checks that the caller's role grants need.
+// Records the caller cannot see at all surface as ErrRecordNotFound so their
+// existence is not revealed.
+func (s *Service) loadRecordAccess(ctx context.Context, userID int64, userUUID string, recordID int64, need Role) (recordAccess, error) {
+	record, err := s.repo.GetByID(ctx, userID, recordID)
+	if err == nil && !record.WorkspaceID.Valid {
+		return recordAccess{record: record, ownerUUID: userUUID, role: RoleOwner}, nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return recordAccess{}, err
+	}
+
+	// Workspace decks belong to the workspace rather than their creator, so
+	// access comes from membership or an explicit collaborator grant.
+	visible := false
+	record, workspaceUUID, workspaceRole, err := s.repo.GetWorkspaceRecord(ctx, userID, recordID)
+	switch {
+	case err == nil:
+		if role := workspaceRole.deckRole(); role.Allows(need) {
+			return recordAccess{record: record, ownerUUID: workspaceUUID, role: role}, nil
+		}
+		visible = true
+	case !errors.Is(err, sql.ErrNoRows):
+		return recordAccess{}, err
+	}
+
+	record, ownerUUID, role, err := s.repo.GetCollaboratorRecord(ctx, userID, recordID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return recordAccess{}, err
+		}
+		if visible {
+			return recordAccess{}, ErrForbidden
+		}
+		return recordAccess{}, ErrRecordNotFound
+	}
+	if !role.Allows(need) {
+		return recordAccess{}, ErrForbidden
+	}
+	return recordAccess{record: record, ownerUUID: ownerUUID, role: role}, nil
+}
+
+// deckPaths rebuilds a record's paths from the UUID of the user or workspace
+// whose directory holds it.
+func (s *Service) deckPaths(record PptRecord, ownerUUID, groupName string) (Paths, error) {
+	if record.WorkspaceID.Valid {
+		return BuildWorkspacePaths(s.presentationsRoot, ownerUUID, groupName)
+	}
+	return BuildPaths(s.presentationsRoot, ownerUUID, groupName)
+}
+
+// authorizeTarget validates target and resolves the caller's access to it,
+// auditing failures under event.
+func (s *Service) authorizeTarget(ctx context.Context, target SlideTarget, event string, need Role) (recordAccess, error) {
+	if target.UserID <= 0 {
+		s.audit.Log(event, map[string]any{
+			"status": "validation_failed",
+			"reason": errInvalidUserID.Error(),
+		})
+		return recordAccess{}, errInvalidUserID
+	}
+	if target.RecordID <= 0 {
+		s.audit.Log(event, map[string]any{
+			"status": "validation_failed",
+			"userId": target.UserID,
+			"reason": errInvalidRecordID.Error(),
+		})
+		return recordAccess{}, errInvalidRecordID
+	}
+
+	access, err := s.loadRecordAccess(ctx, target.UserID, target.UserUUID, target.RecordID, need)
+	if err != nil {
+		s.audit.Log(event, map[string]any{
+			"status":   accessFailureStatus(err),
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return recordAccess{}, err
+	}
+	return access, nil
+}
+
+// accessFailureStatus maps a loadRecordAccess error to an audit status.
+func accessFailureStatus(err error) string {
+	switch {
+	case errors.Is(err, ErrRecordNotFound):
+		return "not_found"
+	case errors.Is(err, ErrForbidden):
+		return "forbidden"
+	default:
+		return "error"
+	}
+}
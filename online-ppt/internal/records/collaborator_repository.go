@@ -0,0 +1,200 @@
+package records
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Collaborator represents a ppt_collaborators row joined with the member's account.
+type Collaborator struct {
+	ID        int64
+	RecordID  int64
+	UserID    int64
+	Email     string
+	Role      Role
+	InvitedBy sql.NullInt64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// sharedRecordColumns qualifies recordColumns for queries joining ppt_records as r.
+var sharedRecordColumns = qualifyColumns(recordColumns, "r")
+
+// GetCollaboratorRecord loads a live record the user collaborates on together
+// with the UUID of the user or workspace whose directory holds it and the
+// user's role.
+func (r *Repository) GetCollaboratorRecord(ctx context.Context, userID, recordID int64) (PptRecord, string, Role, error) {
+	stmt := `SELECT ` + sharedRecordColumns + `, COALESCE(w.uuid, u.uuid), c.role FROM ppt_records r ` +
+		`JOIN ppt_collaborators c ON c.record_id = r.id AND c.user_id = ? ` +
+		`LEFT JOIN user_accounts u ON u.id = r.user_id ` +
+		`LEFT JOIN workspaces w ON w.id = r.workspace_id ` +
+		`WHERE r.id = ? AND r.deleted_at IS NULL LIMIT 1`
+
+	var (
+		ownerUUID string
+		role      string
+	)
+	record, err := scanRecord(withExtraColumns(r.db.QueryRowContext(ctx, stmt, userID, recordID), &ownerUUID, &role))
+	if err != nil {
+		return PptRecord{}, "", "", err
+	}
+	return record, ownerUUID, Role(role), nil
+}
+
+// CollaboratorRoles returns the user's role on each of the given records it collaborates on.
+func (r *Repository) CollaboratorRoles(ctx context.Context, userID int64, recordIDs []int64) (map[int64]Role, error) {
+	roles := make(map[int64]Role, len(recordIDs))
+	if len(recordIDs) == 0 {
+		return roles, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(recordIDs)), ", ")
+	args := make([]any, 0, len(recordIDs)+1)
+	args = append(args, userID)
+	for _, id := range recordIDs {
+		args = append(args, id)
+	}
+
+	stmt := `SELECT record_id, role FROM ppt_collaborators WHERE user_id = ? AND record_id IN (` + placeholders + `)`
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list collaborator roles: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			recordID int64
+			role     string
+		)
+		if err := rows.Scan(&recordID, &role); err != nil {
+			return nil, err
+		}
+		roles[recordID] = Role(role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// FindUserByEmail resolves an active account by its normalized email address.
+func (r *Repository) FindUserByEmail(ctx context.Context, email string) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM user_accounts WHERE email = ? AND status = 'active' LIMIT 1`, email).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// AddCollaborator inserts a collaborator row.
+func (r *Repository) AddCollaborator(ctx context.Context, collaborator Collaborator) (Collaborator, error) {
+	stmt := `INSERT INTO ppt_collaborators (record_id, user_id, role, invited_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`
+	id, err := r.dialect.InsertID(ctx, r.db, stmt,
+		collaborator.RecordID,
+		collaborator.UserID,
+		string(collaborator.Role),
+		collaborator.InvitedBy,
+		collaborator.CreatedAt,
+		collaborator.UpdatedAt,
+	)
+	if err != nil {
+		return Collaborator{}, fmt.Errorf("insert collaborator: %w", err)
+	}
+	collaborator.ID = id
+	return collaborator, nil
+}
+
+// ListCollaborators returns the collaborators of a record, oldest first.
+func (r *Repository) ListCollaborators(ctx context.Context, recordID int64) ([]Collaborator, error) {
+	stmt := `SELECT c.id, c.record_id, c.user_id, u.email, c.role, c.invited_by, c.created_at, c.updated_at ` +
+		`FROM ppt_collaborators c JOIN user_accounts u ON u.id = c.user_id WHERE c.record_id = ? ORDER BY c.created_at ASC, c.id ASC`
+	rows, err := r.db.QueryContext(ctx, stmt, recordID)
+	if err != nil {
+		return nil, fmt.Errorf("list collaborators: %w", err)
+	}
+	defer rows.Close()
+
+	var results []Collaborator
+	for rows.Next() {
+		var (
+			collaborator Collaborator
+			role         string
+		)
+		if err := rows.Scan(
+			&collaborator.ID,
+			&collaborator.RecordID,
+			&collaborator.UserID,
+			&collaborator.Email,
+			&role,
+			&collaborator.InvitedBy,
+			&collaborator.CreatedAt,
+			&collaborator.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		collaborator.Role = Role(role)
+		results = append(results, collaborator)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// UpdateCollaboratorRole changes a collaborator's role.
+func (r *Repository) UpdateCollaboratorRole(ctx context.Context, recordID, userID int64, role Role) error {
+	stmt := `UPDATE ppt_collaborators SET role = ?, updated_at = NOW() WHERE record_id = ? AND user_id = ?`
+	res, err := r.db.ExecContext(ctx, stmt, string(role), recordID, userID)
+	if err != nil {
+		return fmt.Errorf("update collaborator: %w", err)
+	}
+	return expectAffected(res)
+}
+
+// RemoveCollaborator deletes a collaborator row.
+func (r *Repository) RemoveCollaborator(ctx context.Context, recordID, userID int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM ppt_collaborators WHERE record_id = ? AND user_id = ?`, recordID, userID)
+	if err != nil {
+		return fmt.Errorf("remove collaborator: %w", err)
+	}
+	return expectAffected(res)
+}
+
+func expectAffected(res sql.Result) error {
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// extraColumnsScanner appends destinations for columns selected after the
+// ones a scan helper knows about.
+type extraColumnsScanner struct {
+	row   interface{ Scan(dest ...any) error }
+	extra []any
+}
+
+func withExtraColumns(row interface{ Scan(dest ...any) error }, extra ...any) extraColumnsScanner {
+	return extraColumnsScanner{row: row, extra: extra}
+}
+
+func (s extraColumnsScanner) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+func qualifyColumns(columns, alias string) string {
+	parts := strings.Split(columns, ",")
+	for i, part := range parts {
+		parts[i] = alias + "." + strings.TrimSpace(part)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,200 @@
+package records
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+var (
+	// ErrCollaboratorNotFound indicates the user is not a collaborator on the record.
+	ErrCollaboratorNotFound = errors.New("collaborator not found")
+	// ErrCollaboratorExists indicates the user already collaborates on the record.
+	ErrCollaboratorExists = errors.New("collaborator already exists")
+	// ErrInvalidCollaborator reports a malformed invite, an unknown role or an attempt to add the deck owner.
+	ErrInvalidCollaborator = errors.New("invalid collaborator")
+	// ErrInviteeNotFound indicates no active account uses the invited email address.
+	ErrInviteeNotFound = errors.New("no active account for email")
+)
+
+// InviteCollaborator grants the account registered under email a role on a
+// record the caller owns or co-owns.
+func (s *Service) InviteCollaborator(ctx context.Context, target SlideTarget, email string, role Role) (Collaborator, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if _, err := mail.ParseAddress(email); err != nil || email == "" {
+		return Collaborator{}, s.rejectCollaborator(target, "records.collaborators.invite", fmt.Errorf("invalid email: %w", ErrInvalidCollaborator))
+	}
+	if _, ok := ParseRole(string(role)); !ok {
+		return Collaborator{}, s.rejectCollaborator(target, "records.collaborators.invite", fmt.Errorf("unknown role %q: %w", role, ErrInvalidCollaborator))
+	}
+
+	access, err := s.authorizeTarget(ctx, target, "records.collaborators.invite", RoleOwner)
+	if err != nil {
+		return Collaborator{}, err
+	}
+
+	inviteeID, err := s.repo.FindUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("records.collaborators.invite", map[string]any{
+				"status":   "not_found",
+				"userId":   target.UserID,
+				"recordId": target.RecordID,
+				"reason":   ErrInviteeNotFound.Error(),
+			})
+			return Collaborator{}, ErrInviteeNotFound
+		}
+		s.audit.Log("records.collaborators.invite", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return Collaborator{}, err
+	}
+	if inviteeID == access.record.UserID {
+		return Collaborator{}, s.rejectCollaborator(target, "records.collaborators.invite", fmt.Errorf("deck owner cannot be a collaborator: %w", ErrInvalidCollaborator))
+	}
+
+	now := s.clockFn().UTC()
+	created, err := s.repo.AddCollaborator(ctx, Collaborator{
+		RecordID:  access.record.ID,
+		UserID:    inviteeID,
+		Email:     email,
+		Role:      role,
+		InvitedBy: sql.NullInt64{Int64: target.UserID, Valid: true},
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		if s.repo.dialect.IsUniqueViolation(err) {
+			s.audit.Log("records.collaborators.invite", map[string]any{
+				"status":   "conflict",
+				"userId":   target.UserID,
+				"recordId": target.RecordID,
+				"reason":   ErrCollaboratorExists.Error(),
+			})
+			return Collaborator{}, ErrCollaboratorExists
+		}
+		s.audit.Log("records.collaborators.invite", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return Collaborator{}, err
+	}
+
+	s.audit.Log("records.collaborators.invite", map[string]any{
+		"status":   "success",
+		"userId":   target.UserID,
+		"recordId": target.RecordID,
+		"memberId": inviteeID,
+		"role":     string(role),
+	})
+	return created, nil
+}
+
+// ListCollaborators returns the collaborators of a record any member can see.
+func (s *Service) ListCollaborators(ctx context.Context, target SlideTarget) ([]Collaborator, error) {
+	access, err := s.authorizeTarget(ctx, target, "records.collaborators.list", RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	collaborators, err := s.repo.ListCollaborators(ctx, access.record.ID)
+	if err != nil {
+		s.audit.Log("records.collaborators.list", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return nil, err
+	}
+	return collaborators, nil
+}
+
+// UpdateCollaborator changes a collaborator's role on a record the caller owns or co-owns.
+func (s *Service) UpdateCollaborator(ctx context.Context, target SlideTarget, memberID int64, role Role) error {
+	if _, ok := ParseRole(string(role)); !ok {
+		return s.rejectCollaborator(target, "records.collaborators.update", fmt.Errorf("unknown role %q: %w", role, ErrInvalidCollaborator))
+	}
+
+	access, err := s.authorizeTarget(ctx, target, "records.collaborators.update", RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateCollaboratorRole(ctx, access.record.ID, memberID, role); err != nil {
+		return s.collaboratorWriteFailed(target, "records.collaborators.update", memberID, err)
+	}
+
+	s.audit.Log("records.collaborators.update", map[string]any{
+		"status":   "success",
+		"userId":   target.UserID,
+		"recordId": target.RecordID,
+		"memberId": memberID,
+		"role":     string(role),
+	})
+	return nil
+}
+
+// RemoveCollaborator revokes a collaborator's access. Owners and co-owners may
+// remove anyone; any collaborator may remove themselves.
+func (s *Service) RemoveCollaborator(ctx context.Context, target SlideTarget, memberID int64) error {
+	need := RoleOwner
+	if memberID == target.UserID {
+		need = RoleViewer
+	}
+
+	access, err := s.authorizeTarget(ctx, target, "records.collaborators.remove", need)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.RemoveCollaborator(ctx, access.record.ID, memberID); err != nil {
+		return s.collaboratorWriteFailed(target, "records.collaborators.remove", memberID, err)
+	}
+
+	s.audit.Log("records.collaborators.remove", map[string]any{
+		"status":   "success",
+		"userId":   target.UserID,
+		"recordId": target.RecordID,
+		"memberId": memberID,
+	})
+	return nil
+}
+
+func (s *Service) rejectCollaborator(target SlideTarget, event string, err error) error {
+	s.audit.Log(event, map[string]any{
+		"status":   "validation_failed",
+		"userId":   target.UserID,
+		"recordId": target.RecordID,
+		"reason":   err.Error(),
+	})
+	return err
+}
+
+func (s *Service) collaboratorWriteFailed(target SlideTarget, event string, memberID int64, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		s.audit.Log(event, map[string]any{
+			"status":   "not_found",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"memberId": memberID,
+		})
+		return ErrCollaboratorNotFound
+	}
+	s.audit.Log(event, map[string]any{
+		"status":   "error",
+		"userId":   target.UserID,
+		"recordId": target.RecordID,
+		"memberId": memberID,
+		"reason":   err.Error(),
+	})
+	return err
+}
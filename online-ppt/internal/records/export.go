@@ -0,0 +1,371 @@
+package records
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExportFormatZip bundles a deck as a ZIP with a standalone player.
+const ExportFormatZip = "zip"
+
+const (
+	// maxExportAssetBytes caps a single referenced asset pulled into an export.
+	maxExportAssetBytes = 50 << 20
+	// maxExportAssets bounds how many referenced assets are followed per deck.
+	maxExportAssets = 1000
+)
+
+// ErrUnsupportedExportFormat reports an export format other than ExportFormatZip.
+var ErrUnsupportedExportFormat = errors.New("unsupported export format")
+
+var (
+	//go:embed export_player.html
+	playerTemplateSource string
+	playerTemplate       = template.Must(template.New("player").Parse(playerTemplateSource))
+
+	assetAttrPattern  = regexp.MustCompile(`(?i)(\s(?:src|href|poster)\s*=\s*)("[^"]*"|'[^']*')`)
+	cssURLPattern     = regexp.MustCompile(`(?i)url\(\s*("[^"]*"|'[^']*'|[^)'"\s]+)\s*\)`)
+	viteClientPattern = regexp.MustCompile(`(?is)<script[^>]*\ssrc\s*=\s*["']/@vite/client["'][^>]*>\s*</script>`)
+	urlSchemePattern  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.\-]*:`)
+	fontFamilyPattern = regexp.MustCompile(`^[A-Za-z0-9 ,'"\-]{1,200}$`)
+)
+
+// DeckExport is a planned export whose files are written by WriteZip.
+type DeckExport struct {
+	Name    string
+	entries map[string]exportEntry
+	modTime time.Time
+}
+
+type exportEntry struct {
+	content []byte
+	source  string
+}
+
+// FileName returns the suggested download name for the export.
+func (e *DeckExport) FileName() string {
+	return e.Name + ".zip"
+}
+
+// WriteZip streams the export as a ZIP archive rooted at a folder named after the deck.
+func (e *DeckExport) WriteZip(w io.Writer) error {
+	names := make([]string, 0, len(e.entries))
+	for name := range e.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	archive := zip.NewWriter(w)
+	for _, name := range names {
+		header := &zip.FileHeader{
+			Name:     e.Name + "/" + name,
+			Method:   zip.Deflate,
+			Modified: e.modTime,
+		}
+		dst, err := archive.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("create zip entry: %w", err)
+		}
+		if err := e.entries[name].writeTo(dst); err != nil {
+			return fmt.Errorf("write zip entry %s: %w", name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("close zip: %w", err)
+	}
+	return nil
+}
+
+func (entry exportEntry) writeTo(w io.Writer) error {
+	if entry.source == "" {
+		_, err := w.Write(entry.content)
+		return err
+	}
+	file, err := os.Open(entry.source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, io.LimitReader(file, maxExportAssetBytes))
+	return err
+}
+
+// PrepareExport collects a deck's slides, config and referenced assets and
+// renders a standalone player. Slide and stylesheet references to files inside
+// the deck directory are rewritten to relative paths so the bundle works offline.
+func (s *Service) PrepareExport(ctx context.Context, target SlideTarget, format string) (*DeckExport, error) {
+	if format == "" {
+		format = ExportFormatZip
+	}
+	if format != ExportFormatZip {
+		s.audit.Log("records.export", map[string]any{
+			"status":   "validation_failed",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   fmt.Sprintf("format %q", format),
+		})
+		return nil, fmt.Errorf("%q: %w", format, ErrUnsupportedExportFormat)
+	}
+
+	record, paths, err := s.resolveSlidesDir(ctx, target, "records.export", RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	export, err := s.planExport(record, paths)
+	if err != nil {
+		s.audit.Log("records.export", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return nil, err
+	}
+
+	s.audit.Log("records.export", map[string]any{
+		"status":   "success",
+		"userId":   target.UserID,
+		"recordId": target.RecordID,
+		"files":    len(export.entries),
+	})
+	return export, nil
+}
+
+func (s *Service) planExport(record PptRecord, paths Paths) (*DeckExport, error) {
+	cfg, err := loadSyncedConfig(record, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	planner := &exportPlanner{
+		deckDir:   filepath.Dir(paths.Canonical),
+		webPrefix: "/" + path.Dir(paths.Relative) + "/",
+		export: &DeckExport{
+			Name:    record.GroupName,
+			entries: make(map[string]exportEntry),
+			modTime: s.clockFn(),
+		},
+	}
+
+	err = filepath.WalkDir(paths.Canonical, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && file == paths.Canonical {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if file == paths.Canonical {
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(planner.deckDir, file)
+		if err != nil {
+			return err
+		}
+		return planner.include(filepath.ToSlash(rel))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect slides: %w", err)
+	}
+
+	for len(planner.queue) > 0 {
+		next := planner.queue[0]
+		planner.queue = planner.queue[1:]
+		if err := planner.include(next); err != nil {
+			return nil, err
+		}
+	}
+
+	encoded, err := encodeConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	planner.export.entries[SlidesConfigFile] = exportEntry{content: encoded}
+
+	player, err := renderPlayer(cfg, encoded)
+	if err != nil {
+		return nil, err
+	}
+	planner.export.entries["index.html"] = exportEntry{content: player}
+
+	return planner.export, nil
+}
+
+// exportPlanner tracks which deck-relative files belong in an export.
+type exportPlanner struct {
+	deckDir   string
+	webPrefix string
+	export    *DeckExport
+	queue     []string
+	queued    map[string]struct{}
+}
+
+// include adds a deck-relative file, rewriting asset references inside HTML and CSS.
+func (p *exportPlanner) include(rel string) error {
+	if _, done := p.export.entries[rel]; done {
+		return nil
+	}
+
+	source := filepath.Join(p.deckDir, filepath.FromSlash(rel))
+	switch strings.ToLower(path.Ext(rel)) {
+	case ".html", ".htm", ".css":
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", rel, err)
+		}
+		p.export.entries[rel] = exportEntry{content: []byte(p.rewrite(rel, string(content)))}
+	default:
+		p.export.entries[rel] = exportEntry{source: source}
+	}
+	return nil
+}
+
+// rewrite points asset references of the file at rel to bundled relative paths.
+func (p *exportPlanner) rewrite(rel, content string) string {
+	dir := path.Dir(rel)
+	isCSS := strings.EqualFold(path.Ext(rel), ".css")
+
+	if !isCSS {
+		content = viteClientPattern.ReplaceAllString(content, "")
+		content = assetAttrPattern.ReplaceAllStringFunc(content, func(match string) string {
+			parts := assetAttrPattern.FindStringSubmatch(match)
+			quote := parts[2][:1]
+			ref := parts[2][1 : len(parts[2])-1]
+			if rewritten, ok := p.resolve(dir, ref); ok {
+				return parts[1] + quote + rewritten + quote
+			}
+			return match
+		})
+	}
+
+	return cssURLPattern.ReplaceAllStringFunc(content, func(match string) string {
+		raw := cssURLPattern.FindStringSubmatch(match)[1]
+		ref := strings.Trim(raw, `"'`)
+		if rewritten, ok := p.resolve(dir, ref); ok {
+			return `url("` + rewritten + `")`
+		}
+		return match
+	})
+}
+
+// resolve maps a reference found in a file under dir to a file inside the
+// deck directory, queues it for export and returns the relative reference to
+// use instead. References to other origins or outside the deck are left alone.
+func (p *exportPlanner) resolve(dir, ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "//") || urlSchemePattern.MatchString(ref) {
+		return "", false
+	}
+
+	refPath, suffix := ref, ""
+	if i := strings.IndexAny(refPath, "?#"); i >= 0 {
+		refPath, suffix = refPath[:i], refPath[i:]
+		if j := strings.Index(suffix, "#"); j >= 0 {
+			suffix = suffix[j:]
+		} else {
+			suffix = ""
+		}
+	}
+	decoded, err := url.PathUnescape(refPath)
+	if err != nil {
+		return "", false
+	}
+
+	var target string
+	if strings.HasPrefix(decoded, "/") {
+		if !strings.HasPrefix(decoded, p.webPrefix) {
+			return "", false
+		}
+		target = path.Clean(strings.TrimPrefix(decoded, p.webPrefix))
+	} else {
+		target = path.Clean(path.Join(dir, decoded))
+	}
+	if target == "." || target == ".." || strings.HasPrefix(target, "../") {
+		return "", false
+	}
+	for _, segment := range strings.Split(target, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return "", false
+		}
+	}
+
+	info, err := os.Lstat(filepath.Join(p.deckDir, filepath.FromSlash(target)))
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxExportAssetBytes {
+		return "", false
+	}
+
+	if _, included := p.export.entries[target]; !included {
+		if p.queued == nil {
+			p.queued = make(map[string]struct{})
+		}
+		if _, queued := p.queued[target]; !queued {
+			if len(p.queued) >= maxExportAssets {
+				return "", false
+			}
+			p.queued[target] = struct{}{}
+			p.queue = append(p.queue, target)
+		}
+	}
+
+	return relativeRef(dir, target) + suffix, true
+}
+
+// relativeRef returns the slash path from directory dir to file target.
+func relativeRef(dir, target string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(dir), filepath.FromSlash(target))
+	if err != nil {
+		return target
+	}
+	escaped := (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath()
+	return escaped
+}
+
+func renderPlayer(cfg SlidesConfig, configJSON []byte) ([]byte, error) {
+	color := cfg.Theme.PrimaryColor
+	if !themeColorPattern.MatchString(color) {
+		color = defaultThemeColor
+	}
+	font := cfg.Theme.FontFamily
+	if !fontFamilyPattern.MatchString(font) {
+		font = defaultThemeFont
+	}
+
+	var out bytes.Buffer
+	err := playerTemplate.Execute(&out, map[string]any{
+		"Title":        cfg.Title,
+		"PrimaryColor": template.CSS(color),
+		"FontFamily":   template.CSS(font),
+		"ConfigJSON":   template.JS(configJSON),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("render player: %w", err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,448 @@
+package records
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// MaxImportArchiveBytes caps the size of an uploaded import archive.
+	MaxImportArchiveBytes = 100 << 20
+	// maxImportEntries bounds the number of entries read from an archive.
+	maxImportEntries = 2000
+	// maxImportTotalBytes bounds the uncompressed size of all imported files.
+	maxImportTotalBytes = 200 << 20
+	// maxImportAssetBytes bounds a single non-slide file.
+	maxImportAssetBytes = 20 << 20
+	// maxImportDepth bounds how deeply nested an imported file may be.
+	maxImportDepth = 8
+
+	importStagingDirName = ".imports"
+)
+
+var (
+	// ErrInvalidArchive reports an import archive that is malformed or contains disallowed entries.
+	ErrInvalidArchive = errors.New("invalid import archive")
+	// ErrArchiveTooLarge reports an import archive exceeding the size or entry limits.
+	ErrArchiveTooLarge = errors.New("import archive too large")
+
+	importAssetExtensions = map[string]struct{}{
+		".css": {}, ".js": {}, ".json": {}, ".txt": {},
+		".png": {}, ".jpg": {}, ".jpeg": {}, ".gif": {}, ".svg": {}, ".webp": {}, ".avif": {}, ".ico": {}, ".bmp": {},
+		".woff": {}, ".woff2": {}, ".ttf": {}, ".otf": {}, ".eot": {},
+		".mp4": {}, ".webm": {}, ".ogg": {}, ".mp3": {}, ".wav": {}, ".m4a": {},
+	}
+)
+
+// importFile is a validated archive entry mapped to its deck-relative destination.
+type importFile struct {
+	entry *zip.File
+	dest  string
+}
+
+// ImportRecord creates a record from a ZIP archive holding slides/*.html, an
+// optional slides.config.json and assets. Archives produced by the export
+// endpoint, which wrap everything in a folder named after the deck, are accepted
+// as is. When params.Name is empty the wrapping folder name is used.
+func (s *Service) ImportRecord(ctx context.Context, params CreateParams, archive io.ReaderAt, size int64) (RecordView, error) {
+	if size > MaxImportArchiveBytes {
+		s.audit.Log("records.import", map[string]any{
+			"status": "validation_failed",
+			"userId": params.UserID,
+			"reason": ErrArchiveTooLarge.Error(),
+		})
+		return RecordView{}, ErrArchiveTooLarge
+	}
+
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		s.audit.Log("records.import", map[string]any{
+			"status": "validation_failed",
+			"userId": params.UserID,
+			"reason": err.Error(),
+		})
+		return RecordView{}, fmt.Errorf("%v: %w", err, ErrInvalidArchive)
+	}
+
+	plan, err := planImport(reader.File)
+	if err != nil {
+		s.audit.Log("records.import", map[string]any{
+			"status": "validation_failed",
+			"userId": params.UserID,
+			"reason": err.Error(),
+		})
+		return RecordView{}, err
+	}
+	if strings.TrimSpace(params.Name) == "" {
+		params.Name = plan.folder
+	}
+
+	title := strings.TrimSpace(params.Title)
+	if title == "" {
+		title = strings.TrimSpace(params.Name)
+	}
+	imported, err := readImportConfig(plan, title)
+	if err != nil {
+		s.audit.Log("records.import", map[string]any{
+			"status": "validation_failed",
+			"userId": params.UserID,
+			"reason": err.Error(),
+		})
+		return RecordView{}, err
+	}
+
+	staging, err := s.stageImport(plan)
+	if err != nil {
+		s.audit.Log("records.import", map[string]any{
+			"status": "validation_failed",
+			"userId": params.UserID,
+			"reason": err.Error(),
+		})
+		return RecordView{}, err
+	}
+	defer os.RemoveAll(staging)
+
+	view, err := s.CreateRecord(ctx, params)
+	if err != nil {
+		return RecordView{}, err
+	}
+	record := view.Record
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	paths := Paths{Relative: record.RelativePath, Canonical: record.CanonicalPath}
+	if moved, err := s.installImport(record, paths, staging, imported); err != nil {
+		s.abortImport(ctx, record, paths, moved)
+		s.audit.Log("records.import", map[string]any{
+			"status":   "error",
+			"userId":   params.UserID,
+			"recordId": record.ID,
+			"reason":   err.Error(),
+		})
+		return RecordView{}, err
+	}
+
+	view, err = s.makeRecordView(record)
+	if err != nil {
+		return RecordView{}, err
+	}
+
+	s.audit.Log("records.import", map[string]any{
+		"status":   "success",
+		"userId":   params.UserID,
+		"recordId": record.ID,
+		"slides":   len(plan.renames),
+		"files":    len(plan.files),
+	})
+	return view, nil
+}
+
+// importPlan is the validated content of an archive.
+type importPlan struct {
+	folder  string
+	files   []importFile
+	config  *zip.File
+	renames map[string]string
+}
+
+// planImport validates every entry, strips an optional wrapping folder and
+// assigns slide-N.html names to slides that do not follow the convention.
+func planImport(entries []*zip.File) (importPlan, error) {
+	if len(entries) > maxImportEntries {
+		return importPlan{}, fmt.Errorf("archive has more than %d entries: %w", maxImportEntries, ErrArchiveTooLarge)
+	}
+
+	var (
+		names []string
+		files []*zip.File
+		total uint64
+	)
+	for _, entry := range entries {
+		name := entry.Name
+		if entry.FileInfo().IsDir() || isArchiveJunk(name) {
+			continue
+		}
+		if !entry.Mode().IsRegular() {
+			return importPlan{}, fmt.Errorf("entry %q is not a regular file: %w", name, ErrInvalidArchive)
+		}
+		if err := validateArchivePath(name); err != nil {
+			return importPlan{}, err
+		}
+		total += entry.UncompressedSize64
+		if total > maxImportTotalBytes {
+			return importPlan{}, fmt.Errorf("archive expands beyond %d bytes: %w", maxImportTotalBytes, ErrArchiveTooLarge)
+		}
+		names = append(names, name)
+		files = append(files, entry)
+	}
+
+	folder := commonFolder(names)
+	plan := importPlan{folder: folder, renames: make(map[string]string)}
+
+	var slides []importFile
+	highest := 0
+	for i, entry := range files {
+		rel := strings.TrimPrefix(names[i], folder+"/")
+		if folder == "" {
+			rel = names[i]
+		}
+
+		switch {
+		case rel == SlidesConfigFile:
+			if entry.UncompressedSize64 > maxConfigBytes {
+				return importPlan{}, fmt.Errorf("%s exceeds %d bytes: %w", SlidesConfigFile, maxConfigBytes, ErrArchiveTooLarge)
+			}
+			plan.config = entry
+			continue
+		case rel == "index.html":
+			// The player bundled by the export endpoint is regenerated on export.
+			continue
+		}
+
+		ext := strings.ToLower(path.Ext(rel))
+		if ext == ".html" || ext == ".htm" {
+			dir, base := path.Split(rel)
+			if dir != "slides/" {
+				return importPlan{}, fmt.Errorf("html file %q must live directly in slides/: %w", rel, ErrInvalidArchive)
+			}
+			if entry.UncompressedSize64 > MaxSlideBytes {
+				return importPlan{}, fmt.Errorf("slide %q exceeds %d bytes: %w", rel, MaxSlideBytes, ErrArchiveTooLarge)
+			}
+			if index := slideIndex(base); index > highest {
+				highest = index
+			}
+			slides = append(slides, importFile{entry: entry, dest: rel})
+			continue
+		}
+
+		if _, ok := importAssetExtensions[ext]; !ok {
+			return importPlan{}, fmt.Errorf("file type of %q is not allowed: %w", rel, ErrInvalidArchive)
+		}
+		if entry.UncompressedSize64 > maxImportAssetBytes {
+			return importPlan{}, fmt.Errorf("asset %q exceeds %d bytes: %w", rel, maxImportAssetBytes, ErrArchiveTooLarge)
+		}
+		plan.files = append(plan.files, importFile{entry: entry, dest: rel})
+	}
+
+	if len(slides) == 0 {
+		return importPlan{}, fmt.Errorf("archive contains no slides/*.html: %w", ErrInvalidArchive)
+	}
+
+	sort.SliceStable(slides, func(i, j int) bool { return slides[i].dest < slides[j].dest })
+	next := highest + 1
+	for i := range slides {
+		base := path.Base(slides[i].dest)
+		if slideIndex(base) == 0 {
+			renamed, err := slideFileName(next)
+			if err != nil {
+				return importPlan{}, fmt.Errorf("renaming %q: %v: %w", slides[i].dest, err, ErrInvalidArchive)
+			}
+			next++
+			slides[i].dest = "slides/" + renamed
+			plan.renames[base] = renamed
+		} else {
+			plan.renames[base] = base
+		}
+	}
+	plan.files = append(plan.files, slides...)
+	return plan, nil
+}
+
+// validateArchivePath rejects absolute paths, parent references, hidden
+// segments and anything deeper than maxImportDepth (zip-slip protection).
+func validateArchivePath(name string) error {
+	if name == "" || strings.Contains(name, "\\") || strings.HasPrefix(name, "/") || strings.ContainsRune(name, 0) {
+		return fmt.Errorf("entry %q has an unsafe path: %w", name, ErrInvalidArchive)
+	}
+	if path.Clean(name) != name || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return fmt.Errorf("entry %q has an unsafe path: %w", name, ErrInvalidArchive)
+	}
+	segments := strings.Split(name, "/")
+	if len(segments) > maxImportDepth {
+		return fmt.Errorf("entry %q is nested too deeply: %w", name, ErrInvalidArchive)
+	}
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." || strings.HasPrefix(segment, ".") {
+			return fmt.Errorf("entry %q has an unsafe path: %w", name, ErrInvalidArchive)
+		}
+	}
+	return nil
+}
+
+// isArchiveJunk skips metadata that archivers add on their own.
+func isArchiveJunk(name string) bool {
+	base := path.Base(name)
+	return strings.HasPrefix(name, "__MACOSX/") || base == ".DS_Store" || base == "Thumbs.db"
+}
+
+// commonFolder returns the single top-level folder wrapping every entry when
+// that folder is not itself part of the deck layout, or "".
+func commonFolder(names []string) string {
+	folder := ""
+	for _, name := range names {
+		first, _, nested := strings.Cut(name, "/")
+		if !nested {
+			return ""
+		}
+		if folder == "" {
+			folder = first
+		} else if folder != first {
+			return ""
+		}
+	}
+	if folder == "slides" {
+		return ""
+	}
+	return folder
+}
+
+// readImportConfig decodes the archive's slides.config.json, if any, over the
+// defaults for title and maps slide entries onto the names assigned by planImport.
+func readImportConfig(plan importPlan, title string) (*SlidesConfig, error) {
+	if plan.config == nil {
+		return nil, nil
+	}
+	raw, err := readArchiveEntry(plan.config, maxConfigBytes)
+	if err != nil {
+		return nil, err
+	}
+	cfg := DefaultSlidesConfig(title)
+	if err := decodeConfigStrict(raw, &cfg); err != nil {
+		return nil, err
+	}
+	for i, slide := range cfg.Slides {
+		if renamed, ok := plan.renames[slide.File]; ok {
+			cfg.Slides[i].File = renamed
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// stageImport extracts the planned files into a private staging directory,
+// enforcing the per-file limits on the actual decompressed bytes.
+func (s *Service) stageImport(plan importPlan) (string, error) {
+	stagingRoot := filepath.Join(s.presentationsRoot, importStagingDirName)
+	if err := os.MkdirAll(stagingRoot, 0o755); err != nil {
+		return "", fmt.Errorf("ensure staging root: %w", err)
+	}
+	staging, err := os.MkdirTemp(stagingRoot, "import-")
+	if err != nil {
+		return "", fmt.Errorf("create staging dir: %w", err)
+	}
+
+	for _, file := range plan.files {
+		limit := int64(maxImportAssetBytes)
+		if path.Dir(file.dest) == "slides" && slideIndex(path.Base(file.dest)) > 0 {
+			limit = MaxSlideBytes
+		}
+		content, err := readArchiveEntry(file.entry, limit)
+		if err != nil {
+			os.RemoveAll(staging)
+			return "", err
+		}
+		if limit == MaxSlideBytes {
+			if err := validateSlideContent(content); err != nil {
+				os.RemoveAll(staging)
+				return "", fmt.Errorf("%s: %w", file.dest, err)
+			}
+		}
+
+		dest := filepath.Join(staging, filepath.FromSlash(file.dest))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			os.RemoveAll(staging)
+			return "", fmt.Errorf("ensure staging subdir: %w", err)
+		}
+		if err := os.WriteFile(dest, content, 0o644); err != nil {
+			os.RemoveAll(staging)
+			return "", fmt.Errorf("write staged file: %w", err)
+		}
+	}
+	return staging, nil
+}
+
+func readArchiveEntry(entry *zip.File, limit int64) ([]byte, error) {
+	src, err := entry.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %v: %w", entry.Name, err, ErrInvalidArchive)
+	}
+	defer src.Close()
+
+	content, err := io.ReadAll(io.LimitReader(src, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %v: %w", entry.Name, err, ErrInvalidArchive)
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("%s exceeds %d bytes: %w", entry.Name, limit, ErrArchiveTooLarge)
+	}
+	return content, nil
+}
+
+// installImport moves staged files into the new record's deck directory and
+// writes slides.config.json, taking the archive's config when it has one. It
+// returns the paths it created so a failed import can be undone without
+// touching anything that was already there. Callers must hold fsMu.
+func (s *Service) installImport(record PptRecord, paths Paths, staging string, imported *SlidesConfig) ([]string, error) {
+	cfg := DefaultSlidesConfig(recordDisplayTitle(record))
+	if imported != nil {
+		cfg = *imported
+	}
+
+	// CreateRecord left an empty slides directory; the staged one replaces it.
+	if err := os.Remove(paths.Canonical); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("prepare slides dir: %w", ErrDeckDirectoryExists)
+	}
+
+	deckDir := filepath.Dir(paths.Canonical)
+	entries, err := os.ReadDir(staging)
+	if err != nil {
+		return nil, fmt.Errorf("read staging dir: %w", err)
+	}
+	var moved []string
+	for _, entry := range entries {
+		dest := filepath.Join(deckDir, entry.Name())
+		if err := moveDir(filepath.Join(staging, entry.Name()), dest); err != nil {
+			return moved, err
+		}
+		moved = append(moved, dest)
+	}
+
+	files, err := readSlideDir(paths)
+	if err != nil {
+		return moved, err
+	}
+	cfg, _ = reconcileConfig(cfg, files)
+	if err := writeConfig(paths, cfg); err != nil {
+		return moved, err
+	}
+	return moved, nil
+}
+
+// abortImport removes what a failed import installed and deletes the new record.
+func (s *Service) abortImport(ctx context.Context, record PptRecord, paths Paths, moved []string) {
+	for _, path := range moved {
+		_ = os.RemoveAll(path)
+	}
+	_ = os.Remove(paths.Canonical)
+	_ = os.Remove(filepath.Dir(paths.Canonical))
+
+	if err := s.repo.Delete(ctx, record.UserID, record.ID); err != nil {
+		s.audit.Log("records.import.rollback", map[string]any{
+			"status":   "error",
+			"userId":   record.UserID,
+			"recordId": record.ID,
+			"reason":   err.Error(),
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package records
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Problems reported by VerifyPaths and ReindexPaths.
+const (
+	// PathProblemMismatch means the stored paths differ from those derived from
+	// the deck owner and group name.
+	PathProblemMismatch = "mismatch"
+	// PathProblemMissing means the slides directory does not exist.
+	PathProblemMissing = "missing"
+)
+
+const pathScanBatchSize = 200
+
+// PathIssue describes a live deck whose stored location is wrong.
+type PathIssue struct {
+	RecordID int64
+	UserID   int64
+	Problem  string
+	Stored   Paths
+	Expected Paths
+	// Fixed is set by ReindexPaths once the deck was repaired; Error explains
+	// why it could not be.
+	Fixed bool
+	Error string
+}
+
+// VerifyPaths checks every live deck against the layout derived from its owner
+// and group name without changing anything.
+func (s *Service) VerifyPaths(ctx context.Context) ([]PathIssue, error) {
+	return s.scanPaths(ctx, "records.paths.verify", false)
+}
+
+// ReindexPaths repairs the decks VerifyPaths reports: a deck stored elsewhere
+// is moved to its expected directory and its row rewritten, and a missing
+// slides directory is recreated empty.
+func (s *Service) ReindexPaths(ctx context.Context) ([]PathIssue, error) {
+	return s.scanPaths(ctx, "records.paths.reindex", true)
+}
+
+func (s *Service) scanPaths(ctx context.Context, event string, repair bool) ([]PathIssue, error) {
+	var (
+		issues  []PathIssue
+		checked int
+		fixed   int
+		afterID int64
+	)
+	for {
+		batch, err := s.repo.ListDeckLocations(ctx, afterID, pathScanBatchSize)
+		if err != nil {
+			s.audit.Log(event, map[string]any{
+				"status": "error",
+				"reason": err.Error(),
+			})
+			return issues, err
+		}
+
+		for _, location := range batch {
+			if err := ctx.Err(); err != nil {
+				return issues, err
+			}
+			afterID = location.Record.ID
+			checked++
+
+			issue, ok, err := s.inspectDeck(location)
+			if err != nil {
+				issue = PathIssue{RecordID: location.Record.ID, UserID: location.Record.UserID, Error: err.Error()}
+				issues = append(issues, issue)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			if repair {
+				if err := s.repairDeck(ctx, location.Record, issue); err != nil {
+					issue.Error = err.Error()
+				} else {
+					issue.Fixed = true
+					fixed++
+				}
+			}
+			issues = append(issues, issue)
+		}
+
+		if len(batch) < pathScanBatchSize {
+			break
+		}
+	}
+
+	s.audit.Log(event, map[string]any{
+		"status":  "success",
+		"checked": checked,
+		"issues":  len(issues),
+		"fixed":   fixed,
+	})
+	return issues, nil
+}
+
+// inspectDeck reports whether the deck needs repair.
+func (s *Service) inspectDeck(location DeckLocation) (PathIssue, bool, error) {
+	record := location.Record
+	expected, err := s.deckPaths(record, location.OwnerUUID, record.GroupName)
+	if err != nil {
+		return PathIssue{}, false, err
+	}
+	issue := PathIssue{
+		RecordID: record.ID,
+		UserID:   record.UserID,
+		Stored:   Paths{Relative: record.RelativePath, Canonical: record.CanonicalPath},
+		Expected: expected,
+	}
+
+	if issue.Stored.Relative != expected.Relative || filepath.Clean(issue.Stored.Canonical) != expected.Canonical {
+		issue.Problem = PathProblemMismatch
+		return issue, true, nil
+	}
+	if _, err := os.Stat(expected.Canonical); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return PathIssue{}, false, fmt.Errorf("stat slides dir: %w", err)
+		}
+		issue.Problem = PathProblemMissing
+		return issue, true, nil
+	}
+	return PathIssue{}, false, nil
+}
+
+func (s *Service) repairDeck(ctx context.Context, record PptRecord, issue PathIssue) error {
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	if issue.Problem == PathProblemMissing {
+		return EnsureDirectories(issue.Expected)
+	}
+
+	target := record
+	target.RelativePath = issue.Expected.Relative
+	target.CanonicalPath = issue.Expected.Canonical
+
+	// 原路径不在根目录内时无法搬迁，只能在新位置重建目录
+	moved := false
+	if _, err := s.deckDir(record); err == nil {
+		if moved, err = s.relocateDeck(record, target); err != nil {
+			return err
+		}
+	} else if err := EnsureDirectories(issue.Expected); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateDeckPaths(ctx, record.ID, issue.Expected); err != nil {
+		if moved {
+			if rollbackErr := s.rollbackRelocation(record, target); rollbackErr != nil {
+				return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
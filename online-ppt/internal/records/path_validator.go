@@ -5,12 +5,16 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
 )
 
-var groupNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+var (
+	groupNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	slideFilePattern = regexp.MustCompile(`^slide-([1-9][0-9]{0,5})\.html$`)
+)
 
 // Paths encapsulates relative and absolute locations for a PPT deck.
 type Paths struct {
@@ -18,14 +22,31 @@ type Paths struct {
 	Canonical string
 }
 
+// workspaceDirName is the top-level directory under the root that holds
+// workspace decks; it can never collide with a user UUID.
+const workspaceDirName = "ws"
+
 // BuildPaths validates inputs and constructs normalized paths under the root.
 func BuildPaths(rootDir, userUUID, groupName string) (Paths, error) {
-	if rootDir == "" {
-		return Paths{}, fmt.Errorf("root directory required")
-	}
 	if _, err := uuid.Parse(userUUID); err != nil {
 		return Paths{}, fmt.Errorf("invalid user uuid: %w", err)
 	}
+	return buildDeckPaths(rootDir, groupName, userUUID)
+}
+
+// BuildWorkspacePaths constructs the paths of a workspace deck, laid out as
+// <root>/ws/<workspaceUUID>/<group>/slides.
+func BuildWorkspacePaths(rootDir, workspaceUUID, groupName string) (Paths, error) {
+	if _, err := uuid.Parse(workspaceUUID); err != nil {
+		return Paths{}, fmt.Errorf("invalid workspace uuid: %w", err)
+	}
+	return buildDeckPaths(rootDir, groupName, workspaceDirName, workspaceUUID)
+}
+
+func buildDeckPaths(rootDir, groupName string, ownerDirs ...string) (Paths, error) {
+	if rootDir == "" {
+		return Paths{}, fmt.Errorf("root directory required")
+	}
 	if !groupNamePattern.MatchString(groupName) {
 		return Paths{}, fmt.Errorf("invalid group name")
 	}
@@ -35,8 +56,9 @@ func BuildPaths(rootDir, userUUID, groupName string) (Paths, error) {
 		return Paths{}, fmt.Errorf("resolve root: %w", err)
 	}
 
-	relative := filepath.ToSlash(filepath.Join("presentations", userUUID, groupName, "slides"))
-	canonical := filepath.Clean(filepath.Join(cleanRoot, userUUID, groupName, "slides"))
+	segments := append(append([]string{}, ownerDirs...), groupName, "slides")
+	relative := filepath.ToSlash(filepath.Join(append([]string{"presentations"}, segments...)...))
+	canonical := filepath.Clean(filepath.Join(append([]string{cleanRoot}, segments...)...))
 
 	if !strings.HasPrefix(canonical, cleanRoot) {
 		return Paths{}, fmt.Errorf("canonical path escapes root")
@@ -52,3 +74,47 @@ func EnsureDirectories(paths Paths) error {
 	}
 	return os.MkdirAll(paths.Canonical, 0o755)
 }
+
+// SlidePath resolves a slide file name inside the canonical slides directory.
+// Only names of the form slide-N.html are accepted so callers can never address
+// files outside the deck.
+func SlidePath(paths Paths, name string) (string, error) {
+	if paths.Canonical == "" {
+		return "", fmt.Errorf("canonical path required")
+	}
+	if !slideFilePattern.MatchString(name) {
+		return "", ErrInvalidSlideName
+	}
+
+	full := filepath.Join(paths.Canonical, name)
+	if filepath.Dir(full) != filepath.Clean(paths.Canonical) {
+		return "", ErrInvalidSlideName
+	}
+	return full, nil
+}
+
+// slideIndex extracts N from slide-N.html, returning 0 for unrecognised names.
+func slideIndex(name string) int {
+	match := slideFilePattern.FindStringSubmatch(name)
+	if match == nil {
+		return 0
+	}
+	index, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0
+	}
+	return index
+}
+
+// maxSlideIndex is the largest N slideFilePattern accepts in slide-N.html.
+const maxSlideIndex = 999999
+
+// slideFileName formats the canonical file name for a slide index. Indexes past
+// maxSlideIndex would not be addressable through SlidePath, so they fail with
+// ErrSlideLimitReached.
+func slideFileName(index int) (string, error) {
+	if index > maxSlideIndex {
+		return "", fmt.Errorf("slide-%d.html exceeds slide-%d.html: %w", index, maxSlideIndex, ErrSlideLimitReached)
+	}
+	return fmt.Sprintf("slide-%d.html", index), nil
+}
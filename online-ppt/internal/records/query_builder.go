@@ -3,6 +3,8 @@ package records
 import (
 	"fmt"
 	"strings"
+
+	"online-ppt/internal/storage"
 )
 
 const (
@@ -11,7 +13,9 @@ const (
 )
 
 type listQueryBuilder struct {
+	dialect    storage.Dialect
 	filters    ListFilters
+	columns    string
 	whereItems []string
 	args       []any
 	sortClause string
@@ -19,11 +23,13 @@ type listQueryBuilder struct {
 
 func normalizeListFilters(filters ListFilters) ListFilters {
 	normalized := ListFilters{
-		Query:  strings.TrimSpace(filters.Query),
-		Tag:    strings.TrimSpace(filters.Tag),
-		Sort:   strings.TrimSpace(filters.Sort),
-		Limit:  filters.Limit,
-		Offset: filters.Offset,
+		Query:       strings.TrimSpace(filters.Query),
+		Tag:         strings.TrimSpace(filters.Tag),
+		Sort:        strings.TrimSpace(filters.Sort),
+		Scope:       filters.Scope,
+		WorkspaceID: filters.WorkspaceID,
+		Limit:       filters.Limit,
+		Offset:      filters.Offset,
 	}
 
 	if normalized.Tag != "" {
@@ -39,6 +45,13 @@ func normalizeListFilters(filters ListFilters) ListFilters {
 		normalized.Sort = "created_at_desc"
 	}
 
+	switch normalized.Scope {
+	case ListScopeOwned, ListScopeShared:
+		// accepted values
+	default:
+		normalized.Scope = ListScopeAll
+	}
+
 	if normalized.Limit <= 0 || normalized.Limit > maxListLimit {
 		normalized.Limit = defaultListLimit
 	}
@@ -50,14 +63,34 @@ func normalizeListFilters(filters ListFilters) ListFilters {
 	return normalized
 }
 
-func newListQueryBuilder(userID int64, filters ListFilters) *listQueryBuilder {
+func newListQueryBuilder(dialect storage.Dialect, userID int64, filters ListFilters) *listQueryBuilder {
 	builder := &listQueryBuilder{
+		dialect:    dialect,
 		filters:    filters,
-		whereItems: []string{"user_id = ?"},
-		args:       []any{userID},
+		columns:    recordColumns,
 		sortClause: "ORDER BY created_at DESC",
 	}
 
+	const (
+		ownedByUser    = "(user_id = ? AND workspace_id IS NULL)"
+		sharedWithUser = "id IN (SELECT record_id FROM ppt_collaborators WHERE user_id = ?)"
+	)
+	switch {
+	case filters.WorkspaceID > 0:
+		builder.whereItems = []string{"workspace_id = ?"}
+		builder.args = []any{filters.WorkspaceID}
+	case filters.Scope == ListScopeOwned:
+		builder.whereItems = []string{ownedByUser}
+		builder.args = []any{userID}
+	case filters.Scope == ListScopeShared:
+		builder.whereItems = []string{sharedWithUser}
+		builder.args = []any{userID}
+	default:
+		builder.whereItems = []string{"(" + ownedByUser + " OR " + sharedWithUser + ")"}
+		builder.args = []any{userID, userID}
+	}
+	builder.whereItems = append(builder.whereItems, "deleted_at IS NULL")
+
 	builder.applyQuery()
 	builder.applyTag()
 	builder.applySort()
@@ -65,9 +98,28 @@ func newListQueryBuilder(userID int64, filters ListFilters) *listQueryBuilder {
 	return builder
 }
 
+// newTrashQueryBuilder lists the user's trash, newest deletion first.
+func newTrashQueryBuilder(dialect storage.Dialect, userID int64, filters ListFilters) *listQueryBuilder {
+	builder := &listQueryBuilder{
+		dialect:    dialect,
+		filters:    filters,
+		columns:    trashedRecordColumns,
+		whereItems: []string{trashVisibleTo, "deleted_at IS NOT NULL"},
+		args:       []any{userID, userID},
+		sortClause: "ORDER BY deleted_at DESC",
+	}
+
+	builder.applyQuery()
+	builder.applyTag()
+
+	return builder
+}
+
 func (b *listQueryBuilder) selectQuery() (string, []any) {
 	var query strings.Builder
-	query.WriteString(`SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at FROM ppt_records`)
+	query.WriteString(`SELECT `)
+	query.WriteString(b.columns)
+	query.WriteString(` FROM ppt_records`)
 	query.WriteString(b.whereClause())
 	query.WriteRune(' ')
 	query.WriteString(b.sortClause)
@@ -105,7 +157,7 @@ func (b *listQueryBuilder) applyTag() {
 	if b.filters.Tag == "" {
 		return
 	}
-	b.whereItems = append(b.whereItems, "JSON_CONTAINS(IFNULL(tags, JSON_ARRAY()), JSON_QUOTE(?))")
+	b.whereItems = append(b.whereItems, b.dialect.JSONArrayContains("tags"))
 	b.args = append(b.args, b.filters.Tag)
 }
 
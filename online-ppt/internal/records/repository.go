@@ -6,43 +6,73 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"online-ppt/internal/storage"
 )
 
 // Repository provides persistence helpers for ppt_records.
 type Repository struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect storage.Dialect
 }
 
 // PptRecord represents a ppt_records row.
 type PptRecord struct {
-	ID            int64
-	UserID        int64
-	Name          string
-	Title         sql.NullString
-	Description   sql.NullString
-	GroupName     string
-	RelativePath  string
-	CanonicalPath string
-	Tags          []string
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	ID int64
+	// UserID is the owner of a personal deck or the creator of a workspace
+	// deck; it is 0 once a workspace deck's creator has been deleted.
+	UserID         int64
+	WorkspaceID    sql.NullInt64
+	Name           string
+	Title          sql.NullString
+	Description    sql.NullString
+	GroupName      string
+	RelativePath   string
+	CanonicalPath  string
+	Tags           []string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	CurrentVersion int
+	DeletedAt      sql.NullTime
+	TrashPath      sql.NullString
 }
 
-// ListFilters captures optional filters for listing.
+// ListFilters captures optional filters for listing. A non-zero WorkspaceID
+// restricts the listing to that workspace's decks and overrides Scope.
 type ListFilters struct {
-	Query  string
-	Tag    string
-	Sort   string
-	Limit  int
-	Offset int
+	Query       string
+	Tag         string
+	Sort        string
+	Scope       ListScope
+	WorkspaceID int64
+	Limit       int
+	Offset      int
 }
 
+// ListScope selects whose records a listing covers.
+type ListScope string
+
+const (
+	// ListScopeAll lists records the user owns or collaborates on.
+	ListScopeAll ListScope = "all"
+	// ListScopeOwned lists only records the user owns.
+	ListScopeOwned ListScope = "owned"
+	// ListScopeShared lists only records shared with the user as a collaborator.
+	ListScopeShared ListScope = "shared"
+)
+
 // NewRepository instantiates a Repository.
 func NewRepository(db *sql.DB) (*Repository, error) {
 	if db == nil {
 		return nil, fmt.Errorf("records repository requires db handle")
 	}
-	return &Repository{db: db}, nil
+	return &Repository{db: db, dialect: storage.MySQL}, nil
+}
+
+// WithDialect selects the SQL dialect of the database; the default is MySQL.
+func (r *Repository) WithDialect(dialect storage.Dialect) *Repository {
+	r.dialect = dialect
+	return r
 }
 
 // WithTx executes fn within a transaction boundary.
@@ -73,12 +103,13 @@ func (r *Repository) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) erro
 
 // RepositoryTx wraps sql.Tx for helper usage.
 type RepositoryTx struct {
-	tx *sql.Tx
+	tx      *sql.Tx
+	dialect storage.Dialect
 }
 
 // NewRepositoryTx converts a transaction into a RepositoryTx helper.
-func NewRepositoryTx(tx *sql.Tx) RepositoryTx {
-	return RepositoryTx{tx: tx}
+func NewRepositoryTx(tx *sql.Tx, dialect storage.Dialect) RepositoryTx {
+	return RepositoryTx{tx: tx, dialect: dialect}
 }
 
 // CreateWithinTx inserts a record inside an existing transaction.
@@ -88,9 +119,10 @@ func (rt RepositoryTx) CreateWithinTx(ctx context.Context, record PptRecord) (in
 		return 0, err
 	}
 
-	stmt := `INSERT INTO ppt_records (user_id, name, title, description, group_name, relative_path, canonical_path, tags) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	res, err := rt.tx.ExecContext(ctx, stmt,
+	stmt := `INSERT INTO ppt_records (user_id, workspace_id, name, title, description, group_name, relative_path, canonical_path, tags) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	id, err := rt.dialect.InsertID(ctx, rt.tx, stmt,
 		record.UserID,
+		record.WorkspaceID,
 		record.Name,
 		record.Title,
 		record.Description,
@@ -102,11 +134,6 @@ func (rt RepositoryTx) CreateWithinTx(ctx context.Context, record PptRecord) (in
 	if err != nil {
 		return 0, fmt.Errorf("insert ppt_record tx: %w", err)
 	}
-
-	id, err := res.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("derive record id: %w", err)
-	}
 	return id, nil
 }
 
@@ -117,9 +144,10 @@ func (r *Repository) Create(ctx context.Context, record PptRecord) (PptRecord, e
 		return PptRecord{}, err
 	}
 
-	stmt := `INSERT INTO ppt_records (user_id, name, title, description, group_name, relative_path, canonical_path, tags) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	res, err := r.db.ExecContext(ctx, stmt,
+	stmt := `INSERT INTO ppt_records (user_id, workspace_id, name, title, description, group_name, relative_path, canonical_path, tags) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	id, err := r.dialect.InsertID(ctx, r.db, stmt,
 		record.UserID,
+		record.WorkspaceID,
 		record.Name,
 		record.Title,
 		record.Description,
@@ -132,22 +160,25 @@ func (r *Repository) Create(ctx context.Context, record PptRecord) (PptRecord, e
 		return PptRecord{}, fmt.Errorf("insert ppt_record: %w", err)
 	}
 
-	id, err := res.LastInsertId()
-	if err != nil {
-		return PptRecord{}, fmt.Errorf("derive record id: %w", err)
-	}
-
 	return r.GetByID(ctx, record.UserID, id)
 }
 
-// GetByID fetches a single record for a user.
+// GetByID fetches a single live (not soft-deleted) record for a user.
 func (r *Repository) GetByID(ctx context.Context, userID, id int64) (PptRecord, error) {
-	stmt := `SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at FROM ppt_records WHERE user_id = ? AND id = ? LIMIT 1`
+	stmt := `SELECT ` + recordColumns + ` FROM ppt_records WHERE user_id = ? AND id = ? AND deleted_at IS NULL LIMIT 1`
 	row := r.db.QueryRowContext(ctx, stmt, userID, id)
 	return scanRecord(row)
 }
 
-// Delete removes a record by user and id.
+// GetLiveByID fetches a live record by id alone, for callers that have already
+// resolved access. Workspace decks whose creator was deleted have no user_id.
+func (r *Repository) GetLiveByID(ctx context.Context, id int64) (PptRecord, error) {
+	stmt := `SELECT ` + recordColumns + ` FROM ppt_records WHERE id = ? AND deleted_at IS NULL LIMIT 1`
+	row := r.db.QueryRowContext(ctx, stmt, id)
+	return scanRecord(row)
+}
+
+// Delete permanently removes a record by user and id.
 func (r *Repository) Delete(ctx context.Context, userID, id int64) error {
 	stmt := `DELETE FROM ppt_records WHERE user_id = ? AND id = ?`
 	res, err := r.db.ExecContext(ctx, stmt, userID, id)
@@ -166,14 +197,16 @@ func (r *Repository) Delete(ctx context.Context, userID, id int64) error {
 	return nil
 }
 
-// Update modifies editable fields of a record.
+// Update modifies editable fields of a record. The caller must have resolved
+// access already; the row is matched by id so workspace decks can be edited by
+// any member with the right role.
 func (r *Repository) Update(ctx context.Context, record PptRecord) (PptRecord, error) {
 	tagsJSON, err := marshalTags(record.Tags)
 	if err != nil {
 		return PptRecord{}, err
 	}
 
-	stmt := `UPDATE ppt_records SET name = ?, title = ?, description = ?, group_name = ?, relative_path = ?, canonical_path = ?, tags = ?, updated_at = NOW() WHERE user_id = ? AND id = ?`
+	stmt := `UPDATE ppt_records SET name = ?, title = ?, description = ?, group_name = ?, relative_path = ?, canonical_path = ?, tags = ?, updated_at = NOW() WHERE id = ? AND deleted_at IS NULL`
 	res, err := r.db.ExecContext(ctx, stmt,
 		record.Name,
 		record.Title,
@@ -182,7 +215,6 @@ func (r *Repository) Update(ctx context.Context, record PptRecord) (PptRecord, e
 		record.RelativePath,
 		record.CanonicalPath,
 		tagsJSON,
-		record.UserID,
 		record.ID,
 	)
 	if err != nil {
@@ -197,13 +229,13 @@ func (r *Repository) Update(ctx context.Context, record PptRecord) (PptRecord, e
 		return PptRecord{}, sql.ErrNoRows
 	}
 
-	return r.GetByID(ctx, record.UserID, record.ID)
+	return r.GetLiveByID(ctx, record.ID)
 }
 
 // List returns records filtered by the provided criteria.
 func (r *Repository) List(ctx context.Context, userID int64, filters ListFilters) ([]PptRecord, int, error) {
 	normalized := normalizeListFilters(filters)
-	builder := newListQueryBuilder(userID, normalized)
+	builder := newListQueryBuilder(r.dialect, userID, normalized)
 
 	countSQL, countArgs := builder.countQuery()
 	var total int
@@ -234,15 +266,216 @@ func (r *Repository) List(ctx context.Context, userID int64, filters ListFilters
 	return results, total, nil
 }
 
+// SoftDelete marks a live record as deleted and remembers where its deck was
+// parked. Like Update, it expects access to be checked by the caller.
+func (r *Repository) SoftDelete(ctx context.Context, id int64, deletedAt time.Time, trashPath string) error {
+	stmt := `UPDATE ppt_records SET deleted_at = ?, trash_path = ? WHERE id = ? AND deleted_at IS NULL`
+	trash := sql.NullString{String: trashPath, Valid: trashPath != ""}
+	res, err := r.db.ExecContext(ctx, stmt, deletedAt, trash, id)
+	if err != nil {
+		return fmt.Errorf("soft delete record: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Restore clears the soft-delete state of a record in the user's trash.
+func (r *Repository) Restore(ctx context.Context, userID, id int64) error {
+	stmt := `UPDATE ppt_records SET deleted_at = NULL, trash_path = NULL, updated_at = NOW() WHERE ` + trashVisibleTo + ` AND id = ? AND deleted_at IS NOT NULL`
+	res, err := r.db.ExecContext(ctx, stmt, userID, userID, id)
+	if err != nil {
+		return fmt.Errorf("restore record: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetDeletedByID fetches a soft-deleted record from the user's trash.
+func (r *Repository) GetDeletedByID(ctx context.Context, userID, id int64) (PptRecord, error) {
+	stmt := `SELECT ` + trashedRecordColumns + ` FROM ppt_records WHERE ` + trashVisibleTo + ` AND id = ? AND deleted_at IS NOT NULL LIMIT 1`
+	row := r.db.QueryRowContext(ctx, stmt, userID, userID, id)
+	return scanTrashedRecord(row)
+}
+
+// ListDeleted returns the records in a user's trash, most recently deleted first.
+func (r *Repository) ListDeleted(ctx context.Context, userID int64, filters ListFilters) ([]PptRecord, int, error) {
+	normalized := normalizeListFilters(filters)
+	builder := newTrashQueryBuilder(r.dialect, userID, normalized)
+
+	countSQL, countArgs := builder.countQuery()
+	var total int
+	if err := r.db.QueryRowContext(ctx, countSQL, countArgs...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count deleted records: %w", err)
+	}
+
+	selectSQL, selectArgs := builder.selectQuery()
+	rows, err := r.db.QueryContext(ctx, selectSQL, selectArgs...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list deleted records: %w", err)
+	}
+	defer rows.Close()
+
+	var results []PptRecord
+	for rows.Next() {
+		record, err := scanTrashedRecord(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		results = append(results, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+// ListExpiredDeleted returns up to limit records soft-deleted before cutoff, across all users.
+func (r *Repository) ListExpiredDeleted(ctx context.Context, cutoff time.Time, limit int) ([]PptRecord, error) {
+	stmt := `SELECT ` + trashedRecordColumns + ` FROM ppt_records WHERE deleted_at IS NOT NULL AND deleted_at < ? ORDER BY deleted_at ASC LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, stmt, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list expired records: %w", err)
+	}
+	defer rows.Close()
+
+	var results []PptRecord
+	for rows.Next() {
+		record, err := scanTrashedRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// DeckLocation pairs a live record with the UUID of the user or workspace
+// whose directory holds its deck.
+type DeckLocation struct {
+	Record    PptRecord
+	OwnerUUID string
+}
+
+// ListDeckLocations returns up to limit live records with an ID above afterID,
+// in ID order, across all users.
+func (r *Repository) ListDeckLocations(ctx context.Context, afterID int64, limit int) ([]DeckLocation, error) {
+	stmt := `SELECT ` + sharedRecordColumns + `, COALESCE(w.uuid, u.uuid) FROM ppt_records r LEFT JOIN user_accounts u ON u.id = r.user_id LEFT JOIN workspaces w ON w.id = r.workspace_id WHERE r.deleted_at IS NULL AND r.id > ? ORDER BY r.id ASC LIMIT ?`
+	rows, err := r.db.QueryContext(ctx, stmt, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list deck locations: %w", err)
+	}
+	defer rows.Close()
+
+	var results []DeckLocation
+	for rows.Next() {
+		var location DeckLocation
+		record, err := scanRecord(withExtraColumns(rows, &location.OwnerUUID))
+		if err != nil {
+			return nil, err
+		}
+		location.Record = record
+		results = append(results, location)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// UpdateDeckPaths rewrites the stored paths of a live record.
+func (r *Repository) UpdateDeckPaths(ctx context.Context, id int64, paths Paths) error {
+	stmt := `UPDATE ppt_records SET relative_path = ?, canonical_path = ?, updated_at = NOW() WHERE id = ? AND deleted_at IS NULL`
+	if _, err := r.db.ExecContext(ctx, stmt, paths.Relative, paths.Canonical, id); err != nil {
+		return fmt.Errorf("update deck paths: %w", err)
+	}
+	return nil
+}
+
+// Purge permanently removes a soft-deleted record.
+func (r *Repository) Purge(ctx context.Context, id int64) error {
+	stmt := `DELETE FROM ppt_records WHERE id = ? AND deleted_at IS NOT NULL`
+	if _, err := r.db.ExecContext(ctx, stmt, id); err != nil {
+		return fmt.Errorf("purge record: %w", err)
+	}
+	return nil
+}
+
+const (
+	recordColumns        = `id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at, current_version, workspace_id`
+	trashedRecordColumns = recordColumns + `, deleted_at, trash_path`
+)
+
+// trashVisibleTo matches the deleted records a user may see and restore: their
+// personal decks and decks of workspaces they administer, mirroring who may
+// delete them. It takes the user ID twice.
+const trashVisibleTo = `((user_id = ? AND workspace_id IS NULL) OR workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id = ? AND role = 'admin'))`
+
+func scanTrashedRecord(row interface{ Scan(dest ...any) error }) (PptRecord, error) {
+	var (
+		record     PptRecord
+		userID     sql.NullInt64
+		tagsString sql.NullString
+	)
+
+	if err := row.Scan(
+		&record.ID,
+		&userID,
+		&record.Name,
+		&record.Title,
+		&record.Description,
+		&record.GroupName,
+		&record.RelativePath,
+		&record.CanonicalPath,
+		&tagsString,
+		&record.CreatedAt,
+		&record.UpdatedAt,
+		&record.CurrentVersion,
+		&record.WorkspaceID,
+		&record.DeletedAt,
+		&record.TrashPath,
+	); err != nil {
+		return PptRecord{}, err
+	}
+	record.UserID = userID.Int64
+
+	if tagsString.Valid {
+		if err := json.Unmarshal([]byte(tagsString.String), &record.Tags); err != nil {
+			return PptRecord{}, fmt.Errorf("unmarshal tags: %w", err)
+		}
+	}
+
+	return record, nil
+}
+
 func scanRecord(row interface{ Scan(dest ...any) error }) (PptRecord, error) {
 	var (
 		record     PptRecord
+		userID     sql.NullInt64
 		tagsString sql.NullString
 	)
 
 	if err := row.Scan(
 		&record.ID,
-		&record.UserID,
+		&userID,
 		&record.Name,
 		&record.Title,
 		&record.Description,
@@ -252,9 +485,12 @@ func scanRecord(row interface{ Scan(dest ...any) error }) (PptRecord, error) {
 		&tagsString,
 		&record.CreatedAt,
 		&record.UpdatedAt,
+		&record.CurrentVersion,
+		&record.WorkspaceID,
 	); err != nil {
 		return PptRecord{}, err
 	}
+	record.UserID = userID.Int64
 
 	if tagsString.Valid {
 		if err := json.Unmarshal([]byte(tagsString.String), &record.Tags); err != nil {
@@ -9,10 +9,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/go-sql-driver/mysql"
-
 	"online-ppt/internal/storage"
 )
 
@@ -33,13 +32,21 @@ type Service struct {
 	repo              *Repository
 	presentationsRoot string
 	audit             *storage.AuditLogger
+	trashRoot         string
+	trashRetention    time.Duration
+	blobRoot          string
 	clockFn           func() time.Time
+	fsMu              sync.Mutex
+	shareFailures     FailureCounter
+	shareThrottle     ShareThrottle
 }
 
 // RecordView enriches a record with runtime metadata for presentation.
 type RecordView struct {
-	Record     PptRecord
-	PathStatus string
+	Record         PptRecord
+	PathStatus     string
+	CurrentVersion int
+	Role           Role
 }
 
 // ListResult bundles paginated record results.
@@ -56,10 +63,12 @@ type ListParams struct {
 	Filters ListFilters
 }
 
-// CreateParams captures the inputs for creating a PPT record.
+// CreateParams captures the inputs for creating a PPT record. A non-zero
+// WorkspaceID creates the deck under that workspace instead of the user.
 type CreateParams struct {
 	UserID      int64
 	UserUUID    string
+	WorkspaceID int64
 	Name        string
 	Title       string
 	Description string
@@ -99,6 +108,9 @@ func NewService(repo *Repository, presentationsRoot string, audit *storage.Audit
 		repo:              repo,
 		presentationsRoot: absRoot,
 		audit:             audit,
+		trashRoot:         filepath.Join(absRoot, defaultTrashDirName),
+		trashRetention:    DefaultTrashRetention,
+		blobRoot:          filepath.Join(absRoot, defaultVersionsDirName, "blobs"),
 		clockFn:           time.Now,
 	}, nil
 }
@@ -142,17 +154,26 @@ func (s *Service) CreateRecord(ctx context.Context, params CreateParams) (Record
 		return RecordView{}, err
 	}
 
-	paths, err := BuildPaths(s.presentationsRoot, params.UserUUID, groupName)
-	if err != nil {
-		s.audit.Log("records.create", map[string]any{
-			"status": "error",
-			"userId": params.UserID,
-			"reason": err.Error(),
-		})
-		return RecordView{}, err
+	record := PptRecord{
+		UserID:    params.UserID,
+		Name:      name,
+		GroupName: groupName,
+		Tags:      tags,
+	}
+	role := RoleOwner
+	ownerUUID := params.UserUUID
+	if params.WorkspaceID != 0 {
+		membership, err := s.authorizeWorkspace(ctx, params.UserID, params.WorkspaceID, "records.create", WorkspaceRoleMember)
+		if err != nil {
+			return RecordView{}, err
+		}
+		record.WorkspaceID = sql.NullInt64{Int64: membership.Workspace.ID, Valid: true}
+		role = membership.Role.deckRole()
+		ownerUUID = membership.Workspace.UUID
 	}
 
-	if err := EnsureDirectories(paths); err != nil {
+	paths, err := s.deckPaths(record, ownerUUID, groupName)
+	if err != nil {
 		s.audit.Log("records.create", map[string]any{
 			"status": "error",
 			"userId": params.UserID,
@@ -160,15 +181,9 @@ func (s *Service) CreateRecord(ctx context.Context, params CreateParams) (Record
 		})
 		return RecordView{}, err
 	}
+	record.RelativePath = paths.Relative
+	record.CanonicalPath = paths.Canonical
 
-	record := PptRecord{
-		UserID:        params.UserID,
-		Name:          name,
-		GroupName:     groupName,
-		RelativePath:  paths.Relative,
-		CanonicalPath: paths.Canonical,
-		Tags:          tags,
-	}
 	if title := strings.TrimSpace(params.Title); title != "" {
 		if len(title) > 255 {
 			s.audit.Log("records.create", map[string]any{
@@ -186,8 +201,7 @@ func (s *Service) CreateRecord(ctx context.Context, params CreateParams) (Record
 
 	created, err := s.repo.Create(ctx, record)
 	if err != nil {
-		var mysqlErr *mysql.MySQLError
-		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		if s.repo.dialect.IsUniqueViolation(err) {
 			s.audit.Log("records.create", map[string]any{
 				"status": "conflict",
 				"userId": params.UserID,
@@ -203,6 +217,24 @@ func (s *Service) CreateRecord(ctx context.Context, params CreateParams) (Record
 		return RecordView{}, err
 	}
 
+	// 目录在插入成功后才创建，重名失败不会留下占位目录挡住回收站恢复
+	if err := EnsureDirectories(paths); err != nil {
+		if rbErr := s.repo.Delete(ctx, created.UserID, created.ID); rbErr != nil {
+			s.audit.Log("records.create.rollback", map[string]any{
+				"status":   "error",
+				"userId":   params.UserID,
+				"recordId": created.ID,
+				"reason":   rbErr.Error(),
+			})
+		}
+		s.audit.Log("records.create", map[string]any{
+			"status": "error",
+			"userId": params.UserID,
+			"reason": err.Error(),
+		})
+		return RecordView{}, err
+	}
+
 	view, err := s.makeRecordView(created)
 	if err != nil {
 		s.audit.Log("records.create", map[string]any{
@@ -212,6 +244,7 @@ func (s *Service) CreateRecord(ctx context.Context, params CreateParams) (Record
 		})
 		return RecordView{}, err
 	}
+	view.Role = role
 
 	s.audit.Log("records.create", map[string]any{
 		"status":   "success",
@@ -221,7 +254,7 @@ func (s *Service) CreateRecord(ctx context.Context, params CreateParams) (Record
 	return view, nil
 }
 
-// ListRecords retrieves records for a user using optional filters.
+// ListRecords retrieves records the user owns or collaborates on using optional filters.
 func (s *Service) ListRecords(ctx context.Context, params ListParams) (ListResult, error) {
 	if params.UserID <= 0 {
 		s.audit.Log("records.list", map[string]any{
@@ -232,6 +265,16 @@ func (s *Service) ListRecords(ctx context.Context, params ListParams) (ListResul
 	}
 
 	normalized := normalizeListFilters(params.Filters)
+
+	var workspaceRole Role
+	if normalized.WorkspaceID != 0 {
+		membership, err := s.authorizeWorkspace(ctx, params.UserID, normalized.WorkspaceID, "records.list", WorkspaceRoleViewer)
+		if err != nil {
+			return ListResult{}, err
+		}
+		workspaceRole = membership.Role.deckRole()
+	}
+
 	records, total, err := s.repo.List(ctx, params.UserID, normalized)
 	if err != nil {
 		s.audit.Log("records.list", map[string]any{
@@ -249,18 +292,41 @@ func (s *Service) ListRecords(ctx context.Context, params ListParams) (ListResul
 		Offset:  normalized.Offset,
 	}
 
+	var sharedIDs []int64
+	if workspaceRole == "" {
+		for _, record := range records {
+			if record.UserID != params.UserID || record.WorkspaceID.Valid {
+				sharedIDs = append(sharedIDs, record.ID)
+			}
+		}
+	}
+	roles, err := s.repo.CollaboratorRoles(ctx, params.UserID, sharedIDs)
+	if err != nil {
+		s.audit.Log("records.list", map[string]any{
+			"status": "error",
+			"userId": params.UserID,
+			"reason": err.Error(),
+		})
+		return ListResult{}, err
+	}
+
 	for _, record := range records {
 		view, err := s.makeRecordView(record)
 		if err != nil {
 			return ListResult{}, err
 		}
+		if role, ok := roles[record.ID]; ok {
+			view.Role = role
+		} else if workspaceRole != "" {
+			view.Role = workspaceRole
+		}
 		result.Records = append(result.Records, view)
 	}
 
 	return result, nil
 }
 
-// GetRecord returns a single record for the user.
+// GetRecord returns a single record the user owns or collaborates on.
 func (s *Service) GetRecord(ctx context.Context, userID, recordID int64) (RecordView, error) {
 	if userID <= 0 {
 		s.audit.Log("records.get", map[string]any{
@@ -278,18 +344,10 @@ func (s *Service) GetRecord(ctx context.Context, userID, recordID int64) (Record
 		return RecordView{}, errInvalidRecordID
 	}
 
-	record, err := s.repo.GetByID(ctx, userID, recordID)
+	access, err := s.loadRecordAccess(ctx, userID, "", recordID, RoleViewer)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			s.audit.Log("records.get", map[string]any{
-				"status":   "not_found",
-				"userId":   userID,
-				"recordId": recordID,
-			})
-			return RecordView{}, ErrRecordNotFound
-		}
 		s.audit.Log("records.get", map[string]any{
-			"status":   "error",
+			"status":   accessFailureStatus(err),
 			"userId":   userID,
 			"recordId": recordID,
 			"reason":   err.Error(),
@@ -297,7 +355,12 @@ func (s *Service) GetRecord(ctx context.Context, userID, recordID int64) (Record
 		return RecordView{}, err
 	}
 
-	return s.makeRecordView(record)
+	view, err := s.makeRecordView(access.record)
+	if err != nil {
+		return RecordView{}, err
+	}
+	view.Role = access.role
+	return view, nil
 }
 
 // UpdateRecord applies partial updates to a record and returns the refreshed view.
@@ -318,18 +381,15 @@ func (s *Service) UpdateRecord(ctx context.Context, params UpdateParams) (Record
 		return RecordView{}, errInvalidRecordID
 	}
 
-	current, err := s.repo.GetByID(ctx, params.UserID, params.RecordID)
+	need := RoleEditor
+	if params.Name != nil {
+		// Renaming moves the deck directory, which only owners may do.
+		need = RoleOwner
+	}
+	access, err := s.loadRecordAccess(ctx, params.UserID, params.UserUUID, params.RecordID, need)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			s.audit.Log("records.update", map[string]any{
-				"status":   "not_found",
-				"userId":   params.UserID,
-				"recordId": params.RecordID,
-			})
-			return RecordView{}, ErrRecordNotFound
-		}
 		s.audit.Log("records.update", map[string]any{
-			"status":   "error",
+			"status":   accessFailureStatus(err),
 			"userId":   params.UserID,
 			"recordId": params.RecordID,
 			"reason":   err.Error(),
@@ -337,9 +397,10 @@ func (s *Service) UpdateRecord(ctx context.Context, params UpdateParams) (Record
 		return RecordView{}, err
 	}
 
+	current := access.record
 	updated := current
 
-	if err := s.applyNameUpdate(&updated, params.UserUUID, params.Name); err != nil {
+	if err := s.applyNameUpdate(&updated, access.ownerUUID, params.Name); err != nil {
 		s.audit.Log("records.update", map[string]any{
 			"status":   "validation_failed",
 			"userId":   params.UserID,
@@ -371,8 +432,41 @@ func (s *Service) UpdateRecord(ctx context.Context, params UpdateParams) (Record
 		return RecordView{}, err
 	}
 
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	moved, err := s.relocateDeck(current, updated)
+	if err != nil {
+		if errors.Is(err, ErrDeckDirectoryExists) {
+			s.audit.Log("records.update", map[string]any{
+				"status":   "conflict",
+				"userId":   params.UserID,
+				"recordId": params.RecordID,
+				"reason":   err.Error(),
+			})
+			return RecordView{}, err
+		}
+		s.audit.Log("records.update", map[string]any{
+			"status":   "error",
+			"userId":   params.UserID,
+			"recordId": params.RecordID,
+			"reason":   err.Error(),
+		})
+		return RecordView{}, err
+	}
+
 	saved, err := s.repo.Update(ctx, updated)
 	if err != nil {
+		if moved {
+			if rbErr := s.rollbackRelocation(current, updated); rbErr != nil {
+				s.audit.Log("records.update.rollback", map[string]any{
+					"status":   "error",
+					"userId":   params.UserID,
+					"recordId": params.RecordID,
+					"reason":   rbErr.Error(),
+				})
+			}
+		}
 		if errors.Is(err, sql.ErrNoRows) {
 			s.audit.Log("records.update", map[string]any{
 				"status":   "not_found",
@@ -381,8 +475,7 @@ func (s *Service) UpdateRecord(ctx context.Context, params UpdateParams) (Record
 			})
 			return RecordView{}, ErrRecordNotFound
 		}
-		var mysqlErr *mysql.MySQLError
-		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		if s.repo.dialect.IsUniqueViolation(err) {
 			s.audit.Log("records.update", map[string]any{
 				"status":   "conflict",
 				"userId":   params.UserID,
@@ -410,6 +503,7 @@ func (s *Service) UpdateRecord(ctx context.Context, params UpdateParams) (Record
 		})
 		return RecordView{}, err
 	}
+	view.Role = access.role
 
 	s.audit.Log("records.update", map[string]any{
 		"status":   "success",
@@ -419,7 +513,8 @@ func (s *Service) UpdateRecord(ctx context.Context, params UpdateParams) (Record
 	return view, nil
 }
 
-// DeleteRecord removes the record owned by the user.
+// DeleteRecord soft-deletes a record the user owns or co-owns and parks its
+// deck directory in the deck owner's trash until the retention period elapses.
 func (s *Service) DeleteRecord(ctx context.Context, userID, recordID int64) error {
 	if userID <= 0 {
 		s.audit.Log("records.delete", map[string]any{
@@ -437,7 +532,44 @@ func (s *Service) DeleteRecord(ctx context.Context, userID, recordID int64) erro
 		return errInvalidRecordID
 	}
 
-	if err := s.repo.Delete(ctx, userID, recordID); err != nil {
+	access, err := s.loadRecordAccess(ctx, userID, "", recordID, RoleOwner)
+	if err != nil {
+		s.audit.Log("records.delete", map[string]any{
+			"status":   accessFailureStatus(err),
+			"userId":   userID,
+			"recordId": recordID,
+			"reason":   err.Error(),
+		})
+		return err
+	}
+	record := access.record
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	deletedAt := s.clockFn().UTC()
+	trashPath, err := s.trashDeck(record, deletedAt)
+	if err != nil {
+		s.audit.Log("records.delete", map[string]any{
+			"status":   "error",
+			"userId":   userID,
+			"recordId": recordID,
+			"reason":   err.Error(),
+		})
+		return err
+	}
+
+	if err := s.repo.SoftDelete(ctx, recordID, deletedAt, trashPath); err != nil {
+		if trashPath != "" {
+			if rbErr := s.restoreFromTrash(record, trashPath); rbErr != nil {
+				s.audit.Log("records.delete.rollback", map[string]any{
+					"status":   "error",
+					"userId":   userID,
+					"recordId": recordID,
+					"reason":   rbErr.Error(),
+				})
+			}
+		}
 		if errors.Is(err, sql.ErrNoRows) {
 			s.audit.Log("records.delete", map[string]any{
 				"status":   "not_found",
@@ -456,9 +588,10 @@ func (s *Service) DeleteRecord(ctx context.Context, userID, recordID int64) erro
 	}
 
 	s.audit.Log("records.delete", map[string]any{
-		"status":   "success",
-		"userId":   userID,
-		"recordId": recordID,
+		"status":    "success",
+		"userId":    userID,
+		"recordId":  recordID,
+		"trashPath": trashPath,
 	})
 	return nil
 }
@@ -468,7 +601,7 @@ func (s *Service) makeRecordView(record PptRecord) (RecordView, error) {
 	if err != nil {
 		return RecordView{}, err
 	}
-	return RecordView{Record: record, PathStatus: status}, nil
+	return RecordView{Record: record, PathStatus: status, CurrentVersion: record.CurrentVersion, Role: RoleOwner}, nil
 }
 
 func (s *Service) computePathStatus(canonicalPath string) (string, error) {
@@ -499,7 +632,7 @@ func (s *Service) computePathStatus(canonicalPath string) (string, error) {
 	return "valid", nil
 }
 
-func (s *Service) applyNameUpdate(record *PptRecord, userUUID string, namePtr *string) error {
+func (s *Service) applyNameUpdate(record *PptRecord, ownerUUID string, namePtr *string) error {
 	if namePtr == nil {
 		return nil
 	}
@@ -511,20 +644,17 @@ func (s *Service) applyNameUpdate(record *PptRecord, userUUID string, namePtr *s
 	if !groupNamePattern.MatchString(name) {
 		return ErrInvalidRecordName
 	}
-	if userUUID == "" {
+	if ownerUUID == "" {
 		return errMissingUserUUID
 	}
 
 	record.Name = name
 	record.GroupName = strings.ToLower(name)
 
-	paths, err := BuildPaths(s.presentationsRoot, userUUID, record.GroupName)
+	paths, err := s.deckPaths(*record, ownerUUID, record.GroupName)
 	if err != nil {
 		return err
 	}
-	if err := EnsureDirectories(paths); err != nil {
-		return err
-	}
 	record.RelativePath = paths.Relative
 	record.CanonicalPath = paths.Canonical
 	return nil
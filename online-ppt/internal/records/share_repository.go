@@ -0,0 +1,142 @@
+package records
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PptShare represents a ppt_shares row. UserID is the deck owner, whose
+// directory the link serves.
+type PptShare struct {
+	ID           int64
+	RecordID     int64
+	UserID       int64
+	TokenHash    string
+	PasswordHash sql.NullString
+	ExpiresAt    time.Time
+	ViewCount    int64
+	LastViewedAt sql.NullTime
+	RevokedAt    sql.NullTime
+	CreatedAt    time.Time
+}
+
+const shareColumns = `id, record_id, user_id, token_hash, password_hash, expires_at, view_count, last_viewed_at, revoked_at, created_at`
+
+// CreateShare inserts a share link row.
+func (r *Repository) CreateShare(ctx context.Context, share PptShare) (PptShare, error) {
+	stmt := `INSERT INTO ppt_shares (record_id, user_id, token_hash, password_hash, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	id, err := r.dialect.InsertID(ctx, r.db, stmt,
+		share.RecordID,
+		share.UserID,
+		share.TokenHash,
+		share.PasswordHash,
+		share.ExpiresAt,
+		share.CreatedAt,
+	)
+	if err != nil {
+		return PptShare{}, fmt.Errorf("insert share: %w", err)
+	}
+	share.ID = id
+	return share, nil
+}
+
+// ListShares returns every share link of a record, newest first.
+func (r *Repository) ListShares(ctx context.Context, recordID int64) ([]PptShare, error) {
+	stmt := `SELECT ` + shareColumns + ` FROM ppt_shares WHERE record_id = ? ORDER BY created_at DESC, id DESC`
+	rows, err := r.db.QueryContext(ctx, stmt, recordID)
+	if err != nil {
+		return nil, fmt.Errorf("list shares: %w", err)
+	}
+	defer rows.Close()
+
+	var results []PptShare
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, share)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetShareByTokenHash resolves a live share link together with the UUID of
+// the user or workspace whose directory holds the deck.
+func (r *Repository) GetShareByTokenHash(ctx context.Context, tokenHash string) (PptShare, string, error) {
+	stmt := `SELECT s.id, s.record_id, s.user_id, s.token_hash, s.password_hash, s.expires_at, s.view_count, s.last_viewed_at, s.revoked_at, s.created_at, COALESCE(w.uuid, u.uuid) ` +
+		`FROM ppt_shares s JOIN user_accounts u ON u.id = s.user_id ` +
+		`JOIN ppt_records r ON r.id = s.record_id LEFT JOIN workspaces w ON w.id = r.workspace_id ` +
+		`WHERE s.token_hash = ? AND s.revoked_at IS NULL LIMIT 1`
+
+	var (
+		share     PptShare
+		ownerUUID string
+	)
+	err := r.db.QueryRowContext(ctx, stmt, tokenHash).Scan(
+		&share.ID,
+		&share.RecordID,
+		&share.UserID,
+		&share.TokenHash,
+		&share.PasswordHash,
+		&share.ExpiresAt,
+		&share.ViewCount,
+		&share.LastViewedAt,
+		&share.RevokedAt,
+		&share.CreatedAt,
+		&ownerUUID,
+	)
+	if err != nil {
+		return PptShare{}, "", err
+	}
+	return share, ownerUUID, nil
+}
+
+// RevokeShare marks a share link of a record as revoked.
+func (r *Repository) RevokeShare(ctx context.Context, recordID, shareID int64, revokedAt time.Time) error {
+	stmt := `UPDATE ppt_shares SET revoked_at = ? WHERE id = ? AND record_id = ? AND revoked_at IS NULL`
+	res, err := r.db.ExecContext(ctx, stmt, revokedAt, shareID, recordID)
+	if err != nil {
+		return fmt.Errorf("revoke share: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("revoke share rows: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RecordShareView bumps the view counter of a share link.
+func (r *Repository) RecordShareView(ctx context.Context, shareID int64, viewedAt time.Time) error {
+	stmt := `UPDATE ppt_shares SET view_count = view_count + 1, last_viewed_at = ? WHERE id = ?`
+	if _, err := r.db.ExecContext(ctx, stmt, viewedAt, shareID); err != nil {
+		return fmt.Errorf("record share view: %w", err)
+	}
+	return nil
+}
+
+func scanShare(row interface{ Scan(dest ...any) error }) (PptShare, error) {
+	var share PptShare
+	if err := row.Scan(
+		&share.ID,
+		&share.RecordID,
+		&share.UserID,
+		&share.TokenHash,
+		&share.PasswordHash,
+		&share.ExpiresAt,
+		&share.ViewCount,
+		&share.LastViewedAt,
+		&share.RevokedAt,
+		&share.CreatedAt,
+	); err != nil {
+		return PptShare{}, err
+	}
+	return share, nil
+}
@@ -0,0 +1,142 @@
+package records
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	shareFailureKey   = "share:%d"
+	shareIPFailureKey = "share_ip:%s"
+)
+
+// ErrShareThrottled indicates too many wrong passwords were tried on a share
+// link, or from one client, and further attempts must wait.
+var ErrShareThrottled = errors.New("too many share password attempts")
+
+// FailureCounter keeps expiring failure counts. cache.Service satisfies it, so
+// share links reuse the Redis counters of the login lockout.
+type FailureCounter interface {
+	IncrementLoginFailures(ctx context.Context, key string, window time.Duration) (int, error)
+	GetLoginFailures(ctx context.Context, key string) (int, error)
+	ResetLoginFailures(ctx context.Context, key string) error
+}
+
+// ShareThrottle tunes how wrong share link passwords are limited. Failures are
+// counted per share link and per client IP.
+type ShareThrottle struct {
+	// PerShare is the number of failures after which a share link refuses
+	// further password attempts until Window has passed.
+	PerShare int
+	// PerIP bounds the failures one client IP may make across all share links.
+	PerIP int
+	// Window is how long failures are remembered, counted from the first one.
+	Window time.Duration
+}
+
+var defaultShareThrottle = ShareThrottle{
+	PerShare: 10,
+	PerIP:    30,
+	Window:   15 * time.Minute,
+}
+
+// ConfigureShareThrottle turns on password throttling for share links, keeping
+// the counters in counter. Zero fields of policy keep their defaults.
+func (s *Service) ConfigureShareThrottle(counter FailureCounter, policy ShareThrottle) error {
+	if counter == nil {
+		return fmt.Errorf("share throttle requires a failure counter")
+	}
+	if policy.PerShare < 0 || policy.PerIP < 0 || policy.Window < 0 {
+		return fmt.Errorf("share throttle settings must not be negative")
+	}
+
+	merged := defaultShareThrottle
+	if policy.PerShare > 0 {
+		merged.PerShare = policy.PerShare
+	}
+	if policy.PerIP > 0 {
+		merged.PerIP = policy.PerIP
+	}
+	if policy.Window > 0 {
+		merged.Window = policy.Window
+	}
+	s.shareFailures = counter
+	s.shareThrottle = merged
+	return nil
+}
+
+// shareThrottleKeys returns the failure counter keys of a password attempt
+// with their limits, the share key first.
+func (s *Service) shareThrottleKeys(shareID int64, clientIP string) ([]string, []int) {
+	keys := []string{fmt.Sprintf(shareFailureKey, shareID)}
+	limits := []int{s.shareThrottle.PerShare}
+	if clientIP != "" {
+		keys = append(keys, fmt.Sprintf(shareIPFailureKey, clientIP))
+		limits = append(limits, s.shareThrottle.PerIP)
+	}
+	return keys, limits
+}
+
+// checkShareThrottle rejects a password attempt once either counter reached
+// its limit. Without a counter every attempt is allowed.
+func (s *Service) checkShareThrottle(ctx context.Context, share PptShare, clientIP string) error {
+	if s.shareFailures == nil {
+		return nil
+	}
+	keys, limits := s.shareThrottleKeys(share.ID, clientIP)
+	for i, key := range keys {
+		count, err := s.shareFailures.GetLoginFailures(ctx, key)
+		if err != nil {
+			s.audit.Log("records.shares.open", map[string]any{
+				"status":  "error",
+				"shareId": share.ID,
+				"reason":  err.Error(),
+			})
+			return err
+		}
+		if count >= limits[i] {
+			s.audit.Log("records.shares.open", map[string]any{
+				"status":   "throttled",
+				"recordId": share.RecordID,
+				"shareId":  share.ID,
+				"ip":       clientIP,
+			})
+			return ErrShareThrottled
+		}
+	}
+	return nil
+}
+
+// recordShareFailure counts a wrong password against the share and the client.
+func (s *Service) recordShareFailure(ctx context.Context, share PptShare, clientIP string) {
+	if s.shareFailures == nil {
+		return
+	}
+	keys, _ := s.shareThrottleKeys(share.ID, clientIP)
+	for _, key := range keys {
+		if _, err := s.shareFailures.IncrementLoginFailures(ctx, key, s.shareThrottle.Window); err != nil {
+			s.audit.Log("records.shares.record_failure", map[string]any{
+				"status":  "error",
+				"shareId": share.ID,
+				"error":   err.Error(),
+			})
+		}
+	}
+}
+
+// clearShareFailures forgets the failures counted against a share once its
+// password was given correctly. The client IP counter is left to expire.
+func (s *Service) clearShareFailures(ctx context.Context, share PptShare) {
+	if s.shareFailures == nil {
+		return
+	}
+	if err := s.shareFailures.ResetLoginFailures(ctx, fmt.Sprintf(shareFailureKey, share.ID)); err != nil {
+		s.audit.Log("records.shares.clear_failures", map[string]any{
+			"status":  "error",
+			"shareId": share.ID,
+			"error":   err.Error(),
+		})
+	}
+}
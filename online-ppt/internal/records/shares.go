@@ -0,0 +1,350 @@
+package records
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"online-ppt/internal/auth"
+)
+
+const (
+	// DefaultShareTTL applies when a share link is created without an expiry.
+	DefaultShareTTL = 7 * 24 * time.Hour
+	// MaxShareTTL bounds how far in the future a share link may expire.
+	MaxShareTTL = 365 * 24 * time.Hour
+
+	shareTokenBytes     = 32
+	maxShareTokenLength = 64
+	maxSharePassword    = 128
+)
+
+var (
+	// ErrShareNotFound indicates the share link is unknown, revoked or points at a deleted deck.
+	ErrShareNotFound = errors.New("share link not found")
+	// ErrShareExpired indicates the share link is past its expiry.
+	ErrShareExpired = errors.New("share link expired")
+	// ErrSharePasswordRequired indicates the share link is password protected and none was sent.
+	ErrSharePasswordRequired = errors.New("share link requires a password")
+	// ErrInvalidSharePassword indicates the supplied share password does not match.
+	ErrInvalidSharePassword = errors.New("share password is incorrect")
+	// ErrInvalidShareParams reports an out-of-range expiry or an oversized password.
+	ErrInvalidShareParams = errors.New("invalid share parameters")
+)
+
+// ShareParams captures the options for a new share link.
+type ShareParams struct {
+	ExpiresAt *time.Time
+	Password  string
+}
+
+// ShareView describes a share link. Token is only populated on creation since
+// the server keeps nothing but its digest.
+type ShareView struct {
+	Share   PptShare
+	Token   string
+	Expired bool
+}
+
+// PublicDeck is the read-only view of a shared deck.
+type PublicDeck struct {
+	Config    SlidesConfig
+	Slides    []PublicSlide
+	ExpiresAt time.Time
+}
+
+// PublicSlide carries the HTML of one visible slide.
+type PublicSlide struct {
+	File    string
+	Content string
+}
+
+// CreateShare issues a share link for a record the caller owns or co-owns.
+func (s *Service) CreateShare(ctx context.Context, target SlideTarget, params ShareParams) (ShareView, error) {
+	now := s.clockFn().UTC()
+	expiresAt := now.Add(DefaultShareTTL)
+	if params.ExpiresAt != nil {
+		expiresAt = params.ExpiresAt.UTC()
+	}
+	var invalid error
+	switch {
+	case !expiresAt.After(now):
+		invalid = fmt.Errorf("expiry must be in the future: %w", ErrInvalidShareParams)
+	case expiresAt.Sub(now) > MaxShareTTL:
+		invalid = fmt.Errorf("expiry must be within %d days: %w", int(MaxShareTTL/(24*time.Hour)), ErrInvalidShareParams)
+	case len(params.Password) > maxSharePassword:
+		invalid = fmt.Errorf("password must not exceed %d characters: %w", maxSharePassword, ErrInvalidShareParams)
+	}
+	if invalid != nil {
+		s.audit.Log("records.shares.create", map[string]any{
+			"status":   "validation_failed",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   invalid.Error(),
+		})
+		return ShareView{}, invalid
+	}
+
+	record, _, err := s.resolveSlidesDir(ctx, target, "records.shares.create", RoleOwner)
+	if err != nil {
+		return ShareView{}, err
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		return ShareView{}, err
+	}
+
+	share := PptShare{
+		RecordID:  record.ID,
+		UserID:    record.UserID,
+		TokenHash: hashShareToken(token),
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if params.Password != "" {
+		hash, err := auth.HashPassword(params.Password)
+		if err != nil {
+			return ShareView{}, fmt.Errorf("hash share password: %w", err)
+		}
+		share.PasswordHash = sql.NullString{String: hash, Valid: true}
+	}
+
+	created, err := s.repo.CreateShare(ctx, share)
+	if err != nil {
+		s.audit.Log("records.shares.create", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return ShareView{}, err
+	}
+
+	s.audit.Log("records.shares.create", map[string]any{
+		"status":    "success",
+		"userId":    target.UserID,
+		"recordId":  target.RecordID,
+		"shareId":   created.ID,
+		"protected": created.PasswordHash.Valid,
+	})
+	return ShareView{Share: created, Token: token}, nil
+}
+
+// ListShares returns every share link of a record the caller owns or co-owns,
+// including revoked and expired ones.
+func (s *Service) ListShares(ctx context.Context, target SlideTarget) ([]ShareView, error) {
+	record, _, err := s.resolveSlidesDir(ctx, target, "records.shares.list", RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := s.repo.ListShares(ctx, record.ID)
+	if err != nil {
+		s.audit.Log("records.shares.list", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return nil, err
+	}
+
+	now := s.clockFn()
+	views := make([]ShareView, 0, len(shares))
+	for _, share := range shares {
+		views = append(views, ShareView{Share: share, Expired: !now.Before(share.ExpiresAt)})
+	}
+	return views, nil
+}
+
+// RevokeShare disables a share link of a record the caller owns or co-owns.
+func (s *Service) RevokeShare(ctx context.Context, target SlideTarget, shareID int64) error {
+	record, _, err := s.resolveSlidesDir(ctx, target, "records.shares.revoke", RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.RevokeShare(ctx, record.ID, shareID, s.clockFn().UTC()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("records.shares.revoke", map[string]any{
+				"status":   "not_found",
+				"userId":   target.UserID,
+				"recordId": target.RecordID,
+				"shareId":  shareID,
+			})
+			return ErrShareNotFound
+		}
+		s.audit.Log("records.shares.revoke", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"shareId":  shareID,
+			"reason":   err.Error(),
+		})
+		return err
+	}
+
+	s.audit.Log("records.shares.revoke", map[string]any{
+		"status":   "success",
+		"userId":   target.UserID,
+		"recordId": target.RecordID,
+		"shareId":  shareID,
+	})
+	return nil
+}
+
+// OpenShare resolves a share token for an anonymous viewer and returns the
+// deck's visible slides. Speaker notes and hidden slides are left out. Wrong
+// passwords are throttled per share and per clientIP when a ShareThrottle is
+// configured.
+func (s *Service) OpenShare(ctx context.Context, token, password, clientIP string) (PublicDeck, error) {
+	if token == "" || len(token) > maxShareTokenLength {
+		return PublicDeck{}, ErrShareNotFound
+	}
+
+	share, ownerUUID, err := s.repo.GetShareByTokenHash(ctx, hashShareToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("records.shares.open", map[string]any{
+				"status": "not_found",
+			})
+			return PublicDeck{}, ErrShareNotFound
+		}
+		s.audit.Log("records.shares.open", map[string]any{
+			"status": "error",
+			"reason": err.Error(),
+		})
+		return PublicDeck{}, err
+	}
+
+	now := s.clockFn()
+	if !now.Before(share.ExpiresAt) {
+		s.audit.Log("records.shares.open", map[string]any{
+			"status":   "expired",
+			"recordId": share.RecordID,
+			"shareId":  share.ID,
+		})
+		return PublicDeck{}, ErrShareExpired
+	}
+
+	if share.PasswordHash.Valid {
+		if password == "" {
+			return PublicDeck{}, ErrSharePasswordRequired
+		}
+		// 限流判断先于密码校验，达到上限后不再给出密码是否正确的信号
+		if err := s.checkShareThrottle(ctx, share, clientIP); err != nil {
+			return PublicDeck{}, err
+		}
+		ok, err := auth.VerifyPassword(share.PasswordHash.String, password)
+		if err != nil || !ok {
+			s.recordShareFailure(ctx, share, clientIP)
+			s.audit.Log("records.shares.open", map[string]any{
+				"status":   "invalid_password",
+				"recordId": share.RecordID,
+				"shareId":  share.ID,
+			})
+			return PublicDeck{}, ErrInvalidSharePassword
+		}
+		s.clearShareFailures(ctx, share)
+	}
+
+	record, err := s.repo.GetLiveByID(ctx, share.RecordID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("records.shares.open", map[string]any{
+				"status":   "not_found",
+				"recordId": share.RecordID,
+				"shareId":  share.ID,
+			})
+			return PublicDeck{}, ErrShareNotFound
+		}
+		return PublicDeck{}, err
+	}
+
+	paths, err := s.deckPaths(record, ownerUUID, record.GroupName)
+	if err != nil {
+		return PublicDeck{}, err
+	}
+
+	deck, err := s.readPublicDeck(record, paths)
+	if err != nil {
+		s.audit.Log("records.shares.open", map[string]any{
+			"status":   "error",
+			"recordId": share.RecordID,
+			"shareId":  share.ID,
+			"reason":   err.Error(),
+		})
+		return PublicDeck{}, err
+	}
+	deck.ExpiresAt = share.ExpiresAt
+
+	if err := s.repo.RecordShareView(ctx, share.ID, now.UTC()); err != nil {
+		s.audit.Log("records.shares.view_count", map[string]any{
+			"status":  "error",
+			"shareId": share.ID,
+			"reason":  err.Error(),
+		})
+	}
+
+	s.audit.Log("records.shares.open", map[string]any{
+		"status":   "success",
+		"recordId": share.RecordID,
+		"shareId":  share.ID,
+	})
+	return deck, nil
+}
+
+func (s *Service) readPublicDeck(record PptRecord, paths Paths) (PublicDeck, error) {
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	cfg, err := loadSyncedConfig(record, paths)
+	if err != nil {
+		return PublicDeck{}, err
+	}
+
+	visible := make([]SlideEntry, 0, len(cfg.Slides))
+	slides := make([]PublicSlide, 0, len(cfg.Slides))
+	for _, entry := range cfg.Slides {
+		if !entry.Visible {
+			continue
+		}
+		path, err := SlidePath(paths, entry.File)
+		if err != nil {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return PublicDeck{}, fmt.Errorf("read slide: %w", err)
+		}
+		entry.Notes = ""
+		visible = append(visible, entry)
+		slides = append(slides, PublicSlide{File: entry.File, Content: string(content)})
+	}
+	cfg.Slides = visible
+
+	return PublicDeck{Config: cfg, Slides: slides}, nil
+}
+
+func generateShareToken() (string, error) {
+	buf := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate share token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
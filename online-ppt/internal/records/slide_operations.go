@@ -0,0 +1,258 @@
+package records
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SlideAction names an operation applied to slide config entries.
+type SlideAction string
+
+const (
+	// SlideActionMove relocates the selected slides to Index, keeping their relative order.
+	SlideActionMove SlideAction = "move"
+	// SlideActionDuplicate copies each selected slide into a fresh slide-N.html placed right after it.
+	SlideActionDuplicate SlideAction = "duplicate"
+	// SlideActionHide soft-deletes slides by marking them invisible; files stay on disk.
+	SlideActionHide SlideAction = "hide"
+	// SlideActionUnhide makes previously hidden slides visible again.
+	SlideActionUnhide SlideAction = "unhide"
+)
+
+const maxBatchSlides = 100
+
+var (
+	// ErrSlideEntryNotFound indicates a slide id is not present in slides.config.json.
+	ErrSlideEntryNotFound = errors.New("slide entry not found")
+	// ErrInvalidSlideOperation reports a malformed slide operation request.
+	ErrInvalidSlideOperation = errors.New("invalid slide operation")
+)
+
+// SlideBatch describes one action applied atomically to a set of slide ids.
+type SlideBatch struct {
+	Action   SlideAction
+	SlideIDs []string
+	Index    int
+}
+
+// MoveSlide moves a single slide to the given zero-based position.
+func (s *Service) MoveSlide(ctx context.Context, target SlideTarget, slideID string, index int) (SlidesConfig, error) {
+	return s.ApplySlideBatch(ctx, target, SlideBatch{Action: SlideActionMove, SlideIDs: []string{slideID}, Index: index})
+}
+
+// DuplicateSlide copies a slide's file and inserts the copy after the original.
+func (s *Service) DuplicateSlide(ctx context.Context, target SlideTarget, slideID string) (SlidesConfig, error) {
+	return s.ApplySlideBatch(ctx, target, SlideBatch{Action: SlideActionDuplicate, SlideIDs: []string{slideID}})
+}
+
+// SetSlideVisibility hides or unhides a single slide.
+func (s *Service) SetSlideVisibility(ctx context.Context, target SlideTarget, slideID string, visible bool) (SlidesConfig, error) {
+	action := SlideActionHide
+	if visible {
+		action = SlideActionUnhide
+	}
+	return s.ApplySlideBatch(ctx, target, SlideBatch{Action: action, SlideIDs: []string{slideID}})
+}
+
+// ApplySlideBatch applies batch to the deck's config in one step. Either every
+// selected slide is updated and the config is written, or nothing changes on disk.
+func (s *Service) ApplySlideBatch(ctx context.Context, target SlideTarget, batch SlideBatch) (SlidesConfig, error) {
+	event := "records.slides." + string(batch.Action)
+	if err := validateSlideBatch(batch); err != nil {
+		s.audit.Log("records.slides.batch", map[string]any{
+			"status":   "validation_failed",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return SlidesConfig{}, err
+	}
+
+	record, paths, err := s.resolveSlidesDir(ctx, target, event, RoleEditor)
+	if err != nil {
+		return SlidesConfig{}, err
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	cfg, err := loadSyncedConfig(record, paths)
+	if err != nil {
+		return SlidesConfig{}, err
+	}
+
+	positions := make(map[string]int, len(cfg.Slides))
+	for i, slide := range cfg.Slides {
+		positions[slide.ID] = i
+	}
+	for _, id := range batch.SlideIDs {
+		if _, ok := positions[id]; !ok {
+			s.audit.Log(event, map[string]any{
+				"status":   "not_found",
+				"userId":   target.UserID,
+				"recordId": target.RecordID,
+				"slideId":  id,
+			})
+			return SlidesConfig{}, fmt.Errorf("%s: %w", id, ErrSlideEntryNotFound)
+		}
+	}
+
+	var created []string
+	switch batch.Action {
+	case SlideActionHide, SlideActionUnhide:
+		visible := batch.Action == SlideActionUnhide
+		for _, id := range batch.SlideIDs {
+			cfg.Slides[positions[id]].Visible = visible
+		}
+	case SlideActionMove:
+		cfg.Slides = moveSlideEntries(cfg.Slides, batch.SlideIDs, batch.Index)
+	case SlideActionDuplicate:
+		cfg.Slides, created, err = duplicateSlideEntries(paths, cfg.Slides, batch.SlideIDs)
+		if err != nil {
+			removeFiles(created)
+			s.audit.Log(event, map[string]any{
+				"status":   "error",
+				"userId":   target.UserID,
+				"recordId": target.RecordID,
+				"reason":   err.Error(),
+			})
+			return SlidesConfig{}, err
+		}
+	}
+
+	saved, err := s.storeConfig(target, paths, cfg, event)
+	if err != nil {
+		removeFiles(created)
+		return SlidesConfig{}, err
+	}
+	return saved, nil
+}
+
+func validateSlideBatch(batch SlideBatch) error {
+	switch batch.Action {
+	case SlideActionMove, SlideActionDuplicate, SlideActionHide, SlideActionUnhide:
+	default:
+		return fmt.Errorf("unknown action %q: %w", batch.Action, ErrInvalidSlideOperation)
+	}
+	if len(batch.SlideIDs) == 0 {
+		return fmt.Errorf("slide ids required: %w", ErrInvalidSlideOperation)
+	}
+	if len(batch.SlideIDs) > maxBatchSlides {
+		return fmt.Errorf("too many slide ids; maximum is %d: %w", maxBatchSlides, ErrInvalidSlideOperation)
+	}
+	seen := make(map[string]struct{}, len(batch.SlideIDs))
+	for _, id := range batch.SlideIDs {
+		if id == "" {
+			return fmt.Errorf("slide id required: %w", ErrInvalidSlideOperation)
+		}
+		if _, dup := seen[id]; dup {
+			return fmt.Errorf("slide id %q is duplicated: %w", id, ErrInvalidSlideOperation)
+		}
+		seen[id] = struct{}{}
+	}
+	if batch.Action == SlideActionMove && batch.Index < 0 {
+		return fmt.Errorf("index must not be negative: %w", ErrInvalidSlideOperation)
+	}
+	return nil
+}
+
+// moveSlideEntries removes the selected entries and reinserts them as a block
+// at index within the remaining slides, clamping index to the end.
+func moveSlideEntries(slides []SlideEntry, ids []string, index int) []SlideEntry {
+	selected := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		selected[id] = struct{}{}
+	}
+
+	moving := make([]SlideEntry, 0, len(ids))
+	byID := make(map[string]SlideEntry, len(ids))
+	rest := make([]SlideEntry, 0, len(slides))
+	for _, slide := range slides {
+		if _, ok := selected[slide.ID]; ok {
+			byID[slide.ID] = slide
+			continue
+		}
+		rest = append(rest, slide)
+	}
+	for _, id := range ids {
+		moving = append(moving, byID[id])
+	}
+
+	if index > len(rest) {
+		index = len(rest)
+	}
+
+	result := make([]SlideEntry, 0, len(slides))
+	result = append(result, rest[:index]...)
+	result = append(result, moving...)
+	result = append(result, rest[index:]...)
+	return result
+}
+
+// duplicateSlideEntries copies each selected slide file to a new slide-N.html
+// and inserts a matching entry after the original. It returns the paths of the
+// files it created so callers can roll them back.
+func duplicateSlideEntries(paths Paths, slides []SlideEntry, ids []string) ([]SlideEntry, []string, error) {
+	files, err := readSlideDir(paths)
+	if err != nil {
+		return nil, nil, err
+	}
+	next := nextSlideIndex(files)
+
+	selected := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		selected[id] = struct{}{}
+	}
+
+	var created []string
+	result := make([]SlideEntry, 0, len(slides)+len(ids))
+	for _, slide := range slides {
+		result = append(result, slide)
+		if _, ok := selected[slide.ID]; !ok {
+			continue
+		}
+
+		source, err := SlidePath(paths, slide.File)
+		if err != nil {
+			return nil, created, err
+		}
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return nil, created, fmt.Errorf("read slide %s: %w", slide.File, err)
+		}
+
+		name, err := slideFileName(next)
+		if err != nil {
+			return nil, created, err
+		}
+		next++
+		dest, err := SlidePath(paths, name)
+		if err != nil {
+			return nil, created, err
+		}
+		if err := writeFileAtomic(dest, content, 0o644); err != nil {
+			return nil, created, err
+		}
+		created = append(created, dest)
+
+		taken := make([]SlideEntry, 0, len(result)+len(slides))
+		taken = append(append(taken, result...), slides...)
+		copied := newSlideEntry(name, taken)
+		if slide.Title != "" {
+			copied.Title = slide.Title + " (copy)"
+		}
+		copied.Visible = slide.Visible
+		copied.Notes = slide.Notes
+		copied.Duration = slide.Duration
+		result = append(result, copied)
+	}
+	return result, created, nil
+}
+
+func removeFiles(paths []string) {
+	for _, path := range paths {
+		_ = os.Remove(path)
+	}
+}
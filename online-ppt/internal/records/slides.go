@@ -0,0 +1,383 @@
+package records
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"time"
+)
+
+// MaxSlideBytes bounds the size of a single slide HTML file.
+const MaxSlideBytes = 2 << 20
+
+var (
+	// ErrInvalidSlideName reports a slide file name that is not slide-N.html.
+	ErrInvalidSlideName = errors.New("invalid slide name")
+	// ErrSlideNotFound indicates the requested slide file does not exist.
+	ErrSlideNotFound = errors.New("slide not found")
+	// ErrSlideExists signals an upload would overwrite an existing slide.
+	ErrSlideExists = errors.New("slide already exists")
+	// ErrSlideLimitReached indicates the next slide-N.html would exceed the
+	// highest index a slide file name may carry.
+	ErrSlideLimitReached = errors.New("slide index limit reached")
+	// ErrInvalidSlideContent reports empty or oversized slide HTML.
+	ErrInvalidSlideContent = errors.New("invalid slide content")
+)
+
+// SlideTarget identifies the record whose slides directory is being accessed.
+type SlideTarget struct {
+	UserID   int64
+	UserUUID string
+	RecordID int64
+}
+
+// SlideFile describes a slide HTML file stored on disk.
+type SlideFile struct {
+	Name       string
+	Index      int
+	Size       int64
+	ModifiedAt time.Time
+}
+
+// ListSlides returns the slide files of a record ordered by their index.
+func (s *Service) ListSlides(ctx context.Context, target SlideTarget) ([]SlideFile, error) {
+	_, paths, err := s.resolveSlidesDir(ctx, target, "records.slides.list", RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	slides, err := readSlideDir(paths)
+	if err != nil {
+		s.audit.Log("records.slides.list", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return nil, err
+	}
+	return slides, nil
+}
+
+// ReadSlide returns the metadata and HTML content of a single slide.
+func (s *Service) ReadSlide(ctx context.Context, target SlideTarget, name string) (SlideFile, []byte, error) {
+	_, paths, err := s.resolveSlidesDir(ctx, target, "records.slides.read", RoleViewer)
+	if err != nil {
+		return SlideFile{}, nil, err
+	}
+
+	path, err := SlidePath(paths, name)
+	if err != nil {
+		return SlideFile{}, nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return SlideFile{}, nil, ErrSlideNotFound
+		}
+		return SlideFile{}, nil, fmt.Errorf("stat slide: %w", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return SlideFile{}, nil, fmt.Errorf("read slide: %w", err)
+	}
+
+	return makeSlideFile(name, info), content, nil
+}
+
+// CreateSlide stores a new slide file and appends it to slides.config.json.
+// When name is empty the next free slide-N.html is allocated.
+func (s *Service) CreateSlide(ctx context.Context, target SlideTarget, name string, content []byte) (SlideFile, error) {
+	if err := validateSlideContent(content); err != nil {
+		s.audit.Log("records.slides.create", map[string]any{
+			"status":   "validation_failed",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return SlideFile{}, err
+	}
+
+	record, paths, err := s.resolveSlidesDir(ctx, target, "records.slides.create", RoleEditor)
+	if err != nil {
+		return SlideFile{}, err
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	if err := EnsureDirectories(paths); err != nil {
+		return SlideFile{}, err
+	}
+
+	if name == "" {
+		existing, err := readSlideDir(paths)
+		if err != nil {
+			return SlideFile{}, err
+		}
+		name, err = slideFileName(nextSlideIndex(existing))
+		if err != nil {
+			return SlideFile{}, err
+		}
+	}
+
+	path, err := SlidePath(paths, name)
+	if err != nil {
+		return SlideFile{}, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		s.audit.Log("records.slides.create", map[string]any{
+			"status":   "conflict",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"slide":    name,
+		})
+		return SlideFile{}, ErrSlideExists
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return SlideFile{}, fmt.Errorf("stat slide: %w", err)
+	}
+
+	slide, err := writeSlide(path, name, content)
+	if err != nil {
+		s.audit.Log("records.slides.create", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"slide":    name,
+			"reason":   err.Error(),
+		})
+		return SlideFile{}, err
+	}
+
+	if err := syncConfig(record, paths); err != nil {
+		_ = os.Remove(path)
+		s.audit.Log("records.slides.create", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"slide":    name,
+			"reason":   err.Error(),
+		})
+		return SlideFile{}, err
+	}
+
+	s.audit.Log("records.slides.create", map[string]any{
+		"status":   "success",
+		"userId":   target.UserID,
+		"recordId": target.RecordID,
+		"slide":    name,
+	})
+	return slide, nil
+}
+
+// ReplaceSlide overwrites the content of an existing slide file.
+func (s *Service) ReplaceSlide(ctx context.Context, target SlideTarget, name string, content []byte) (SlideFile, error) {
+	if err := validateSlideContent(content); err != nil {
+		s.audit.Log("records.slides.replace", map[string]any{
+			"status":   "validation_failed",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return SlideFile{}, err
+	}
+
+	record, paths, err := s.resolveSlidesDir(ctx, target, "records.slides.replace", RoleEditor)
+	if err != nil {
+		return SlideFile{}, err
+	}
+
+	path, err := SlidePath(paths, name)
+	if err != nil {
+		return SlideFile{}, err
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return SlideFile{}, ErrSlideNotFound
+		}
+		return SlideFile{}, fmt.Errorf("stat slide: %w", err)
+	}
+
+	if err := s.autoSnapshot(ctx, target, &record, paths, "records.slides.replace"); err != nil {
+		return SlideFile{}, err
+	}
+
+	slide, err := writeSlide(path, name, content)
+	if err != nil {
+		s.audit.Log("records.slides.replace", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"slide":    name,
+			"reason":   err.Error(),
+		})
+		return SlideFile{}, err
+	}
+
+	s.audit.Log("records.slides.replace", map[string]any{
+		"status":   "success",
+		"userId":   target.UserID,
+		"recordId": target.RecordID,
+		"slide":    name,
+	})
+	return slide, nil
+}
+
+// DeleteSlide removes a slide file from disk along with its config entry.
+func (s *Service) DeleteSlide(ctx context.Context, target SlideTarget, name string) error {
+	record, paths, err := s.resolveSlidesDir(ctx, target, "records.slides.delete", RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	path, err := SlidePath(paths, name)
+	if err != nil {
+		return err
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrSlideNotFound
+		}
+		return fmt.Errorf("stat slide: %w", err)
+	}
+
+	if err := s.autoSnapshot(ctx, target, &record, paths, "records.slides.delete"); err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrSlideNotFound
+		}
+		s.audit.Log("records.slides.delete", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"slide":    name,
+			"reason":   err.Error(),
+		})
+		return fmt.Errorf("remove slide: %w", err)
+	}
+
+	if err := syncConfig(record, paths); err != nil {
+		s.audit.Log("records.slides.delete", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"slide":    name,
+			"reason":   err.Error(),
+		})
+		return err
+	}
+
+	s.audit.Log("records.slides.delete", map[string]any{
+		"status":   "success",
+		"userId":   target.UserID,
+		"recordId": target.RecordID,
+		"slide":    name,
+	})
+	return nil
+}
+
+// resolveSlidesDir loads a record the caller may access with at least the
+// need role and rebuilds its canonical slides directory from the presentations
+// root under the deck owner's UUID.
+func (s *Service) resolveSlidesDir(ctx context.Context, target SlideTarget, event string, need Role) (PptRecord, Paths, error) {
+	access, err := s.authorizeTarget(ctx, target, event, need)
+	if err != nil {
+		return PptRecord{}, Paths{}, err
+	}
+
+	paths, err := s.deckPaths(access.record, access.ownerUUID, access.record.GroupName)
+	if err != nil {
+		s.audit.Log(event, map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return PptRecord{}, Paths{}, err
+	}
+
+	return access.record, paths, nil
+}
+
+func readSlideDir(paths Paths) ([]SlideFile, error) {
+	entries, err := os.ReadDir(paths.Canonical)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []SlideFile{}, nil
+		}
+		return nil, fmt.Errorf("read slides dir: %w", err)
+	}
+
+	slides := make([]SlideFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !slideFilePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("stat slide: %w", err)
+		}
+		slides = append(slides, makeSlideFile(entry.Name(), info))
+	}
+
+	sort.Slice(slides, func(i, j int) bool {
+		return slides[i].Index < slides[j].Index
+	})
+	return slides, nil
+}
+
+func writeSlide(path, name string, content []byte) (SlideFile, error) {
+	if err := writeFileAtomic(path, content, 0o644); err != nil {
+		return SlideFile{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return SlideFile{}, fmt.Errorf("stat slide: %w", err)
+	}
+	return makeSlideFile(name, info), nil
+}
+
+func makeSlideFile(name string, info fs.FileInfo) SlideFile {
+	return SlideFile{
+		Name:       name,
+		Index:      slideIndex(name),
+		Size:       info.Size(),
+		ModifiedAt: info.ModTime(),
+	}
+}
+
+func nextSlideIndex(slides []SlideFile) int {
+	next := 1
+	for _, slide := range slides {
+		if slide.Index >= next {
+			next = slide.Index + 1
+		}
+	}
+	return next
+}
+
+func validateSlideContent(content []byte) error {
+	if len(content) == 0 {
+		return fmt.Errorf("slide content required: %w", ErrInvalidSlideContent)
+	}
+	if len(content) > MaxSlideBytes {
+		return fmt.Errorf("slide content exceeds %d bytes: %w", MaxSlideBytes, ErrInvalidSlideContent)
+	}
+	return nil
+}
@@ -0,0 +1,538 @@
+package records
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// SlidesConfigFile is the per-presentation config consumed by ppt-framework.
+	SlidesConfigFile = "slides.config.json"
+
+	maxConfigBytes    = 1 << 20
+	maxConfigSlides   = 500
+	maxConfigTextLen  = 255
+	maxSlideNotesLen  = 10000
+	defaultAutoPlayMs = 5000
+	defaultTransition = "slide"
+	defaultThemeColor = "#3b82f6"
+	defaultThemeFont  = "system-ui"
+	randomTransition  = "random"
+)
+
+// ErrInvalidSlidesConfig reports a slides.config.json document that fails schema validation.
+var ErrInvalidSlidesConfig = errors.New("invalid slides config")
+
+var (
+	themeColorPattern = regexp.MustCompile(`^#(?:[0-9A-Fa-f]{3}|[0-9A-Fa-f]{4}|[0-9A-Fa-f]{6}|[0-9A-Fa-f]{8})$`)
+
+	// transitionPresets mirrors the presets understood by the ppt-framework player.
+	transitionPresets = map[string]struct{}{
+		"slide": {}, "zoom": {}, "blur": {}, "flip": {}, "rotate": {}, "skew": {}, "fade": {},
+		"cover": {}, "push": {}, "cube": {}, "parallax": {}, "zoomfade": {}, "tilt": {},
+		randomTransition: {},
+	}
+)
+
+// SlidesConfig mirrors presentations/<group>/slides.config.json.
+type SlidesConfig struct {
+	Title       string         `json:"title"`
+	Author      string         `json:"author"`
+	Description string         `json:"description"`
+	Theme       SlidesTheme    `json:"theme"`
+	Settings    SlidesSettings `json:"settings"`
+	Slides      []SlideEntry   `json:"slides"`
+}
+
+// SlidesTheme holds visual presentation options.
+type SlidesTheme struct {
+	PrimaryColor   string `json:"primaryColor"`
+	SecondaryColor string `json:"secondaryColor,omitempty"`
+	FontFamily     string `json:"fontFamily"`
+	Transition     string `json:"transition"`
+}
+
+// SlidesSettings holds player behaviour switches.
+type SlidesSettings struct {
+	AutoPlay             bool `json:"autoPlay"`
+	AutoPlayInterval     int  `json:"autoPlayInterval"`
+	Loop                 bool `json:"loop"`
+	ShowProgress         bool `json:"showProgress"`
+	ShowThumbnails       bool `json:"showThumbnails"`
+	EnableKeyboardNav    bool `json:"enableKeyboardNav"`
+	EnableTouchNav       bool `json:"enableTouchNav"`
+	AutoStartOnHome      bool `json:"autoStartOnHome"`
+	AutoFullscreenOnHome bool `json:"autoFullscreenOnHome"`
+}
+
+// SlideEntry describes one slide in presentation order.
+type SlideEntry struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	File     string `json:"file"`
+	Visible  bool   `json:"visible"`
+	Notes    string `json:"notes"`
+	Duration *int   `json:"duration"`
+}
+
+// DefaultSlidesConfig returns the defaults used when a deck has no config yet.
+func DefaultSlidesConfig(title string) SlidesConfig {
+	return SlidesConfig{
+		Title: title,
+		Theme: SlidesTheme{
+			PrimaryColor: defaultThemeColor,
+			FontFamily:   defaultThemeFont,
+			Transition:   defaultTransition,
+		},
+		Settings: SlidesSettings{
+			AutoPlayInterval:  defaultAutoPlayMs,
+			ShowProgress:      true,
+			ShowThumbnails:    true,
+			EnableKeyboardNav: true,
+			EnableTouchNav:    true,
+		},
+		Slides: []SlideEntry{},
+	}
+}
+
+// Validate checks the config against the schema understood by the player.
+func (c SlidesConfig) Validate() error {
+	if strings.TrimSpace(c.Title) == "" {
+		return fmt.Errorf("title required: %w", ErrInvalidSlidesConfig)
+	}
+	if len(c.Title) > maxConfigTextLen || len(c.Author) > maxConfigTextLen {
+		return fmt.Errorf("title and author must not exceed %d characters: %w", maxConfigTextLen, ErrInvalidSlidesConfig)
+	}
+	if c.Theme.PrimaryColor != "" && !themeColorPattern.MatchString(c.Theme.PrimaryColor) {
+		return fmt.Errorf("theme.primaryColor must be a hex color: %w", ErrInvalidSlidesConfig)
+	}
+	if c.Theme.SecondaryColor != "" && !themeColorPattern.MatchString(c.Theme.SecondaryColor) {
+		return fmt.Errorf("theme.secondaryColor must be a hex color: %w", ErrInvalidSlidesConfig)
+	}
+	if c.Theme.Transition != "" {
+		if _, ok := transitionPresets[c.Theme.Transition]; !ok {
+			return fmt.Errorf("theme.transition %q is not supported: %w", c.Theme.Transition, ErrInvalidSlidesConfig)
+		}
+	}
+	if c.Settings.AutoPlayInterval < 0 {
+		return fmt.Errorf("settings.autoPlayInterval must not be negative: %w", ErrInvalidSlidesConfig)
+	}
+	if len(c.Slides) > maxConfigSlides {
+		return fmt.Errorf("too many slides; maximum is %d: %w", maxConfigSlides, ErrInvalidSlidesConfig)
+	}
+
+	ids := make(map[string]struct{}, len(c.Slides))
+	files := make(map[string]struct{}, len(c.Slides))
+	for i, slide := range c.Slides {
+		if strings.TrimSpace(slide.ID) == "" {
+			return fmt.Errorf("slides[%d].id required: %w", i, ErrInvalidSlidesConfig)
+		}
+		if _, dup := ids[slide.ID]; dup {
+			return fmt.Errorf("slides[%d].id %q is duplicated: %w", i, slide.ID, ErrInvalidSlidesConfig)
+		}
+		ids[slide.ID] = struct{}{}
+
+		if !slideFilePattern.MatchString(slide.File) {
+			return fmt.Errorf("slides[%d].file must match slide-N.html: %w", i, ErrInvalidSlidesConfig)
+		}
+		if _, dup := files[slide.File]; dup {
+			return fmt.Errorf("slides[%d].file %q is duplicated: %w", i, slide.File, ErrInvalidSlidesConfig)
+		}
+		files[slide.File] = struct{}{}
+
+		if len(slide.Title) > maxConfigTextLen {
+			return fmt.Errorf("slides[%d].title must not exceed %d characters: %w", i, maxConfigTextLen, ErrInvalidSlidesConfig)
+		}
+		if len(slide.Notes) > maxSlideNotesLen {
+			return fmt.Errorf("slides[%d].notes must not exceed %d characters: %w", i, maxSlideNotesLen, ErrInvalidSlidesConfig)
+		}
+		if slide.Duration != nil && *slide.Duration <= 0 {
+			return fmt.Errorf("slides[%d].duration must be positive: %w", i, ErrInvalidSlidesConfig)
+		}
+	}
+	return nil
+}
+
+// GetConfig returns the deck's slides.config.json reconciled with the slide files on disk.
+// A default document is synthesised when the deck has no config yet.
+func (s *Service) GetConfig(ctx context.Context, target SlideTarget) (SlidesConfig, error) {
+	record, paths, err := s.resolveSlidesDir(ctx, target, "records.config.get", RoleViewer)
+	if err != nil {
+		return SlidesConfig{}, err
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	cfg, err := loadSyncedConfig(record, paths)
+	if err != nil {
+		s.audit.Log("records.config.get", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return SlidesConfig{}, err
+	}
+	return cfg, nil
+}
+
+// ReplaceConfig validates and stores a complete slides.config.json document.
+// Fields omitted from raw keep their default values.
+func (s *Service) ReplaceConfig(ctx context.Context, target SlideTarget, raw []byte) (SlidesConfig, error) {
+	record, paths, err := s.resolveSlidesDir(ctx, target, "records.config.replace", RoleEditor)
+	if err != nil {
+		return SlidesConfig{}, err
+	}
+
+	cfg := DefaultSlidesConfig(recordDisplayTitle(record))
+	if err := decodeConfigStrict(raw, &cfg); err != nil {
+		s.audit.Log("records.config.replace", map[string]any{
+			"status":   "validation_failed",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return SlidesConfig{}, err
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	cfg, err = s.prepareConfig(target, paths, cfg, "records.config.replace")
+	if err != nil {
+		return SlidesConfig{}, err
+	}
+	if err := s.autoSnapshot(ctx, target, &record, paths, "records.config.replace"); err != nil {
+		return SlidesConfig{}, err
+	}
+	return s.writePreparedConfig(target, paths, cfg, "records.config.replace")
+}
+
+// PatchConfig applies an RFC 7386 JSON merge patch to the current config.
+func (s *Service) PatchConfig(ctx context.Context, target SlideTarget, patch []byte) (SlidesConfig, error) {
+	record, paths, err := s.resolveSlidesDir(ctx, target, "records.config.patch", RoleEditor)
+	if err != nil {
+		return SlidesConfig{}, err
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	current, err := loadSyncedConfig(record, paths)
+	if err != nil {
+		return SlidesConfig{}, err
+	}
+
+	merged, err := applyMergePatch(current, patch)
+	if err != nil {
+		s.audit.Log("records.config.patch", map[string]any{
+			"status":   "validation_failed",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return SlidesConfig{}, err
+	}
+
+	merged, err = s.prepareConfig(target, paths, merged, "records.config.patch")
+	if err != nil {
+		return SlidesConfig{}, err
+	}
+	if err := s.autoSnapshot(ctx, target, &record, paths, "records.config.patch"); err != nil {
+		return SlidesConfig{}, err
+	}
+	return s.writePreparedConfig(target, paths, merged, "records.config.patch")
+}
+
+// storeConfig validates cfg against the files on disk and writes it atomically.
+// Callers must hold fsMu.
+func (s *Service) storeConfig(target SlideTarget, paths Paths, cfg SlidesConfig, event string) (SlidesConfig, error) {
+	cfg, err := s.prepareConfig(target, paths, cfg, event)
+	if err != nil {
+		return SlidesConfig{}, err
+	}
+	return s.writePreparedConfig(target, paths, cfg, event)
+}
+
+// prepareConfig validates cfg and reconciles it with the slide files on disk.
+func (s *Service) prepareConfig(target SlideTarget, paths Paths, cfg SlidesConfig, event string) (SlidesConfig, error) {
+	if err := cfg.Validate(); err != nil {
+		s.audit.Log(event, map[string]any{
+			"status":   "validation_failed",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return SlidesConfig{}, err
+	}
+
+	files, err := readSlideDir(paths)
+	if err != nil {
+		return SlidesConfig{}, err
+	}
+	onDisk := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		onDisk[file.Name] = struct{}{}
+	}
+	for _, slide := range cfg.Slides {
+		if _, ok := onDisk[slide.File]; !ok {
+			err := fmt.Errorf("slide file %s does not exist: %w", slide.File, ErrInvalidSlidesConfig)
+			s.audit.Log(event, map[string]any{
+				"status":   "validation_failed",
+				"userId":   target.UserID,
+				"recordId": target.RecordID,
+				"reason":   err.Error(),
+			})
+			return SlidesConfig{}, err
+		}
+	}
+
+	cfg, _ = reconcileConfig(cfg, files)
+	return cfg, nil
+}
+
+// writePreparedConfig persists a config already checked by prepareConfig.
+func (s *Service) writePreparedConfig(target SlideTarget, paths Paths, cfg SlidesConfig, event string) (SlidesConfig, error) {
+	if err := writeConfig(paths, cfg); err != nil {
+		s.audit.Log(event, map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return SlidesConfig{}, err
+	}
+
+	s.audit.Log(event, map[string]any{
+		"status":   "success",
+		"userId":   target.UserID,
+		"recordId": target.RecordID,
+	})
+	return cfg, nil
+}
+
+// ConfigPath returns the location of slides.config.json, which sits next to the slides directory.
+func ConfigPath(paths Paths) string {
+	return filepath.Join(filepath.Dir(paths.Canonical), SlidesConfigFile)
+}
+
+// loadSyncedConfig reads the config (or a default) and reconciles it with the slide files.
+func loadSyncedConfig(record PptRecord, paths Paths) (SlidesConfig, error) {
+	cfg, err := readConfig(paths)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return SlidesConfig{}, err
+		}
+		cfg = DefaultSlidesConfig(recordDisplayTitle(record))
+	}
+
+	files, err := readSlideDir(paths)
+	if err != nil {
+		return SlidesConfig{}, err
+	}
+
+	cfg, _ = reconcileConfig(cfg, files)
+	return cfg, nil
+}
+
+// syncConfig rewrites slides.config.json so it matches the slide files on disk.
+// Callers must hold fsMu.
+func syncConfig(record PptRecord, paths Paths) error {
+	missing := false
+	cfg, err := readConfig(paths)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		cfg = DefaultSlidesConfig(recordDisplayTitle(record))
+		missing = true
+	}
+
+	files, err := readSlideDir(paths)
+	if err != nil {
+		return err
+	}
+
+	cfg, changed := reconcileConfig(cfg, files)
+	if !changed && !missing {
+		return nil
+	}
+	return writeConfig(paths, cfg)
+}
+
+func readConfig(paths Paths) (SlidesConfig, error) {
+	data, err := os.ReadFile(ConfigPath(paths))
+	if err != nil {
+		return SlidesConfig{}, err
+	}
+
+	var cfg SlidesConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return SlidesConfig{}, fmt.Errorf("parse %s: %w", SlidesConfigFile, err)
+	}
+	if cfg.Slides == nil {
+		cfg.Slides = []SlideEntry{}
+	}
+	return cfg, nil
+}
+
+func writeConfig(paths Paths, cfg SlidesConfig) error {
+	if err := os.MkdirAll(filepath.Dir(ConfigPath(paths)), 0o755); err != nil {
+		return fmt.Errorf("ensure config dir: %w", err)
+	}
+
+	encoded, err := encodeConfig(cfg)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(ConfigPath(paths), encoded, 0o644)
+}
+
+func encodeConfig(cfg SlidesConfig) ([]byte, error) {
+	encoded, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", SlidesConfigFile, err)
+	}
+	return append(encoded, '\n'), nil
+}
+
+// reconcileConfig drops entries whose file vanished and appends entries for
+// slide files the config does not reference yet. The bool reports whether
+// anything changed.
+func reconcileConfig(cfg SlidesConfig, files []SlideFile) (SlidesConfig, bool) {
+	onDisk := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		onDisk[file.Name] = struct{}{}
+	}
+
+	changed := false
+	referenced := make(map[string]struct{}, len(cfg.Slides))
+	kept := make([]SlideEntry, 0, len(cfg.Slides)+len(files))
+	for _, slide := range cfg.Slides {
+		if _, ok := onDisk[slide.File]; !ok {
+			changed = true
+			continue
+		}
+		referenced[slide.File] = struct{}{}
+		kept = append(kept, slide)
+	}
+
+	for _, file := range files {
+		if _, ok := referenced[file.Name]; ok {
+			continue
+		}
+		kept = append(kept, newSlideEntry(file.Name, kept))
+		changed = true
+	}
+
+	cfg.Slides = kept
+	return cfg, changed
+}
+
+// newSlideEntry builds a visible entry for file, picking an id unused by existing.
+func newSlideEntry(file string, existing []SlideEntry) SlideEntry {
+	base := strings.TrimSuffix(file, ".html")
+	id := base
+	for suffix := 2; slideEntryIDTaken(id, existing); suffix++ {
+		id = fmt.Sprintf("%s-%d", base, suffix)
+	}
+	return SlideEntry{
+		ID:      id,
+		Title:   fmt.Sprintf("Slide %d", slideIndex(file)),
+		File:    file,
+		Visible: true,
+	}
+}
+
+func slideEntryIDTaken(id string, entries []SlideEntry) bool {
+	for _, entry := range entries {
+		if entry.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeConfigStrict(raw []byte, cfg *SlidesConfig) error {
+	if len(raw) > maxConfigBytes {
+		return fmt.Errorf("config exceeds %d bytes: %w", maxConfigBytes, ErrInvalidSlidesConfig)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("%s: %w", err.Error(), ErrInvalidSlidesConfig)
+	}
+	if decoder.More() {
+		return fmt.Errorf("unexpected trailing data: %w", ErrInvalidSlidesConfig)
+	}
+	if cfg.Slides == nil {
+		cfg.Slides = []SlideEntry{}
+	}
+	return nil
+}
+
+func applyMergePatch(current SlidesConfig, patch []byte) (SlidesConfig, error) {
+	var patchDoc any
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return SlidesConfig{}, fmt.Errorf("%s: %w", err.Error(), ErrInvalidSlidesConfig)
+	}
+	if _, ok := patchDoc.(map[string]any); !ok {
+		return SlidesConfig{}, fmt.Errorf("patch must be a JSON object: %w", ErrInvalidSlidesConfig)
+	}
+
+	encoded, err := json.Marshal(current)
+	if err != nil {
+		return SlidesConfig{}, fmt.Errorf("encode current config: %w", err)
+	}
+	var currentDoc any
+	if err := json.Unmarshal(encoded, &currentDoc); err != nil {
+		return SlidesConfig{}, fmt.Errorf("decode current config: %w", err)
+	}
+
+	merged, err := json.Marshal(mergePatch(currentDoc, patchDoc))
+	if err != nil {
+		return SlidesConfig{}, fmt.Errorf("encode merged config: %w", err)
+	}
+
+	var result SlidesConfig
+	if err := decodeConfigStrict(merged, &result); err != nil {
+		return SlidesConfig{}, err
+	}
+	return result, nil
+}
+
+// mergePatch implements the RFC 7386 merge algorithm on decoded JSON values.
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+func recordDisplayTitle(record PptRecord) string {
+	if record.Title.Valid && record.Title.String != "" {
+		return record.Title.String
+	}
+	return record.Name
+}
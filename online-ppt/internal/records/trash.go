@@ -0,0 +1,241 @@
+package records
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTrashRetention is how long deleted decks stay in the trash area.
+	DefaultTrashRetention = 30 * 24 * time.Hour
+
+	defaultTrashDirName = ".trash"
+	trashStampLayout    = "20060102T150405Z"
+)
+
+// ErrDeckDirectoryExists signals that a rename target directory is already occupied.
+var ErrDeckDirectoryExists = errors.New("deck directory already exists")
+
+// ConfigureTrash overrides where deleted decks are parked and how long they are kept.
+// An empty dir keeps the default <presentationsRoot>/.trash location.
+func (s *Service) ConfigureTrash(dir string, retention time.Duration) error {
+	if retention < 0 {
+		return fmt.Errorf("trash retention must not be negative")
+	}
+	if dir != "" {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("resolve trash dir: %w", err)
+		}
+		s.trashRoot = absDir
+	}
+	if retention > 0 {
+		s.trashRetention = retention
+	}
+	return nil
+}
+
+// PurgeTrash permanently removes trashed deck directories older than the retention period.
+// It sweeps entries left behind without a database row; PurgeDeletedRecords handles the rest.
+func (s *Service) PurgeTrash(ctx context.Context) (int, error) {
+	cutoff := s.clockFn().Add(-s.trashRetention)
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	owners, err := os.ReadDir(s.trashRoot)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("read trash dir: %w", err)
+	}
+
+	purged := 0
+	for _, owner := range owners {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		if !owner.IsDir() {
+			continue
+		}
+		ownerDir := filepath.Join(s.trashRoot, owner.Name())
+		entries, err := os.ReadDir(ownerDir)
+		if err != nil {
+			return purged, fmt.Errorf("read trash owner dir: %w", err)
+		}
+		for _, entry := range entries {
+			_, _, deletedAt, ok := parseTrashEntryName(entry.Name())
+			if !ok || deletedAt.After(cutoff) {
+				continue
+			}
+			if err := os.RemoveAll(filepath.Join(ownerDir, entry.Name())); err != nil {
+				return purged, fmt.Errorf("purge trash entry: %w", err)
+			}
+			purged++
+		}
+	}
+
+	if purged > 0 {
+		s.audit.Log("records.trash.purge", map[string]any{
+			"status": "success",
+			"purged": purged,
+		})
+	}
+	return purged, nil
+}
+
+// RunTrashPurger calls PurgeDeletedRecords, PurgeUnreferencedBlobs and PurgeTrash
+// every interval until ctx is cancelled.
+func (s *Service) RunTrashPurger(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PurgeDeletedRecords(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			s.audit.Log("records.trash.purge_records", map[string]any{
+				"status": "error",
+				"reason": err.Error(),
+			})
+		}
+		if _, err := s.PurgeUnreferencedBlobs(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			s.audit.Log("records.versions.gc", map[string]any{
+				"status": "error",
+				"reason": err.Error(),
+			})
+		}
+		if _, err := s.PurgeTrash(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			s.audit.Log("records.trash.purge", map[string]any{
+				"status": "error",
+				"reason": err.Error(),
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deckDir returns the directory holding a record's slides and config, verifying
+// that it lives strictly inside the presentations root.
+func (s *Service) deckDir(record PptRecord) (string, error) {
+	if record.CanonicalPath == "" {
+		return "", fmt.Errorf("canonical path required")
+	}
+	dir := filepath.Dir(filepath.Clean(record.CanonicalPath))
+	rel, err := filepath.Rel(s.presentationsRoot, dir)
+	if err != nil {
+		return "", fmt.Errorf("resolve relative path: %w", err)
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("canonical path escapes root")
+	}
+	return dir, nil
+}
+
+// relocateDeck moves the deck directory when a rename changes its canonical path.
+// It reports whether a move happened so the caller can roll it back.
+func (s *Service) relocateDeck(from, to PptRecord) (bool, error) {
+	if filepath.Clean(from.CanonicalPath) == filepath.Clean(to.CanonicalPath) {
+		return false, nil
+	}
+
+	oldDir, err := s.deckDir(from)
+	if err != nil {
+		return false, err
+	}
+	newDir, err := s.deckDir(to)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := os.Stat(oldDir); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return false, fmt.Errorf("stat deck dir: %w", err)
+		}
+		return false, EnsureDirectories(Paths{Relative: to.RelativePath, Canonical: to.CanonicalPath})
+	}
+
+	if err := moveDir(oldDir, newDir); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// trashDeck moves a record's deck directory into the trash area and returns
+// its new location, or "" when the deck had no directory on disk.
+func (s *Service) trashDeck(record PptRecord, deletedAt time.Time) (string, error) {
+	dir, err := s.deckDir(record)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("stat deck dir: %w", err)
+	}
+
+	owner := filepath.Base(filepath.Dir(dir))
+	dest := filepath.Join(s.trashRoot, owner, trashEntryName(record, deletedAt))
+	if err := moveDir(dir, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+func trashEntryName(record PptRecord, deletedAt time.Time) string {
+	return fmt.Sprintf("%d_%s_%s", record.ID, record.GroupName, deletedAt.UTC().Format(trashStampLayout))
+}
+
+// parseTrashEntryName splits <recordID>_<group>_<timestamp>; group names may contain underscores.
+func parseTrashEntryName(name string) (int64, string, time.Time, bool) {
+	first := strings.Index(name, "_")
+	last := strings.LastIndex(name, "_")
+	if first <= 0 || last <= first {
+		return 0, "", time.Time{}, false
+	}
+	id, err := strconv.ParseInt(name[:first], 10, 64)
+	if err != nil {
+		return 0, "", time.Time{}, false
+	}
+	deletedAt, err := time.Parse(trashStampLayout, name[last+1:])
+	if err != nil {
+		return 0, "", time.Time{}, false
+	}
+	return id, name[first+1 : last], deletedAt, true
+}
+
+// rollbackRelocation moves a deck back after the database rejected a rename.
+func (s *Service) rollbackRelocation(from, to PptRecord) error {
+	oldDir, err := s.deckDir(from)
+	if err != nil {
+		return err
+	}
+	newDir, err := s.deckDir(to)
+	if err != nil {
+		return err
+	}
+	return moveDir(newDir, oldDir)
+}
+
+// restoreFromTrash moves a trashed deck back to its original location.
+func (s *Service) restoreFromTrash(record PptRecord, trashPath string) error {
+	dir, err := s.deckDir(record)
+	if err != nil {
+		return err
+	}
+	return moveDir(trashPath, dir)
+}
@@ -0,0 +1,271 @@
+package records
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const purgeBatchSize = 100
+
+// TrashView describes a soft-deleted record and when it will be purged.
+type TrashView struct {
+	Record    PptRecord
+	DeletedAt time.Time
+	PurgeAt   time.Time
+}
+
+// TrashListResult bundles paginated trash results.
+type TrashListResult struct {
+	Records []TrashView
+	Total   int
+	Limit   int
+	Offset  int
+}
+
+// ListTrash returns the soft-deleted records the user may restore, most
+// recently deleted first.
+func (s *Service) ListTrash(ctx context.Context, params ListParams) (TrashListResult, error) {
+	if params.UserID <= 0 {
+		s.audit.Log("records.trash.list", map[string]any{
+			"status": "validation_failed",
+			"reason": errInvalidUserID.Error(),
+		})
+		return TrashListResult{}, errInvalidUserID
+	}
+
+	normalized := normalizeListFilters(params.Filters)
+	deleted, total, err := s.repo.ListDeleted(ctx, params.UserID, normalized)
+	if err != nil {
+		s.audit.Log("records.trash.list", map[string]any{
+			"status": "error",
+			"userId": params.UserID,
+			"reason": err.Error(),
+		})
+		return TrashListResult{}, err
+	}
+
+	result := TrashListResult{
+		Records: make([]TrashView, 0, len(deleted)),
+		Total:   total,
+		Limit:   normalized.Limit,
+		Offset:  normalized.Offset,
+	}
+	for _, record := range deleted {
+		result.Records = append(result.Records, s.makeTrashView(record))
+	}
+	return result, nil
+}
+
+// RestoreRecord brings a soft-deleted record back, moving its deck directory
+// out of the trash area to its original location.
+func (s *Service) RestoreRecord(ctx context.Context, userID, recordID int64) (RecordView, error) {
+	if userID <= 0 {
+		s.audit.Log("records.restore", map[string]any{
+			"status": "validation_failed",
+			"reason": errInvalidUserID.Error(),
+		})
+		return RecordView{}, errInvalidUserID
+	}
+	if recordID <= 0 {
+		s.audit.Log("records.restore", map[string]any{
+			"status": "validation_failed",
+			"userId": userID,
+			"reason": errInvalidRecordID.Error(),
+		})
+		return RecordView{}, errInvalidRecordID
+	}
+
+	record, err := s.repo.GetDeletedByID(ctx, userID, recordID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("records.restore", map[string]any{
+				"status":   "not_found",
+				"userId":   userID,
+				"recordId": recordID,
+			})
+			return RecordView{}, ErrRecordNotFound
+		}
+		s.audit.Log("records.restore", map[string]any{
+			"status":   "error",
+			"userId":   userID,
+			"recordId": recordID,
+			"reason":   err.Error(),
+		})
+		return RecordView{}, err
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	moved, err := s.untrashDeck(record)
+	if err != nil {
+		status := "error"
+		if errors.Is(err, ErrDeckDirectoryExists) {
+			status = "conflict"
+		}
+		s.audit.Log("records.restore", map[string]any{
+			"status":   status,
+			"userId":   userID,
+			"recordId": recordID,
+			"reason":   err.Error(),
+		})
+		return RecordView{}, err
+	}
+
+	if err := s.repo.Restore(ctx, userID, recordID); err != nil {
+		if moved {
+			if rbErr := s.retrashDeck(record); rbErr != nil {
+				s.audit.Log("records.restore.rollback", map[string]any{
+					"status":   "error",
+					"userId":   userID,
+					"recordId": recordID,
+					"reason":   rbErr.Error(),
+				})
+			}
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("records.restore", map[string]any{
+				"status":   "not_found",
+				"userId":   userID,
+				"recordId": recordID,
+			})
+			return RecordView{}, ErrRecordNotFound
+		}
+		if s.repo.dialect.IsUniqueViolation(err) {
+			s.audit.Log("records.restore", map[string]any{
+				"status":   "conflict",
+				"userId":   userID,
+				"recordId": recordID,
+				"reason":   ErrDuplicateRecord.Error(),
+			})
+			return RecordView{}, ErrDuplicateRecord
+		}
+		s.audit.Log("records.restore", map[string]any{
+			"status":   "error",
+			"userId":   userID,
+			"recordId": recordID,
+			"reason":   err.Error(),
+		})
+		return RecordView{}, err
+	}
+
+	record.DeletedAt = sql.NullTime{}
+	record.TrashPath = sql.NullString{}
+	record.UpdatedAt = s.clockFn().UTC()
+
+	s.audit.Log("records.restore", map[string]any{
+		"status":   "success",
+		"userId":   userID,
+		"recordId": recordID,
+	})
+	return s.makeRecordView(record)
+}
+
+// PurgeDeletedRecords permanently removes records, and their trashed decks,
+// that were soft-deleted longer ago than the retention period.
+func (s *Service) PurgeDeletedRecords(ctx context.Context) (int, error) {
+	cutoff := s.clockFn().Add(-s.trashRetention)
+
+	purged := 0
+	for {
+		expired, err := s.repo.ListExpiredDeleted(ctx, cutoff, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, record := range expired {
+			if err := ctx.Err(); err != nil {
+				return purged, err
+			}
+			if err := s.purgeRecord(ctx, record); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+
+		if len(expired) < purgeBatchSize {
+			break
+		}
+	}
+
+	if purged > 0 {
+		s.audit.Log("records.trash.purge_records", map[string]any{
+			"status": "success",
+			"purged": purged,
+		})
+	}
+	return purged, nil
+}
+
+func (s *Service) purgeRecord(ctx context.Context, record PptRecord) error {
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	if trashPath, ok := s.trashEntryPath(record); ok {
+		if err := os.RemoveAll(trashPath); err != nil {
+			return fmt.Errorf("purge trash entry: %w", err)
+		}
+	}
+	return s.repo.Purge(ctx, record.ID)
+}
+
+func (s *Service) makeTrashView(record PptRecord) TrashView {
+	view := TrashView{Record: record}
+	if record.DeletedAt.Valid {
+		view.DeletedAt = record.DeletedAt.Time.UTC()
+		view.PurgeAt = view.DeletedAt.Add(s.trashRetention)
+	}
+	return view
+}
+
+// trashEntryPath returns the record's trashed deck directory when it is set
+// and lives inside the trash root.
+func (s *Service) trashEntryPath(record PptRecord) (string, bool) {
+	if !record.TrashPath.Valid || record.TrashPath.String == "" {
+		return "", false
+	}
+	path := filepath.Clean(record.TrashPath.String)
+	rel, err := filepath.Rel(s.trashRoot, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return path, true
+}
+
+// untrashDeck moves the trashed deck back into place. When the trashed copy is
+// gone the deck directories are recreated empty. It reports whether a move happened.
+func (s *Service) untrashDeck(record PptRecord) (bool, error) {
+	trashPath, ok := s.trashEntryPath(record)
+	if ok {
+		if _, err := os.Stat(trashPath); err == nil {
+			if err := s.restoreFromTrash(record, trashPath); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+
+	if _, err := s.deckDir(record); err != nil {
+		return false, err
+	}
+	return false, EnsureDirectories(Paths{Relative: record.RelativePath, Canonical: record.CanonicalPath})
+}
+
+// retrashDeck undoes untrashDeck after the database rejected a restore.
+func (s *Service) retrashDeck(record PptRecord) error {
+	trashPath, ok := s.trashEntryPath(record)
+	if !ok {
+		return nil
+	}
+	dir, err := s.deckDir(record)
+	if err != nil {
+		return err
+	}
+	return moveDir(dir, trashPath)
+}
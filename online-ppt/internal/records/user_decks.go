@@ -0,0 +1,78 @@
+package records
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// RemoveUserDecks deletes the deck directories of a user whose account has
+// been removed: the user's personal tree and their trash entries. Workspace
+// decks they created belong to the workspace and are left in place; version
+// blobs the personal decks referenced are left to PurgeUnreferencedBlobs. It
+// returns how many directories were removed.
+func (s *Service) RemoveUserDecks(ctx context.Context, adminID, userID int64, userUUID string) (int, error) {
+	if userID <= 0 {
+		s.audit.Log("records.user.purge", map[string]any{
+			"status":  "validation_failed",
+			"adminId": adminID,
+			"reason":  errInvalidUserID.Error(),
+		})
+		return 0, errInvalidUserID
+	}
+	if _, err := uuid.Parse(userUUID); err != nil {
+		s.audit.Log("records.user.purge", map[string]any{
+			"status":  "validation_failed",
+			"adminId": adminID,
+			"userId":  userID,
+			"reason":  "invalid user uuid",
+		})
+		return 0, fmt.Errorf("invalid user uuid: %w", err)
+	}
+
+	// 个人演示文稿与回收站按用户 UUID 分目录；工作区目录按工作区 UUID 存放，不受影响
+	dirs := []string{
+		filepath.Join(s.presentationsRoot, userUUID),
+		filepath.Join(s.trashRoot, userUUID),
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	removed := 0
+	for _, dir := range dirs {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		if _, err := os.Stat(dir); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return removed, fmt.Errorf("stat deck dir: %w", err)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			s.audit.Log("records.user.purge", map[string]any{
+				"status":  "error",
+				"adminId": adminID,
+				"userId":  userID,
+				"removed": removed,
+				"reason":  err.Error(),
+			})
+			return removed, fmt.Errorf("remove deck dir: %w", err)
+		}
+		removed++
+	}
+
+	s.audit.Log("records.user.purge", map[string]any{
+		"status":  "success",
+		"adminId": adminID,
+		"userId":  userID,
+		"removed": removed,
+	})
+	return removed, nil
+}
@@ -0,0 +1,295 @@
+package records
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// File statuses reported by DiffVersions.
+const (
+	DiffStatusAdded     = "added"
+	DiffStatusRemoved   = "removed"
+	DiffStatusModified  = "modified"
+	DiffStatusUnchanged = "unchanged"
+)
+
+const (
+	diffContextLines = 3
+	// maxDiffCells bounds the LCS table; larger files are diffed as a full rewrite.
+	maxDiffCells = 4_000_000
+)
+
+// VersionDiff compares two snapshots of a deck file by file.
+type VersionDiff struct {
+	From          int
+	To            int
+	ConfigChanged bool
+	ConfigPatch   string
+	Files         []FileDiff
+}
+
+// FileDiff describes how one file in the slides directory changed.
+// Patch holds a unified diff for text files that were added, removed or modified.
+type FileDiff struct {
+	Name   string
+	Status string
+	Binary bool
+	Patch  string
+}
+
+// DiffVersions compares version from against version to.
+func (s *Service) DiffVersions(ctx context.Context, target SlideTarget, from, to int) (VersionDiff, error) {
+	if from <= 0 || to <= 0 {
+		s.audit.Log("records.versions.diff", map[string]any{
+			"status":   "validation_failed",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   ErrInvalidVersion.Error(),
+		})
+		return VersionDiff{}, ErrInvalidVersion
+	}
+
+	record, _, err := s.resolveSlidesDir(ctx, target, "records.versions.diff", RoleViewer)
+	if err != nil {
+		return VersionDiff{}, err
+	}
+
+	older, err := s.loadVersion(ctx, target, record, from, "records.versions.diff")
+	if err != nil {
+		return VersionDiff{}, err
+	}
+	newer, err := s.loadVersion(ctx, target, record, to, "records.versions.diff")
+	if err != nil {
+		return VersionDiff{}, err
+	}
+
+	diff := VersionDiff{From: from, To: to, Files: []FileDiff{}}
+
+	if older.Manifest.Config != newer.Manifest.Config {
+		diff.ConfigChanged = true
+		patch, _, err := s.diffBlobs(SlidesConfigFile, older.Manifest.Config, newer.Manifest.Config)
+		if err != nil {
+			return VersionDiff{}, err
+		}
+		diff.ConfigPatch = patch
+	}
+
+	before := make(map[string]VersionFile, len(older.Manifest.Files))
+	for _, file := range older.Manifest.Files {
+		before[file.Name] = file
+	}
+	after := make(map[string]VersionFile, len(newer.Manifest.Files))
+	for _, file := range newer.Manifest.Files {
+		after[file.Name] = file
+	}
+
+	names := make([]string, 0, len(before)+len(after))
+	for _, file := range older.Manifest.Files {
+		names = append(names, file.Name)
+	}
+	for _, file := range newer.Manifest.Files {
+		if _, ok := before[file.Name]; !ok {
+			names = append(names, file.Name)
+		}
+	}
+
+	for _, name := range names {
+		oldFile, inOld := before[name]
+		newFile, inNew := after[name]
+
+		entry := FileDiff{Name: name}
+		switch {
+		case !inOld:
+			entry.Status = DiffStatusAdded
+		case !inNew:
+			entry.Status = DiffStatusRemoved
+		case oldFile.Hash != newFile.Hash:
+			entry.Status = DiffStatusModified
+		default:
+			entry.Status = DiffStatusUnchanged
+		}
+
+		if entry.Status != DiffStatusUnchanged {
+			entry.Patch, entry.Binary, err = s.diffBlobs(name, oldFile.Hash, newFile.Hash)
+			if err != nil {
+				return VersionDiff{}, err
+			}
+		}
+		diff.Files = append(diff.Files, entry)
+	}
+
+	return diff, nil
+}
+
+// diffBlobs renders a unified diff between two blobs; an empty hash stands for
+// a missing file. Binary content yields no patch.
+func (s *Service) diffBlobs(name, oldHash, newHash string) (string, bool, error) {
+	var oldContent, newContent []byte
+	var err error
+	if oldHash != "" {
+		if oldContent, err = s.readBlob(oldHash); err != nil {
+			return "", false, err
+		}
+	}
+	if newHash != "" {
+		if newContent, err = s.readBlob(newHash); err != nil {
+			return "", false, err
+		}
+	}
+	if isBinaryContent(oldContent) || isBinaryContent(newContent) {
+		return "", true, nil
+	}
+	return unifiedDiff(name, string(oldContent), string(newContent)), false, nil
+}
+
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// unifiedDiff produces a diff -u style patch with three lines of context.
+func unifiedDiff(name, a, b string) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	for _, hunk := range diffHunks(ops, diffContextLines) {
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+		}
+		out.WriteString(hunk)
+	}
+	return out.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a line-level edit script from the longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	if len(a)*len(b) > maxDiffCells {
+		ops := make([]diffOp, 0, len(a)+len(b))
+		for _, line := range a {
+			ops = append(ops, diffOp{kind: '-', text: line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{kind: '+', text: line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{kind: '-', text: a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{kind: '-', text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{kind: '+', text: b[j]})
+	}
+	return ops
+}
+
+// diffHunks groups changes that are within 2*context lines of each other.
+func diffHunks(ops []diffOp, context int) []string {
+	// aLine[k] and bLine[k] count the old and new lines preceding ops[k].
+	aLine := make([]int, len(ops)+1)
+	bLine := make([]int, len(ops)+1)
+	for k, op := range ops {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if op.kind != '+' {
+			aLine[k+1]++
+		}
+		if op.kind != '-' {
+			bLine[k+1]++
+		}
+	}
+
+	var hunks []string
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+
+		start := max(0, k-context)
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+		stop := min(len(ops), end+context)
+
+		aCount := aLine[stop] - aLine[start]
+		bCount := bLine[stop] - bLine[start]
+		aStart := aLine[start]
+		if aCount > 0 {
+			aStart++
+		}
+		bStart := bLine[start]
+		if bCount > 0 {
+			bStart++
+		}
+
+		var hunk strings.Builder
+		fmt.Fprintf(&hunk, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, op := range ops[start:stop] {
+			hunk.WriteByte(op.kind)
+			hunk.WriteString(op.text)
+			if !strings.HasSuffix(op.text, "\n") {
+				hunk.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		hunks = append(hunks, hunk.String())
+		k = stop
+	}
+	return hunks
+}
@@ -0,0 +1,26 @@
+package records
+
+import "testing"
+
+func TestUnifiedDiffHunks(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+	got := unifiedDiff("slide-1.html", before, after)
+	want := "--- a/slide-1.html\n+++ b/slide-1.html\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -8,3 +8,4 @@\n h\n i\n j\n+k\n"
+	if got != want {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+
+	if diff := unifiedDiff("slide-1.html", before, before); diff != "" {
+		t.Fatalf("expected empty diff for identical input, got:\n%s", diff)
+	}
+
+	added := unifiedDiff("slide-2.html", "", "x")
+	wantAdded := "--- a/slide-2.html\n+++ b/slide-2.html\n@@ -0,0 +1,1 @@\n+x\n\\ No newline at end of file\n"
+	if added != wantAdded {
+		t.Fatalf("unexpected diff for new file:\n%s", added)
+	}
+}
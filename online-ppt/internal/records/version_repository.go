@@ -0,0 +1,175 @@
+package records
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RecordVersion represents a ppt_record_versions row.
+type RecordVersion struct {
+	ID        int64
+	RecordID  int64
+	Version   int
+	Label     sql.NullString
+	Reason    VersionReason
+	Manifest  VersionManifest
+	CreatedAt time.Time
+}
+
+// VersionManifest lists the blobs that make up a snapshot.
+type VersionManifest struct {
+	Config string        `json:"config"`
+	Files  []VersionFile `json:"files"`
+}
+
+// VersionFile maps a path inside the slides directory to its blob.
+type VersionFile struct {
+	Name string `json:"name"`
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+}
+
+// SlideCount reports how many slide-N.html files the snapshot holds.
+func (m VersionManifest) SlideCount() int {
+	count := 0
+	for _, file := range m.Files {
+		if slideFilePattern.MatchString(file.Name) {
+			count++
+		}
+	}
+	return count
+}
+
+const versionColumns = `id, record_id, version, label, reason, manifest, created_at`
+
+// CreateVersion inserts a snapshot row numbered after the record's latest
+// version and points the record's current_version at it. version.Version is
+// ignored; the allocated number is returned.
+func (r *Repository) CreateVersion(ctx context.Context, version RecordVersion) (RecordVersion, error) {
+	manifestJSON, err := json.Marshal(version.Manifest)
+	if err != nil {
+		return RecordVersion{}, fmt.Errorf("marshal manifest: %w", err)
+	}
+
+	err = r.WithTx(ctx, func(tx *sql.Tx) error {
+		// 先写记录行取得行锁，并发的快照在此排队，再在锁内读取最新版本号
+		if _, err := tx.ExecContext(ctx, `UPDATE ppt_records SET current_version = current_version WHERE id = ?`, version.RecordID); err != nil {
+			return fmt.Errorf("lock record: %w", err)
+		}
+		var latest int
+		if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM ppt_record_versions WHERE record_id = ?`, version.RecordID).Scan(&latest); err != nil {
+			return fmt.Errorf("read latest version: %w", err)
+		}
+		version.Version = latest + 1
+
+		stmt := `INSERT INTO ppt_record_versions (record_id, version, label, reason, manifest, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+		id, err := r.dialect.InsertID(ctx, tx, stmt,
+			version.RecordID,
+			version.Version,
+			version.Label,
+			string(version.Reason),
+			string(manifestJSON),
+			version.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("insert version: %w", err)
+		}
+		version.ID = id
+
+		if _, err := tx.ExecContext(ctx, `UPDATE ppt_records SET current_version = ? WHERE id = ?`, version.Version, version.RecordID); err != nil {
+			return fmt.Errorf("update current version: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return RecordVersion{}, err
+	}
+	return version, nil
+}
+
+// GetVersion fetches a single snapshot by its number.
+func (r *Repository) GetVersion(ctx context.Context, recordID int64, version int) (RecordVersion, error) {
+	stmt := `SELECT ` + versionColumns + ` FROM ppt_record_versions WHERE record_id = ? AND version = ? LIMIT 1`
+	return scanVersion(r.db.QueryRowContext(ctx, stmt, recordID, version))
+}
+
+// ListVersions returns every snapshot of a record, newest first.
+func (r *Repository) ListVersions(ctx context.Context, recordID int64) ([]RecordVersion, error) {
+	stmt := `SELECT ` + versionColumns + ` FROM ppt_record_versions WHERE record_id = ? ORDER BY version DESC`
+	rows, err := r.db.QueryContext(ctx, stmt, recordID)
+	if err != nil {
+		return nil, fmt.Errorf("list versions: %w", err)
+	}
+	defer rows.Close()
+
+	var results []RecordVersion
+	for rows.Next() {
+		version, err := scanVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ReferencedBlobs returns the set of blob hashes used by any snapshot.
+func (r *Repository) ReferencedBlobs(ctx context.Context) (map[string]struct{}, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT manifest FROM ppt_record_versions`)
+	if err != nil {
+		return nil, fmt.Errorf("list manifests: %w", err)
+	}
+	defer rows.Close()
+
+	referenced := make(map[string]struct{})
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		var manifest VersionManifest
+		if err := json.Unmarshal([]byte(raw), &manifest); err != nil {
+			return nil, fmt.Errorf("unmarshal manifest: %w", err)
+		}
+		if manifest.Config != "" {
+			referenced[manifest.Config] = struct{}{}
+		}
+		for _, file := range manifest.Files {
+			referenced[file.Hash] = struct{}{}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return referenced, nil
+}
+
+func scanVersion(row interface{ Scan(dest ...any) error }) (RecordVersion, error) {
+	var (
+		version  RecordVersion
+		reason   string
+		manifest string
+	)
+	if err := row.Scan(
+		&version.ID,
+		&version.RecordID,
+		&version.Version,
+		&version.Label,
+		&reason,
+		&manifest,
+		&version.CreatedAt,
+	); err != nil {
+		return RecordVersion{}, err
+	}
+	version.Reason = VersionReason(reason)
+	if err := json.Unmarshal([]byte(manifest), &version.Manifest); err != nil {
+		return RecordVersion{}, fmt.Errorf("unmarshal manifest: %w", err)
+	}
+	return version, nil
+}
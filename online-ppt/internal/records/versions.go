@@ -0,0 +1,488 @@
+package records
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// VersionReason records why a snapshot was taken.
+type VersionReason string
+
+const (
+	// VersionReasonManual marks snapshots requested explicitly by the user.
+	VersionReasonManual VersionReason = "manual"
+	// VersionReasonAuto marks snapshots taken before a destructive edit.
+	VersionReasonAuto VersionReason = "auto"
+	// VersionReasonRestore marks snapshots produced by restoring an older version.
+	VersionReasonRestore VersionReason = "restore"
+)
+
+const (
+	defaultVersionsDirName = ".versions"
+	maxVersionLabelLength  = 255
+)
+
+var (
+	// ErrVersionNotFound indicates the requested version does not exist for the record.
+	ErrVersionNotFound = errors.New("version not found")
+	// ErrInvalidVersion reports a malformed version number or label.
+	ErrInvalidVersion = errors.New("invalid version")
+
+	blobHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// SaveVersion snapshots the deck's slides directory and slides.config.json.
+func (s *Service) SaveVersion(ctx context.Context, target SlideTarget, label string) (RecordVersion, error) {
+	label = strings.TrimSpace(label)
+	if len([]rune(label)) > maxVersionLabelLength {
+		err := fmt.Errorf("label must not exceed %d characters: %w", maxVersionLabelLength, ErrInvalidVersion)
+		s.audit.Log("records.versions.save", map[string]any{
+			"status":   "validation_failed",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return RecordVersion{}, err
+	}
+
+	record, paths, err := s.resolveSlidesDir(ctx, target, "records.versions.save", RoleEditor)
+	if err != nil {
+		return RecordVersion{}, err
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	version, _, err := s.snapshot(ctx, &record, paths, VersionReasonManual, label, false)
+	if err != nil {
+		s.audit.Log("records.versions.save", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return RecordVersion{}, err
+	}
+
+	s.audit.Log("records.versions.save", map[string]any{
+		"status":   "success",
+		"userId":   target.UserID,
+		"recordId": target.RecordID,
+		"version":  version.Version,
+	})
+	return version, nil
+}
+
+// ListVersions returns the deck's snapshots, newest first, and its current version number.
+func (s *Service) ListVersions(ctx context.Context, target SlideTarget) ([]RecordVersion, int, error) {
+	record, _, err := s.resolveSlidesDir(ctx, target, "records.versions.list", RoleViewer)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	versions, err := s.repo.ListVersions(ctx, record.ID)
+	if err != nil {
+		s.audit.Log("records.versions.list", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   err.Error(),
+		})
+		return nil, 0, err
+	}
+	return versions, record.CurrentVersion, nil
+}
+
+// RestoreVersion replaces the deck's slides and config with the contents of an
+// older version. The state being replaced is snapshotted first, and the restore
+// itself is recorded as a new version so history only ever grows.
+func (s *Service) RestoreVersion(ctx context.Context, target SlideTarget, number int) (RecordVersion, error) {
+	if number <= 0 {
+		s.audit.Log("records.versions.restore", map[string]any{
+			"status":   "validation_failed",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   ErrInvalidVersion.Error(),
+		})
+		return RecordVersion{}, ErrInvalidVersion
+	}
+
+	record, paths, err := s.resolveSlidesDir(ctx, target, "records.versions.restore", RoleEditor)
+	if err != nil {
+		return RecordVersion{}, err
+	}
+
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	source, err := s.loadVersion(ctx, target, record, number, "records.versions.restore")
+	if err != nil {
+		return RecordVersion{}, err
+	}
+
+	if err := s.autoSnapshot(ctx, target, &record, paths, "records.versions.restore"); err != nil {
+		return RecordVersion{}, err
+	}
+
+	if err := s.materializeVersion(paths, source.Manifest); err != nil {
+		s.audit.Log("records.versions.restore", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"version":  number,
+			"reason":   err.Error(),
+		})
+		return RecordVersion{}, err
+	}
+
+	restored, _, err := s.snapshot(ctx, &record, paths, VersionReasonRestore, fmt.Sprintf("Restored from version %d", number), false)
+	if err != nil {
+		s.audit.Log("records.versions.restore", map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"version":  number,
+			"reason":   err.Error(),
+		})
+		return RecordVersion{}, err
+	}
+
+	s.audit.Log("records.versions.restore", map[string]any{
+		"status":     "success",
+		"userId":     target.UserID,
+		"recordId":   target.RecordID,
+		"version":    number,
+		"newVersion": restored.Version,
+	})
+	return restored, nil
+}
+
+// PurgeUnreferencedBlobs removes blobs no snapshot points at any more, for
+// example after the records owning them were purged from the trash.
+func (s *Service) PurgeUnreferencedBlobs(ctx context.Context) (int, error) {
+	s.fsMu.Lock()
+	defer s.fsMu.Unlock()
+
+	referenced, err := s.repo.ReferencedBlobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	err = filepath.WalkDir(s.blobRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return ctx.Err()
+		}
+		name := entry.Name()
+		if !blobHashPattern.MatchString(name) {
+			return nil
+		}
+		if _, ok := referenced[name]; ok {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("remove blob: %w", err)
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	if removed > 0 {
+		s.audit.Log("records.versions.gc", map[string]any{
+			"status":  "success",
+			"removed": removed,
+		})
+	}
+	return removed, nil
+}
+
+// autoSnapshot captures the deck before a destructive edit. It is a no-op when
+// the deck is empty or unchanged since the latest version. Callers must hold fsMu.
+func (s *Service) autoSnapshot(ctx context.Context, target SlideTarget, record *PptRecord, paths Paths, event string) error {
+	if _, _, err := s.snapshot(ctx, record, paths, VersionReasonAuto, "", true); err != nil {
+		s.audit.Log(event, map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"reason":   fmt.Sprintf("auto snapshot: %v", err),
+		})
+		return err
+	}
+	return nil
+}
+
+// snapshot stores the deck's files as blobs and records a new version. With
+// dedupe set, nothing is recorded when the deck is empty or matches the current
+// version. It reports whether a version was created. Callers must hold fsMu.
+func (s *Service) snapshot(ctx context.Context, record *PptRecord, paths Paths, reason VersionReason, label string, dedupe bool) (RecordVersion, bool, error) {
+	manifest, err := s.captureManifest(paths)
+	if err != nil {
+		return RecordVersion{}, false, err
+	}
+
+	if dedupe {
+		if manifest.Config == "" && len(manifest.Files) == 0 {
+			return RecordVersion{}, false, nil
+		}
+		if record.CurrentVersion > 0 {
+			current, err := s.repo.GetVersion(ctx, record.ID, record.CurrentVersion)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return RecordVersion{}, false, err
+			}
+			if err == nil && manifestsEqual(current.Manifest, manifest) {
+				return current, false, nil
+			}
+		}
+	}
+
+	version, err := s.repo.CreateVersion(ctx, RecordVersion{
+		RecordID:  record.ID,
+		Label:     sql.NullString{String: label, Valid: label != ""},
+		Reason:    reason,
+		Manifest:  manifest,
+		CreatedAt: s.clockFn().UTC(),
+	})
+	if err != nil {
+		return RecordVersion{}, false, err
+	}
+	record.CurrentVersion = version.Version
+	return version, true, nil
+}
+
+func (s *Service) loadVersion(ctx context.Context, target SlideTarget, record PptRecord, number int, event string) (RecordVersion, error) {
+	version, err := s.repo.GetVersion(ctx, record.ID, number)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log(event, map[string]any{
+				"status":   "not_found",
+				"userId":   target.UserID,
+				"recordId": target.RecordID,
+				"version":  number,
+			})
+			return RecordVersion{}, ErrVersionNotFound
+		}
+		s.audit.Log(event, map[string]any{
+			"status":   "error",
+			"userId":   target.UserID,
+			"recordId": target.RecordID,
+			"version":  number,
+			"reason":   err.Error(),
+		})
+		return RecordVersion{}, err
+	}
+	return version, nil
+}
+
+// captureManifest hashes every regular file in the slides directory plus
+// slides.config.json, storing any blob not already present.
+func (s *Service) captureManifest(paths Paths) (VersionManifest, error) {
+	manifest := VersionManifest{Files: []VersionFile{}}
+
+	err := filepath.WalkDir(paths.Canonical, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == paths.Canonical {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if path == paths.Canonical {
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", entry.Name(), err)
+		}
+		hash, err := s.storeBlob(content)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(paths.Canonical, path)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, VersionFile{
+			Name: filepath.ToSlash(rel),
+			Hash: hash,
+			Size: int64(len(content)),
+		})
+		return nil
+	})
+	if err != nil {
+		return VersionManifest{}, fmt.Errorf("capture slides: %w", err)
+	}
+
+	config, err := os.ReadFile(ConfigPath(paths))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return VersionManifest{}, fmt.Errorf("read %s: %w", SlidesConfigFile, err)
+		}
+		return manifest, nil
+	}
+	if manifest.Config, err = s.storeBlob(config); err != nil {
+		return VersionManifest{}, err
+	}
+	return manifest, nil
+}
+
+// materializeVersion rebuilds the slides directory from a manifest in a staging
+// directory and swaps it into place, then restores slides.config.json.
+func (s *Service) materializeVersion(paths Paths, manifest VersionManifest) error {
+	deckDir := filepath.Dir(paths.Canonical)
+	if err := os.MkdirAll(deckDir, 0o755); err != nil {
+		return fmt.Errorf("ensure deck dir: %w", err)
+	}
+
+	staging, err := os.MkdirTemp(deckDir, ".slides-restore-")
+	if err != nil {
+		return fmt.Errorf("create staging dir: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	for _, file := range manifest.Files {
+		dest, err := stagedFilePath(staging, file.Name)
+		if err != nil {
+			return err
+		}
+		content, err := s.readBlob(file.Hash)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return fmt.Errorf("ensure staging subdir: %w", err)
+		}
+		if err := os.WriteFile(dest, content, 0o644); err != nil {
+			return fmt.Errorf("write staged file: %w", err)
+		}
+	}
+
+	var config []byte
+	if manifest.Config != "" {
+		if config, err = s.readBlob(manifest.Config); err != nil {
+			return err
+		}
+	}
+
+	previous := ""
+	if _, err := os.Stat(paths.Canonical); err == nil {
+		previous = staging + ".old"
+		if err := os.Rename(paths.Canonical, previous); err != nil {
+			return fmt.Errorf("move current slides aside: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("stat slides dir: %w", err)
+	}
+
+	if err := os.Rename(staging, paths.Canonical); err != nil {
+		if previous != "" {
+			_ = os.Rename(previous, paths.Canonical)
+		}
+		return fmt.Errorf("swap in restored slides: %w", err)
+	}
+	if previous != "" {
+		_ = os.RemoveAll(previous)
+	}
+
+	if config == nil {
+		if err := os.Remove(ConfigPath(paths)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove %s: %w", SlidesConfigFile, err)
+		}
+		return nil
+	}
+	return writeFileAtomic(ConfigPath(paths), config, 0o644)
+}
+
+// stagedFilePath joins a manifest entry onto the staging dir, rejecting names
+// that would escape it.
+func stagedFilePath(staging, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if clean == "." || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("manifest entry %q escapes slides dir", name)
+	}
+	return filepath.Join(staging, clean), nil
+}
+
+// storeBlob writes content under its SHA-256 hash unless it is already stored.
+func (s *Service) storeBlob(content []byte) (string, error) {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	path := s.blobPath(hash)
+
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("stat blob: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("ensure blob dir: %w", err)
+	}
+	if err := writeFileAtomic(path, content, 0o644); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// readBlob loads a blob and verifies it still matches its hash.
+func (s *Service) readBlob(hash string) ([]byte, error) {
+	if !blobHashPattern.MatchString(hash) {
+		return nil, fmt.Errorf("invalid blob hash %q", hash)
+	}
+	content, err := os.ReadFile(s.blobPath(hash))
+	if err != nil {
+		return nil, fmt.Errorf("read blob: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != hash {
+		return nil, fmt.Errorf("blob %s is corrupt", hash)
+	}
+	return content, nil
+}
+
+func (s *Service) blobPath(hash string) string {
+	return filepath.Join(s.blobRoot, hash[:2], hash)
+}
+
+func manifestsEqual(a, b VersionManifest) bool {
+	if a.Config != b.Config || len(a.Files) != len(b.Files) {
+		return false
+	}
+	for i := range a.Files {
+		if a.Files[i].Name != b.Files[i].Name || a.Files[i].Hash != b.Files[i].Hash {
+			return false
+		}
+	}
+	return true
+}
+
+func isBinaryContent(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0
+}
@@ -0,0 +1,215 @@
+package records
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Workspace represents a workspaces row.
+type Workspace struct {
+	ID        int64
+	UUID      string
+	Name      string
+	CreatedBy sql.NullInt64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WorkspaceMembership is a workspace as seen by one of its members.
+type WorkspaceMembership struct {
+	Workspace Workspace
+	Role      WorkspaceRole
+}
+
+// WorkspaceMember represents a workspace_members row joined with the member's account.
+type WorkspaceMember struct {
+	WorkspaceID int64
+	UserID      int64
+	Email       string
+	Role        WorkspaceRole
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+const workspaceColumns = `w.id, w.uuid, w.name, w.created_by, w.created_at, w.updated_at`
+
+// CreateWorkspace inserts a workspace and makes adminID its first admin.
+func (r *Repository) CreateWorkspace(ctx context.Context, workspace Workspace, adminID int64) (Workspace, error) {
+	err := r.WithTx(ctx, func(tx *sql.Tx) error {
+		id, err := r.dialect.InsertID(ctx, tx,
+			`INSERT INTO workspaces (uuid, name, created_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+			workspace.UUID,
+			workspace.Name,
+			workspace.CreatedBy,
+			workspace.CreatedAt,
+			workspace.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("insert workspace: %w", err)
+		}
+		workspace.ID = id
+
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO workspace_members (workspace_id, user_id, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+			id, adminID, string(WorkspaceRoleAdmin), workspace.CreatedAt, workspace.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("insert workspace admin: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return Workspace{}, err
+	}
+	return workspace, nil
+}
+
+// ListWorkspaces returns the workspaces a user belongs to, ordered by name.
+func (r *Repository) ListWorkspaces(ctx context.Context, userID int64) ([]WorkspaceMembership, error) {
+	stmt := `SELECT ` + workspaceColumns + `, m.role FROM workspaces w ` +
+		`JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = ? ORDER BY w.name ASC, w.id ASC`
+	rows, err := r.db.QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	var results []WorkspaceMembership
+	for rows.Next() {
+		membership, err := scanWorkspaceMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, membership)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetWorkspaceMembership loads a workspace together with the user's role in it.
+func (r *Repository) GetWorkspaceMembership(ctx context.Context, userID, workspaceID int64) (WorkspaceMembership, error) {
+	stmt := `SELECT ` + workspaceColumns + `, m.role FROM workspaces w ` +
+		`JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = ? WHERE w.id = ? LIMIT 1`
+	return scanWorkspaceMembership(r.db.QueryRowContext(ctx, stmt, userID, workspaceID))
+}
+
+// GetWorkspaceRecord loads a live workspace deck the user is a member of
+// together with the workspace UUID and the user's workspace role.
+func (r *Repository) GetWorkspaceRecord(ctx context.Context, userID, recordID int64) (PptRecord, string, WorkspaceRole, error) {
+	stmt := `SELECT ` + sharedRecordColumns + `, w.uuid, m.role FROM ppt_records r ` +
+		`JOIN workspaces w ON w.id = r.workspace_id ` +
+		`JOIN workspace_members m ON m.workspace_id = r.workspace_id AND m.user_id = ? ` +
+		`WHERE r.id = ? AND r.deleted_at IS NULL LIMIT 1`
+
+	var (
+		workspaceUUID string
+		role          string
+	)
+	record, err := scanRecord(withExtraColumns(r.db.QueryRowContext(ctx, stmt, userID, recordID), &workspaceUUID, &role))
+	if err != nil {
+		return PptRecord{}, "", "", err
+	}
+	return record, workspaceUUID, WorkspaceRole(role), nil
+}
+
+// AddWorkspaceMember inserts a workspace_members row.
+func (r *Repository) AddWorkspaceMember(ctx context.Context, member WorkspaceMember) error {
+	stmt := `INSERT INTO workspace_members (workspace_id, user_id, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`
+	if _, err := r.db.ExecContext(ctx, stmt,
+		member.WorkspaceID,
+		member.UserID,
+		string(member.Role),
+		member.CreatedAt,
+		member.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("insert workspace member: %w", err)
+	}
+	return nil
+}
+
+// ListWorkspaceMembers returns the members of a workspace, oldest first.
+func (r *Repository) ListWorkspaceMembers(ctx context.Context, workspaceID int64) ([]WorkspaceMember, error) {
+	stmt := `SELECT m.workspace_id, m.user_id, u.email, m.role, m.created_at, m.updated_at ` +
+		`FROM workspace_members m JOIN user_accounts u ON u.id = m.user_id WHERE m.workspace_id = ? ORDER BY m.created_at ASC, m.id ASC`
+	rows, err := r.db.QueryContext(ctx, stmt, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("list workspace members: %w", err)
+	}
+	defer rows.Close()
+
+	var results []WorkspaceMember
+	for rows.Next() {
+		var (
+			member WorkspaceMember
+			role   string
+		)
+		if err := rows.Scan(
+			&member.WorkspaceID,
+			&member.UserID,
+			&member.Email,
+			&role,
+			&member.CreatedAt,
+			&member.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		member.Role = WorkspaceRole(role)
+		results = append(results, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// UpdateWorkspaceMemberRole changes a member's workspace role.
+func (r *Repository) UpdateWorkspaceMemberRole(ctx context.Context, workspaceID, userID int64, role WorkspaceRole) error {
+	stmt := `UPDATE workspace_members SET role = ?, updated_at = NOW() WHERE workspace_id = ? AND user_id = ?`
+	res, err := r.db.ExecContext(ctx, stmt, string(role), workspaceID, userID)
+	if err != nil {
+		return fmt.Errorf("update workspace member: %w", err)
+	}
+	return expectAffected(res)
+}
+
+// RemoveWorkspaceMember deletes a workspace_members row.
+func (r *Repository) RemoveWorkspaceMember(ctx context.Context, workspaceID, userID int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM workspace_members WHERE workspace_id = ? AND user_id = ?`, workspaceID, userID)
+	if err != nil {
+		return fmt.Errorf("remove workspace member: %w", err)
+	}
+	return expectAffected(res)
+}
+
+// CountWorkspaceAdmins returns how many admins a workspace has.
+func (r *Repository) CountWorkspaceAdmins(ctx context.Context, workspaceID int64) (int, error) {
+	var count int
+	stmt := `SELECT COUNT(*) FROM workspace_members WHERE workspace_id = ? AND role = 'admin'`
+	if err := r.db.QueryRowContext(ctx, stmt, workspaceID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count workspace admins: %w", err)
+	}
+	return count, nil
+}
+
+func scanWorkspaceMembership(row interface{ Scan(dest ...any) error }) (WorkspaceMembership, error) {
+	var (
+		membership WorkspaceMembership
+		role       string
+	)
+	if err := row.Scan(
+		&membership.Workspace.ID,
+		&membership.Workspace.UUID,
+		&membership.Workspace.Name,
+		&membership.Workspace.CreatedBy,
+		&membership.Workspace.CreatedAt,
+		&membership.Workspace.UpdatedAt,
+		&role,
+	); err != nil {
+		return WorkspaceMembership{}, err
+	}
+	membership.Role = WorkspaceRole(role)
+	return membership, nil
+}
@@ -0,0 +1,378 @@
+package records
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// WorkspaceRole is a member's level of access to a workspace and its decks.
+type WorkspaceRole string
+
+const (
+	// WorkspaceRoleViewer may read every deck in the workspace.
+	WorkspaceRoleViewer WorkspaceRole = "viewer"
+	// WorkspaceRoleMember may additionally create and edit decks.
+	WorkspaceRoleMember WorkspaceRole = "member"
+	// WorkspaceRoleAdmin may additionally rename, delete and share decks and manage members.
+	WorkspaceRoleAdmin WorkspaceRole = "admin"
+)
+
+const maxWorkspaceNameLength = 120
+
+var (
+	// ErrWorkspaceNotFound indicates the workspace does not exist or the user is not a member.
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	// ErrInvalidWorkspace reports an invalid workspace name, member role or invite.
+	ErrInvalidWorkspace = errors.New("invalid workspace request")
+	// ErrWorkspaceMemberExists indicates the user already belongs to the workspace.
+	ErrWorkspaceMemberExists = errors.New("workspace member already exists")
+	// ErrWorkspaceMemberNotFound indicates the user does not belong to the workspace.
+	ErrWorkspaceMemberNotFound = errors.New("workspace member not found")
+	// ErrLastWorkspaceAdmin prevents a workspace from being left without an admin.
+	ErrLastWorkspaceAdmin = errors.New("workspace must keep at least one admin")
+)
+
+// ParseWorkspaceRole validates a workspace role name.
+func ParseWorkspaceRole(value string) (WorkspaceRole, bool) {
+	role := WorkspaceRole(value)
+	return role, role.deckRole() != ""
+}
+
+// Allows reports whether r grants at least the access of need.
+func (r WorkspaceRole) Allows(need WorkspaceRole) bool {
+	return r.deckRole().Allows(need.deckRole())
+}
+
+// deckRole maps a workspace role onto the role it grants on workspace decks.
+func (r WorkspaceRole) deckRole() Role {
+	switch r {
+	case WorkspaceRoleViewer:
+		return RoleViewer
+	case WorkspaceRoleMember:
+		return RoleEditor
+	case WorkspaceRoleAdmin:
+		return RoleOwner
+	default:
+		return ""
+	}
+}
+
+// CreateWorkspace creates a workspace with the user as its first admin.
+func (s *Service) CreateWorkspace(ctx context.Context, userID int64, name string) (WorkspaceMembership, error) {
+	if userID <= 0 {
+		s.audit.Log("workspaces.create", map[string]any{
+			"status": "validation_failed",
+			"reason": errInvalidUserID.Error(),
+		})
+		return WorkspaceMembership{}, errInvalidUserID
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxWorkspaceNameLength {
+		s.audit.Log("workspaces.create", map[string]any{
+			"status": "validation_failed",
+			"userId": userID,
+			"reason": "invalid workspace name",
+		})
+		return WorkspaceMembership{}, fmt.Errorf("name must be 1-%d characters: %w", maxWorkspaceNameLength, ErrInvalidWorkspace)
+	}
+
+	now := s.clockFn().UTC()
+	workspace, err := s.repo.CreateWorkspace(ctx, Workspace{
+		UUID:      uuid.NewString(),
+		Name:      name,
+		CreatedBy: sql.NullInt64{Int64: userID, Valid: true},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, userID)
+	if err != nil {
+		s.audit.Log("workspaces.create", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return WorkspaceMembership{}, err
+	}
+
+	s.audit.Log("workspaces.create", map[string]any{
+		"status":      "success",
+		"userId":      userID,
+		"workspaceId": workspace.ID,
+	})
+	return WorkspaceMembership{Workspace: workspace, Role: WorkspaceRoleAdmin}, nil
+}
+
+// ListWorkspaces returns the workspaces the user belongs to.
+func (s *Service) ListWorkspaces(ctx context.Context, userID int64) ([]WorkspaceMembership, error) {
+	if userID <= 0 {
+		return nil, errInvalidUserID
+	}
+
+	memberships, err := s.repo.ListWorkspaces(ctx, userID)
+	if err != nil {
+		s.audit.Log("workspaces.list", map[string]any{
+			"status": "error",
+			"userId": userID,
+			"reason": err.Error(),
+		})
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// AddWorkspaceMember adds the account registered under email to a workspace
+// the user administers.
+func (s *Service) AddWorkspaceMember(ctx context.Context, userID, workspaceID int64, email string, role WorkspaceRole) (WorkspaceMember, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if _, err := mail.ParseAddress(email); err != nil || email == "" {
+		return WorkspaceMember{}, s.rejectWorkspaceRequest(userID, workspaceID, "workspaces.members.add", fmt.Errorf("invalid email: %w", ErrInvalidWorkspace))
+	}
+	if _, ok := ParseWorkspaceRole(string(role)); !ok {
+		return WorkspaceMember{}, s.rejectWorkspaceRequest(userID, workspaceID, "workspaces.members.add", fmt.Errorf("unknown role %q: %w", role, ErrInvalidWorkspace))
+	}
+
+	membership, err := s.authorizeWorkspace(ctx, userID, workspaceID, "workspaces.members.add", WorkspaceRoleAdmin)
+	if err != nil {
+		return WorkspaceMember{}, err
+	}
+
+	memberID, err := s.repo.FindUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.audit.Log("workspaces.members.add", map[string]any{
+				"status":      "not_found",
+				"userId":      userID,
+				"workspaceId": workspaceID,
+				"reason":      ErrInviteeNotFound.Error(),
+			})
+			return WorkspaceMember{}, ErrInviteeNotFound
+		}
+		s.audit.Log("workspaces.members.add", map[string]any{
+			"status":      "error",
+			"userId":      userID,
+			"workspaceId": workspaceID,
+			"reason":      err.Error(),
+		})
+		return WorkspaceMember{}, err
+	}
+
+	now := s.clockFn().UTC()
+	member := WorkspaceMember{
+		WorkspaceID: membership.Workspace.ID,
+		UserID:      memberID,
+		Email:       email,
+		Role:        role,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.AddWorkspaceMember(ctx, member); err != nil {
+		if s.repo.dialect.IsUniqueViolation(err) {
+			s.audit.Log("workspaces.members.add", map[string]any{
+				"status":      "conflict",
+				"userId":      userID,
+				"workspaceId": workspaceID,
+				"reason":      ErrWorkspaceMemberExists.Error(),
+			})
+			return WorkspaceMember{}, ErrWorkspaceMemberExists
+		}
+		s.audit.Log("workspaces.members.add", map[string]any{
+			"status":      "error",
+			"userId":      userID,
+			"workspaceId": workspaceID,
+			"reason":      err.Error(),
+		})
+		return WorkspaceMember{}, err
+	}
+
+	s.audit.Log("workspaces.members.add", map[string]any{
+		"status":      "success",
+		"userId":      userID,
+		"workspaceId": workspaceID,
+		"memberId":    memberID,
+		"role":        string(role),
+	})
+	return member, nil
+}
+
+// ListWorkspaceMembers returns the members of a workspace the user belongs to.
+func (s *Service) ListWorkspaceMembers(ctx context.Context, userID, workspaceID int64) ([]WorkspaceMember, error) {
+	membership, err := s.authorizeWorkspace(ctx, userID, workspaceID, "workspaces.members.list", WorkspaceRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.repo.ListWorkspaceMembers(ctx, membership.Workspace.ID)
+	if err != nil {
+		s.audit.Log("workspaces.members.list", map[string]any{
+			"status":      "error",
+			"userId":      userID,
+			"workspaceId": workspaceID,
+			"reason":      err.Error(),
+		})
+		return nil, err
+	}
+	return members, nil
+}
+
+// UpdateWorkspaceMember changes a member's role in a workspace the user administers.
+func (s *Service) UpdateWorkspaceMember(ctx context.Context, userID, workspaceID, memberID int64, role WorkspaceRole) error {
+	if _, ok := ParseWorkspaceRole(string(role)); !ok {
+		return s.rejectWorkspaceRequest(userID, workspaceID, "workspaces.members.update", fmt.Errorf("unknown role %q: %w", role, ErrInvalidWorkspace))
+	}
+
+	membership, err := s.authorizeWorkspace(ctx, userID, workspaceID, "workspaces.members.update", WorkspaceRoleAdmin)
+	if err != nil {
+		return err
+	}
+	if memberID == userID && role != WorkspaceRoleAdmin {
+		if err := s.ensureAnotherAdmin(ctx, userID, membership.Workspace.ID, "workspaces.members.update"); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.UpdateWorkspaceMemberRole(ctx, membership.Workspace.ID, memberID, role); err != nil {
+		return s.workspaceMemberWriteFailed(userID, workspaceID, memberID, "workspaces.members.update", err)
+	}
+
+	s.audit.Log("workspaces.members.update", map[string]any{
+		"status":      "success",
+		"userId":      userID,
+		"workspaceId": workspaceID,
+		"memberId":    memberID,
+		"role":        string(role),
+	})
+	return nil
+}
+
+// RemoveWorkspaceMember removes a member from a workspace. Admins may remove
+// anyone; any member may leave. Decks stay with the workspace either way.
+func (s *Service) RemoveWorkspaceMember(ctx context.Context, userID, workspaceID, memberID int64) error {
+	need := WorkspaceRoleAdmin
+	if memberID == userID {
+		need = WorkspaceRoleViewer
+	}
+
+	membership, err := s.authorizeWorkspace(ctx, userID, workspaceID, "workspaces.members.remove", need)
+	if err != nil {
+		return err
+	}
+	if memberID == userID && membership.Role == WorkspaceRoleAdmin {
+		if err := s.ensureAnotherAdmin(ctx, userID, membership.Workspace.ID, "workspaces.members.remove"); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.RemoveWorkspaceMember(ctx, membership.Workspace.ID, memberID); err != nil {
+		return s.workspaceMemberWriteFailed(userID, workspaceID, memberID, "workspaces.members.remove", err)
+	}
+
+	s.audit.Log("workspaces.members.remove", map[string]any{
+		"status":      "success",
+		"userId":      userID,
+		"workspaceId": workspaceID,
+		"memberId":    memberID,
+	})
+	return nil
+}
+
+// authorizeWorkspace resolves the user's membership of a workspace and checks
+// that it grants need, auditing failures under event. Workspaces the user
+// does not belong to surface as ErrWorkspaceNotFound.
+func (s *Service) authorizeWorkspace(ctx context.Context, userID, workspaceID int64, event string, need WorkspaceRole) (WorkspaceMembership, error) {
+	if userID <= 0 {
+		s.audit.Log(event, map[string]any{
+			"status": "validation_failed",
+			"reason": errInvalidUserID.Error(),
+		})
+		return WorkspaceMembership{}, errInvalidUserID
+	}
+	if workspaceID <= 0 {
+		return WorkspaceMembership{}, s.rejectWorkspaceRequest(userID, workspaceID, event, fmt.Errorf("workspace id must be positive: %w", ErrInvalidWorkspace))
+	}
+
+	membership, err := s.repo.GetWorkspaceMembership(ctx, userID, workspaceID)
+	if err != nil {
+		status := "error"
+		if errors.Is(err, sql.ErrNoRows) {
+			status = "not_found"
+			err = ErrWorkspaceNotFound
+		}
+		s.audit.Log(event, map[string]any{
+			"status":      status,
+			"userId":      userID,
+			"workspaceId": workspaceID,
+			"reason":      err.Error(),
+		})
+		return WorkspaceMembership{}, err
+	}
+	if !membership.Role.Allows(need) {
+		s.audit.Log(event, map[string]any{
+			"status":      "forbidden",
+			"userId":      userID,
+			"workspaceId": workspaceID,
+			"reason":      ErrForbidden.Error(),
+		})
+		return WorkspaceMembership{}, ErrForbidden
+	}
+	return membership, nil
+}
+
+func (s *Service) ensureAnotherAdmin(ctx context.Context, userID, workspaceID int64, event string) error {
+	admins, err := s.repo.CountWorkspaceAdmins(ctx, workspaceID)
+	if err != nil {
+		s.audit.Log(event, map[string]any{
+			"status":      "error",
+			"userId":      userID,
+			"workspaceId": workspaceID,
+			"reason":      err.Error(),
+		})
+		return err
+	}
+	if admins <= 1 {
+		s.audit.Log(event, map[string]any{
+			"status":      "conflict",
+			"userId":      userID,
+			"workspaceId": workspaceID,
+			"reason":      ErrLastWorkspaceAdmin.Error(),
+		})
+		return ErrLastWorkspaceAdmin
+	}
+	return nil
+}
+
+func (s *Service) rejectWorkspaceRequest(userID, workspaceID int64, event string, err error) error {
+	s.audit.Log(event, map[string]any{
+		"status":      "validation_failed",
+		"userId":      userID,
+		"workspaceId": workspaceID,
+		"reason":      err.Error(),
+	})
+	return err
+}
+
+func (s *Service) workspaceMemberWriteFailed(userID, workspaceID, memberID int64, event string, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		s.audit.Log(event, map[string]any{
+			"status":      "not_found",
+			"userId":      userID,
+			"workspaceId": workspaceID,
+			"memberId":    memberID,
+		})
+		return ErrWorkspaceMemberNotFound
+	}
+	s.audit.Log(event, map[string]any{
+		"status":      "error",
+		"userId":      userID,
+		"workspaceId": workspaceID,
+		"memberId":    memberID,
+		"reason":      err.Error(),
+	})
+	return err
+}
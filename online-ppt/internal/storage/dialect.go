@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// Dialect covers the SQL that differs between the supported databases.
+// Repositories write MySQL-flavoured SQL with `?` placeholders and `NOW()`;
+// the PostgreSQL and SQLite connections accept both, so only the constructs
+// below need a dialect.
+type Dialect interface {
+	// Name is the storage.driver value selecting the dialect.
+	Name() string
+	// IsUniqueViolation reports whether err was caused by a unique constraint.
+	IsUniqueViolation(err error) bool
+	// JSONArrayContains returns a predicate that holds when the JSON array
+	// stored in column contains the string bound to its one placeholder. A
+	// NULL column counts as an empty array.
+	JSONArrayContains(column string) string
+	// Upsert returns the clause that turns an INSERT into an update of
+	// assignments when a row with the same key exists.
+	Upsert(key string, assignments ...string) string
+	// Excluded refers to the value an upserting INSERT tried to write to column.
+	Excluded(column string) string
+	// InsertID runs an INSERT into a table with an `id` column and returns the
+	// generated id.
+	InsertID(ctx context.Context, q Execer, stmt string, args ...any) (int64, error)
+
+	migrations() fs.FS
+	sessionSetup() []string
+	ledgerDDL() string
+	// tableExistsQuery counts the tables named by its one placeholder.
+	tableExistsQuery() string
+	// legacyVersion is the last migration databases created before the
+	// schema_migrations ledger already have, or 0 when none predate it.
+	legacyVersion() int64
+	lock(ctx context.Context, conn *sql.Conn, timeout time.Duration) (func(), error)
+}
+
+// Execer is satisfied by *sql.DB, *sql.Tx and *sql.Conn.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Supported storage.driver values.
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Dialects of the supported databases.
+var (
+	MySQL    Dialect = mysqlDialect{}
+	Postgres Dialect = postgresDialect{}
+	SQLite   Dialect = sqliteDialect{}
+)
+
+// DialectFor returns the dialect configured by storage.driver.
+func DialectFor(driver string) (Dialect, error) {
+	switch strings.ToLower(driver) {
+	case DriverMySQL:
+		return MySQL, nil
+	case DriverPostgres, "postgresql":
+		return Postgres, nil
+	case DriverSQLite, "sqlite3":
+		return SQLite, nil
+	default:
+		return nil, fmt.Errorf("unsupported storage driver %q", driver)
+	}
+}
+
+// Open connects to the database configured by driver and dsn and returns its
+// dialect.
+func Open(ctx context.Context, driver, dsn string) (*sql.DB, Dialect, error) {
+	dialect, err := DialectFor(driver)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var db *sql.DB
+	switch dialect {
+	case Postgres:
+		db, err = PostgresFactory{DSN: dsn}.Open(ctx)
+	case SQLite:
+		db, err = SQLiteFactory{Path: dsn}.Open(ctx)
+	default:
+		db, err = MySQLFactory{DSN: dsn}.Open(ctx)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, dialect, nil
+}
+
+// lastInsertID is InsertID for drivers that report the generated id.
+func lastInsertID(ctx context.Context, q Execer, stmt string, args ...any) (int64, error) {
+	res, err := q.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("derive insert id: %w", err)
+	}
+	return id, nil
+}
+
+// configurePool applies the pool settings shared by the factories.
+func configurePool(db *sql.DB, maxIdle, maxOpen int, life, idle time.Duration) {
+	if maxIdle <= 0 {
+		maxIdle = defaultMaxIdleConns
+	}
+	db.SetMaxIdleConns(maxIdle)
+
+	if maxOpen <= 0 {
+		maxOpen = defaultMaxOpenConns
+	}
+	db.SetMaxOpenConns(maxOpen)
+
+	if life <= 0 {
+		life = defaultConnMaxLifetime
+	}
+	db.SetConnMaxLifetime(life)
+
+	if idle <= 0 {
+		idle = defaultConnMaxIdleTime
+	}
+	db.SetConnMaxIdleTime(idle)
+}
+
+// pingDB verifies connectivity with a bounded ping, closing db on failure.
+func pingDB(ctx context.Context, db *sql.DB, name string) error {
+	pingCtx, cancel := context.WithTimeout(ctx, defaultConnectTimeout)
+	defer cancel()
+
+	if err := db.PingContext(pingCtx); err != nil {
+		db.Close()
+		return fmt.Errorf("ping %s: %w", name, err)
+	}
+	return nil
+}
+
+// subFS returns the migration set in dir of the embedded migrations.
+func subFS(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		// fs.Sub only fails for invalid paths, which dir never is
+		panic(err)
+	}
+	return sub
+}
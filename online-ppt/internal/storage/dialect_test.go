@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialectFor(t *testing.T) {
+	for driver, want := range map[string]Dialect{
+		"mysql":      MySQL,
+		"postgres":   Postgres,
+		"PostgreSQL": Postgres,
+		"sqlite":     SQLite,
+		"sqlite3":    SQLite,
+	} {
+		got, err := DialectFor(driver)
+		require.NoError(t, err, driver)
+		require.Equal(t, want, got, driver)
+	}
+
+	_, err := DialectFor("oracle")
+	require.ErrorContains(t, err, `unsupported storage driver "oracle"`)
+}
+
+func TestDialectUpsert(t *testing.T) {
+	require.Equal(t, "ON DUPLICATE KEY UPDATE a = VALUES(a), b = NULL", MySQL.Upsert("id", "a = "+MySQL.Excluded("a"), "b = NULL"))
+	require.Equal(t, "ON CONFLICT (id) DO UPDATE SET a = EXCLUDED.a, b = NULL", Postgres.Upsert("id", "a = "+Postgres.Excluded("a"), "b = NULL"))
+	require.Equal(t, "ON CONFLICT (id) DO UPDATE SET a = excluded.a, b = NULL", SQLite.Upsert("id", "a = "+SQLite.Excluded("a"), "b = NULL"))
+}
@@ -2,88 +2,413 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/fs"
-	"os"
-	"path/filepath"
+	"path"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
-const defaultMigrationDir = "migrations"
+const defaultLockTimeout = time.Minute
 
-// Migrator executes plain SQL migration files in lexical order.
+var (
+	// ErrMigrationLocked reports that another instance held the migration lock
+	// for longer than the lock timeout.
+	ErrMigrationLocked = errors.New("migration lock held by another instance")
+	// ErrChecksumMismatch reports an applied migration whose file was edited afterwards.
+	ErrChecksumMismatch = errors.New("applied migration was modified")
+	// ErrUnknownMigration reports a ledger entry without a matching file, as
+	// left behind when an older binary runs against a newer schema.
+	ErrUnknownMigration = errors.New("applied migration not found")
+)
+
+// Migration is one schema version read from a pair of NNN_name.up.sql and
+// NNN_name.down.sql files.
+type Migration struct {
+	Version int64
+	Name    string
+	// Checksum is the SHA-256 of the up file, recorded when it is applied.
+	Checksum string
+	Up       []string
+	Down     []string
+}
+
+// MigrationStatus pairs a migration with its ledger entry.
+type MigrationStatus struct {
+	Migration
+	AppliedAt sql.NullTime
+	// Modified reports that the up file changed after it was applied.
+	Modified bool
+}
+
+// Migrator applies versioned migrations and records each one in the
+// schema_migrations ledger, so every version runs exactly once.
+//
+// MySQL commits DDL implicitly, so a migration failing halfway is not rolled
+// back and is not recorded; fix the schema by hand before retrying.
 type Migrator struct {
-	Dir string
+	// Dialect selects the database; nil means MySQL.
+	Dialect Dialect
+	// FS holds the migration files; nil uses the dialect's embedded set.
+	FS fs.FS
+	// DryRun makes Apply, Rollback and Baseline report what they would do
+	// without taking the lock or changing the database.
+	DryRun bool
+	// LockTimeout bounds the wait for another instance to finish migrating.
+	LockTimeout time.Duration
 }
 
-// Apply walks the migration directory and runs each *.sql file sequentially.
-func (m Migrator) Apply(ctx context.Context, db *sql.DB) error {
-	if db == nil {
-		return fmt.Errorf("nil database handle")
+// Migrations reads and parses every migration, ordered by version.
+func (m Migrator) Migrations() ([]Migration, error) {
+	fsys := m.FS
+	if fsys == nil {
+		fsys = m.dialect().migrations()
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
 	}
 
-	dir := m.Dir
-	if dir == "" {
-		dir = defaultMigrationDir
+	type pair struct{ up, down string }
+	files := make(map[string]*pair)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".sql" {
+			continue
+		}
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: name must end in .up.sql or .down.sql", name)
+		}
+		if files[base] == nil {
+			files[base] = &pair{}
+		}
+		if direction == "up" {
+			files[base].up = name
+		} else {
+			files[base].down = name
+		}
 	}
 
-	entries, err := os.ReadDir(dir)
+	result := make([]Migration, 0, len(files))
+	seen := make(map[int64]string, len(files))
+	for base, p := range files {
+		if p.up == "" || p.down == "" {
+			return nil, fmt.Errorf("migration %s: both up and down files are required", base)
+		}
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: name must start with a positive version number", base)
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, base, version)
+		}
+		seen[version] = base
+
+		migration := Migration{Version: version, Name: name}
+		up, err := fs.ReadFile(fsys, p.up)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", p.up, err)
+		}
+		sum := sha256.Sum256(up)
+		migration.Checksum = hex.EncodeToString(sum[:])
+		if migration.Up, err = splitStatements(string(up)); err != nil {
+			return nil, fmt.Errorf("parse migration %s: %w", p.up, err)
+		}
+		down, err := fs.ReadFile(fsys, p.down)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", p.down, err)
+		}
+		if migration.Down, err = splitStatements(string(down)); err != nil {
+			return nil, fmt.Errorf("parse migration %s: %w", p.down, err)
+		}
+		result = append(result, migration)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// Status lists every migration with the time it was applied, if it was.
+func (m Migrator) Status(ctx context.Context, db *sql.DB) ([]MigrationStatus, error) {
+	if db == nil {
+		return nil, fmt.Errorf("nil database handle")
+	}
+	all, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("read migrations dir: %w", err)
+		return nil, fmt.Errorf("acquire connection: %w", err)
 	}
+	defer conn.Close()
 
-	files := filterSQL(entries)
-	sort.Strings(files)
+	applied, err := m.readLedger(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, name := range files {
-		path := filepath.Join(dir, name)
-		if err := runSQLFile(ctx, db, path); err != nil {
-			return fmt.Errorf("apply migration %s: %w", name, err)
+	statuses := make([]MigrationStatus, 0, len(all))
+	for _, migration := range all {
+		status := MigrationStatus{Migration: migration}
+		if entry, ok := applied[migration.Version]; ok {
+			status.AppliedAt = sql.NullTime{Time: entry.appliedAt, Valid: true}
+			status.Modified = entry.checksum != migration.Checksum
 		}
+		statuses = append(statuses, status)
 	}
+	return statuses, nil
+}
 
-	return nil
+// Apply runs the pending migrations in version order and returns those it
+// applied, or in dry-run mode those it would apply. It refuses to run when an
+// applied migration was edited or is unknown. A database that predates the
+// ledger is baselined first, see adoptLegacySchema.
+func (m Migrator) Apply(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	var done []Migration
+	err := m.run(ctx, db, func(conn *sql.Conn, all []Migration, applied map[int64]ledgerEntry) error {
+		if err := m.adoptLegacySchema(ctx, conn, all, applied); err != nil {
+			return err
+		}
+		for _, migration := range all {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if !m.DryRun {
+				if err := execStatements(ctx, conn, migration.Up); err != nil {
+					return fmt.Errorf("apply migration %d_%s: %w", migration.Version, migration.Name, err)
+				}
+				if err := recordMigration(ctx, conn, migration); err != nil {
+					return err
+				}
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
 }
 
-func filterSQL(entries []fs.DirEntry) []string {
-	var files []string
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+// Rollback undoes the last steps applied migrations, newest first, and
+// returns those it reverted, or in dry-run mode those it would revert.
+func (m Migrator) Rollback(ctx context.Context, db *sql.DB, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("rollback steps must be positive")
+	}
+
+	var done []Migration
+	err := m.run(ctx, db, func(conn *sql.Conn, all []Migration, applied map[int64]ledgerEntry) error {
+		for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := all[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if !m.DryRun {
+				if err := execStatements(ctx, conn, migration.Down); err != nil {
+					return fmt.Errorf("roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+				}
+				if _, err := conn.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, migration.Version); err != nil {
+					return fmt.Errorf("unrecord migration %d: %w", migration.Version, err)
+				}
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Baseline records every migration up to and including version as applied
+// without running it, for databases created before the ledger existed.
+func (m Migrator) Baseline(ctx context.Context, db *sql.DB, version int64) ([]Migration, error) {
+	var done []Migration
+	err := m.run(ctx, db, func(conn *sql.Conn, all []Migration, applied map[int64]ledgerEntry) error {
+		for _, migration := range all {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if !m.DryRun {
+				if err := recordMigration(ctx, conn, migration); err != nil {
+					return err
+				}
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+type ledgerEntry struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// run loads the migrations and the ledger and calls fn while holding the
+// migration lock. Everything runs on one connection because both the session
+// settings and the advisory locks are scoped to it.
+func (m Migrator) run(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn, all []Migration, applied map[int64]ledgerEntry) error) error {
+	if db == nil {
+		return fmt.Errorf("nil database handle")
+	}
+	all, err := m.Migrations()
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if !m.DryRun {
+		dialect := m.dialect()
+		if err := execStatements(ctx, conn, dialect.sessionSetup()); err != nil {
+			return fmt.Errorf("prepare session: %w", err)
+		}
+		timeout := m.LockTimeout
+		if timeout <= 0 {
+			timeout = defaultLockTimeout
 		}
-		if strings.HasSuffix(entry.Name(), ".sql") {
-			files = append(files, entry.Name())
+		release, err := dialect.lock(ctx, conn, timeout)
+		if err != nil {
+			return err
 		}
+		defer release()
+
+		if _, err := conn.ExecContext(ctx, dialect.ledgerDDL()); err != nil {
+			return fmt.Errorf("create schema_migrations: %w", err)
+		}
+	}
+
+	applied, err := m.readLedger(ctx, conn)
+	if err != nil {
+		return err
 	}
-	return files
+	if err := verifyLedger(all, applied); err != nil {
+		return err
+	}
+	return fn(conn, all, applied)
 }
 
-func runSQLFile(ctx context.Context, db *sql.DB, path string) error {
-	data, err := os.ReadFile(path)
+func (m Migrator) dialect() Dialect {
+	if m.Dialect == nil {
+		return MySQL
+	}
+	return m.Dialect
+}
+
+// readLedger loads the applied versions. A missing ledger, which only a dry
+// run can observe, means nothing was applied.
+func (m Migrator) readLedger(ctx context.Context, conn *sql.Conn) (map[int64]ledgerEntry, error) {
+	exists, err := m.tableExists(ctx, conn, "schema_migrations")
 	if err != nil {
-		return fmt.Errorf("read file: %w", err)
+		return nil, err
+	}
+	applied := make(map[int64]ledgerEntry)
+	if !exists {
+		return applied, nil
 	}
 
-	stmts := splitStatements(string(data))
-	for _, stmt := range stmts {
-		if _, err := db.ExecContext(ctx, stmt); err != nil {
-			return fmt.Errorf("exec statement: %w", err)
+	rows, err := conn.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			version int64
+			entry   ledgerEntry
+		)
+		if err := rows.Scan(&version, &entry.checksum, &entry.appliedAt); err != nil {
+			return nil, fmt.Errorf("scan schema_migrations: %w", err)
 		}
+		applied[version] = entry
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate schema_migrations: %w", err)
+	}
+	return applied, nil
+}
+
+func (m Migrator) tableExists(ctx context.Context, conn *sql.Conn, table string) (bool, error) {
+	var count int
+	if err := conn.QueryRowContext(ctx, m.dialect().tableExistsQuery(), table).Scan(&count); err != nil {
+		return false, fmt.Errorf("check %s: %w", table, err)
+	}
+	return count > 0, nil
+}
+
+// adoptLegacySchema baselines a database created before the ledger existed:
+// with an empty ledger but an existing user_accounts table, the migrations up
+// to the dialect's legacy version are recorded as applied without running.
+func (m Migrator) adoptLegacySchema(ctx context.Context, conn *sql.Conn, all []Migration, applied map[int64]ledgerEntry) error {
+	legacy := m.dialect().legacyVersion()
+	if legacy == 0 || len(applied) > 0 {
+		return nil
+	}
+	exists, err := m.tableExists(ctx, conn, "user_accounts")
+	if err != nil || !exists {
+		return err
+	}
+	for _, migration := range all {
+		if migration.Version > legacy {
+			break
+		}
+		if !m.DryRun {
+			if err := recordMigration(ctx, conn, migration); err != nil {
+				return err
+			}
+		}
+		applied[migration.Version] = ledgerEntry{checksum: migration.Checksum}
 	}
 	return nil
 }
 
-func splitStatements(sqlText string) []string {
-	raw := strings.Split(sqlText, ";")
-	result := make([]string, 0, len(raw))
-	for _, stmt := range raw {
-		trimmed := strings.TrimSpace(stmt)
-		if trimmed == "" {
-			continue
+func verifyLedger(all []Migration, applied map[int64]ledgerEntry) error {
+	known := make(map[int64]Migration, len(all))
+	for _, migration := range all {
+		known[migration.Version] = migration
+	}
+	for version, entry := range applied {
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("version %d: %w", version, ErrUnknownMigration)
+		}
+		if migration.Checksum != entry.checksum {
+			return fmt.Errorf("version %d_%s: %w", version, migration.Name, ErrChecksumMismatch)
 		}
-		result = append(result, trimmed)
 	}
-	return result
+	return nil
+}
+
+func recordMigration(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	stmt := `INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)`
+	if _, err := conn.ExecContext(ctx, stmt, migration.Version, migration.Name, migration.Checksum); err != nil {
+		return fmt.Errorf("record migration %d: %w", migration.Version, err)
+	}
+	return nil
+}
+
+func execStatements(ctx context.Context, conn *sql.Conn, stmts []string) error {
+	for _, stmt := range stmts {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("exec statement: %w", err)
+		}
+	}
+	return nil
 }
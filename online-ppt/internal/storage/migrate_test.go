@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+var testMigrations = fstest.MapFS{
+	"001_create_a.up.sql":   {Data: []byte("-- 001\nCREATE TABLE a (id INT);\n")},
+	"001_create_a.down.sql": {Data: []byte("DROP TABLE a;\n")},
+	"002_add_b.up.sql":      {Data: []byte("ALTER TABLE a ADD COLUMN b INT;\nCREATE INDEX idx_a_b ON a(b);\n")},
+	"002_add_b.down.sql":    {Data: []byte("DROP INDEX idx_a_b ON a;\nALTER TABLE a DROP COLUMN b;\n")},
+}
+
+func newMigratorMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db, mock
+}
+
+func exact(query string) string {
+	return "^" + regexp.QuoteMeta(query) + "$"
+}
+
+// expectLocked queues the session setup, lock and ledger reads of a migration
+// run that finds the given versions applied.
+func expectLocked(mock sqlmock.Sqlmock, all []Migration, applied ...int64) {
+	mock.ExpectExec(exact("SET NAMES utf8mb4")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(exact("SET time_zone = '+00:00'")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT GET_LOCK").WithArgs(60).
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectLedger(mock, all, applied...)
+}
+
+func expectLedger(mock sqlmock.Sqlmock, all []Migration, applied ...int64) {
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM information_schema.tables").WithArgs("schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	rows := sqlmock.NewRows([]string{"version", "checksum", "applied_at"})
+	for _, version := range applied {
+		rows.AddRow(version, all[version-1].Checksum, time.Now())
+	}
+	mock.ExpectQuery(exact("SELECT version, checksum, applied_at FROM schema_migrations ORDER BY version")).WillReturnRows(rows)
+}
+
+func TestEmbeddedMigrationsParse(t *testing.T) {
+	latest := make(map[string]int64)
+	for _, dialect := range []Dialect{MySQL, Postgres, SQLite} {
+		all, err := Migrator{Dialect: dialect}.Migrations()
+		require.NoError(t, err, dialect.Name())
+		require.NotEmpty(t, all, dialect.Name())
+		if dialect == MySQL {
+			require.Equal(t, int64(1), all[0].Version)
+		}
+		for i, migration := range all {
+			require.Equal(t, all[0].Version+int64(i), migration.Version, "%s migrations must be numbered without gaps", dialect.Name())
+			require.NotEmpty(t, migration.Up, migration.Name)
+			require.NotEmpty(t, migration.Down, migration.Name)
+			require.Len(t, migration.Checksum, 64)
+		}
+		latest[dialect.Name()] = all[len(all)-1].Version
+	}
+	require.Equal(t, latest[DriverMySQL], latest[DriverPostgres], "dialects must share their latest version")
+	require.Equal(t, latest[DriverMySQL], latest[DriverSQLite], "dialects must share their latest version")
+}
+
+func TestMigrationsRequirePairs(t *testing.T) {
+	_, err := Migrator{FS: fstest.MapFS{
+		"001_create_a.up.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+	}}.Migrations()
+	require.ErrorContains(t, err, "both up and down files are required")
+
+	_, err = Migrator{FS: fstest.MapFS{
+		"001_create_a.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+	}}.Migrations()
+	require.ErrorContains(t, err, ".up.sql or .down.sql")
+}
+
+func TestApplyRunsPendingMigrationsOnce(t *testing.T) {
+	db, mock := newMigratorMock(t)
+	migrator := Migrator{FS: testMigrations}
+	all, err := migrator.Migrations()
+	require.NoError(t, err)
+
+	expectLocked(mock, all, 1)
+	mock.ExpectExec(exact("ALTER TABLE a ADD COLUMN b INT")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(exact("CREATE INDEX idx_a_b ON a(b)")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").
+		WithArgs(int64(2), "add_b", all[1].Checksum).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SELECT RELEASE_LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migrator.Apply(context.Background(), db)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	require.Equal(t, int64(2), applied[0].Version)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyRejectsModifiedMigration(t *testing.T) {
+	db, mock := newMigratorMock(t)
+	migrator := Migrator{FS: testMigrations}
+	all, err := migrator.Migrations()
+	require.NoError(t, err)
+
+	edited := append([]Migration(nil), all...)
+	edited[0].Checksum = "0000"
+	expectLocked(mock, edited, 1)
+	mock.ExpectExec("SELECT RELEASE_LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = migrator.Apply(context.Background(), db)
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyFailsWhenLockIsHeld(t *testing.T) {
+	db, mock := newMigratorMock(t)
+
+	mock.ExpectExec("SET NAMES").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SET time_zone").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT GET_LOCK").WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(0))
+
+	_, err := Migrator{FS: testMigrations, LockTimeout: 5 * time.Second}.Apply(context.Background(), db)
+	require.ErrorIs(t, err, ErrMigrationLocked)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyDryRunChangesNothing(t *testing.T) {
+	db, mock := newMigratorMock(t)
+
+	// 账本表不存在时视为尚未执行任何迁移，且不会创建它
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM information_schema.tables").WithArgs("schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM information_schema.tables").WithArgs("user_accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	pending, err := Migrator{FS: testMigrations, DryRun: true}.Apply(context.Background(), db)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	require.Equal(t, []string{"CREATE TABLE a (id INT)"}, pending[0].Up)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRollbackRevertsNewestFirst(t *testing.T) {
+	db, mock := newMigratorMock(t)
+	migrator := Migrator{FS: testMigrations}
+	all, err := migrator.Migrations()
+	require.NoError(t, err)
+
+	expectLocked(mock, all, 1, 2)
+	mock.ExpectExec(exact("DROP INDEX idx_a_b ON a")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(exact("ALTER TABLE a DROP COLUMN b")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM schema_migrations WHERE version = \\?").
+		WithArgs(int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SELECT RELEASE_LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	reverted, err := migrator.Rollback(context.Background(), db, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	require.Equal(t, int64(2), reverted[0].Version)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBaselineRecordsWithoutRunning(t *testing.T) {
+	db, mock := newMigratorMock(t)
+	migrator := Migrator{FS: testMigrations}
+	all, err := migrator.Migrations()
+	require.NoError(t, err)
+
+	expectLocked(mock, all)
+	mock.ExpectExec("INSERT INTO schema_migrations").
+		WithArgs(int64(1), "create_a", all[0].Checksum).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SELECT RELEASE_LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	marked, err := migrator.Baseline(context.Background(), db, 1)
+	require.NoError(t, err)
+	require.Len(t, marked, 1)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyBaselinesLegacySchema(t *testing.T) {
+	db, mock := newMigratorMock(t)
+	migrator := Migrator{FS: fstest.MapFS{
+		"001_create_a.up.sql":   testMigrations["001_create_a.up.sql"],
+		"001_create_a.down.sql": testMigrations["001_create_a.down.sql"],
+		"002_add_b.up.sql":      testMigrations["002_add_b.up.sql"],
+		"002_add_b.down.sql":    testMigrations["002_add_b.down.sql"],
+		"003_add_c.up.sql":      {Data: []byte("ALTER TABLE a ADD COLUMN c INT;\n")},
+		"003_add_c.down.sql":    {Data: []byte("ALTER TABLE a DROP COLUMN c;\n")},
+		"004_create_d.up.sql":   {Data: []byte("CREATE TABLE d (id INT);\n")},
+		"004_create_d.down.sql": {Data: []byte("DROP TABLE d;\n")},
+	}}
+	all, err := migrator.Migrations()
+	require.NoError(t, err)
+
+	// 账本为空但已有 user_accounts：001-003 是引入账本前手工执行的，只记录不执行
+	expectLocked(mock, all)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM information_schema.tables").WithArgs("user_accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	for _, migration := range all[:3] {
+		mock.ExpectExec("INSERT INTO schema_migrations").
+			WithArgs(migration.Version, migration.Name, migration.Checksum).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec(exact("CREATE TABLE d (id INT)")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").
+		WithArgs(int64(4), "create_d", all[3].Checksum).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SELECT RELEASE_LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migrator.Apply(context.Background(), db)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	require.Equal(t, int64(4), applied[0].Version)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
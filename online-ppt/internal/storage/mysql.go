@@ -3,10 +3,15 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
+	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+
+	"online-ppt/migrations"
 )
 
 const (
@@ -37,37 +42,81 @@ func (f MySQLFactory) Open(ctx context.Context) (*sql.DB, error) {
 		return nil, fmt.Errorf("open mysql: %w", err)
 	}
 
-	maxIdle := f.MaxIdleConns
-	if maxIdle <= 0 {
-		maxIdle = defaultMaxIdleConns
-	}
-	db.SetMaxIdleConns(maxIdle)
+	configurePool(db, f.MaxIdleConns, f.MaxOpenConns, f.ConnMaxLifetime, f.ConnMaxIdleTime)
 
-	maxOpen := f.MaxOpenConns
-	if maxOpen <= 0 {
-		maxOpen = defaultMaxOpenConns
+	if err := pingDB(ctx, db, "mysql"); err != nil {
+		return nil, err
 	}
-	db.SetMaxOpenConns(maxOpen)
 
-	life := f.ConnMaxLifetime
-	if life <= 0 {
-		life = defaultConnMaxLifetime
-	}
-	db.SetConnMaxLifetime(life)
+	return db, nil
+}
+
+// mysqlDuplicateEntry is ER_DUP_ENTRY.
+const mysqlDuplicateEntry = 1062
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return DriverMySQL }
+
+func (mysqlDialect) IsUniqueViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
+
+func (mysqlDialect) JSONArrayContains(column string) string {
+	return "JSON_CONTAINS(IFNULL(" + column + ", JSON_ARRAY()), JSON_QUOTE(?))"
+}
+
+func (mysqlDialect) Upsert(_ string, assignments ...string) string {
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+}
 
-	idle := f.ConnMaxIdleTime
-	if idle <= 0 {
-		idle = defaultConnMaxIdleTime
+func (mysqlDialect) Excluded(column string) string {
+	return "VALUES(" + column + ")"
+}
+
+func (mysqlDialect) InsertID(ctx context.Context, q Execer, stmt string, args ...any) (int64, error) {
+	return lastInsertID(ctx, q, stmt, args...)
+}
+
+func (mysqlDialect) migrations() fs.FS { return subFS(migrations.FS, DriverMySQL) }
+
+func (mysqlDialect) sessionSetup() []string {
+	return []string{
+		"SET NAMES utf8mb4",
+		"SET time_zone = '+00:00'",
 	}
-	db.SetConnMaxIdleTime(idle)
+}
 
-	pingCtx, cancel := context.WithTimeout(ctx, defaultConnectTimeout)
-	defer cancel()
+func (mysqlDialect) ledgerDDL() string {
+	return `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+}
 
-	if err := db.PingContext(pingCtx); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("ping mysql: %w", err)
+func (mysqlDialect) tableExistsQuery() string {
+	return `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?`
+}
+
+// legacyVersion: MySQL deployments ran 001-003 by hand before the ledger existed.
+func (mysqlDialect) legacyVersion() int64 { return 3 }
+
+// lock takes a named lock scoped to the current database.
+func (mysqlDialect) lock(ctx context.Context, conn *sql.Conn, timeout time.Duration) (func(), error) {
+	var acquired sql.NullInt64
+	err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(CONCAT(DATABASE(), '.schema_migrations'), ?)`, int(timeout.Seconds())).Scan(&acquired)
+	if err != nil {
+		return nil, fmt.Errorf("acquire migration lock: %w", err)
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return nil, ErrMigrationLocked
 	}
 
-	return db, nil
+	return func() {
+		// 连接关闭时锁也会释放，这里的错误无需处理
+		conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(CONCAT(DATABASE(), '.schema_migrations'))`)
+	}, nil
 }
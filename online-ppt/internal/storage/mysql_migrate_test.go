@@ -0,0 +1,90 @@
+//go:build mysql
+
+// 需要一个空的 MySQL 8 数据库，运行方式：
+// TEST_MYSQL_DSN='root:secret@tcp(127.0.0.1:3306)/ppt_test?parseTime=true' go test -tags mysql ./internal/storage
+
+package storage
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestMySQLMigrationsApplyAndRollback runs the embedded MySQL migrations against
+// a real server. sqlmock only checks the statements we expect, not whether
+// MySQL accepts them.
+func TestMySQLMigrationsApplyAndRollback(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	require.NotEmpty(t, dsn, "TEST_MYSQL_DSN must point at an empty MySQL database")
+
+	ctx := context.Background()
+	db, dialect, err := Open(ctx, DriverMySQL, dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator := Migrator{Dialect: dialect}
+	all, err := migrator.Migrations()
+	require.NoError(t, err)
+
+	applied, err := migrator.Apply(ctx, db)
+	require.NoError(t, err)
+	require.Len(t, applied, len(all), "the database must start empty")
+
+	exec := func(stmt string, args ...any) error {
+		_, err := db.ExecContext(ctx, stmt, args...)
+		return err
+	}
+	insertDeck := func(userID any, workspaceID any, group string) (int64, error) {
+		res, err := db.ExecContext(ctx,
+			`INSERT INTO ppt_records (user_id, workspace_id, name, group_name, relative_path, canonical_path) VALUES (?, ?, ?, ?, ?, ?)`,
+			userID, workspaceID, group, group, "presentations/"+group, "/tmp/"+group)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+
+	res, err := db.ExecContext(ctx, `INSERT INTO user_accounts (uuid, email, password_hash) VALUES (?, ?, ?)`,
+		"00000000-0000-0000-0000-000000000001", "owner@example.com", "hash")
+	require.NoError(t, err)
+	ownerID, err := res.LastInsertId()
+	require.NoError(t, err)
+	res, err = db.ExecContext(ctx, `INSERT INTO workspaces (uuid, name, created_by) VALUES (?, ?, ?)`,
+		"00000000-0000-0000-0000-0000000000aa", "Team", ownerID)
+	require.NoError(t, err)
+	workspaceID, err := res.LastInsertId()
+	require.NoError(t, err)
+
+	// 组名只在未删除的演示中唯一
+	personalID, err := insertDeck(ownerID, nil, "alpha")
+	require.NoError(t, err)
+	_, err = insertDeck(ownerID, nil, "alpha")
+	require.True(t, dialect.IsUniqueViolation(err), "duplicate live personal group must fail: %v", err)
+	require.NoError(t, exec(`UPDATE ppt_records SET deleted_at = NOW() WHERE id = ?`, personalID))
+	_, err = insertDeck(ownerID, nil, "alpha")
+	require.NoError(t, err)
+
+	// 工作区演示按工作区唯一，且不与个人演示冲突
+	teamID, err := insertDeck(ownerID, workspaceID, "alpha")
+	require.NoError(t, err)
+	_, err = insertDeck(ownerID, workspaceID, "alpha")
+	require.True(t, dialect.IsUniqueViolation(err), "duplicate live workspace group must fail: %v", err)
+
+	// 删除创建者后工作区演示保留，创建者置空
+	require.NoError(t, exec(`DELETE FROM ppt_records WHERE user_id = ? AND workspace_id IS NULL`, ownerID))
+	require.NoError(t, exec(`DELETE FROM user_accounts WHERE id = ?`, ownerID))
+	var creator *int64
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT user_id FROM ppt_records WHERE id = ?`, teamID).Scan(&creator))
+	require.Nil(t, creator)
+
+	// 回滚要求先清掉无法还原到旧结构的数据
+	require.NoError(t, exec(`DELETE FROM ppt_records`))
+	require.NoError(t, exec(`DELETE FROM workspaces`))
+
+	reverted, err := migrator.Rollback(ctx, db, len(all))
+	require.NoError(t, err)
+	require.Len(t, reverted, len(all))
+}
@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"online-ppt/migrations"
+)
+
+// postgresUniqueViolation is SQLSTATE unique_violation.
+const postgresUniqueViolation = "23505"
+
+const migrationLockPollInterval = 500 * time.Millisecond
+
+// PostgresFactory opens pooled PostgreSQL connections. Queries may use `?`
+// placeholders; they are rewritten to `$1`, `$2`, ... before reaching the
+// server.
+type PostgresFactory struct {
+	DSN             string
+	MaxIdleConns    int
+	MaxOpenConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// Open initializes the SQL client and verifies connectivity with a bounded ping.
+func (f PostgresFactory) Open(ctx context.Context) (*sql.DB, error) {
+	if f.DSN == "" {
+		return nil, fmt.Errorf("postgres factory requires DSN")
+	}
+
+	connector, err := pq.NewConnector(f.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+	db := sql.OpenDB(rebindConnector{connector})
+	configurePool(db, f.MaxIdleConns, f.MaxOpenConns, f.ConnMaxLifetime, f.ConnMaxIdleTime)
+
+	if err := pingDB(ctx, db, "postgres"); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// rebindConnector hands out connections that rewrite `?` placeholders.
+type rebindConnector struct {
+	driver.Connector
+}
+
+func (c rebindConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rebindConn{conn}, nil
+}
+
+// rebindConn forwards to the driver connection, rewriting every query.
+type rebindConn struct {
+	driver.Conn
+}
+
+func (c rebindConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(rebind(query))
+}
+
+func (c rebindConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, rebind(query))
+	}
+	return c.Prepare(query)
+}
+
+func (c rebindConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return e.ExecContext(ctx, rebind(query), args)
+}
+
+func (c rebindConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return q.QueryContext(ctx, rebind(query), args)
+}
+
+func (c rebindConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c rebindConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c rebindConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// rebind replaces `?` placeholders outside quotes and comments with
+// PostgreSQL's numbered `$n` form.
+func rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+
+	var (
+		b strings.Builder
+		n int
+	)
+	b.Grow(len(query) + 8)
+	for i := 0; i < len(query); {
+		ch := query[i]
+		switch {
+		case ch == '\'' || ch == '"':
+			end, err := skipQuoted(query, i)
+			if err != nil {
+				// 引号不配对时原样交给服务端报错
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i:end])
+			i = end
+		case ch == '$' && dollarTag(query, i) != "":
+			tag := dollarTag(query, i)
+			end := strings.Index(query[i+len(tag):], tag)
+			if end < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			end += i + 2*len(tag)
+			b.WriteString(query[i:end])
+			i = end
+		case isDashComment(query[i:]):
+			end := skipLine(query, i)
+			b.WriteString(query[i:end])
+			i = end
+		case ch == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			i++
+		default:
+			b.WriteByte(ch)
+			i++
+		}
+	}
+	return b.String()
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return DriverPostgres }
+
+func (postgresDialect) IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == postgresUniqueViolation
+}
+
+func (postgresDialect) JSONArrayContains(column string) string {
+	return "COALESCE(" + column + ", '[]'::jsonb) @> jsonb_build_array(?::text)"
+}
+
+func (postgresDialect) Upsert(key string, assignments ...string) string {
+	return "ON CONFLICT (" + key + ") DO UPDATE SET " + strings.Join(assignments, ", ")
+}
+
+func (postgresDialect) Excluded(column string) string {
+	return "EXCLUDED." + column
+}
+
+// InsertID asks for the id with RETURNING, as lib/pq has no LastInsertId.
+func (postgresDialect) InsertID(ctx context.Context, q Execer, stmt string, args ...any) (int64, error) {
+	var id int64
+	if err := q.QueryRowContext(ctx, stmt+" RETURNING id", args...).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (postgresDialect) migrations() fs.FS { return subFS(migrations.FS, DriverPostgres) }
+
+func (postgresDialect) sessionSetup() []string {
+	return []string{"SET TIME ZONE 'UTC'"}
+}
+
+func (postgresDialect) ledgerDDL() string {
+	return `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+}
+
+func (postgresDialect) tableExistsQuery() string {
+	return `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?`
+}
+
+func (postgresDialect) legacyVersion() int64 { return 0 }
+
+// lock polls for a session advisory lock so that the timeout applies without
+// touching the session's lock_timeout.
+func (postgresDialect) lock(ctx context.Context, conn *sql.Conn, timeout time.Duration) (func(), error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext('schema_migrations'))`).Scan(&acquired); err != nil {
+			return nil, fmt.Errorf("acquire migration lock: %w", err)
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrMigrationLocked
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(migrationLockPollInterval):
+		}
+	}
+
+	return func() {
+		conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext('schema_migrations'))`)
+	}, nil
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebind(t *testing.T) {
+	cases := map[string]string{
+		`SELECT 1`: `SELECT 1`,
+		`SELECT * FROM t WHERE a = ? AND b IN (?, ?)`:             `SELECT * FROM t WHERE a = $1 AND b IN ($2, $3)`,
+		`SELECT '?', "col?" FROM t WHERE a = ?`:                   `SELECT '?', "col?" FROM t WHERE a = $1`,
+		`SELECT 'it''s ?' FROM t WHERE a = ?`:                     `SELECT 'it''s ?' FROM t WHERE a = $1`,
+		"SELECT a -- why?\nFROM t WHERE a = ?":                    "SELECT a -- why?\nFROM t WHERE a = $1",
+		`SELECT $$ ? $$, tags @> jsonb_build_array(?::text)`:      `SELECT $$ ? $$, tags @> jsonb_build_array($1::text)`,
+		`INSERT INTO t (a) VALUES (?) ON CONFLICT (a) DO NOTHING`: `INSERT INTO t (a) VALUES ($1) ON CONFLICT (a) DO NOTHING`,
+	}
+	for query, want := range cases {
+		require.Equal(t, want, rebind(query), query)
+	}
+}
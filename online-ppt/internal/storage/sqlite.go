@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"online-ppt/migrations"
+)
+
+// sqliteDriverName is the database/sql driver registered with the hooks below.
+const sqliteDriverName = "sqlite3_online_ppt"
+
+// sqliteTimeFormat is the layout go-sqlite3 writes time.Time values in.
+var sqliteTimeFormat = sqlite3.SQLiteTimestampFormats[0]
+
+func init() {
+	sql.Register(sqliteDriverName, sqliteDriver{&sqlite3.SQLiteDriver{ConnectHook: prepareSQLiteConn}})
+}
+
+// prepareSQLiteConn adds the MySQL NOW() function the repositories rely on and
+// turns on foreign keys, which SQLite leaves off for every new connection.
+func prepareSQLiteConn(conn *sqlite3.SQLiteConn) error {
+	now := func() string { return time.Now().UTC().Format(sqliteTimeFormat) }
+	if err := conn.RegisterFunc("now", now, false); err != nil {
+		return fmt.Errorf("register now(): %w", err)
+	}
+	for _, pragma := range []string{"PRAGMA foreign_keys = ON", "PRAGMA busy_timeout = 5000"} {
+		if _, err := conn.Exec(pragma, nil); err != nil {
+			return fmt.Errorf("%s: %w", pragma, err)
+		}
+	}
+	return nil
+}
+
+// SQLiteFactory opens a SQLite database file, for single-instance deployments
+// and local development.
+type SQLiteFactory struct {
+	// Path is the database file, or any DSN go-sqlite3 accepts.
+	Path string
+}
+
+// Open initializes the SQL client and verifies the file can be opened.
+func (f SQLiteFactory) Open(ctx context.Context) (*sql.DB, error) {
+	if f.Path == "" {
+		return nil, fmt.Errorf("sqlite factory requires path")
+	}
+
+	dsn := f.Path
+	if !strings.Contains(dsn, "_txlock=") {
+		// 事务开始即拿写锁，读后写的事务才会等 busy_timeout 而不是直接 SQLITE_BUSY
+		dsn = appendDSNParam(dsn, "_txlock=immediate")
+	}
+	db, err := sql.Open(sqliteDriverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+
+	if err := pingDB(ctx, db, "sqlite"); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// appendDSNParam adds a query parameter to a go-sqlite3 DSN.
+func appendDSNParam(dsn, param string) string {
+	if !strings.HasPrefix(dsn, "file:") {
+		dsn = "file:" + dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + param
+	}
+	return dsn + "?" + param
+}
+
+// sqliteDriver hands out connections that store times in UTC.
+type sqliteDriver struct {
+	*sqlite3.SQLiteDriver
+}
+
+func (d sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return utcConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+// utcConn converts time arguments to UTC. SQLite keeps times as text, so
+// comparisons such as `expires_at > NOW()` only hold when every value is
+// written in the same zone.
+type utcConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (utcConn) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC()
+	}
+	nv.Value = value
+	return nil
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return DriverSQLite }
+
+func (sqliteDialect) IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+}
+
+func (sqliteDialect) JSONArrayContains(column string) string {
+	return "EXISTS (SELECT 1 FROM json_each(COALESCE(" + column + ", '[]')) WHERE json_each.value = ?)"
+}
+
+func (sqliteDialect) Upsert(key string, assignments ...string) string {
+	return "ON CONFLICT (" + key + ") DO UPDATE SET " + strings.Join(assignments, ", ")
+}
+
+func (sqliteDialect) Excluded(column string) string {
+	return "excluded." + column
+}
+
+func (sqliteDialect) InsertID(ctx context.Context, q Execer, stmt string, args ...any) (int64, error) {
+	return lastInsertID(ctx, q, stmt, args...)
+}
+
+func (sqliteDialect) migrations() fs.FS { return subFS(migrations.FS, DriverSQLite) }
+
+func (sqliteDialect) sessionSetup() []string { return nil }
+
+func (sqliteDialect) ledgerDDL() string {
+	return `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER NOT NULL PRIMARY KEY,
+    name TEXT NOT NULL,
+    checksum TEXT NOT NULL,
+    applied_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+)`
+}
+
+func (sqliteDialect) tableExistsQuery() string {
+	return `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`
+}
+
+func (sqliteDialect) legacyVersion() int64 { return 0 }
+
+// lock is a no-op: a SQLite file has a single writer, and the migration
+// statements already serialise on the database lock.
+func (sqliteDialect) lock(context.Context, *sql.Conn, time.Duration) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+const defaultDelimiter = ";"
+
+// splitStatements splits a SQL script into statements the way the mysql client
+// does: delimiters inside quoted strings, identifiers and comments are ignored,
+// and a `DELIMITER xx` line changes the delimiter so routine bodies can contain
+// semicolons. PostgreSQL `$tag$ ... $tag$` bodies are kept whole as well.
+// Comments are dropped except MySQL `/*! ... */` executable ones.
+func splitStatements(sqlText string) ([]string, error) {
+	var (
+		statements []string
+		current    strings.Builder
+		delimiter  = defaultDelimiter
+		lineStart  = true
+	)
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(sqlText); {
+		// 与 mysql 客户端一致，只在语句开头识别 DELIMITER，列名等不受影响
+		if lineStart && strings.TrimSpace(current.String()) == "" {
+			if next, ok := parseDelimiterLine(sqlText[i:]); ok {
+				current.Reset()
+				if next == "" {
+					return nil, fmt.Errorf("DELIMITER without a value at offset %d", i)
+				}
+				delimiter = next
+				i = skipLine(sqlText, i)
+				continue
+			}
+		}
+
+		ch := sqlText[i]
+		switch {
+		case strings.HasPrefix(sqlText[i:], delimiter):
+			flush()
+			i += len(delimiter)
+			lineStart = false
+			continue
+		case ch == '\'' || ch == '"' || ch == '`':
+			end, err := skipQuoted(sqlText, i)
+			if err != nil {
+				return nil, err
+			}
+			current.WriteString(sqlText[i:end])
+			i = end
+			lineStart = false
+			continue
+		case ch == '$' && dollarTag(sqlText, i) != "":
+			tag := dollarTag(sqlText, i)
+			end := strings.Index(sqlText[i+len(tag):], tag)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %s quote at offset %d", tag, i)
+			}
+			end += i + 2*len(tag)
+			current.WriteString(sqlText[i:end])
+			i = end
+			lineStart = false
+			continue
+		case ch == '#' || isDashComment(sqlText[i:]):
+			// 行注释保留换行，避免前后两行的内容粘在一起
+			i = skipLine(sqlText, i)
+			current.WriteByte('\n')
+			lineStart = true
+			continue
+		case strings.HasPrefix(sqlText[i:], "/*"):
+			end := strings.Index(sqlText[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			end += i + 4
+			if strings.HasPrefix(sqlText[i:], "/*!") {
+				current.WriteString(sqlText[i:end])
+			} else {
+				current.WriteByte(' ')
+			}
+			i = end
+			lineStart = false
+			continue
+		}
+
+		current.WriteByte(ch)
+		if ch == '\n' {
+			lineStart = true
+		} else if ch != ' ' && ch != '\t' && ch != '\r' {
+			lineStart = false
+		}
+		i++
+	}
+
+	flush()
+	return statements, nil
+}
+
+// parseDelimiterLine recognises a mysql client `DELIMITER xx` command at the
+// start of text and returns the new delimiter.
+func parseDelimiterLine(text string) (string, bool) {
+	const keyword = "DELIMITER"
+	if len(text) <= len(keyword) || !strings.EqualFold(text[:len(keyword)], keyword) {
+		return "", false
+	}
+	if c := text[len(keyword)]; c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+		return "", false
+	}
+	line := text[len(keyword):]
+	if end := strings.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", true
+	}
+	return fields[0], true
+}
+
+// isDashComment reports whether text starts a `-- ` comment. Like MySQL, the
+// dashes must be followed by whitespace, so `1--1` stays an expression.
+func isDashComment(text string) bool {
+	if !strings.HasPrefix(text, "--") {
+		return false
+	}
+	if len(text) == 2 {
+		return true
+	}
+	switch text[2] {
+	case ' ', '\t', '\r', '\n':
+		return true
+	}
+	return false
+}
+
+// skipLine returns the offset just past the newline ending the line at i.
+func skipLine(text string, i int) int {
+	if end := strings.IndexByte(text[i:], '\n'); end >= 0 {
+		return i + end + 1
+	}
+	return len(text)
+}
+
+// skipQuoted returns the offset just past the quoted string or identifier
+// starting at i. A doubled quote escapes itself; backslash escapes apply
+// inside string literals but not backquoted identifiers.
+func skipQuoted(text string, i int) (int, error) {
+	quote := text[i]
+	for j := i + 1; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			if quote != '`' {
+				j++
+			}
+		case quote:
+			if j+1 < len(text) && text[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated %c quote at offset %d", quote, i)
+}
+
+// dollarTag returns the PostgreSQL dollar-quote opener starting at i, such as
+// `$$` or `$body$`, or "" when there is none. Positional parameters like `$1`
+// and `$` inside identifiers are not openers.
+func dollarTag(text string, i int) string {
+	if i > 0 && isIdentByte(text[i-1]) {
+		return ""
+	}
+	for j := i + 1; j < len(text); j++ {
+		c := text[j]
+		if c == '$' {
+			return text[i : j+1]
+		}
+		if !isIdentByte(c) || (j == i+1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
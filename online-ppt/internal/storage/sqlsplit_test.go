@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- leading comment; not a statement
+CREATE TABLE t (
+    id INT, -- trailing; comment
+    note VARCHAR(20) DEFAULT 'a;b', # hash; comment
+    ` + "`weird;name`" + ` INT,
+    quoted VARCHAR(20) DEFAULT 'it''s; fine',
+    escaped VARCHAR(20) DEFAULT "say \"hi;\""
+);
+/* block; comment */ INSERT INTO t (id) VALUES (1--1);
+/*!40101 SET NAMES utf8mb4 */;
+
+DELIMITER //
+CREATE TRIGGER t_bi BEFORE INSERT ON t FOR EACH ROW
+BEGIN
+    SET NEW.note = 'x';
+    SET NEW.id = NEW.id + 1;
+END//
+DELIMITER ;
+DROP TABLE t`
+
+	stmts, err := splitStatements(script)
+	require.NoError(t, err)
+	require.Len(t, stmts, 5)
+	require.Contains(t, stmts[0], "DEFAULT 'a;b'")
+	require.Contains(t, stmts[0], "`weird;name`")
+	require.Contains(t, stmts[0], `'it''s; fine'`)
+	require.Contains(t, stmts[0], `"say \"hi;\""`)
+	require.NotContains(t, stmts[0], "comment")
+	require.Equal(t, "INSERT INTO t (id) VALUES (1--1)", stmts[1])
+	require.Equal(t, "/*!40101 SET NAMES utf8mb4 */", stmts[2])
+	require.Contains(t, stmts[3], "SET NEW.note = 'x';\n    SET NEW.id = NEW.id + 1;\nEND")
+	require.Equal(t, "DROP TABLE t", stmts[4])
+}
+
+func TestSplitStatementsDelimiterOnlyAtStatementStart(t *testing.T) {
+	stmts, err := splitStatements("CREATE TABLE d (\n    delimiter VARCHAR(1)\n);\nSELECT 1;")
+	require.NoError(t, err)
+	require.Equal(t, []string{"CREATE TABLE d (\n    delimiter VARCHAR(1)\n)", "SELECT 1"}, stmts)
+}
+
+func TestSplitStatementsDollarQuotes(t *testing.T) {
+	script := `CREATE FUNCTION f() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.note = 'x';
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+SELECT $body$ a; b $body$, price$1 FROM t WHERE id = $1;`
+
+	stmts, err := splitStatements(script)
+	require.NoError(t, err)
+	require.Len(t, stmts, 2)
+	require.Contains(t, stmts[0], "RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql")
+	require.Equal(t, "SELECT $body$ a; b $body$, price$1 FROM t WHERE id = $1", stmts[1])
+}
+
+func TestSplitStatementsRejectsUnterminated(t *testing.T) {
+	for _, script := range []string{
+		"SELECT 'open;",
+		"SELECT `open;",
+		"SELECT 1; /* open",
+		"DELIMITER\nSELECT 1;",
+		"SELECT $$ open;",
+	} {
+		_, err := splitStatements(script)
+		require.Error(t, err, script)
+	}
+}
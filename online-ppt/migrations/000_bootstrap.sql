@@ -1,5 +0,0 @@
--- 000_bootstrap.sql
--- Ensures the session uses utf8mb4 encoding before applying further migrations.
-SET NAMES utf8mb4;
-SET CHARACTER SET utf8mb4;
-SET time_zone = '+00:00';
@@ -0,0 +1,15 @@
+// Package migrations embeds the versioned schema migrations, one directory per
+// database dialect. Every version has an NNN_name.up.sql file and an
+// NNN_name.down.sql file that undoes it.
+//
+// The PostgreSQL and SQLite sets start with a single 015 migration creating
+// the schema that MySQL reached in fifteen steps, so later versions share
+// their numbers across dialects.
+package migrations
+
+import "embed"
+
+// FS holds the migration files under mysql/, postgres/ and sqlite/.
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var FS embed.FS
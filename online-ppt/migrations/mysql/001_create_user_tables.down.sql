@@ -0,0 +1,5 @@
+-- 001_create_user_tables.down.sql
+-- Drops the user tables.
+
+DROP TABLE IF EXISTS user_sessions;
+DROP TABLE IF EXISTS user_accounts;
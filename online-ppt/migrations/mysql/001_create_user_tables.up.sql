@@ -1,4 +1,4 @@
--- 001_create_user_tables.sql
+-- 001_create_user_tables.up.sql
 -- Creates user_accounts and user_sessions tables with supporting indexes.
 
 CREATE TABLE IF NOT EXISTS user_accounts (
@@ -0,0 +1,4 @@
+-- 002_create_ppt_records.down.sql
+-- Drops ppt_records.
+
+DROP TABLE IF EXISTS ppt_records;
@@ -1,4 +1,4 @@
--- 002_create_ppt_records.sql
+-- 002_create_ppt_records.up.sql
 -- Creates ppt_records table for managing per-user PPT metadata.
 
 CREATE TABLE IF NOT EXISTS ppt_records (
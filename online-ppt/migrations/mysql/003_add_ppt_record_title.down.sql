@@ -0,0 +1,5 @@
+-- 003_add_ppt_record_title.down.sql
+-- Removes the title field from ppt_records.
+
+ALTER TABLE ppt_records
+DROP COLUMN title;
@@ -1,4 +1,4 @@
--- 003_add_ppt_record_title.sql
+-- 003_add_ppt_record_title.up.sql
 -- Adds title field to ppt_records table for user-friendly display names.
 
 ALTER TABLE ppt_records 
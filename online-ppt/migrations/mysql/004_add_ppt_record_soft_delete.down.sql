@@ -0,0 +1,13 @@
+-- 004_add_ppt_record_soft_delete.down.sql
+-- Removes soft-delete state. Decks still in the trash become live again, so
+-- restoring the per-user group name constraint fails while a trashed deck
+-- shares its group name with a live one.
+
+DROP INDEX idx_ppt_records_user_deleted_at ON ppt_records;
+
+ALTER TABLE ppt_records
+ADD CONSTRAINT uq_ppt_records_user_group UNIQUE (user_id, group_name),
+DROP INDEX uq_ppt_records_live_group,
+DROP COLUMN live_owner_id,
+DROP COLUMN trash_path,
+DROP COLUMN deleted_at;
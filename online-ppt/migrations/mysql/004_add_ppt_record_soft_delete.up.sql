@@ -0,0 +1,15 @@
+-- 004_add_ppt_record_soft_delete.up.sql
+-- Adds soft-delete state so deleted decks can be listed in the trash and restored.
+-- Group names only need to be unique among live decks, so the unique key moves
+-- to a generated column that is NULL for trashed rows. The column is VIRTUAL
+-- because MySQL rejects the cascading user_id foreign key on the base column of
+-- a STORED generated column; InnoDB still keeps the unique index on it.
+
+ALTER TABLE ppt_records
+ADD COLUMN deleted_at DATETIME NULL AFTER updated_at,
+ADD COLUMN trash_path VARCHAR(512) NULL AFTER deleted_at,
+ADD COLUMN live_owner_id INT AS (IF(deleted_at IS NULL, user_id, NULL)) VIRTUAL AFTER trash_path,
+ADD CONSTRAINT uq_ppt_records_live_group UNIQUE (live_owner_id, group_name),
+DROP INDEX uq_ppt_records_user_group;
+
+CREATE INDEX idx_ppt_records_user_deleted_at ON ppt_records(user_id, deleted_at);
@@ -0,0 +1,7 @@
+-- 005_create_ppt_record_versions.down.sql
+-- Drops deck snapshots. Blobs on disk are left for the operator to remove.
+
+ALTER TABLE ppt_records
+DROP COLUMN current_version;
+
+DROP TABLE IF EXISTS ppt_record_versions;
@@ -0,0 +1,17 @@
+-- 005_create_ppt_record_versions.up.sql
+-- Stores deck snapshots; file contents live in content-addressed blobs on disk.
+
+CREATE TABLE IF NOT EXISTS ppt_record_versions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    record_id INT NOT NULL,
+    version INT NOT NULL,
+    label VARCHAR(255) NULL,
+    reason VARCHAR(32) NOT NULL,
+    manifest JSON NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_ppt_record_versions_record FOREIGN KEY (record_id) REFERENCES ppt_records(id) ON DELETE CASCADE,
+    CONSTRAINT uq_ppt_record_versions_record_version UNIQUE (record_id, version)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE ppt_records
+ADD COLUMN current_version INT NOT NULL DEFAULT 0 AFTER updated_at;
@@ -0,0 +1,4 @@
+-- 006_create_ppt_shares.down.sql
+-- Drops share links.
+
+DROP TABLE IF EXISTS ppt_shares;
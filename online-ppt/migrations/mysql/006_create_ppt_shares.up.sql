@@ -0,0 +1,20 @@
+-- 006_create_ppt_shares.up.sql
+-- Public read-only share links; only a SHA-256 digest of each token is stored.
+
+CREATE TABLE IF NOT EXISTS ppt_shares (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    record_id INT NOT NULL,
+    user_id INT NOT NULL,
+    token_hash CHAR(43) NOT NULL,
+    password_hash VARCHAR(255) NULL,
+    expires_at DATETIME NOT NULL,
+    view_count INT NOT NULL DEFAULT 0,
+    last_viewed_at DATETIME NULL,
+    revoked_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_ppt_shares_record FOREIGN KEY (record_id) REFERENCES ppt_records(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ppt_shares_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE,
+    CONSTRAINT uq_ppt_shares_token_hash UNIQUE (token_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE INDEX idx_ppt_shares_record_created_at ON ppt_shares(record_id, created_at DESC);
@@ -0,0 +1,4 @@
+-- 007_create_ppt_collaborators.down.sql
+-- Drops deck collaborators.
+
+DROP TABLE IF EXISTS ppt_collaborators;
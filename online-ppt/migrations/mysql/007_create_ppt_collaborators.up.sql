@@ -0,0 +1,18 @@
+-- 007_create_ppt_collaborators.up.sql
+-- Grants other users viewer, editor or owner access to a deck.
+
+CREATE TABLE IF NOT EXISTS ppt_collaborators (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    record_id INT NOT NULL,
+    user_id INT NOT NULL,
+    role ENUM('viewer','editor','owner') NOT NULL,
+    invited_by INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_ppt_collaborators_record FOREIGN KEY (record_id) REFERENCES ppt_records(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ppt_collaborators_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ppt_collaborators_invited_by FOREIGN KEY (invited_by) REFERENCES user_accounts(id) ON DELETE SET NULL,
+    CONSTRAINT uq_ppt_collaborators_record_user UNIQUE (record_id, user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE INDEX idx_ppt_collaborators_user ON ppt_collaborators(user_id);
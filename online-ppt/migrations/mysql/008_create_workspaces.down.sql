@@ -0,0 +1,25 @@
+-- 008_create_workspaces.down.sql
+-- Drops workspaces. Workspace decks must be deleted first, including those whose
+-- creator is gone, and restoring the per-user group name constraint fails while
+-- a user's live personal and workspace decks share a group name.
+
+ALTER TABLE ppt_records
+DROP FOREIGN KEY fk_ppt_records_workspace;
+
+ALTER TABLE ppt_records
+DROP INDEX idx_ppt_records_workspace_created_at,
+DROP INDEX uq_ppt_records_workspace_group,
+DROP INDEX uq_ppt_records_personal_group,
+DROP COLUMN live_workspace_id,
+DROP COLUMN personal_owner_id,
+DROP COLUMN workspace_id,
+DROP FOREIGN KEY fk_ppt_records_user,
+MODIFY user_id INT NOT NULL;
+
+ALTER TABLE ppt_records
+ADD CONSTRAINT fk_ppt_records_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE,
+ADD COLUMN live_owner_id INT AS (IF(deleted_at IS NULL, user_id, NULL)) VIRTUAL AFTER trash_path,
+ADD CONSTRAINT uq_ppt_records_live_group UNIQUE (live_owner_id, group_name);
+
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,48 @@
+-- 008_create_workspaces.up.sql
+-- Adds team workspaces that own decks independently of any single member.
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    uuid CHAR(36) NOT NULL UNIQUE,
+    name VARCHAR(120) NOT NULL,
+    created_by INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_workspaces_created_by FOREIGN KEY (created_by) REFERENCES user_accounts(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    workspace_id INT NOT NULL,
+    user_id INT NOT NULL,
+    role ENUM('viewer','member','admin') NOT NULL DEFAULT 'member',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_workspace_members_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    CONSTRAINT fk_workspace_members_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE,
+    CONSTRAINT uq_workspace_members_workspace_user UNIQUE (workspace_id, user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE INDEX idx_workspace_members_user ON workspace_members(user_id);
+
+-- Workspace decks keep user_id as their creator, so group names are unique per
+-- workspace for workspace decks and per user for personal ones, counting only
+-- live decks. The creator is nullable so a workspace deck outlives the account
+-- that created it; personal decks are deleted together with their owner by the
+-- application. As in 004 the generated key columns are VIRTUAL, since MySQL
+-- rejects a SET NULL foreign key on the base column of a STORED one.
+ALTER TABLE ppt_records
+DROP INDEX uq_ppt_records_live_group,
+DROP COLUMN live_owner_id,
+DROP FOREIGN KEY fk_ppt_records_user,
+MODIFY user_id INT NULL;
+
+ALTER TABLE ppt_records
+ADD CONSTRAINT fk_ppt_records_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE SET NULL,
+ADD COLUMN workspace_id INT NULL AFTER user_id,
+ADD COLUMN personal_owner_id INT AS (IF(workspace_id IS NULL AND deleted_at IS NULL, user_id, NULL)) VIRTUAL AFTER workspace_id,
+ADD COLUMN live_workspace_id INT AS (IF(deleted_at IS NULL, workspace_id, NULL)) VIRTUAL AFTER personal_owner_id,
+ADD INDEX idx_ppt_records_workspace_created_at (workspace_id, created_at DESC),
+ADD CONSTRAINT fk_ppt_records_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE RESTRICT,
+ADD CONSTRAINT uq_ppt_records_workspace_group UNIQUE (live_workspace_id, group_name),
+ADD CONSTRAINT uq_ppt_records_personal_group UNIQUE (personal_owner_id, group_name);
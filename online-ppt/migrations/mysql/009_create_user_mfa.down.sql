@@ -0,0 +1,5 @@
+-- 009_create_user_mfa.down.sql
+-- Drops two-factor secrets and recovery codes; affected users sign in with a password only.
+
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_mfa;
@@ -0,0 +1,22 @@
+-- 009_create_user_mfa.up.sql
+-- Stores TOTP secrets and hashed single-use recovery codes for two-factor login.
+
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id INT NOT NULL PRIMARY KEY,
+    totp_secret VARCHAR(64) NOT NULL,
+    enabled_at DATETIME NULL,
+    last_used_step BIGINT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_mfa_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_recovery_codes_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE,
+    CONSTRAINT uq_user_recovery_codes_user_hash UNIQUE (user_id, code_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,6 @@
+-- 010_add_user_session_client_info.down.sql
+-- Removes the session client details.
+
+ALTER TABLE user_sessions
+DROP COLUMN user_agent,
+DROP COLUMN ip_address;
@@ -0,0 +1,6 @@
+-- 010_add_user_session_client_info.up.sql
+-- Records where each session was signed in from so users can review their devices.
+
+ALTER TABLE user_sessions
+ADD COLUMN ip_address VARCHAR(45) NULL AFTER client_fingerprint,
+ADD COLUMN user_agent VARCHAR(512) NULL AFTER ip_address;
@@ -0,0 +1,9 @@
+-- 011_add_user_session_family.down.sql
+-- Removes refresh token families.
+
+DROP INDEX idx_user_sessions_parent ON user_sessions;
+DROP INDEX idx_user_sessions_family ON user_sessions;
+
+ALTER TABLE user_sessions
+DROP COLUMN family_id,
+DROP COLUMN parent_id;
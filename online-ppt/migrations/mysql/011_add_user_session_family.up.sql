@@ -0,0 +1,11 @@
+-- 011_add_user_session_family.up.sql
+-- Links rotated refresh tokens into families so a replayed token can revoke its whole chain.
+
+ALTER TABLE user_sessions
+ADD COLUMN parent_id INT NULL AFTER user_id,
+ADD COLUMN family_id CHAR(36) NULL AFTER parent_id;
+
+UPDATE user_sessions SET family_id = UUID() WHERE family_id IS NULL;
+
+CREATE INDEX idx_user_sessions_family ON user_sessions(family_id, revoked_at);
+CREATE INDEX idx_user_sessions_parent ON user_sessions(parent_id);
@@ -0,0 +1,4 @@
+-- 012_create_api_tokens.down.sql
+-- Drops personal access tokens.
+
+DROP TABLE IF EXISTS api_tokens;
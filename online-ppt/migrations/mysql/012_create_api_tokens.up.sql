@@ -0,0 +1,18 @@
+-- 012_create_api_tokens.up.sql
+-- Stores hashed, scoped personal access tokens used by scripts and CI.
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token_hash CHAR(43) NOT NULL,
+    token_prefix VARCHAR(16) NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    expires_at DATETIME NULL,
+    last_used_at DATETIME NULL,
+    revoked_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_api_tokens_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE,
+    CONSTRAINT uq_api_tokens_hash UNIQUE (token_hash),
+    INDEX idx_api_tokens_user_revoked (user_id, revoked_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
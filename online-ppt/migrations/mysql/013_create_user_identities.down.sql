@@ -0,0 +1,4 @@
+-- 013_create_user_identities.down.sql
+-- Drops external identity links; affected users sign in with a password only.
+
+DROP TABLE IF EXISTS user_identities;
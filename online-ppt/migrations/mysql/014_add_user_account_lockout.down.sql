@@ -0,0 +1,5 @@
+-- 014_add_user_account_lockout.down.sql
+-- Removes lock expiry; automatically locked accounts stay locked until unlocked manually.
+
+ALTER TABLE user_accounts
+DROP COLUMN locked_until;
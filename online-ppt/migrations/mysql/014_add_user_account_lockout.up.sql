@@ -0,0 +1,6 @@
+-- 014_add_user_account_lockout.up.sql
+-- Records when an automatic lock after repeated failed logins expires.
+-- A locked account without locked_until stays locked until unlocked manually.
+
+ALTER TABLE user_accounts
+ADD COLUMN locked_until DATETIME NULL AFTER status;
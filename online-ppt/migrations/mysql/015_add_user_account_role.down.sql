@@ -0,0 +1,5 @@
+-- 015_add_user_account_role.down.sql
+-- Removes account roles; nobody can use the admin endpoints afterwards.
+
+ALTER TABLE user_accounts
+DROP COLUMN role;
@@ -0,0 +1,5 @@
+-- 015_add_user_account_role.up.sql
+-- Adds the account role embedded in access tokens; admins may use admin endpoints.
+
+ALTER TABLE user_accounts
+ADD COLUMN role ENUM('user', 'admin') NOT NULL DEFAULT 'user' AFTER status;
@@ -0,0 +1,16 @@
+-- 015_initial_schema.down.sql
+-- Drops the whole PostgreSQL schema, including every deck and account.
+
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS api_tokens;
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_mfa;
+DROP TABLE IF EXISTS ppt_collaborators;
+DROP TABLE IF EXISTS ppt_shares;
+DROP TABLE IF EXISTS ppt_record_versions;
+DROP TABLE IF EXISTS ppt_records;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
+DROP TABLE IF EXISTS user_sessions;
+DROP TABLE IF EXISTS user_accounts;
+DROP FUNCTION IF EXISTS touch_updated_at();
@@ -0,0 +1,219 @@
+-- 015_initial_schema.up.sql
+-- Creates the SQLite schema matching MySQL migrations 001 through 015.
+-- Later migrations keep the version numbers of all dialects aligned.
+
+CREATE TABLE IF NOT EXISTS user_accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid CHAR(36) NOT NULL UNIQUE,
+    email VARCHAR(320) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'locked', 'pending')),
+    role VARCHAR(16) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+    locked_until DATETIME NULL,
+    last_login_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    parent_id INTEGER NULL,
+    family_id CHAR(36) NULL,
+    refresh_token_hash VARCHAR(255) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    issued_at DATETIME NOT NULL,
+    client_fingerprint VARCHAR(255) NULL,
+    ip_address VARCHAR(45) NULL,
+    user_agent VARCHAR(512) NULL,
+    revoked_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT fk_user_sessions_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_sessions_user_revoked ON user_sessions(user_id, revoked_at);
+CREATE INDEX idx_user_sessions_family ON user_sessions(family_id, revoked_at);
+CREATE INDEX idx_user_sessions_parent ON user_sessions(parent_id);
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid CHAR(36) NOT NULL UNIQUE,
+    name VARCHAR(120) NOT NULL,
+    created_by INTEGER NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT fk_workspaces_created_by FOREIGN KEY (created_by) REFERENCES user_accounts(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'member' CHECK (role IN ('viewer', 'member', 'admin')),
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT fk_workspace_members_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    CONSTRAINT fk_workspace_members_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE,
+    CONSTRAINT uq_workspace_members_workspace_user UNIQUE (workspace_id, user_id)
+);
+
+CREATE INDEX idx_workspace_members_user ON workspace_members(user_id);
+
+CREATE TABLE IF NOT EXISTS ppt_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NULL,
+    workspace_id INTEGER NULL,
+    name VARCHAR(120) NOT NULL,
+    title VARCHAR(255) NULL,
+    description VARCHAR(500) NULL,
+    group_name VARCHAR(120) NOT NULL,
+    relative_path VARCHAR(255) NOT NULL,
+    canonical_path VARCHAR(512) NOT NULL,
+    tags TEXT NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    current_version INTEGER NOT NULL DEFAULT 0,
+    deleted_at DATETIME NULL,
+    trash_path VARCHAR(512) NULL,
+    CONSTRAINT fk_ppt_records_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE SET NULL,
+    CONSTRAINT fk_ppt_records_workspace FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE RESTRICT
+);
+
+-- 组名只在未删除的 deck 之间唯一：工作区内按工作区、个人 deck 按用户，
+-- 对应 MySQL 里 live_workspace_id / personal_owner_id 生成列上的唯一键
+CREATE UNIQUE INDEX uq_ppt_records_workspace_group ON ppt_records(workspace_id, group_name) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX uq_ppt_records_personal_group ON ppt_records(user_id, group_name) WHERE workspace_id IS NULL AND deleted_at IS NULL;
+CREATE INDEX idx_ppt_records_user_created_at ON ppt_records(user_id, created_at DESC);
+CREATE INDEX idx_ppt_records_user_deleted_at ON ppt_records(user_id, deleted_at);
+CREATE INDEX idx_ppt_records_workspace_created_at ON ppt_records(workspace_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS ppt_record_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    record_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    label VARCHAR(255) NULL,
+    reason VARCHAR(32) NOT NULL,
+    manifest TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT fk_ppt_record_versions_record FOREIGN KEY (record_id) REFERENCES ppt_records(id) ON DELETE CASCADE,
+    CONSTRAINT uq_ppt_record_versions_record_version UNIQUE (record_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS ppt_shares (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    record_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    token_hash CHAR(43) NOT NULL,
+    password_hash VARCHAR(255) NULL,
+    expires_at DATETIME NOT NULL,
+    view_count INTEGER NOT NULL DEFAULT 0,
+    last_viewed_at DATETIME NULL,
+    revoked_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT fk_ppt_shares_record FOREIGN KEY (record_id) REFERENCES ppt_records(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ppt_shares_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE,
+    CONSTRAINT uq_ppt_shares_token_hash UNIQUE (token_hash)
+);
+
+CREATE INDEX idx_ppt_shares_record_created_at ON ppt_shares(record_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS ppt_collaborators (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    record_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+    invited_by INTEGER NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT fk_ppt_collaborators_record FOREIGN KEY (record_id) REFERENCES ppt_records(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ppt_collaborators_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ppt_collaborators_invited_by FOREIGN KEY (invited_by) REFERENCES user_accounts(id) ON DELETE SET NULL,
+    CONSTRAINT uq_ppt_collaborators_record_user UNIQUE (record_id, user_id)
+);
+
+CREATE INDEX idx_ppt_collaborators_user ON ppt_collaborators(user_id);
+
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id INTEGER NOT NULL PRIMARY KEY,
+    totp_secret VARCHAR(64) NOT NULL,
+    enabled_at DATETIME NULL,
+    last_used_step BIGINT NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT fk_user_mfa_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT fk_user_recovery_codes_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE,
+    CONSTRAINT uq_user_recovery_codes_user_hash UNIQUE (user_id, code_hash)
+);
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token_hash CHAR(43) NOT NULL,
+    token_prefix VARCHAR(16) NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    expires_at DATETIME NULL,
+    last_used_at DATETIME NULL,
+    revoked_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT fk_api_tokens_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE,
+    CONSTRAINT uq_api_tokens_hash UNIQUE (token_hash)
+);
+
+CREATE INDEX idx_api_tokens_user_revoked ON api_tokens(user_id, revoked_at);
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    provider VARCHAR(64) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(320) NULL,
+    last_login_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT fk_user_identities_user FOREIGN KEY (user_id) REFERENCES user_accounts(id) ON DELETE CASCADE,
+    CONSTRAINT uq_user_identities_provider_subject UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user ON user_identities(user_id);
+
+-- MySQL 的 ON UPDATE CURRENT_TIMESTAMP 用触发器模拟；语句显式改了 updated_at 时保留其值
+DELIMITER //
+CREATE TRIGGER trg_user_accounts_updated_at AFTER UPDATE ON user_accounts
+FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE user_accounts SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END//
+CREATE TRIGGER trg_workspaces_updated_at AFTER UPDATE ON workspaces
+FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE workspaces SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END//
+CREATE TRIGGER trg_workspace_members_updated_at AFTER UPDATE ON workspace_members
+FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE workspace_members SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END//
+CREATE TRIGGER trg_ppt_records_updated_at AFTER UPDATE ON ppt_records
+FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE ppt_records SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END//
+CREATE TRIGGER trg_ppt_collaborators_updated_at AFTER UPDATE ON ppt_collaborators
+FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE ppt_collaborators SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE id = NEW.id;
+END//
+CREATE TRIGGER trg_user_mfa_updated_at AFTER UPDATE ON user_mfa
+FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+BEGIN
+    UPDATE user_mfa SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now') WHERE user_id = NEW.user_id;
+END//
+DELIMITER ;
@@ -0,0 +1,271 @@
+package integration
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/auth"
+	"online-ppt/internal/config"
+	internalhttp "online-ppt/internal/http"
+	"online-ppt/internal/http/handlers"
+	"online-ppt/internal/storage"
+)
+
+const selectActiveSessionQuery = "SELECT id, user_id, parent_id, family_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, ip_address, user_agent, revoked_at, created_at FROM user_sessions WHERE refresh_token_hash = \\?"
+
+var userSessionColumns = []string{"id", "user_id", "parent_id", "family_id", "refresh_token_hash", "expires_at", "issued_at", "client_fingerprint", "ip_address", "user_agent", "revoked_at", "created_at"}
+
+type accountTestContext struct {
+	t       *testing.T
+	mock    sqlmock.Sqlmock
+	router  *gin.Engine
+	cache   *memoryCache
+	mailer  *recordingMailer
+	audit   *bytes.Buffer
+	token   string
+	email   string
+	pwdHash string
+	now     time.Time
+}
+
+func newAccountTestContext(t *testing.T, password string) *accountTestContext {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	repo, err := auth.NewRepository(db)
+	require.NoError(t, err)
+	tokenManager, err := auth.NewTokenManager("test-secret", time.Minute*5, time.Hour*24*30)
+	require.NoError(t, err)
+	auditBuf := &bytes.Buffer{}
+	auditLogger := storage.NewAuditLogger(log.New(auditBuf, "", 0))
+
+	cacheService := newMemoryCache()
+	mailer := newRecordingMailer()
+	authService, err := auth.NewService(repo, tokenManager, auditLogger, cacheService, fixedCaptcha{}, mailer)
+	require.NoError(t, err)
+
+	cfg := &config.Config{Server: config.ServerConfig{Addr: ":8080"}}
+	router := internalhttp.NewRouter(cfg)
+	internalhttp.RegisterAuthRoutes(router, handlers.NewAuthHandler(authService, cfg))
+
+	token, _, err := tokenManager.IssueAccessToken(1, "123e4567-e89b-12d3-a456-426614174000")
+	require.NoError(t, err)
+	hash, err := auth.HashPassword(password)
+	require.NoError(t, err)
+
+	return &accountTestContext{
+		t:       t,
+		mock:    mock,
+		router:  router,
+		cache:   cacheService,
+		mailer:  mailer,
+		audit:   auditBuf,
+		token:   token,
+		email:   "user@example.com",
+		pwdHash: hash,
+		now:     time.Now().UTC(),
+	}
+}
+
+func (ctx *accountTestContext) do(method, path string, payload any) *httptest.ResponseRecorder {
+	body, err := json.Marshal(payload)
+	require.NoError(ctx.t, err)
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+ctx.token)
+	rec := httptest.NewRecorder()
+	ctx.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (ctx *accountTestContext) expectUser() {
+	ctx.mock.ExpectQuery(selectUserByIDQuery).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(userAccountColumns).
+			AddRow(int64(1), "123e4567-e89b-12d3-a456-426614174000", ctx.email, ctx.pwdHash, "active", "user", sql.NullTime{}, sql.NullTime{}, ctx.now, ctx.now))
+}
+
+func requireAccountError(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	require.Equal(t, status, rec.Code, rec.Body.String())
+	var resp struct {
+		Code string `json:"code"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, code, resp.Code)
+}
+
+func TestChangePassword(t *testing.T) {
+	ctx := newAccountTestContext(t, "OldPassword123")
+
+	// 未登录
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/account/password", bytes.NewReader([]byte(`{}`)))
+	rec := httptest.NewRecorder()
+	ctx.router.ServeHTTP(rec, req)
+	requireAccountError(t, rec, http.StatusUnauthorized, "unauthorized")
+
+	// 当前密码错误
+	ctx.expectUser()
+	rec = ctx.do(http.MethodPut, "/api/v1/account/password", map[string]string{
+		"currentPassword": "WrongPassword1", "newPassword": "BrandNewPassword456",
+	})
+	requireAccountError(t, rec, http.StatusBadRequest, "incorrect_password")
+
+	// 新密码过短
+	rec = ctx.do(http.MethodPut, "/api/v1/account/password", map[string]string{
+		"currentPassword": "OldPassword123", "newPassword": "short",
+	})
+	requireAccountError(t, rec, http.StatusBadRequest, "weak_password")
+
+	// 修改成功，保留当前会话并吊销其他会话
+	refreshToken := "current-refresh-token"
+	var newHash string
+	ctx.expectUser()
+	ctx.mock.ExpectQuery(selectActiveSessionQuery).
+		WithArgs(auth.HashRefreshToken(refreshToken)).
+		WillReturnRows(sqlmock.NewRows(userSessionColumns).
+			AddRow(int64(7), int64(1), sql.NullInt64{}, "family-1", auth.HashRefreshToken(refreshToken), ctx.now.Add(time.Hour), ctx.now, sql.NullString{}, sql.NullString{}, sql.NullString{}, sql.NullTime{}, ctx.now))
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("UPDATE user_accounts SET password_hash = \\?").
+		WithArgs(captureArg{value: &newHash}, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectExec("UPDATE user_sessions SET revoked_at = \\? WHERE user_id = \\? AND id <> \\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), int64(1), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	ctx.mock.ExpectCommit()
+
+	rec = ctx.do(http.MethodPut, "/api/v1/account/password", map[string]string{
+		"currentPassword": "OldPassword123", "newPassword": "BrandNewPassword456", "refreshToken": refreshToken,
+	})
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+	match, err := auth.VerifyPassword(newHash, "BrandNewPassword456")
+	require.NoError(t, err)
+	require.True(t, match)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestChangeEmail(t *testing.T) {
+	ctx := newAccountTestContext(t, "OldPassword123")
+	newEmail := "renamed@example.com"
+
+	// 新邮箱已被占用
+	ctx.expectUser()
+	ctx.mock.ExpectQuery(selectUserByEmailQuery).
+		WithArgs("taken@example.com").
+		WillReturnRows(sqlmock.NewRows(userAccountColumns).
+			AddRow(int64(2), "9b2f0c1e-7d4a-4e8b-a1c3-5f6e7d8c9b0a", "taken@example.com", "hash", "active", "user", sql.NullTime{}, sql.NullTime{}, ctx.now, ctx.now))
+	rec := ctx.do(http.MethodPost, "/api/v1/account/email", map[string]string{
+		"email": "taken@example.com", "password": "OldPassword123", "captcha_id": "captcha-id", "captcha_code": testCaptchaCode,
+	})
+	requireAccountError(t, rec, http.StatusConflict, "email_exists")
+	require.Empty(t, ctx.mailer.codes)
+
+	// 与当前邮箱相同
+	ctx.expectUser()
+	rec = ctx.do(http.MethodPost, "/api/v1/account/email", map[string]string{
+		"email": ctx.email, "password": "OldPassword123", "captcha_id": "captcha-id", "captcha_code": testCaptchaCode,
+	})
+	requireAccountError(t, rec, http.StatusBadRequest, "email_unchanged")
+
+	// 向新邮箱发送验证码
+	ctx.expectUser()
+	ctx.mock.ExpectQuery(selectUserByEmailQuery).
+		WithArgs(newEmail).
+		WillReturnError(sql.ErrNoRows)
+	rec = ctx.do(http.MethodPost, "/api/v1/account/email", map[string]string{
+		"email": newEmail, "password": "OldPassword123", "captcha_id": "captcha-id", "captcha_code": testCaptchaCode,
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	code := ctx.mailer.codes[newEmail]
+	require.Len(t, code, 6)
+
+	// 验证码错误时邮箱不变
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	ctx.expectUser()
+	rec = ctx.do(http.MethodPost, "/api/v1/account/email/confirm", map[string]string{
+		"email": newEmail, "email_code": wrong,
+	})
+	requireAccountError(t, rec, http.StatusBadRequest, "invalid_code")
+
+	// 确认后更换邮箱并吊销全部其他会话
+	ctx.expectUser()
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("UPDATE user_accounts SET email = \\?").
+		WithArgs(newEmail, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectExec("UPDATE user_sessions SET revoked_at = \\? WHERE user_id = \\? AND id <> \\?").
+		WithArgs(sqlmock.AnyArg(), int64(1), int64(0)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	ctx.mock.ExpectCommit()
+
+	rec = ctx.do(http.MethodPost, "/api/v1/account/email/confirm", map[string]string{
+		"email": newEmail, "email_code": code,
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp struct {
+		User struct {
+			Email string `json:"email"`
+		} `json:"user"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, newEmail, resp.User.Email)
+
+	// 验证码已被消耗
+	ctx.expectUser()
+	rec = ctx.do(http.MethodPost, "/api/v1/account/email/confirm", map[string]string{
+		"email": newEmail, "email_code": code,
+	})
+	requireAccountError(t, rec, http.StatusBadRequest, "invalid_code")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestConfirmEmailChangeConflict(t *testing.T) {
+	ctx := newAccountTestContext(t, "OldPassword123")
+	newEmail := "race@example.com"
+
+	ctx.expectUser()
+	ctx.mock.ExpectQuery(selectUserByEmailQuery).
+		WithArgs(newEmail).
+		WillReturnError(sql.ErrNoRows)
+	rec := ctx.do(http.MethodPost, "/api/v1/account/email", map[string]string{
+		"email": newEmail, "password": "OldPassword123", "captcha_id": "captcha-id", "captcha_code": testCaptchaCode,
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// 另一账号抢先注册了该邮箱
+	ctx.expectUser()
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("UPDATE user_accounts SET email = \\?").
+		WithArgs(newEmail, int64(1)).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	ctx.mock.ExpectRollback()
+
+	rec = ctx.do(http.MethodPost, "/api/v1/account/email/confirm", map[string]string{
+		"email": newEmail, "email_code": ctx.mailer.codes[newEmail],
+	})
+	requireAccountError(t, rec, http.StatusConflict, "email_exists")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
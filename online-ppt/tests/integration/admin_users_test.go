@@ -0,0 +1,346 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/auth"
+	"online-ppt/internal/config"
+	internalhttp "online-ppt/internal/http"
+	"online-ppt/internal/http/handlers"
+	"online-ppt/internal/records"
+	"online-ppt/internal/storage"
+)
+
+const (
+	adminTestAdminID  = int64(9)
+	adminTestUserUUID = "123e4567-e89b-12d3-a456-426614174000"
+)
+
+type adminTestContext struct {
+	t          *testing.T
+	mock       sqlmock.Sqlmock
+	service    *auth.Service
+	router     *gin.Engine
+	mailer     *recordingMailer
+	audit      *bytes.Buffer
+	root       string
+	adminToken string
+	userToken  string
+	now        time.Time
+}
+
+func newAdminTestContext(t *testing.T) *adminTestContext {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	auditBuf := &bytes.Buffer{}
+	auditLogger := storage.NewAuditLogger(log.New(auditBuf, "", 0))
+
+	authRepo, err := auth.NewRepository(db)
+	require.NoError(t, err)
+	tokenManager, err := auth.NewTokenManager("test-secret", time.Minute*5, time.Hour*24)
+	require.NoError(t, err)
+	mailer := newRecordingMailer()
+	authService, err := auth.NewService(authRepo, tokenManager, auditLogger, newMemoryCache(), fixedCaptcha{}, mailer)
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	recordsRepo, err := records.NewRepository(db)
+	require.NoError(t, err)
+	recordsService, err := records.NewService(recordsRepo, root, auditLogger)
+	require.NoError(t, err)
+
+	cfg := &config.Config{Server: config.ServerConfig{Addr: ":8080"}}
+	router := internalhttp.NewRouter(cfg)
+	internalhttp.RegisterAdminRoutes(router, handlers.NewAdminHandler(authService, recordsService))
+
+	adminToken, _, err := tokenManager.IssueSessionAccessToken(adminTestAdminID, "9b2f0c1e-7d4a-4e8b-a1c3-5f6e7d8c9b0a", auth.RoleAdmin, 0)
+	require.NoError(t, err)
+	userToken, _, err := tokenManager.IssueAccessToken(1, adminTestUserUUID)
+	require.NoError(t, err)
+
+	return &adminTestContext{
+		t:          t,
+		mock:       mock,
+		service:    authService,
+		router:     router,
+		mailer:     mailer,
+		audit:      auditBuf,
+		root:       root,
+		adminToken: adminToken,
+		userToken:  userToken,
+		now:        time.Now().UTC(),
+	}
+}
+
+func (ctx *adminTestContext) do(token, method, path string, payload any) *httptest.ResponseRecorder {
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		require.NoError(ctx.t, err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	ctx.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (ctx *adminTestContext) expectUser(status string) {
+	ctx.mock.ExpectQuery(selectUserByIDQuery).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(userAccountColumns).
+			AddRow(int64(1), adminTestUserUUID, "user@example.com", "hash", status, "user", sql.NullTime{}, sql.NullTime{}, ctx.now, ctx.now))
+}
+
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	ctx := newAdminTestContext(t)
+
+	rec := ctx.do("", http.MethodGet, "/api/v1/admin/users", nil)
+	requireAccountError(t, rec, http.StatusUnauthorized, "unauthorized")
+
+	rec = ctx.do(ctx.userToken, http.MethodGet, "/api/v1/admin/users", nil)
+	requireAccountError(t, rec, http.StatusForbidden, "forbidden")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestAdminListUsers(t *testing.T) {
+	ctx := newAdminTestContext(t)
+
+	ctx.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM user_accounts WHERE email LIKE \\? ESCAPE '!' AND status = \\?").
+		WithArgs("%example%", "locked").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	ctx.mock.ExpectQuery("SELECT id, uuid, email, password_hash, status, role, locked_until, last_login_at, created_at, updated_at FROM user_accounts WHERE email LIKE \\? ESCAPE '!' AND status = \\? ORDER BY created_at DESC, id DESC LIMIT \\? OFFSET \\?").
+		WithArgs("%example%", "locked", 20, 0).
+		WillReturnRows(sqlmock.NewRows(userAccountColumns).
+			AddRow(int64(1), adminTestUserUUID, "user@example.com", "hash", "locked", "user", sql.NullTime{}, sql.NullTime{}, ctx.now, ctx.now))
+
+	rec := ctx.do(ctx.adminToken, http.MethodGet, "/api/v1/admin/users?q=Example&status=locked&limit=20", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp struct {
+		Total int `json:"total"`
+		Users []struct {
+			Email  string `json:"email"`
+			Status string `json:"status"`
+			Role   string `json:"role"`
+		} `json:"users"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, 1, resp.Total)
+	require.Len(t, resp.Users, 1)
+	require.Equal(t, "locked", resp.Users[0].Status)
+	require.Equal(t, "user", resp.Users[0].Role)
+	require.Contains(t, ctx.audit.String(), "auth.admin.users.list")
+
+	rec = ctx.do(ctx.adminToken, http.MethodGet, "/api/v1/admin/users?status=banned", nil)
+	requireAccountError(t, rec, http.StatusBadRequest, "invalid_status")
+
+	// 搜索词中的通配符按字面匹配
+	ctx.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM user_accounts WHERE email LIKE \\? ESCAPE '!'$").
+		WithArgs("%50!%!_off!!%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	ctx.mock.ExpectQuery("SELECT id, uuid, email, password_hash, status, role, locked_until, last_login_at, created_at, updated_at FROM user_accounts WHERE email LIKE \\? ESCAPE '!' ORDER BY").
+		WithArgs("%50!%!_off!!%", sqlmock.AnyArg(), 0).
+		WillReturnRows(sqlmock.NewRows(userAccountColumns))
+	rec = ctx.do(ctx.adminToken, http.MethodGet, "/api/v1/admin/users?q=50%25_off!", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestAdminSetUserStatus(t *testing.T) {
+	ctx := newAdminTestContext(t)
+
+	rec := ctx.do(ctx.adminToken, http.MethodPut, "/api/v1/admin/users/1/status", map[string]string{"status": "banned"})
+	requireAccountError(t, rec, http.StatusBadRequest, "invalid_status")
+
+	// 管理员不能锁定自己
+	rec = ctx.do(ctx.adminToken, http.MethodPut, "/api/v1/admin/users/9/status", map[string]string{"status": "locked"})
+	requireAccountError(t, rec, http.StatusConflict, "self_action")
+
+	// 手动锁定没有到期时间，并吊销全部会话
+	ctx.expectUser("active")
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("UPDATE user_accounts SET status = \\?, locked_until = NULL, updated_at = NOW\\(\\) WHERE id = \\?").
+		WithArgs("locked", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectExec("UPDATE user_sessions SET revoked_at = \\? WHERE user_id = \\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	ctx.mock.ExpectCommit()
+
+	rec = ctx.do(ctx.adminToken, http.MethodPut, "/api/v1/admin/users/1/status", map[string]string{"status": "locked"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp struct {
+		User struct {
+			Status      string  `json:"status"`
+			LockedUntil *string `json:"lockedUntil"`
+		} `json:"user"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "locked", resp.User.Status)
+	require.Nil(t, resp.User.LockedUntil)
+	require.Contains(t, ctx.audit.String(), `"adminId":9`)
+	require.Contains(t, ctx.audit.String(), `"revokedSessions":2`)
+
+	// 用户不存在
+	ctx.mock.ExpectQuery(selectUserByIDQuery).
+		WithArgs(int64(42)).
+		WillReturnError(sql.ErrNoRows)
+	rec = ctx.do(ctx.adminToken, http.MethodPut, "/api/v1/admin/users/42/status", map[string]string{"status": "active"})
+	requireAccountError(t, rec, http.StatusNotFound, "user_not_found")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestAdminSetUserRole(t *testing.T) {
+	ctx := newAdminTestContext(t)
+	bg := context.Background()
+
+	_, err := ctx.service.SetUserRole(bg, adminTestAdminID, 1, "owner")
+	require.ErrorIs(t, err, auth.ErrInvalidUserRole)
+
+	// 管理员不能取消自己的管理员身份
+	_, err = ctx.service.SetUserRole(bg, adminTestAdminID, adminTestAdminID, auth.RoleUser)
+	require.ErrorIs(t, err, auth.ErrAdminSelfAction)
+
+	ctx.mock.ExpectQuery(selectUserByEmailQuery).
+		WithArgs("user@example.com").
+		WillReturnRows(sqlmock.NewRows(userAccountColumns).
+			AddRow(int64(1), adminTestUserUUID, "user@example.com", "hash", "active", "user", sql.NullTime{}, sql.NullTime{}, ctx.now, ctx.now))
+	user, err := ctx.service.FindUser(bg, adminTestAdminID, " User@Example.com ")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), user.ID)
+
+	ctx.expectUser("active")
+	ctx.mock.ExpectExec("UPDATE user_accounts SET role = \\?, updated_at = NOW\\(\\) WHERE id = \\?").
+		WithArgs(auth.RoleAdmin, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	user, err = ctx.service.SetUserRole(bg, adminTestAdminID, 1, auth.RoleAdmin)
+	require.NoError(t, err)
+	require.Equal(t, auth.RoleAdmin, user.Role)
+	require.Contains(t, ctx.audit.String(), `"to":"admin"`)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestAdminForcePasswordReset(t *testing.T) {
+	ctx := newAdminTestContext(t)
+
+	ctx.expectUser("active")
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("UPDATE user_accounts SET password_hash = \\?, updated_at = NOW\\(\\) WHERE id = \\?").
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectExec("UPDATE user_sessions SET revoked_at = \\? WHERE user_id = \\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectCommit()
+
+	rec := ctx.do(ctx.adminToken, http.MethodPost, "/api/v1/admin/users/1/password-reset", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Contains(t, ctx.mailer.resetLinks["user@example.com"], "token=")
+
+	// 锁定的账号无法通过重置链接设置密码
+	ctx.expectUser("locked")
+	rec = ctx.do(ctx.adminToken, http.MethodPost, "/api/v1/admin/users/1/password-reset", nil)
+	requireAccountError(t, rec, http.StatusConflict, "account_inactive")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestAdminRevokeUserSessions(t *testing.T) {
+	ctx := newAdminTestContext(t)
+
+	ctx.expectUser("active")
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("UPDATE user_sessions SET revoked_at = \\? WHERE user_id = \\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	ctx.mock.ExpectCommit()
+
+	rec := ctx.do(ctx.adminToken, http.MethodDelete, "/api/v1/admin/users/1/sessions", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.JSONEq(t, `{"revoked":3}`, rec.Body.String())
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestAdminDeleteUserRemovesDecks(t *testing.T) {
+	ctx := newAdminTestContext(t)
+
+	workspaceUUID := "5f0c3a2e-8d7b-4c1a-9e6f-2b3c4d5e6f70"
+	personal := filepath.Join(ctx.root, adminTestUserUUID, "deckone", "slides")
+	trashed := filepath.Join(ctx.root, ".trash", adminTestUserUUID, "3_decktwo_20261001T000000Z")
+	workspaceDeck := filepath.Join(ctx.root, "ws", workspaceUUID, "teamdeck", "slides")
+	otherDeck := filepath.Join(ctx.root, "ws", workspaceUUID, "otherdeck", "slides")
+	for _, dir := range []string{personal, trashed, workspaceDeck, otherDeck} {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+
+	rec := ctx.do(ctx.adminToken, http.MethodDelete, "/api/v1/admin/users/9", nil)
+	requireAccountError(t, rec, http.StatusConflict, "self_action")
+
+	// 数据库删除失败时目录原样保留
+	ctx.expectUser("active")
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("DELETE FROM ppt_records WHERE user_id = \\? AND workspace_id IS NULL").
+		WithArgs(int64(1)).
+		WillReturnError(errors.New("lock wait timeout"))
+	ctx.mock.ExpectRollback()
+	rec = ctx.do(ctx.adminToken, http.MethodDelete, "/api/v1/admin/users/1", nil)
+	require.Equal(t, http.StatusInternalServerError, rec.Code, rec.Body.String())
+	_, err := os.Stat(personal)
+	require.NoError(t, err)
+
+	ctx.expectUser("active")
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("DELETE FROM ppt_records WHERE user_id = \\? AND workspace_id IS NULL").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	ctx.mock.ExpectExec("DELETE FROM user_accounts WHERE id = \\?").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectCommit()
+
+	rec = ctx.do(ctx.adminToken, http.MethodDelete, "/api/v1/admin/users/1", nil)
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	for _, dir := range []string{filepath.Join(ctx.root, adminTestUserUUID), filepath.Join(ctx.root, ".trash", adminTestUserUUID)} {
+		_, err := os.Stat(dir)
+		require.True(t, os.IsNotExist(err), dir)
+	}
+	// 工作区 deck 归工作区所有，删除创建者不影响
+	for _, dir := range []string{workspaceDeck, otherDeck} {
+		_, err := os.Stat(dir)
+		require.NoError(t, err, dir)
+	}
+	require.Contains(t, ctx.audit.String(), "records.user.purge")
+	require.Contains(t, ctx.audit.String(), "auth.admin.users.delete")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
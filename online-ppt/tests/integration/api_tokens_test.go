@@ -0,0 +1,187 @@
+package integration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/auth"
+	"online-ppt/internal/storage"
+)
+
+const (
+	selectAPITokenByIDQuery   = "SELECT id, user_id, name, token_hash, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_tokens WHERE id = \\?"
+	selectAPITokenByHashQuery = "SELECT id, user_id, name, token_hash, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_tokens WHERE token_hash = \\? AND revoked_at IS NULL"
+	touchAPITokenQuery        = "UPDATE api_tokens SET last_used_at = \\? WHERE id = \\? AND \\(last_used_at IS NULL OR last_used_at < \\?\\)"
+)
+
+var apiTokenColumns = []string{"id", "user_id", "name", "token_hash", "token_prefix", "scopes", "expires_at", "last_used_at", "revoked_at", "created_at"}
+
+func TestAPITokenLifecycle(t *testing.T) {
+	ctx := newAccountTestContext(t, "Password123456")
+
+	// 未知权限范围
+	rec := ctx.do(http.MethodPost, "/api/v1/account/tokens", map[string]any{"name": "CI", "scopes": []string{"everything"}})
+	requireAccountError(t, rec, http.StatusBadRequest, "invalid_token_request")
+
+	// 创建令牌：只保存摘要，明文仅返回一次
+	var storedHash string
+	ctx.mock.ExpectExec("INSERT INTO api_tokens").
+		WithArgs(int64(1), "CI", captureArg{value: &storedHash}, sqlmock.AnyArg(), "records:write", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	ctx.mock.ExpectQuery(selectAPITokenByIDQuery).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(apiTokenColumns).
+			AddRow(int64(3), int64(1), "CI", "hash", "ppt_abcdefgh", "records:write", ctx.now.Add(30*24*time.Hour), sql.NullTime{}, sql.NullTime{}, ctx.now))
+	rec = ctx.do(http.MethodPost, "/api/v1/account/tokens", map[string]any{
+		"name": " CI ", "scopes": []string{"Records:Write"}, "expiresInDays": 30,
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created struct {
+		ID     int64    `json:"id"`
+		Token  string   `json:"token"`
+		Scopes []string `json:"scopes"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Equal(t, int64(3), created.ID)
+	require.True(t, strings.HasPrefix(created.Token, auth.APITokenPrefix))
+	require.Equal(t, auth.HashRefreshToken(created.Token), storedHash)
+	require.Equal(t, []string{"records:write"}, created.Scopes)
+
+	// 列表不返回明文
+	ctx.mock.ExpectQuery("FROM api_tokens WHERE user_id = \\? AND revoked_at IS NULL").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(apiTokenColumns).
+			AddRow(int64(3), int64(1), "CI", "hash", "ppt_abcdefgh", "records:write", nil, ctx.now, sql.NullTime{}, ctx.now))
+	rec = ctx.do(http.MethodGet, "/api/v1/account/tokens", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotContains(t, rec.Body.String(), `"token"`)
+	require.Contains(t, rec.Body.String(), `"prefix":"ppt_abcdefgh"`)
+
+	// 吊销
+	ctx.mock.ExpectExec("UPDATE api_tokens SET revoked_at = \\? WHERE id = \\? AND user_id = \\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), int64(3), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rec = ctx.do(http.MethodDelete, "/api/v1/account/tokens/3", nil)
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	ctx.mock.ExpectExec("UPDATE api_tokens SET revoked_at = \\?").
+		WithArgs(sqlmock.AnyArg(), int64(4), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	rec = ctx.do(http.MethodDelete, "/api/v1/account/tokens/4", nil)
+	requireAccountError(t, rec, http.StatusNotFound, "token_not_found")
+
+	// 访问令牌不能用于账号设置
+	ctx.token = created.Token
+	rec = ctx.do(http.MethodGet, "/api/v1/account/tokens", nil)
+	requireAccountError(t, rec, http.StatusUnauthorized, "unauthorized")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+type apiTokenRecordsContext struct {
+	*recordsTestContext
+	authMock sqlmock.Sqlmock
+}
+
+func newAPITokenRecordsContext(t *testing.T) *apiTokenRecordsContext {
+	ctx := newRecordsTestContext(t)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	repo, err := auth.NewRepository(db)
+	require.NoError(t, err)
+	tokenManager, err := auth.NewTokenManager("test-secret", time.Minute*5, time.Hour*24)
+	require.NoError(t, err)
+	authService, err := auth.NewService(repo, tokenManager, storage.NewAuditLogger(log.New(io.Discard, "", 0)), newMemoryCache(), fixedCaptcha{}, newRecordingMailer())
+	require.NoError(t, err)
+	ctx.handler.WithAPITokens(authService)
+
+	return &apiTokenRecordsContext{recordsTestContext: ctx, authMock: mock}
+}
+
+// useAPIToken authenticates following requests with a personal access token
+// holding the given scopes.
+func (ctx *apiTokenRecordsContext) useAPIToken(token, scopes string, expiresAt any) {
+	ctx.token = token
+	now := time.Now().UTC()
+	ctx.authMock.ExpectQuery(selectAPITokenByHashQuery).
+		WithArgs(auth.HashRefreshToken(token)).
+		WillReturnRows(sqlmock.NewRows(apiTokenColumns).
+			AddRow(int64(3), ctx.userID, "CI", auth.HashRefreshToken(token), token[:12], scopes, expiresAt, sql.NullTime{}, sql.NullTime{}, now))
+}
+
+func (ctx *apiTokenRecordsContext) expectTokenUser() {
+	now := time.Now().UTC()
+	ctx.authMock.ExpectQuery(selectUserByIDQuery).
+		WithArgs(ctx.userID).
+		WillReturnRows(sqlmock.NewRows(userAccountColumns).
+			AddRow(ctx.userID, ctx.userUUID, "ci@example.com", "hash", "active", "user", sql.NullTime{}, sql.NullTime{}, now, now))
+	ctx.authMock.ExpectExec(touchAPITokenQuery).
+		WithArgs(sqlmock.AnyArg(), int64(3), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestRecordsAcceptAPITokens(t *testing.T) {
+	ctx := newAPITokenRecordsContext(t)
+	now := time.Now().UTC()
+
+	// 只读令牌可以读取
+	ctx.useAPIToken("ppt_read-token-value", "read", nil)
+	ctx.expectTokenUser()
+	ctx.mock.ExpectQuery("FROM workspaces w JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = \\?").
+		WithArgs(ctx.userID).
+		WillReturnRows(sqlmock.NewRows(workspaceMembershipColumns).
+			AddRow(testWorkspaceID, testWorkspaceUUID, "Design Team", ctx.userID, now, now, "admin"))
+	rec := ctx.do(http.MethodGet, "/api/v1/workspaces", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// 只读令牌不能写入
+	ctx.useAPIToken("ppt_read-token-value", "read", nil)
+	ctx.expectTokenUser()
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts", []byte(`{"name":"Deck"}`), "application/json")
+	requireAccountError(t, rec, http.StatusForbidden, "insufficient_scope")
+
+	// records:write 不能修改分享与成员
+	ctx.useAPIToken("ppt_write-token-value", "records:write", nil)
+	ctx.expectTokenUser()
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/5/shares", []byte(`{}`), "application/json")
+	requireAccountError(t, rec, http.StatusForbidden, "insufficient_scope")
+
+	// 过期令牌
+	ctx.useAPIToken("ppt_expired-token-value", "admin", now.Add(-time.Minute))
+	rec = ctx.do(http.MethodGet, "/api/v1/workspaces", nil, "")
+	requireAccountError(t, rec, http.StatusUnauthorized, "unauthorized")
+
+	// 已吊销或不存在的令牌
+	ctx.token = "ppt_unknown-token-value"
+	ctx.authMock.ExpectQuery(selectAPITokenByHashQuery).
+		WithArgs(auth.HashRefreshToken(ctx.token)).
+		WillReturnError(sql.ErrNoRows)
+	rec = ctx.do(http.MethodGet, "/api/v1/workspaces", nil, "")
+	requireAccountError(t, rec, http.StatusUnauthorized, "unauthorized")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+	require.NoError(t, ctx.authMock.ExpectationsWereMet())
+}
+
+func TestRecordsRejectAPITokensWhenDisabled(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+	ctx.token = "ppt_some-token-value"
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/workspaces", nil)
+	ctx.authorize(req)
+	rec := httptest.NewRecorder()
+	ctx.router.ServeHTTP(rec, req)
+	requireAccountError(t, rec, http.StatusUnauthorized, "unauthorized")
+}
@@ -2,6 +2,7 @@ package integration
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"io"
@@ -16,6 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"online-ppt/internal/auth"
+	"online-ppt/internal/cache"
 	"online-ppt/internal/config"
 	internalhttp "online-ppt/internal/http"
 	"online-ppt/internal/http/handlers"
@@ -39,7 +41,8 @@ func TestAuthRegisterAndLoginFlow(t *testing.T) {
 
 	auditLogger := storage.NewAuditLogger(log.New(io.Discard, "", 0))
 
-	authService, err := auth.NewService(repo, tokenManager, auditLogger)
+	codes := newMemoryCache()
+	authService, err := auth.NewService(repo, tokenManager, auditLogger, codes, fixedCaptcha{}, newRecordingMailer())
 	require.NoError(t, err)
 
 	cfg := &config.Config{
@@ -67,32 +70,39 @@ func TestAuthRegisterAndLoginFlow(t *testing.T) {
 		WithArgs(email, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("SELECT id, uuid, email, password_hash, status, last_login_at, created_at, updated_at FROM user_accounts WHERE id = \\?").
+	mock.ExpectQuery("SELECT id, uuid, email, password_hash, status, role, locked_until, last_login_at, created_at, updated_at FROM user_accounts WHERE id = \\?").
 		WithArgs(int64(1)).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "email", "password_hash", "status", "last_login_at", "created_at", "updated_at"}).
-			AddRow(int64(1), uuidValue, email, hashedPassword, "active", sql.NullTime{}, now, now))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "email", "password_hash", "status", "role", "locked_until", "last_login_at", "created_at", "updated_at"}).
+			AddRow(int64(1), uuidValue, email, hashedPassword, "active", "user", sql.NullTime{}, sql.NullTime{}, now, now))
 
-	mock.ExpectQuery("SELECT id, uuid, email, password_hash, status, last_login_at, created_at, updated_at FROM user_accounts WHERE email = \\?").
+	mock.ExpectQuery("SELECT id, uuid, email, password_hash, status, role, locked_until, last_login_at, created_at, updated_at FROM user_accounts WHERE email = \\?").
 		WithArgs(email).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "email", "password_hash", "status", "last_login_at", "created_at", "updated_at"}).
-			AddRow(int64(1), uuidValue, email, hashedPassword, "active", sql.NullTime{}, now, now))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "email", "password_hash", "status", "role", "locked_until", "last_login_at", "created_at", "updated_at"}).
+			AddRow(int64(1), uuidValue, email, hashedPassword, "active", "user", sql.NullTime{}, sql.NullTime{}, now, now))
+
+	mock.ExpectQuery("SELECT user_id, totp_secret, enabled_at, last_used_step, created_at, updated_at FROM user_mfa WHERE user_id = \\?").
+		WithArgs(int64(1)).
+		WillReturnError(sql.ErrNoRows)
 
 	mock.ExpectExec("INSERT INTO user_sessions").
-		WithArgs(int64(1), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(int64(1), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	mock.ExpectQuery("SELECT id, user_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, revoked_at, created_at FROM user_sessions WHERE id = \\?").
+	mock.ExpectQuery("SELECT id, user_id, parent_id, family_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, ip_address, user_agent, revoked_at, created_at FROM user_sessions WHERE id = \\?").
 		WithArgs(int64(1)).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "refresh_token_hash", "expires_at", "issued_at", "client_fingerprint", "revoked_at", "created_at"}).
-			AddRow(int64(1), int64(1), "hash", now.Add(24*time.Hour), now, sql.NullString{}, sql.NullTime{}, now))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "parent_id", "family_id", "refresh_token_hash", "expires_at", "issued_at", "client_fingerprint", "ip_address", "user_agent", "revoked_at", "created_at"}).
+			AddRow(int64(1), int64(1), sql.NullInt64{}, "family-1", "hash", now.Add(24*time.Hour), now, sql.NullString{}, sql.NullString{}, sql.NullString{}, sql.NullTime{}, now))
 
 	mock.ExpectExec("UPDATE user_accounts SET last_login_at = \\?").
 		WithArgs(sqlmock.AnyArg(), int64(1)).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
+	require.NoError(t, codes.SetEmailCode(context.Background(), email, &cache.EmailCodeData{Code: "123456", CreatedAt: now}))
+
 	payload := map[string]string{
-		"email":    email,
-		"password": password,
+		"email":      email,
+		"password":   password,
+		"email_code": "123456",
 	}
 	body, err := json.Marshal(payload)
 	require.NoError(t, err)
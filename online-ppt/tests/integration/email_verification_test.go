@@ -1,3 +1,7 @@
+//go:build redis
+
+// 需要本地 Redis（127.0.0.1:6379），运行方式：go test -tags redis ./tests/integration
+
 package integration
 
 import (
@@ -8,7 +12,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -59,8 +65,12 @@ func TestEmailVerificationFlow(t *testing.T) {
 	captchaService := captcha.NewService(cacheService)
 	mailService := &mockMailService{} // 使用 mock 邮件服务
 
-	// Mock 其他依赖
-	authRepo := &mockAuthRepository{}
+	// 发送验证码不访问数据库，用 sqlmock 占位
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	authRepo, err := auth.NewRepository(db)
+	require.NoError(t, err)
 	tokenManager, _ := auth.NewTokenManager(cfg.Security.JWTSecret, 0, 0)
 	auditLogger := storage.NewAuditLogger(nil)
 
@@ -121,6 +131,8 @@ func TestEmailVerificationFlow(t *testing.T) {
 		assert.Contains(t, resp["message"], "验证码已发送")
 		assert.Equal(t, float64(600), resp["expires_in"])
 	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 // mockMailService Mock 邮件服务
@@ -139,42 +151,18 @@ func (m *mockMailService) SendVerificationCode(to, code string) error {
 	return nil
 }
 
-// mockAuthRepository Mock 认证仓库
-type mockAuthRepository struct{}
-
-func (m *mockAuthRepository) CreateUser(ctx context.Context, email, passwordHash, uuidValue string) (auth.UserAccount, error) {
-	return auth.UserAccount{
-		ID:     1,
-		Email:  email,
-		Status: "active",
-	}, nil
-}
-
-func (m *mockAuthRepository) GetUserByEmail(ctx context.Context, email string) (auth.UserAccount, error) {
-	return auth.UserAccount{}, fmt.Errorf("user not found")
-}
-
-// 实现其他必需的方法...
-func (m *mockAuthRepository) GetUserByID(ctx context.Context, id int64) (auth.UserAccount, error) {
-	return auth.UserAccount{}, fmt.Errorf("not implemented")
-}
-
-func (m *mockAuthRepository) CreateSession(ctx context.Context, userID int64, refreshToken, fingerprint string, expiresAt interface{}) (auth.Session, error) {
-	return auth.Session{}, fmt.Errorf("not implemented")
-}
-
-func (m *mockAuthRepository) GetSessionByRefreshToken(ctx context.Context, token string) (auth.Session, error) {
-	return auth.Session{}, fmt.Errorf("not implemented")
-}
-
-func (m *mockAuthRepository) RefreshSession(ctx context.Context, sessionID int64, newRefreshToken string, newExpiresAt interface{}) error {
-	return fmt.Errorf("not implemented")
-}
-
-func (m *mockAuthRepository) RevokeSession(ctx context.Context, refreshToken string) error {
-	return fmt.Errorf("not implemented")
+func (m *mockMailService) SendPasswordReset(to, link string, expiresIn time.Duration) error {
+	m.SentEmails = append(m.SentEmails, struct {
+		To   string
+		Code string
+	}{To: to, Code: link})
+	return nil
 }
 
-func (m *mockAuthRepository) UpdateLastLogin(ctx context.Context, userID int64, loginTime interface{}) error {
+func (m *mockMailService) SendAccountUnlock(to, link string, lockedUntil time.Time) error {
+	m.SentEmails = append(m.SentEmails, struct {
+		To   string
+		Code string
+	}{To: to, Code: link})
 	return nil
 }
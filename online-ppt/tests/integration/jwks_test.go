@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/auth"
+	"online-ppt/internal/config"
+	internalhttp "online-ppt/internal/http"
+	"online-ppt/internal/http/handlers"
+	"online-ppt/internal/storage"
+)
+
+// TestJWKSVerifiesAccessTokens checks that another service can validate our
+// access tokens with nothing but the published key set.
+func TestJWKSVerifiesAccessTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	tokenManager, err := auth.NewTokenManagerWithKeys([]auth.SigningKey{
+		{ID: "ed-1", Algorithm: auth.AlgorithmEdDSA, Private: edKey, Public: edKey.Public()},
+		{Algorithm: auth.AlgorithmHS256, Public: []byte("legacy-secret")},
+	}, 5*time.Minute, 24*time.Hour)
+	require.NoError(t, err)
+
+	repo, err := auth.NewRepository(db)
+	require.NoError(t, err)
+	auditLogger := storage.NewAuditLogger(log.New(io.Discard, "", 0))
+	authService, err := auth.NewService(repo, tokenManager, auditLogger, newMemoryCache(), fixedCaptcha{}, newRecordingMailer())
+	require.NoError(t, err)
+
+	cfg := &config.Config{Server: config.ServerConfig{Addr: ":8080"}}
+	router := internalhttp.NewRouter(cfg)
+	internalhttp.RegisterAuthRoutes(router, handlers.NewAuthHandler(authService, cfg))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// HS256 密钥不会被发布
+	var set auth.JSONWebKeySet
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &set))
+	require.Len(t, set.Keys, 1)
+	require.Equal(t, "ed-1", set.Keys[0].Kid)
+	require.Equal(t, "Ed25519", set.Keys[0].Crv)
+	published, err := base64.RawURLEncoding.DecodeString(set.Keys[0].X)
+	require.NoError(t, err)
+
+	token, _, err := tokenManager.IssueSessionAccessToken(1, "123e4567-e89b-12d3-a456-426614174000", auth.RoleUser, 3)
+	require.NoError(t, err)
+	claims := &auth.Claims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(tok *jwt.Token) (interface{}, error) {
+		require.Equal(t, "ed-1", tok.Header["kid"])
+		return ed25519.PublicKey(published), nil
+	}, jwt.WithValidMethods([]string{"EdDSA"}))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), claims.UserID)
+	require.Equal(t, int64(3), claims.SessionID)
+}
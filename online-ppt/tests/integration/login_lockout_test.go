@@ -0,0 +1,132 @@
+package integration
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func (ctx *accountTestContext) expectUserByEmail(status string, lockedUntil sql.NullTime) {
+	ctx.mock.ExpectQuery(selectUserByEmailQuery).
+		WithArgs(ctx.email).
+		WillReturnRows(sqlmock.NewRows(userAccountColumns).
+			AddRow(int64(1), "123e4567-e89b-12d3-a456-426614174000", ctx.email, ctx.pwdHash, status, "user", lockedUntil, sql.NullTime{}, ctx.now, ctx.now))
+}
+
+func (ctx *accountTestContext) login(password string, withCaptcha bool) *httptest.ResponseRecorder {
+	payload := map[string]string{"email": ctx.email, "password": password}
+	if withCaptcha {
+		payload["captcha_id"] = "captcha-id"
+		payload["captcha_code"] = testCaptchaCode
+	}
+	return ctx.do(http.MethodPost, "/api/v1/auth/login", payload)
+}
+
+func TestLoginLockoutAndUnlock(t *testing.T) {
+	ctx := newAccountTestContext(t, "CorrectPassword1")
+
+	// 前三次失败只返回凭据错误
+	for i := 0; i < 3; i++ {
+		ctx.expectUserByEmail("active", sql.NullTime{})
+		requireAccountError(t, ctx.login("WrongPassword1", false), http.StatusUnauthorized, "invalid_credentials")
+	}
+
+	// 达到阈值后先退避，退避结束后需要图形验证码
+	requireAccountError(t, ctx.login("WrongPassword1", false), http.StatusTooManyRequests, "rate_limited")
+	ctx.cache.clearRateLimits()
+	requireAccountError(t, ctx.login("WrongPassword1", false), http.StatusBadRequest, "captcha_required")
+	rec := ctx.do(http.MethodPost, "/api/v1/auth/login", map[string]string{
+		"email": ctx.email, "password": "WrongPassword1", "captcha_id": "captcha-id", "captcha_code": "000000",
+	})
+	requireAccountError(t, rec, http.StatusBadRequest, "invalid_captcha")
+
+	for i := 3; i < 9; i++ {
+		ctx.cache.clearRateLimits()
+		ctx.expectUserByEmail("active", sql.NullTime{})
+		requireAccountError(t, ctx.login("WrongPassword1", true), http.StatusUnauthorized, "invalid_credentials")
+	}
+
+	// 第十次失败锁定账号并发送解锁邮件
+	ctx.cache.clearRateLimits()
+	ctx.expectUserByEmail("active", sql.NullTime{})
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("UPDATE user_accounts SET status = 'locked', locked_until = \\?, updated_at = NOW\\(\\) WHERE id = \\? AND status = 'active'").
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// 自动锁定同时吊销已有会话
+	ctx.mock.ExpectExec("UPDATE user_sessions SET revoked_at = \\? WHERE user_id = \\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	ctx.mock.ExpectCommit()
+	requireAccountError(t, ctx.login("WrongPassword1", true), http.StatusLocked, "account_locked")
+	require.Contains(t, ctx.mailer.unlockLinks, ctx.email)
+
+	// 锁定期间即使密码正确也拒绝登录
+	ctx.cache.clearRateLimits()
+	lockedUntil := sql.NullTime{Time: ctx.now.Add(30 * time.Minute), Valid: true}
+	ctx.expectUserByEmail("locked", lockedUntil)
+	requireAccountError(t, ctx.login("CorrectPassword1", true), http.StatusLocked, "account_locked")
+
+	// 通过邮件链接解锁，令牌只能使用一次
+	link, err := url.Parse(ctx.mailer.unlockLinks[ctx.email])
+	require.NoError(t, err)
+	require.Equal(t, "/unlock-account", link.Path)
+	token := link.Query().Get("token")
+
+	ctx.mock.ExpectQuery(selectUserByIDQuery).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(userAccountColumns).
+			AddRow(int64(1), "123e4567-e89b-12d3-a456-426614174000", ctx.email, ctx.pwdHash, "locked", "user", lockedUntil, sql.NullTime{}, ctx.now, ctx.now))
+	ctx.mock.ExpectExec("UPDATE user_accounts SET status = 'active', locked_until = NULL").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rec = ctx.do(http.MethodPost, "/api/v1/auth/unlock", map[string]string{"token": token})
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	rec = ctx.do(http.MethodPost, "/api/v1/auth/unlock", map[string]string{"token": token})
+	requireAccountError(t, rec, http.StatusBadRequest, "invalid_unlock_token")
+
+	// 同一 IP 的失败次数仍然保留，登录仍需验证码
+	ctx.cache.clearRateLimits()
+	ctx.expectUserByEmail("active", sql.NullTime{})
+	expectSessionIssued(ctx.mock, 1, ctx.now)
+	requireSignedIn(t, ctx.login("CorrectPassword1", true), ctx.email)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestLoginExpiredLockIsLifted(t *testing.T) {
+	ctx := newAccountTestContext(t, "CorrectPassword1")
+
+	ctx.expectUserByEmail("locked", sql.NullTime{Time: ctx.now.Add(-time.Minute), Valid: true})
+	ctx.mock.ExpectExec("UPDATE user_accounts SET status = 'active', locked_until = NULL").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSessionIssued(ctx.mock, 1, ctx.now)
+	requireSignedIn(t, ctx.login("CorrectPassword1", false), ctx.email)
+
+	// 人工锁定没有到期时间
+	ctx.expectUserByEmail("locked", sql.NullTime{})
+	requireAccountError(t, ctx.login("CorrectPassword1", false), http.StatusLocked, "account_locked")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestLoginPendingAccount(t *testing.T) {
+	ctx := newAccountTestContext(t, "CorrectPassword1")
+
+	// 密码错误时不透露账号状态
+	ctx.expectUserByEmail("pending", sql.NullTime{})
+	requireAccountError(t, ctx.login("WrongPassword1", false), http.StatusUnauthorized, "invalid_credentials")
+
+	ctx.expectUserByEmail("pending", sql.NullTime{})
+	requireAccountError(t, ctx.login("CorrectPassword1", false), http.StatusForbidden, "account_pending")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,456 @@
+package integration
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/auth"
+	"online-ppt/internal/config"
+	internalhttp "online-ppt/internal/http"
+	"online-ppt/internal/http/handlers"
+	"online-ppt/internal/storage"
+)
+
+const (
+	fakeIdPClientID     = "online-ppt"
+	fakeIdPClientSecret = "idp-secret"
+	fakeIdPKeyID        = "test-key"
+	oidcCallbackBase    = "https://ppt.example.com/api/v1/auth/oidc"
+
+	selectIdentityQuery = "SELECT id, user_id, provider, subject, email, last_login_at, created_at FROM user_identities WHERE provider = \\? AND subject = \\?"
+	selectMFAQuery      = "SELECT user_id, totp_secret, enabled_at, last_used_step, created_at, updated_at FROM user_mfa WHERE user_id = \\?"
+)
+
+var userIdentityColumns = []string{"id", "user_id", "provider", "subject", "email", "last_login_at", "created_at"}
+
+// fakeIdPUser is the account the fake identity provider signs in next.
+type fakeIdPUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type fakeIdPGrant struct {
+	user        fakeIdPUser
+	nonce       string
+	challenge   string
+	redirectURI string
+}
+
+// fakeIdP is an in-process OpenID Connect provider supporting the
+// authorization code flow with PKCE (S256) and RS256-signed ID tokens.
+type fakeIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	// issuer is reported by discovery and signed into ID tokens; it defaults
+	// to the server URL.
+	issuer string
+
+	mu     sync.Mutex
+	user   fakeIdPUser
+	grants map[string]fakeIdPGrant
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &fakeIdP{t: t, key: key, grants: make(map[string]fakeIdPGrant)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.handleDiscovery)
+	mux.HandleFunc("/authorize", idp.handleAuthorize)
+	mux.HandleFunc("/token", idp.handleToken)
+	mux.HandleFunc("/jwks", idp.handleJWKS)
+	idp.server = httptest.NewServer(mux)
+	idp.issuer = idp.server.URL
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *fakeIdP) signInAs(user fakeIdPUser) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.user = user
+}
+
+func (idp *fakeIdP) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 idp.issuer,
+		"authorization_endpoint": idp.server.URL + "/authorize",
+		"token_endpoint":         idp.server.URL + "/token",
+		"jwks_uri":               idp.server.URL + "/jwks",
+	})
+}
+
+// handleAuthorize approves every request for the current user and redirects
+// back to the client with a single-use code.
+func (idp *fakeIdP) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("response_type") != "code" || q.Get("client_id") != fakeIdPClientID ||
+		q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" || q.Get("nonce") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	idp.mu.Lock()
+	code := fmt.Sprintf("code-%d", len(idp.grants)+1)
+	idp.grants[code] = fakeIdPGrant{
+		user:        idp.user,
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+		redirectURI: q.Get("redirect_uri"),
+	}
+	idp.mu.Unlock()
+
+	target, err := url.Parse(q.Get("redirect_uri"))
+	require.NoError(idp.t, err)
+	callback := target.Query()
+	callback.Set("code", code)
+	callback.Set("state", q.Get("state"))
+	target.RawQuery = callback.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (idp *fakeIdP) handleToken(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok || clientID != fakeIdPClientID || secret != fakeIdPClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	idp.mu.Lock()
+	grant, found := idp.grants[r.PostForm.Get("code")]
+	delete(idp.grants, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !found || grant.redirectURI != r.PostForm.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            idp.issuer,
+		"aud":            fakeIdPClientID,
+		"sub":            grant.user.Subject,
+		"email":          grant.user.Email,
+		"email_verified": grant.user.EmailVerified,
+		"nonce":          grant.nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	})
+	token.Header["kid"] = fakeIdPKeyID
+	signed, err := token.SignedString(idp.key)
+	require.NoError(idp.t, err)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "idp-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func (idp *fakeIdP) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	pub := idp.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": fakeIdPKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+type oidcTestContext struct {
+	t      *testing.T
+	mock   sqlmock.Sqlmock
+	router *gin.Engine
+	idp    *fakeIdP
+	now    time.Time
+}
+
+func newOIDCTestContext(t *testing.T) *oidcTestContext {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	repo, err := auth.NewRepository(db)
+	require.NoError(t, err)
+	tokenManager, err := auth.NewTokenManager("test-secret", time.Minute*5, time.Hour*24*30)
+	require.NoError(t, err)
+	auditLogger := storage.NewAuditLogger(log.New(io.Discard, "", 0))
+	authService, err := auth.NewService(repo, tokenManager, auditLogger, newMemoryCache(), fixedCaptcha{}, newRecordingMailer())
+	require.NoError(t, err)
+
+	idp := newFakeIdP(t)
+	provider, err := auth.NewOIDCProvider(auth.OIDCProviderOptions{
+		Name:         "corp",
+		Issuer:       idp.server.URL,
+		ClientID:     fakeIdPClientID,
+		ClientSecret: fakeIdPClientSecret,
+		HTTPClient:   idp.server.Client(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, authService.ConfigureIdentityProviders(oidcCallbackBase, provider))
+
+	cfg := &config.Config{Server: config.ServerConfig{Addr: ":8080"}}
+	router := internalhttp.NewRouter(cfg)
+	internalhttp.RegisterAuthRoutes(router, handlers.NewAuthHandler(authService, cfg))
+
+	return &oidcTestContext{t: t, mock: mock, router: router, idp: idp, now: time.Now().UTC()}
+}
+
+func (ctx *oidcTestContext) get(target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rec := httptest.NewRecorder()
+	ctx.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// authorize starts a login and follows the fake IdP back to our callback URL.
+func (ctx *oidcTestContext) authorize() *url.URL {
+	rec := ctx.get("/api/v1/auth/oidc/corp/login")
+	require.Equal(ctx.t, http.StatusFound, rec.Code, rec.Body.String())
+
+	client := ctx.idp.server.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Get(rec.Header().Get("Location"))
+	require.NoError(ctx.t, err)
+	resp.Body.Close()
+	require.Equal(ctx.t, http.StatusFound, resp.StatusCode)
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(ctx.t, err)
+	require.Equal(ctx.t, "/api/v1/auth/oidc/corp/callback", callback.Path)
+	return callback
+}
+
+func (ctx *oidcTestContext) callback(callback *url.URL) *httptest.ResponseRecorder {
+	return ctx.get(callback.RequestURI())
+}
+
+func (ctx *oidcTestContext) userRow(id int64, email string) *sqlmock.Rows {
+	return sqlmock.NewRows(userAccountColumns).
+		AddRow(id, "123e4567-e89b-12d3-a456-426614174000", email, "hash", "active", "user", sql.NullTime{}, sql.NullTime{}, ctx.now, ctx.now)
+}
+
+// expectSession expects the writes of a successful sign-in without two-factor login.
+func (ctx *oidcTestContext) expectSession(userID int64) {
+	expectSessionIssued(ctx.mock, userID, ctx.now)
+}
+
+func expectSessionIssued(mock sqlmock.Sqlmock, userID int64, now time.Time) {
+	mock.ExpectQuery(selectMFAQuery).
+		WithArgs(userID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO user_sessions").
+		WithArgs(userID, sql.NullInt64{}, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectQuery(selectSessionByIDQuery).
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows(userSessionColumns).
+			AddRow(int64(9), userID, sql.NullInt64{}, "family-1", "hash", now.Add(time.Hour), now,
+				sql.NullString{}, sql.NullString{}, sql.NullString{}, sql.NullTime{}, now))
+	mock.ExpectExec("UPDATE user_accounts SET last_login_at = \\?").
+		WithArgs(sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func requireSignedIn(t *testing.T, rec *httptest.ResponseRecorder, email string) {
+	t.Helper()
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp struct {
+		User struct {
+			Email string `json:"email"`
+		} `json:"user"`
+		AccessToken string `json:"accessToken"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, email, resp.User.Email)
+	require.NotEmpty(t, resp.AccessToken)
+}
+
+func TestOIDCLoginProvisionsNewUser(t *testing.T) {
+	ctx := newOIDCTestContext(t)
+
+	rec := ctx.get("/api/v1/auth/oidc/providers")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"providers":["corp"]}`, rec.Body.String())
+
+	ctx.idp.signInAs(fakeIdPUser{Subject: "sub-new", Email: "New.User@Example.com", EmailVerified: true})
+	callback := ctx.authorize()
+
+	ctx.mock.ExpectQuery(selectIdentityQuery).
+		WithArgs("corp", "sub-new").
+		WillReturnError(sql.ErrNoRows)
+	ctx.mock.ExpectQuery(selectUserByEmailQuery).
+		WithArgs("new.user@example.com").
+		WillReturnError(sql.ErrNoRows)
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("INSERT INTO user_accounts").
+		WithArgs("new.user@example.com", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	ctx.mock.ExpectExec("INSERT INTO user_identities").
+		WithArgs(int64(7), "corp", "sub-new", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	ctx.mock.ExpectCommit()
+	ctx.mock.ExpectQuery(selectUserByIDQuery).
+		WithArgs(int64(7)).
+		WillReturnRows(ctx.userRow(7, "new.user@example.com"))
+	ctx.expectSession(7)
+
+	rec = ctx.callback(callback)
+	requireSignedIn(t, rec, "new.user@example.com")
+	require.Contains(t, rec.Header().Get("Set-Cookie"), "refresh_token=")
+
+	// state 只能使用一次
+	rec = ctx.callback(callback)
+	requireAccountError(t, rec, http.StatusBadRequest, "invalid_state")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestOIDCLoginLinksExistingAccountByVerifiedEmail(t *testing.T) {
+	ctx := newOIDCTestContext(t)
+
+	ctx.idp.signInAs(fakeIdPUser{Subject: "sub-42", Email: "user@example.com", EmailVerified: true})
+	callback := ctx.authorize()
+
+	ctx.mock.ExpectQuery(selectIdentityQuery).
+		WithArgs("corp", "sub-42").
+		WillReturnError(sql.ErrNoRows)
+	ctx.mock.ExpectQuery(selectUserByEmailQuery).
+		WithArgs("user@example.com").
+		WillReturnRows(ctx.userRow(1, "user@example.com"))
+	ctx.mock.ExpectExec("INSERT INTO user_identities").
+		WithArgs(int64(1), "corp", "sub-42", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	ctx.expectSession(1)
+	requireSignedIn(t, ctx.callback(callback), "user@example.com")
+
+	// 再次登录直接使用已关联的身份
+	callback = ctx.authorize()
+	ctx.mock.ExpectQuery(selectIdentityQuery).
+		WithArgs("corp", "sub-42").
+		WillReturnRows(sqlmock.NewRows(userIdentityColumns).
+			AddRow(int64(2), int64(1), "corp", "sub-42", "user@example.com", ctx.now, ctx.now))
+	ctx.mock.ExpectExec("UPDATE user_identities SET email = \\?, last_login_at = \\? WHERE id = \\?").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectQuery(selectUserByIDQuery).
+		WithArgs(int64(1)).
+		WillReturnRows(ctx.userRow(1, "user@example.com"))
+	ctx.expectSession(1)
+	requireSignedIn(t, ctx.callback(callback), "user@example.com")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestOIDCLoginRejectsUnverifiedEmail(t *testing.T) {
+	ctx := newOIDCTestContext(t)
+
+	ctx.idp.signInAs(fakeIdPUser{Subject: "sub-x", Email: "user@example.com", EmailVerified: false})
+	callback := ctx.authorize()
+
+	// 未验证邮箱不能关联已有账号
+	ctx.mock.ExpectQuery(selectIdentityQuery).
+		WithArgs("corp", "sub-x").
+		WillReturnError(sql.ErrNoRows)
+	requireAccountError(t, ctx.callback(callback), http.StatusForbidden, "email_unverified")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestOIDCLoginMatchesIssuerAsDiscovered(t *testing.T) {
+	ctx := newOIDCTestContext(t)
+
+	// 配置中的 issuer 不带末尾的 /，提供方声明的带 /，ID Token 按声明的值校验
+	ctx.idp.issuer = ctx.idp.server.URL + "/"
+	ctx.idp.signInAs(fakeIdPUser{Subject: "sub-42", Email: "user@example.com", EmailVerified: true})
+	callback := ctx.authorize()
+
+	ctx.mock.ExpectQuery(selectIdentityQuery).
+		WithArgs("corp", "sub-42").
+		WillReturnRows(sqlmock.NewRows(userIdentityColumns).
+			AddRow(int64(2), int64(1), "corp", "sub-42", "user@example.com", ctx.now, ctx.now))
+	ctx.mock.ExpectExec("UPDATE user_identities SET email = \\?, last_login_at = \\? WHERE id = \\?").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectQuery(selectUserByIDQuery).
+		WithArgs(int64(1)).
+		WillReturnRows(ctx.userRow(1, "user@example.com"))
+	ctx.expectSession(1)
+	requireSignedIn(t, ctx.callback(callback), "user@example.com")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestOIDCLoginRejectsTamperedCallback(t *testing.T) {
+	ctx := newOIDCTestContext(t)
+
+	rec := ctx.get("/api/v1/auth/oidc/unknown/login")
+	requireAccountError(t, rec, http.StatusNotFound, "provider_not_found")
+
+	// 伪造的 state
+	rec = ctx.get("/api/v1/auth/oidc/corp/callback?state=forged&code=code-1")
+	requireAccountError(t, rec, http.StatusBadRequest, "invalid_state")
+
+	// 授权码被替换后令牌端点拒绝兑换
+	ctx.idp.signInAs(fakeIdPUser{Subject: "sub-1", Email: "user@example.com", EmailVerified: true})
+	callback := ctx.authorize()
+	query := callback.Query()
+	query.Set("code", "stolen-code")
+	callback.RawQuery = query.Encode()
+	requireAccountError(t, ctx.callback(callback), http.StatusBadGateway, "oidc_login_failed")
+
+	// 用户在身份提供方拒绝授权
+	rec = ctx.get("/api/v1/auth/oidc/corp/callback?error=access_denied&state=x")
+	requireAccountError(t, rec, http.StatusUnauthorized, "oidc_denied")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,469 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/auth"
+	"online-ppt/internal/cache"
+	"online-ppt/internal/config"
+	internalhttp "online-ppt/internal/http"
+	"online-ppt/internal/http/handlers"
+	"online-ppt/internal/storage"
+)
+
+const (
+	selectUserByEmailQuery = "SELECT id, uuid, email, password_hash, status, role, locked_until, last_login_at, created_at, updated_at FROM user_accounts WHERE email = \\?"
+	selectUserByIDQuery    = "SELECT id, uuid, email, password_hash, status, role, locked_until, last_login_at, created_at, updated_at FROM user_accounts WHERE id = \\?"
+	testCaptchaCode        = "424242"
+)
+
+var userAccountColumns = []string{"id", "uuid", "email", "password_hash", "status", "role", "locked_until", "last_login_at", "created_at", "updated_at"}
+
+// memoryCache is an in-memory cache.Service used to exercise flows without Redis.
+type memoryCache struct {
+	mu          sync.Mutex
+	rateLimits  map[string]bool
+	emailCodes  map[string]cache.EmailCodeData
+	resetTokens map[string]cache.PasswordResetData
+	challenges  map[string]cache.MFAChallengeData
+	oidcStates  map[string]cache.OIDCStateData
+	failures    map[string]int
+	unlocks     map[string]cache.AccountUnlockData
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{
+		rateLimits:  make(map[string]bool),
+		emailCodes:  make(map[string]cache.EmailCodeData),
+		resetTokens: make(map[string]cache.PasswordResetData),
+		challenges:  make(map[string]cache.MFAChallengeData),
+		oidcStates:  make(map[string]cache.OIDCStateData),
+		failures:    make(map[string]int),
+		unlocks:     make(map[string]cache.AccountUnlockData),
+	}
+}
+
+func (m *memoryCache) SetCaptcha(ctx context.Context, captchaID, code string) error { return nil }
+func (m *memoryCache) GetCaptcha(ctx context.Context, captchaID string) (string, error) {
+	return "", errors.New("not found")
+}
+func (m *memoryCache) DeleteCaptcha(ctx context.Context, captchaID string) error { return nil }
+
+func (m *memoryCache) SetEmailCode(ctx context.Context, email string, data *cache.EmailCodeData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.emailCodes[email] = *data
+	return nil
+}
+
+func (m *memoryCache) GetEmailCode(ctx context.Context, email string) (*cache.EmailCodeData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.emailCodes[email]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &data, nil
+}
+
+func (m *memoryCache) DeleteEmailCode(ctx context.Context, email string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.emailCodes, email)
+	return nil
+}
+
+func (m *memoryCache) IncrementEmailCodeAttempts(ctx context.Context, email string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.emailCodes[email]
+	if !ok {
+		return errors.New("not found")
+	}
+	data.Attempts++
+	m.emailCodes[email] = data
+	return nil
+}
+
+func (m *memoryCache) SetRateLimit(ctx context.Context, key string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rateLimits[key] = true
+	return nil
+}
+
+func (m *memoryCache) CheckRateLimit(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rateLimits[key], nil
+}
+
+func (m *memoryCache) clearRateLimits() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rateLimits = make(map[string]bool)
+}
+
+func (m *memoryCache) SetPasswordResetToken(ctx context.Context, tokenHash string, data *cache.PasswordResetData, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resetTokens[tokenHash] = *data
+	return nil
+}
+
+func (m *memoryCache) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (*cache.PasswordResetData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.resetTokens[tokenHash]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	delete(m.resetTokens, tokenHash)
+	return &data, nil
+}
+
+func (m *memoryCache) SetMFAChallenge(ctx context.Context, tokenHash string, data *cache.MFAChallengeData, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.challenges[tokenHash] = *data
+	return nil
+}
+
+func (m *memoryCache) GetMFAChallenge(ctx context.Context, tokenHash string) (*cache.MFAChallengeData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.challenges[tokenHash]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &data, nil
+}
+
+func (m *memoryCache) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.challenges, tokenHash)
+	return nil
+}
+
+func (m *memoryCache) IncrementMFAChallengeAttempts(ctx context.Context, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.challenges[tokenHash]
+	if !ok {
+		return errors.New("not found")
+	}
+	data.Attempts++
+	m.challenges[tokenHash] = data
+	return nil
+}
+
+func (m *memoryCache) SetOIDCState(ctx context.Context, stateHash string, data *cache.OIDCStateData, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.oidcStates[stateHash] = *data
+	return nil
+}
+
+func (m *memoryCache) ConsumeOIDCState(ctx context.Context, stateHash string) (*cache.OIDCStateData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.oidcStates[stateHash]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	delete(m.oidcStates, stateHash)
+	return &data, nil
+}
+
+func (m *memoryCache) IncrementLoginFailures(ctx context.Context, key string, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures[key]++
+	return m.failures[key], nil
+}
+
+func (m *memoryCache) GetLoginFailures(ctx context.Context, key string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.failures[key], nil
+}
+
+func (m *memoryCache) ResetLoginFailures(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.failures, key)
+	return nil
+}
+
+func (m *memoryCache) SetAccountUnlockToken(ctx context.Context, tokenHash string, data *cache.AccountUnlockData, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unlocks[tokenHash] = *data
+	return nil
+}
+
+func (m *memoryCache) ConsumeAccountUnlockToken(ctx context.Context, tokenHash string) (*cache.AccountUnlockData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.unlocks[tokenHash]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	delete(m.unlocks, tokenHash)
+	return &data, nil
+}
+
+// fixedCaptcha accepts a single known code for any captcha id.
+type fixedCaptcha struct{}
+
+func (fixedCaptcha) Generate(ctx context.Context) (string, string, error) {
+	return "captcha-id", "data:image/png;base64,", nil
+}
+
+func (fixedCaptcha) Verify(ctx context.Context, captchaID, code string) (bool, error) {
+	return code == testCaptchaCode, nil
+}
+
+// recordingMailer keeps the verification codes and links it was asked to send.
+type recordingMailer struct {
+	codes       map[string]string
+	resetLinks  map[string]string
+	unlockLinks map[string]string
+}
+
+func newRecordingMailer() *recordingMailer {
+	return &recordingMailer{
+		codes:       make(map[string]string),
+		resetLinks:  make(map[string]string),
+		unlockLinks: make(map[string]string),
+	}
+}
+
+func (m *recordingMailer) SendVerificationCode(to, code string) error {
+	m.codes[to] = code
+	return nil
+}
+
+func (m *recordingMailer) SendPasswordReset(to, link string, expiresIn time.Duration) error {
+	m.resetLinks[to] = link
+	return nil
+}
+
+func (m *recordingMailer) SendAccountUnlock(to, link string, lockedUntil time.Time) error {
+	m.unlockLinks[to] = link
+	return nil
+}
+
+func TestPasswordResetFlow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	repo, err := auth.NewRepository(db)
+	require.NoError(t, err)
+	tokenManager, err := auth.NewTokenManager("test-secret", time.Minute*5, time.Hour*24*30)
+	require.NoError(t, err)
+	auditLogger := storage.NewAuditLogger(log.New(io.Discard, "", 0))
+
+	cacheService := newMemoryCache()
+	mailer := newRecordingMailer()
+	authService, err := auth.NewService(repo, tokenManager, auditLogger, cacheService, fixedCaptcha{}, mailer)
+	require.NoError(t, err)
+	require.NoError(t, authService.ConfigurePasswordReset("https://ppt.example.com/reset-password", 15*time.Minute))
+
+	cfg := &config.Config{Server: config.ServerConfig{Addr: ":8080"}}
+	router := internalhttp.NewRouter(cfg)
+	internalhttp.RegisterAuthRoutes(router, handlers.NewAuthHandler(authService, cfg))
+
+	post := func(path string, payload any) *httptest.ResponseRecorder {
+		body, err := json.Marshal(payload)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	requireErrorCode := func(rec *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		require.Equal(t, status, rec.Code, rec.Body.String())
+		var resp struct {
+			Code string `json:"code"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, code, resp.Code)
+	}
+
+	email := "user@example.com"
+	oldHash, err := auth.HashPassword("OldPassword123")
+	require.NoError(t, err)
+	now := time.Now().UTC()
+	userRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows(userAccountColumns).
+			AddRow(int64(1), "123e4567-e89b-12d3-a456-426614174000", email, oldHash, "active", "user", sql.NullTime{}, sql.NullTime{}, now, now)
+	}
+
+	// 图形验证码错误
+	rec := post("/api/v1/auth/password/reset", map[string]string{
+		"email": email, "captcha_id": "captcha-id", "captcha_code": "000000",
+	})
+	requireErrorCode(rec, http.StatusBadRequest, "invalid_captcha")
+
+	// 未注册邮箱返回相同响应且不发送邮件
+	mock.ExpectQuery(selectUserByEmailQuery).
+		WithArgs("nobody@example.com").
+		WillReturnError(sql.ErrNoRows)
+	rec = post("/api/v1/auth/password/reset", map[string]string{
+		"email": "nobody@example.com", "captcha_id": "captcha-id", "captcha_code": testCaptchaCode,
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Empty(t, mailer.resetLinks)
+
+	// 已注册邮箱收到重置链接
+	mock.ExpectQuery(selectUserByEmailQuery).
+		WithArgs(email).
+		WillReturnRows(userRow())
+	rec = post("/api/v1/auth/password/reset", map[string]string{
+		"email": email, "captcha_id": "captcha-id", "captcha_code": testCaptchaCode,
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var requestResp struct {
+		ExpiresIn int `json:"expires_in"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &requestResp))
+	require.Equal(t, 900, requestResp.ExpiresIn)
+
+	link, err := url.Parse(mailer.resetLinks[email])
+	require.NoError(t, err)
+	require.Equal(t, "ppt.example.com", link.Host)
+	require.Equal(t, "/reset-password", link.Path)
+	token := link.Query().Get("token")
+	require.NotEmpty(t, token)
+
+	// 缓存中只保存令牌摘要
+	require.Len(t, cacheService.resetTokens, 1)
+	_, storedRaw := cacheService.resetTokens[token]
+	require.False(t, storedRaw)
+
+	// 同一邮箱短时间内再次申请被限流
+	rec = post("/api/v1/auth/password/reset", map[string]string{
+		"email": email, "captcha_id": "captcha-id", "captcha_code": testCaptchaCode,
+	})
+	requireErrorCode(rec, http.StatusTooManyRequests, "rate_limited")
+
+	// 弱密码不会消耗令牌
+	rec = post("/api/v1/auth/password/reset/confirm", map[string]string{
+		"token": token, "password": "short", "captcha_id": "captcha-id", "captcha_code": testCaptchaCode,
+	})
+	requireErrorCode(rec, http.StatusBadRequest, "weak_password")
+	require.Len(t, cacheService.resetTokens, 1)
+
+	// 重置密码并吊销全部会话
+	newPassword := "BrandNewPassword456"
+	var newHash string
+	mock.ExpectQuery(selectUserByIDQuery).
+		WithArgs(int64(1)).
+		WillReturnRows(userRow())
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE user_accounts SET password_hash = \\?").
+		WithArgs(captureArg{value: &newHash}, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE user_sessions SET revoked_at = \\? WHERE user_id = \\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	rec = post("/api/v1/auth/password/reset/confirm", map[string]string{
+		"token": token, "password": newPassword, "captcha_id": "captcha-id", "captcha_code": testCaptchaCode,
+	})
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+	match, err := auth.VerifyPassword(newHash, newPassword)
+	require.NoError(t, err)
+	require.True(t, match)
+	require.Empty(t, cacheService.resetTokens)
+
+	// 令牌只能使用一次
+	rec = post("/api/v1/auth/password/reset/confirm", map[string]string{
+		"token": token, "password": newPassword, "captcha_id": "captcha-id", "captcha_code": testCaptchaCode,
+	})
+	requireErrorCode(rec, http.StatusBadRequest, "invalid_reset_token")
+
+	// 无效令牌之后同一客户端被短暂限流
+	rec = post("/api/v1/auth/password/reset/confirm", map[string]string{
+		"token": "guessed-token", "password": newPassword, "captcha_id": "captcha-id", "captcha_code": testCaptchaCode,
+	})
+	requireErrorCode(rec, http.StatusTooManyRequests, "rate_limited")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPasswordResetRejectsTokenAfterEmailChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	repo, err := auth.NewRepository(db)
+	require.NoError(t, err)
+	tokenManager, err := auth.NewTokenManager("test-secret", time.Minute*5, time.Hour*24*30)
+	require.NoError(t, err)
+	auditLogger := storage.NewAuditLogger(log.New(io.Discard, "", 0))
+
+	cacheService := newMemoryCache()
+	mailer := newRecordingMailer()
+	authService, err := auth.NewService(repo, tokenManager, auditLogger, cacheService, fixedCaptcha{}, mailer)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	mock.ExpectQuery(selectUserByEmailQuery).
+		WithArgs("old@example.com").
+		WillReturnRows(sqlmock.NewRows(userAccountColumns).
+			AddRow(int64(5), "9b2f0c1e-7d4a-4e8b-a1c3-5f6e7d8c9b0a", "old@example.com", "hash", "active", "user", sql.NullTime{}, sql.NullTime{}, now, now))
+	_, err = authService.RequestPasswordReset(ctx, "old@example.com", "captcha-id", testCaptchaCode)
+	require.NoError(t, err)
+
+	link, err := url.Parse(mailer.resetLinks["old@example.com"])
+	require.NoError(t, err)
+	require.Equal(t, "/reset-password", link.Path)
+	cacheService.clearRateLimits()
+
+	mock.ExpectQuery(selectUserByIDQuery).
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows(userAccountColumns).
+			AddRow(int64(5), "9b2f0c1e-7d4a-4e8b-a1c3-5f6e7d8c9b0a", "new@example.com", "hash", "active", "user", sql.NullTime{}, sql.NullTime{}, now, now))
+	err = authService.ConfirmPasswordReset(ctx, auth.PasswordResetConfirmation{
+		Token:       link.Query().Get("token"),
+		Password:    "BrandNewPassword456",
+		CaptchaID:   "captcha-id",
+		CaptchaCode: testCaptchaCode,
+		ClientKey:   "192.0.2.1",
+	})
+	require.ErrorIs(t, err, auth.ErrInvalidResetToken)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
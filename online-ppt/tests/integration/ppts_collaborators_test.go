@@ -0,0 +1,226 @@
+package integration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	selectCollaboratorRecordQuery = "FROM ppt_records r JOIN ppt_collaborators c ON c.record_id = r.id AND c.user_id = \\?"
+	ownerUserID                   = int64(2)
+	ownerUserUUID                 = "9b2f0c1e-7d4a-4e8b-a1c3-5f6e7d8c9b0a"
+)
+
+// expectSharedRecordLookup mocks a record owned by ownerUserID that the test
+// user reaches through a collaborator grant with the given role.
+func (ctx *recordsTestContext) expectSharedRecordLookup(recordID int64, groupName, role string) string {
+	rel := filepath.ToSlash(filepath.Join("presentations", ownerUserUUID, groupName, "slides"))
+	canonical := filepath.Join(ctx.root, ownerUserUUID, groupName, "slides")
+	now := time.Now().UTC()
+
+	ctx.expectNonMemberLookup(recordID)
+	ctx.mock.ExpectQuery(selectCollaboratorRecordQuery).
+		WithArgs(ctx.userID, recordID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id", "uuid", "role"}).
+			AddRow(recordID, ownerUserID, groupName, nil, nil, groupName, rel, canonical, nil, now, now, 0, nil, ownerUserUUID, role))
+
+	return canonical
+}
+
+func TestCollaboratorManagement(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+	now := time.Now().UTC()
+
+	ctx.expectRecordLookup(5, "deckone")
+	ctx.mock.ExpectQuery("SELECT id FROM user_accounts WHERE email = \\? AND status = 'active'").
+		WithArgs("bob@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)))
+	ctx.mock.ExpectExec("INSERT INTO ppt_collaborators").
+		WithArgs(int64(5), int64(7), "editor", ctx.userID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	body, err := json.Marshal(map[string]string{"email": " Bob@Example.com ", "role": "editor"})
+	require.NoError(t, err)
+	rec := ctx.do(http.MethodPost, "/api/v1/ppts/5/collaborators", body, "application/json")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.EqualValues(t, 7, created["userId"])
+	require.Equal(t, "bob@example.com", created["email"])
+	require.Equal(t, "editor", created["role"])
+
+	ctx.expectRecordLookup(5, "deckone")
+	ctx.mock.ExpectQuery("SELECT id FROM user_accounts WHERE email = \\?").
+		WithArgs("bob@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)))
+	ctx.mock.ExpectExec("INSERT INTO ppt_collaborators").
+		WithArgs(int64(5), int64(7), "editor", ctx.userID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/5/collaborators", body, "application/json")
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	ctx.expectRecordLookup(5, "deckone")
+	ctx.mock.ExpectQuery("SELECT id FROM user_accounts WHERE email = \\?").
+		WithArgs("nobody@example.com").
+		WillReturnError(sql.ErrNoRows)
+	body, err = json.Marshal(map[string]string{"email": "nobody@example.com", "role": "viewer"})
+	require.NoError(t, err)
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/5/collaborators", body, "application/json")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	ctx.expectRecordLookup(5, "deckone")
+	ctx.mock.ExpectQuery("SELECT id FROM user_accounts WHERE email = \\?").
+		WithArgs("me@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(ctx.userID))
+	body, err = json.Marshal(map[string]string{"email": "me@example.com", "role": "viewer"})
+	require.NoError(t, err)
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/5/collaborators", body, "application/json")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	ctx.expectRecordLookup(5, "deckone")
+	ctx.mock.ExpectQuery("FROM ppt_collaborators c JOIN user_accounts u ON u.id = c.user_id WHERE c.record_id = \\?").
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "record_id", "user_id", "email", "role", "invited_by", "created_at", "updated_at"}).
+			AddRow(int64(1), int64(5), int64(7), "bob@example.com", "editor", ctx.userID, now, now))
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts/5/collaborators", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed struct {
+		Items []struct {
+			UserID int64  `json:"userId"`
+			Email  string `json:"email"`
+			Role   string `json:"role"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed.Items, 1)
+	require.Equal(t, "editor", listed.Items[0].Role)
+
+	ctx.expectRecordLookup(5, "deckone")
+	ctx.mock.ExpectExec("UPDATE ppt_collaborators SET role = \\?").
+		WithArgs("viewer", int64(5), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	body, err = json.Marshal(map[string]string{"role": "viewer"})
+	require.NoError(t, err)
+	rec = ctx.do(http.MethodPatch, "/api/v1/ppts/5/collaborators/7", body, "application/json")
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	body, err = json.Marshal(map[string]string{"role": "admin"})
+	require.NoError(t, err)
+	rec = ctx.do(http.MethodPatch, "/api/v1/ppts/5/collaborators/7", body, "application/json")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	ctx.expectRecordLookup(5, "deckone")
+	ctx.mock.ExpectExec("DELETE FROM ppt_collaborators WHERE record_id = \\? AND user_id = \\?").
+		WithArgs(int64(5), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rec = ctx.do(http.MethodDelete, "/api/v1/ppts/5/collaborators/7", nil, "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	ctx.expectRecordLookup(5, "deckone")
+	ctx.mock.ExpectExec("DELETE FROM ppt_collaborators").
+		WithArgs(int64(5), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	rec = ctx.do(http.MethodDelete, "/api/v1/ppts/5/collaborators/7", nil, "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+	require.Equal(t, "collaborator_not_found", payload["code"])
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestCollaboratorRolesGateDeckAccess(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectSharedRecordLookup(20, "shared", "editor")
+	body, err := json.Marshal(map[string]string{"content": "<section>from editor</section>"})
+	require.NoError(t, err)
+	rec := ctx.do(http.MethodPost, "/api/v1/ppts/20/slides", body, "application/json")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	data, err := os.ReadFile(filepath.Join(canonical, "slide-1.html"))
+	require.NoError(t, err)
+	require.Equal(t, "<section>from editor</section>", string(data))
+
+	ctx.expectSharedRecordLookup(20, "shared", "viewer")
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts/20/slides/slide-1.html", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	ctx.expectSharedRecordLookup(20, "shared", "viewer")
+	rec = ctx.do(http.MethodDelete, "/api/v1/ppts/20/slides/slide-1.html", nil, "")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+	require.Equal(t, "forbidden", payload["code"])
+
+	ctx.expectSharedRecordLookup(20, "shared", "editor")
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/20/shares", nil, "")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	ctx.expectSharedRecordLookup(20, "shared", "editor")
+	rec = ctx.do(http.MethodDelete, "/api/v1/ppts/20", nil, "")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	ctx.expectSharedRecordLookup(20, "shared", "viewer")
+	ctx.mock.ExpectExec("DELETE FROM ppt_collaborators").
+		WithArgs(int64(20), ctx.userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rec = ctx.do(http.MethodDelete, "/api/v1/ppts/20/collaborators/1", nil, "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	_, err = os.Stat(filepath.Join(canonical, "slide-1.html"))
+	require.NoError(t, err)
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestListSharedPptRecords(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+	now := time.Now().UTC()
+
+	rel := filepath.ToSlash(filepath.Join("presentations", ownerUserUUID, "shared", "slides"))
+	canonical := filepath.Join(ctx.root, ownerUserUUID, "shared", "slides")
+	require.NoError(t, os.MkdirAll(canonical, 0o755))
+
+	ctx.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM ppt_records WHERE id IN \\(SELECT record_id FROM ppt_collaborators WHERE user_id = \\?\\) AND deleted_at IS NULL").
+		WithArgs(ctx.userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	ctx.mock.ExpectQuery("SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at, current_version, workspace_id FROM ppt_records WHERE id IN").
+		WithArgs(ctx.userID, 50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(int64(20), ownerUserID, "Shared", nil, nil, "shared", rel, canonical, nil, now, now, 0, nil))
+	ctx.mock.ExpectQuery("SELECT record_id, role FROM ppt_collaborators WHERE user_id = \\? AND record_id IN \\(\\?\\)").
+		WithArgs(ctx.userID, int64(20)).
+		WillReturnRows(sqlmock.NewRows([]string{"record_id", "role"}).AddRow(int64(20), "viewer"))
+
+	rec := ctx.do(http.MethodGet, "/api/v1/ppts?scope=shared", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Items []struct {
+			ID         int64  `json:"id"`
+			Role       string `json:"role"`
+			PathStatus string `json:"pathStatus"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Items, 1)
+	require.Equal(t, "viewer", resp.Items[0].Role)
+	require.Equal(t, "valid", resp.Items[0].PathStatus)
+
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts?scope=everyone", nil, "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
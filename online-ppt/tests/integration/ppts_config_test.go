@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type slidesConfigResponse struct {
+	Title string `json:"title"`
+	Theme struct {
+		PrimaryColor string `json:"primaryColor"`
+		Transition   string `json:"transition"`
+	} `json:"theme"`
+	Settings struct {
+		ShowProgress bool `json:"showProgress"`
+	} `json:"settings"`
+	Slides []struct {
+		ID      string `json:"id"`
+		File    string `json:"file"`
+		Visible bool   `json:"visible"`
+		Notes   string `json:"notes"`
+	} `json:"slides"`
+}
+
+func TestSlidesConfigSyncsWithSlideFiles(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectRecordLookup(8, "deckone")
+	require.NoError(t, os.MkdirAll(canonical, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(canonical, "slide-1.html"), []byte("<p>1</p>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(canonical, "slide-2.html"), []byte("<p>2</p>"), 0o644))
+
+	rec := ctx.do(http.MethodGet, "/api/v1/ppts/8/config", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var cfg slidesConfigResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cfg))
+	require.Equal(t, "deckone", cfg.Title)
+	require.True(t, cfg.Settings.ShowProgress)
+	require.Len(t, cfg.Slides, 2)
+	require.Equal(t, "slide-1", cfg.Slides[0].ID)
+	require.True(t, cfg.Slides[0].Visible)
+
+	// 修改配置前先为当前内容保存版本
+	ctx.expectRecordLookup(8, "deckone")
+	ctx.expectSnapshot(8, 1)
+	patch := []byte(`{"title":"Quarterly Review","theme":{"transition":"fade"}}`)
+	rec = ctx.do(http.MethodPatch, "/api/v1/ppts/8/config", patch, "application/json")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cfg))
+	require.Equal(t, "Quarterly Review", cfg.Title)
+	require.Equal(t, "fade", cfg.Theme.Transition)
+	require.Equal(t, "#3b82f6", cfg.Theme.PrimaryColor)
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(canonical), "slides.config.json"))
+	require.NoError(t, err)
+	var onDisk slidesConfigResponse
+	require.NoError(t, json.Unmarshal(data, &onDisk))
+	require.Equal(t, "Quarterly Review", onDisk.Title)
+	require.Len(t, onDisk.Slides, 2)
+
+	ctx.expectRecordLookup(8, "deckone")
+	ctx.expectSnapshot(8, 2)
+	rec = ctx.do(http.MethodDelete, "/api/v1/ppts/8/slides/slide-1.html", nil, "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	data, err = os.ReadFile(filepath.Join(filepath.Dir(canonical), "slides.config.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &onDisk))
+	require.Len(t, onDisk.Slides, 1)
+	require.Equal(t, "slide-2.html", onDisk.Slides[0].File)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestSlidesConfigRejectsInvalidDocuments(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectRecordLookup(8, "deckone")
+	require.NoError(t, os.MkdirAll(canonical, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(canonical, "slide-1.html"), []byte("<p>1</p>"), 0o644))
+
+	missingFile := []byte(`{"title":"Deck","slides":[{"id":"a","file":"slide-7.html","visible":true}]}`)
+	rec := ctx.do(http.MethodPut, "/api/v1/ppts/8/config", missingFile, "application/json")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	ctx.expectRecordLookup(8, "deckone")
+	unknownField := []byte(`{"title":"Deck","colour":"red"}`)
+	rec = ctx.do(http.MethodPut, "/api/v1/ppts/8/config", unknownField, "application/json")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	ctx.expectRecordLookup(8, "deckone")
+	badTransition := []byte(`{"theme":{"transition":"spin"}}`)
+	rec = ctx.do(http.MethodPatch, "/api/v1/ppts/8/config", badTransition, "application/json")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	ctx.expectRecordLookup(8, "deckone")
+	ctx.expectSnapshot(8, 1)
+	valid := []byte(`{"title":"Deck","slides":[{"id":"intro","title":"Intro","file":"slide-1.html","visible":false,"notes":"hidden","duration":30}]}`)
+	rec = ctx.do(http.MethodPut, "/api/v1/ppts/8/config", valid, "application/json")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var cfg slidesConfigResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cfg))
+	require.Len(t, cfg.Slides, 1)
+	require.Equal(t, "intro", cfg.Slides[0].ID)
+	require.False(t, cfg.Slides[0].Visible)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
@@ -70,14 +70,14 @@ func TestCreatePptRecord(t *testing.T) {
 	canonicalPath := filepath.Join(canonicalRoot, userUUID, groupName, "slides")
 
 	mock.ExpectExec("INSERT INTO ppt_records").
-		WithArgs(userID, "DemoDeck", sqlmock.AnyArg(), sqlmock.AnyArg(), groupName, relativePath, canonicalPath, sqlmock.AnyArg()).
+		WithArgs(userID, nil, "DemoDeck", sqlmock.AnyArg(), sqlmock.AnyArg(), groupName, relativePath, canonicalPath, sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(42, 1))
 
 	now := time.Now().UTC()
-	mock.ExpectQuery("SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at FROM ppt_records WHERE user_id = \\? AND id = \\? LIMIT 1").
+	mock.ExpectQuery("SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at, current_version, workspace_id FROM ppt_records WHERE user_id = \\? AND id = \\? AND deleted_at IS NULL LIMIT 1").
 		WithArgs(userID, int64(42)).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at"}).
-			AddRow(int64(42), userID, "DemoDeck", nil, description, groupName, relativePath, canonicalPath, `["tag1","tag2"]`, now, now))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(int64(42), userID, "DemoDeck", nil, description, groupName, relativePath, canonicalPath, `["tag1","tag2"]`, now, now, 0, nil))
 
 	token, _, err := tokenManager.IssueAccessToken(userID, userUUID)
 	require.NoError(t, err)
@@ -175,14 +175,14 @@ func TestCreatePptRecordWithTitle(t *testing.T) {
 	canonicalPath := filepath.Join(canonicalRoot, userUUID, groupName, "slides")
 
 	mock.ExpectExec("INSERT INTO ppt_records").
-		WithArgs(userID, "MyDeck", sqlmock.AnyArg(), sqlmock.AnyArg(), groupName, relativePath, canonicalPath, sqlmock.AnyArg()).
+		WithArgs(userID, nil, "MyDeck", sqlmock.AnyArg(), sqlmock.AnyArg(), groupName, relativePath, canonicalPath, sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(99, 1))
 
 	now := time.Now().UTC()
-	mock.ExpectQuery("SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at FROM ppt_records WHERE user_id = \\? AND id = \\? LIMIT 1").
+	mock.ExpectQuery("SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at, current_version, workspace_id FROM ppt_records WHERE user_id = \\? AND id = \\? AND deleted_at IS NULL LIMIT 1").
 		WithArgs(userID, int64(99)).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at"}).
-			AddRow(int64(99), userID, "MyDeck", title, nil, groupName, relativePath, canonicalPath, nil, now, now))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(int64(99), userID, "MyDeck", title, nil, groupName, relativePath, canonicalPath, nil, now, now, 0, nil))
 
 	token, _, err := tokenManager.IssueAccessToken(userID, userUUID)
 	require.NoError(t, err)
@@ -0,0 +1,99 @@
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportPptRecordAsZip(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectRecordLookup(21, "deckone")
+	deckDir := filepath.Dir(canonical)
+	require.NoError(t, os.MkdirAll(filepath.Join(deckDir, "assets", "img"), 0o755))
+	require.NoError(t, os.MkdirAll(canonical, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(deckDir, "assets", "logo.png"), []byte("png"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(deckDir, "assets", "img", "tile.png"), []byte("tile"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(deckDir, "assets", "theme.css"), []byte(`body { background: url('img/tile.png'); }`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(ctx.root, "secret.txt"), []byte("secret"), 0o644))
+
+	slide := `<html><head>
+<script type="module" src="/@vite/client"></script>
+<link rel="stylesheet" href="/presentations/` + ctx.userUUID + `/deckone/assets/theme.css">
+<link rel="stylesheet" href="https://cdnjs.cloudflare.com/ajax/libs/font-awesome/6.4.0/css/all.min.css">
+</head><body>
+<img src="../assets/logo.png?v=2">
+<img src='../../../secret.txt'>
+<a href="#top">top</a>
+</body></html>`
+	require.NoError(t, os.WriteFile(filepath.Join(canonical, "slide-1.html"), []byte(slide), 0o644))
+
+	rec := ctx.do(http.MethodGet, "/api/v1/ppts/21/export?format=zip", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Header().Get("Content-Disposition"), `filename="deckone.zip"`)
+
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, file := range archive.File {
+		r, err := file.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+		files[file.Name] = string(data)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{
+		"deckone/assets/img/tile.png",
+		"deckone/assets/logo.png",
+		"deckone/assets/theme.css",
+		"deckone/index.html",
+		"deckone/slides.config.json",
+		"deckone/slides/slide-1.html",
+	}, names)
+
+	exported := files["deckone/slides/slide-1.html"]
+	require.NotContains(t, exported, "/@vite/client")
+	require.Contains(t, exported, `href="../assets/theme.css"`)
+	require.Contains(t, exported, `src="../assets/logo.png"`)
+	require.Contains(t, exported, "https://cdnjs.cloudflare.com/ajax/libs/font-awesome/6.4.0/css/all.min.css")
+	require.Contains(t, exported, `src='../../../secret.txt'`)
+	require.Contains(t, files["deckone/assets/theme.css"], `url("img/tile.png")`)
+
+	player := files["deckone/index.html"]
+	require.Contains(t, player, "<title>deckone</title>")
+	require.True(t, strings.Contains(player, `"file": "slide-1.html"`), player)
+	require.Contains(t, files["deckone/slides.config.json"], `"slide-1.html"`)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestExportRejectsUnknownFormat(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	rec := ctx.do(http.MethodGet, "/api/v1/ppts/21/export?format=pdf", nil, "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	ctx.expectRecordLookup(22, "decktwo")
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts/22/export", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
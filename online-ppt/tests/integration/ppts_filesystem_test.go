@@ -0,0 +1,100 @@
+package integration
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenamePptRecordMovesSlides(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	oldCanonical := ctx.expectRecordLookup(7, "deckone")
+	seedSlides(t, oldCanonical, 2)
+
+	newCanonical := filepath.Join(ctx.root, ctx.userUUID, "decktwo", "slides")
+	ctx.mock.ExpectExec("UPDATE ppt_records SET").
+		WithArgs("DeckTwo", sqlmock.AnyArg(), sqlmock.AnyArg(), "decktwo", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.expectLiveRecordLookup(7, "decktwo")
+
+	rec := ctx.do(http.MethodPatch, "/api/v1/ppts/7", []byte(`{"name":"DeckTwo"}`), "application/json")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	_, err := os.Stat(filepath.Join(newCanonical, "slide-2.html"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Dir(oldCanonical))
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestRenamePptRecordRollsBackOnDatabaseFailure(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	oldCanonical := ctx.expectRecordLookup(7, "deckone")
+	seedSlides(t, oldCanonical, 1)
+
+	ctx.mock.ExpectExec("UPDATE ppt_records SET").
+		WithArgs("DeckTwo", sqlmock.AnyArg(), sqlmock.AnyArg(), "decktwo", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), int64(7)).
+		WillReturnError(errors.New("connection reset"))
+
+	rec := ctx.do(http.MethodPatch, "/api/v1/ppts/7", []byte(`{"name":"DeckTwo"}`), "application/json")
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	_, err := os.Stat(filepath.Join(oldCanonical, "slide-1.html"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(ctx.root, ctx.userUUID, "decktwo"))
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestDeletePptRecordMovesDeckToTrash(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectRecordLookup(9, "deckone")
+	seedSlides(t, canonical, 1)
+	ctx.mock.ExpectExec("UPDATE ppt_records SET deleted_at").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec := ctx.do(http.MethodDelete, "/api/v1/ppts/9", nil, "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	_, err := os.Stat(filepath.Dir(canonical))
+	require.True(t, os.IsNotExist(err))
+
+	trashed, err := filepath.Glob(filepath.Join(ctx.root, ".trash", ctx.userUUID, "9_deckone_*", "slides", "slide-1.html"))
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestDeletePptRecordRestoresDeckOnDatabaseFailure(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectRecordLookup(9, "deckone")
+	seedSlides(t, canonical, 1)
+	ctx.mock.ExpectExec("UPDATE ppt_records SET deleted_at").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(9)).
+		WillReturnError(errors.New("deadlock"))
+
+	rec := ctx.do(http.MethodDelete, "/api/v1/ppts/9", nil, "")
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	_, err := os.Stat(filepath.Join(canonical, "slide-1.html"))
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(filepath.Join(ctx.root, ".trash", ctx.userUUID))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,172 @@
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/records"
+)
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func (ctx *recordsTestContext) importZip(t *testing.T, archive []byte, fields map[string]string) (int, map[string]any) {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for key, value := range fields {
+		require.NoError(t, form.WriteField(key, value))
+	}
+	part, err := form.CreateFormFile("file", "deck.zip")
+	require.NoError(t, err)
+	_, err = part.Write(archive)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	rec := ctx.do(http.MethodPost, "/api/v1/ppts/import", body.Bytes(), form.FormDataContentType())
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payload), rec.Body.String())
+	return rec.Code, payload
+}
+
+func (ctx *recordsTestContext) expectImportCreate(recordID int64, name string) string {
+	groupName := name
+	rel := filepath.ToSlash(filepath.Join("presentations", ctx.userUUID, groupName, "slides"))
+	canonical := filepath.Join(ctx.root, ctx.userUUID, groupName, "slides")
+	now := time.Now().UTC()
+
+	ctx.mock.ExpectExec("INSERT INTO ppt_records").
+		WithArgs(ctx.userID, nil, name, sqlmock.AnyArg(), sqlmock.AnyArg(), groupName, rel, canonical, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(recordID, 1))
+	ctx.mock.ExpectQuery(selectRecordQuery).
+		WithArgs(ctx.userID, recordID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(recordID, ctx.userID, name, nil, nil, groupName, rel, canonical, nil, now, now, 0, nil))
+
+	return canonical
+}
+
+func TestImportPptRecordFromWrappedZip(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectImportCreate(31, "roundtrip")
+
+	archive := buildZip(t, map[string]string{
+		"roundtrip/index.html":              "<html>player</html>",
+		"roundtrip/slides.config.json":      `{"title":"Round trip","slides":[{"id":"intro","title":"Intro","file":"intro.html","visible":true},{"id":"one","title":"One","file":"slide-1.html","visible":false}]}`,
+		"roundtrip/slides/slide-1.html":     `<html><body><link rel="stylesheet" href="../assets/app.css">One</body></html>`,
+		"roundtrip/slides/intro.html":       "<html><body>Intro</body></html>",
+		"roundtrip/assets/app.css":          "body { color: red; }",
+		"roundtrip/assets/img/logo.png":     "png",
+		"__MACOSX/roundtrip/._slide-1.html": "junk",
+	})
+
+	code, payload := ctx.importZip(t, archive, nil)
+	require.Equal(t, http.StatusCreated, code, payload)
+	require.Equal(t, float64(31), payload["id"])
+
+	deckDir := filepath.Dir(canonical)
+	for _, rel := range []string{"slides/slide-1.html", "slides/slide-2.html", "assets/app.css", "assets/img/logo.png"} {
+		_, err := os.Stat(filepath.Join(deckDir, filepath.FromSlash(rel)))
+		require.NoError(t, err, rel)
+	}
+	_, err := os.Stat(filepath.Join(deckDir, "index.html"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(filepath.Join(canonical, "intro.html"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	raw, err := os.ReadFile(filepath.Join(deckDir, records.SlidesConfigFile))
+	require.NoError(t, err)
+	var cfg records.SlidesConfig
+	require.NoError(t, json.Unmarshal(raw, &cfg))
+	require.Equal(t, "Round trip", cfg.Title)
+	require.Len(t, cfg.Slides, 2)
+	require.Equal(t, "slide-2.html", cfg.Slides[0].File)
+	require.Equal(t, "slide-1.html", cfg.Slides[1].File)
+	require.False(t, cfg.Slides[1].Visible)
+
+	entries, err := os.ReadDir(filepath.Join(ctx.root, ".imports"))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestImportPptRecordGeneratesConfig(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectImportCreate(32, "flatdeck")
+
+	archive := buildZip(t, map[string]string{
+		"slides/slide-2.html": "<html><body>Two</body></html>",
+		"slides/slide-1.html": "<html><body>One</body></html>",
+	})
+
+	code, payload := ctx.importZip(t, archive, map[string]string{"name": "flatdeck"})
+	require.Equal(t, http.StatusCreated, code, payload)
+
+	raw, err := os.ReadFile(filepath.Join(filepath.Dir(canonical), records.SlidesConfigFile))
+	require.NoError(t, err)
+	var cfg records.SlidesConfig
+	require.NoError(t, json.Unmarshal(raw, &cfg))
+	require.Len(t, cfg.Slides, 2)
+	require.Equal(t, "slide-1.html", cfg.Slides[0].File)
+	require.Equal(t, "slide-2.html", cfg.Slides[1].File)
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestImportPptRecordRejectsUnsafeArchives(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	cases := []struct {
+		name  string
+		files map[string]string
+		code  string
+	}{
+		{"zip slip", map[string]string{"slides/slide-1.html": "<html></html>", "../evil.html": "<html></html>"}, "invalid_archive"},
+		{"absolute path", map[string]string{"slides/slide-1.html": "<html></html>", "/etc/cron.d/evil.css": "x"}, "invalid_archive"},
+		{"disallowed type", map[string]string{"slides/slide-1.html": "<html></html>", "assets/run.exe": "MZ"}, "invalid_archive"},
+		{"html outside slides", map[string]string{"slides/slide-1.html": "<html></html>", "assets/extra.html": "<html></html>"}, "invalid_archive"},
+		{"no slides", map[string]string{"assets/app.css": "body {}"}, "invalid_archive"},
+		{"bad config", map[string]string{"slides/slide-1.html": "<html></html>", "slides.config.json": `{"unknown":true}`}, "invalid_config"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, payload := ctx.importZip(t, buildZip(t, tc.files), map[string]string{"name": "unsafe"})
+			require.Equal(t, http.StatusBadRequest, code, payload)
+			require.Equal(t, tc.code, payload["code"])
+		})
+	}
+
+	code, payload := ctx.importZip(t, []byte("not a zip"), map[string]string{"name": "unsafe"})
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, "invalid_archive", payload["code"])
+
+	_, err := os.Stat(filepath.Join(ctx.root, ctx.userUUID, "unsafe"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
@@ -27,6 +27,8 @@ import (
 
 type recordsTestContext struct {
 	router   *gin.Engine
+	handler  *handlers.RecordsHandler
+	service  *records.Service
 	mock     sqlmock.Sqlmock
 	token    string
 	userID   int64
@@ -35,8 +37,9 @@ type recordsTestContext struct {
 }
 
 const (
-	selectRecordQuery = "SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at FROM ppt_records WHERE user_id = \\? AND id = \\? LIMIT 1"
-	baseDescription   = "Primary deck"
+	selectRecordQuery     = "SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at, current_version, workspace_id FROM ppt_records WHERE user_id = \\? AND id = \\? AND deleted_at IS NULL LIMIT 1"
+	selectLiveRecordQuery = "SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at, current_version, workspace_id FROM ppt_records WHERE id = \\? AND deleted_at IS NULL LIMIT 1"
+	baseDescription       = "Primary deck"
 )
 
 func newRecordsTestContext(t *testing.T) *recordsTestContext {
@@ -82,6 +85,8 @@ func newRecordsTestContext(t *testing.T) *recordsTestContext {
 
 	return &recordsTestContext{
 		router:   router,
+		handler:  handler,
+		service:  service,
 		mock:     mock,
 		token:    token,
 		userID:   userID,
@@ -108,14 +113,14 @@ func TestListPptRecords(t *testing.T) {
 
 	like := "%demo%"
 	ctx.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM ppt_records").
-		WithArgs(ctx.userID, like, like, like, "tag1").
+		WithArgs(ctx.userID, ctx.userID, like, like, like, "tag1").
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 
-	ctx.mock.ExpectQuery("SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at FROM ppt_records").
-		WithArgs(ctx.userID, like, like, like, "tag1", 10, 5).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at"}).
-			AddRow(int64(10), ctx.userID, "DeckOne", nil, baseDescription, "deckone", rel, canonicalValid, "[\"tag1\",\"tag2\"]", now, now).
-			AddRow(int64(11), ctx.userID, "DeckTwo", nil, nil, "decktwo", relMissing, canonicalMissing, nil, now, now))
+	ctx.mock.ExpectQuery("SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at, current_version, workspace_id FROM ppt_records").
+		WithArgs(ctx.userID, ctx.userID, like, like, like, "tag1", 10, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(int64(10), ctx.userID, "DeckOne", nil, baseDescription, "deckone", rel, canonicalValid, "[\"tag1\",\"tag2\"]", now, now, 0, nil).
+			AddRow(int64(11), ctx.userID, "DeckTwo", nil, nil, "decktwo", relMissing, canonicalMissing, nil, now, now, 0, nil))
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/ppts?q=demo&tag=tag1&sort=name_asc&limit=10&offset=5", nil)
 	ctx.authorize(req)
@@ -175,8 +180,8 @@ func TestGetPptRecord(t *testing.T) {
 
 	ctx.mock.ExpectQuery(selectRecordQuery).
 		WithArgs(ctx.userID, int64(42)).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at"}).
-			AddRow(int64(42), ctx.userID, "DeckOne", nil, baseDescription, "deckone", rel, canonical, "[\"tag1\"]", now, now))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(int64(42), ctx.userID, "DeckOne", nil, baseDescription, "deckone", rel, canonical, "[\"tag1\"]", now, now, 0, nil))
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/ppts/42", nil)
 	ctx.authorize(req)
@@ -208,7 +213,8 @@ func TestGetPptRecord(t *testing.T) {
 func TestGetPptRecordNotFound(t *testing.T) {
 	ctx := newRecordsTestContext(t)
 
-	ctx.mock.ExpectQuery(selectRecordQuery).
+	ctx.expectNonMemberLookup(99)
+	ctx.mock.ExpectQuery(selectCollaboratorRecordQuery).
 		WithArgs(ctx.userID, int64(99)).
 		WillReturnError(sql.ErrNoRows)
 
@@ -233,20 +239,20 @@ func TestUpdatePptRecord(t *testing.T) {
 
 	ctx.mock.ExpectQuery(selectRecordQuery).
 		WithArgs(ctx.userID, int64(7)).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at"}).
-			AddRow(int64(7), ctx.userID, "DeckOne", nil, baseDescription, "deckone", existingRel, existingCanonical, "[\"tag1\"]", now, now))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(int64(7), ctx.userID, "DeckOne", nil, baseDescription, "deckone", existingRel, existingCanonical, "[\"tag1\"]", now, now, 0, nil))
 
 	newRel := filepath.ToSlash(filepath.Join("presentations", ctx.userUUID, "decktwo", "slides"))
 	newCanonical := filepath.Join(ctx.root, ctx.userUUID, "decktwo", "slides")
 
 	ctx.mock.ExpectExec("UPDATE ppt_records SET").
-		WithArgs("DeckTwo", sqlmock.AnyArg(), sqlmock.AnyArg(), "decktwo", newRel, newCanonical, sqlmock.AnyArg(), ctx.userID, int64(7)).
+		WithArgs("DeckTwo", sqlmock.AnyArg(), sqlmock.AnyArg(), "decktwo", newRel, newCanonical, sqlmock.AnyArg(), int64(7)).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	ctx.mock.ExpectQuery(selectRecordQuery).
-		WithArgs(ctx.userID, int64(7)).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at"}).
-			AddRow(int64(7), ctx.userID, "DeckTwo", nil, "Updated deck", "decktwo", newRel, newCanonical, "[\"tag2\"]", now, now))
+	ctx.mock.ExpectQuery(selectLiveRecordQuery).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(int64(7), ctx.userID, "DeckTwo", nil, "Updated deck", "decktwo", newRel, newCanonical, "[\"tag2\"]", now, now, 0, nil))
 
 	payload := map[string]any{
 		"name":        "DeckTwo",
@@ -287,8 +293,9 @@ func TestUpdatePptRecord(t *testing.T) {
 func TestDeletePptRecord(t *testing.T) {
 	ctx := newRecordsTestContext(t)
 
-	ctx.mock.ExpectExec("DELETE FROM ppt_records WHERE user_id = \\? AND id = \\?").
-		WithArgs(ctx.userID, int64(77)).
+	ctx.expectRecordLookup(77, "deckone")
+	ctx.mock.ExpectExec("UPDATE ppt_records SET deleted_at = \\?, trash_path = \\? WHERE id = \\? AND deleted_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(77)).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	req := httptest.NewRequest(http.MethodDelete, "/api/v1/ppts/77", nil)
@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/records"
+)
+
+const selectDeckLocationsQuery = "SELECT r.id, r.user_id, .*, r.workspace_id, COALESCE\\(w.uuid, u.uuid\\) FROM ppt_records r LEFT JOIN user_accounts u ON u.id = r.user_id LEFT JOIN workspaces w ON w.id = r.workspace_id WHERE r.deleted_at IS NULL AND r.id > \\? ORDER BY r.id ASC LIMIT \\?"
+
+var deckLocationColumns = []string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id", "owner_uuid"}
+
+// expectDeckLocations queues the path scan query: deck 1 is consistent, deck 2
+// is stored under its old group name and deck 3 has no slides directory.
+func (ctx *recordsTestContext) expectDeckLocations() (moved, missing string) {
+	now := time.Now().UTC()
+	row := func(rows *sqlmock.Rows, recordID int64, groupName, storedGroup string) *sqlmock.Rows {
+		rel := filepath.ToSlash(filepath.Join("presentations", ctx.userUUID, storedGroup, "slides"))
+		canonical := filepath.Join(ctx.root, ctx.userUUID, storedGroup, "slides")
+		return rows.AddRow(recordID, ctx.userID, groupName, nil, nil, groupName, rel, canonical, nil, now, now, 0, nil, ctx.userUUID)
+	}
+
+	rows := sqlmock.NewRows(deckLocationColumns)
+	row(rows, 1, "deckone", "deckone")
+	row(rows, 2, "renamed", "oldname")
+	row(rows, 3, "deckthree", "deckthree")
+	ctx.mock.ExpectQuery(selectDeckLocationsQuery).
+		WithArgs(int64(0), 200).
+		WillReturnRows(rows)
+
+	return filepath.Join(ctx.root, ctx.userUUID, "oldname", "slides"), filepath.Join(ctx.root, ctx.userUUID, "deckthree", "slides")
+}
+
+func TestVerifyPathsReportsWithoutChanges(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+	seedSlides(t, filepath.Join(ctx.root, ctx.userUUID, "deckone", "slides"), 1)
+	moved, missing := ctx.expectDeckLocations()
+	seedSlides(t, moved, 2)
+
+	issues, err := ctx.service.VerifyPaths(context.Background())
+	require.NoError(t, err)
+	require.Len(t, issues, 2)
+
+	require.Equal(t, int64(2), issues[0].RecordID)
+	require.Equal(t, records.PathProblemMismatch, issues[0].Problem)
+	require.Equal(t, moved, issues[0].Stored.Canonical)
+	require.Equal(t, filepath.Join(ctx.root, ctx.userUUID, "renamed", "slides"), issues[0].Expected.Canonical)
+	require.False(t, issues[0].Fixed)
+
+	require.Equal(t, int64(3), issues[1].RecordID)
+	require.Equal(t, records.PathProblemMissing, issues[1].Problem)
+
+	require.DirExists(t, moved)
+	require.NoDirExists(t, missing)
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestReindexPathsRepairsDecks(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+	seedSlides(t, filepath.Join(ctx.root, ctx.userUUID, "deckone", "slides"), 1)
+	moved, missing := ctx.expectDeckLocations()
+	seedSlides(t, moved, 2)
+
+	expected := filepath.Join(ctx.root, ctx.userUUID, "renamed", "slides")
+	ctx.mock.ExpectExec("UPDATE ppt_records SET relative_path = \\?, canonical_path = \\?").
+		WithArgs("presentations/"+ctx.userUUID+"/renamed/slides", expected, int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	issues, err := ctx.service.ReindexPaths(context.Background())
+	require.NoError(t, err)
+	require.Len(t, issues, 2)
+	for _, issue := range issues {
+		require.True(t, issue.Fixed, issue.Error)
+	}
+
+	require.NoDirExists(t, filepath.Dir(moved))
+	entries, err := os.ReadDir(expected)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.DirExists(t, missing)
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
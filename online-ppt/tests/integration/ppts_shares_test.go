@@ -0,0 +1,247 @@
+package integration
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/auth"
+	"online-ppt/internal/records"
+)
+
+const selectShareByTokenQuery = "SELECT s.id, s.record_id, s.user_id, s.token_hash, s.password_hash, s.expires_at, s.view_count, s.last_viewed_at, s.revoked_at, s.created_at, COALESCE\\(w.uuid, u.uuid\\) FROM ppt_shares s JOIN user_accounts u ON u.id = s.user_id JOIN ppt_records r ON r.id = s.record_id LEFT JOIN workspaces w ON w.id = r.workspace_id WHERE s.token_hash = \\? AND s.revoked_at IS NULL LIMIT 1"
+
+var shareColumns = []string{"id", "record_id", "user_id", "token_hash", "password_hash", "expires_at", "view_count", "last_viewed_at", "revoked_at", "created_at"}
+
+func shareTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (ctx *recordsTestContext) expectShareLookup(token string, shareID, recordID int64, passwordHash any, expiresAt time.Time) {
+	now := time.Now().UTC()
+	ctx.mock.ExpectQuery(selectShareByTokenQuery).
+		WithArgs(shareTokenHash(token)).
+		WillReturnRows(sqlmock.NewRows(append(append([]string{}, shareColumns...), "uuid")).
+			AddRow(shareID, recordID, ctx.userID, shareTokenHash(token), passwordHash, expiresAt, 0, nil, nil, now, ctx.userUUID))
+}
+
+func (ctx *recordsTestContext) openShare(token, password string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/public/"+token, nil)
+	if password != "" {
+		req.Header.Set("X-Share-Password", password)
+	}
+	rec := httptest.NewRecorder()
+	ctx.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestShareLinkLifecycle(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := filepath.Join(ctx.root, ctx.userUUID, "shared", "slides")
+	require.NoError(t, os.MkdirAll(canonical, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(canonical, "slide-1.html"), []byte("<html>one</html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(canonical, "slide-2.html"), []byte("<html>two</html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(canonical), "slides.config.json"), []byte(`{
+  "title": "Shared",
+  "slides": [
+    {"id": "slide-1", "title": "One", "file": "slide-1.html", "visible": true, "notes": "private notes"},
+    {"id": "slide-2", "title": "Two", "file": "slide-2.html", "visible": false, "notes": ""}
+  ]
+}`), 0o644))
+
+	ctx.expectRecordLookup(40, "shared")
+	var passwordHash string
+	ctx.mock.ExpectExec("INSERT INTO ppt_shares").
+		WithArgs(int64(40), ctx.userID, sqlmock.AnyArg(), captureArg{value: &passwordHash}, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+
+	rec := ctx.do(http.MethodPost, "/api/v1/ppts/40/shares", []byte(`{"password":"open sesame"}`), "application/json")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	token, _ := created["token"].(string)
+	require.Len(t, token, 43)
+	require.Equal(t, true, created["passwordProtected"])
+	require.Equal(t, "active", created["status"])
+	ok, err := auth.VerifyPassword(passwordHash, "open sesame")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	expiresAt := time.Now().UTC().Add(time.Hour)
+
+	ctx.expectShareLookup(token, 5, 40, passwordHash, expiresAt)
+	rec = ctx.openShare(token, "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), "password_required")
+
+	ctx.expectShareLookup(token, 5, 40, passwordHash, expiresAt)
+	rec = ctx.openShare(token, "wrong")
+	require.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+
+	ctx.expectShareLookup(token, 5, 40, passwordHash, expiresAt)
+	ctx.expectLiveRecordLookup(40, "shared")
+	ctx.mock.ExpectExec("UPDATE ppt_shares SET view_count = view_count \\+ 1").
+		WithArgs(sqlmock.AnyArg(), int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rec = ctx.openShare(token, "open sesame")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+	var deck struct {
+		Config struct {
+			Title  string `json:"title"`
+			Slides []struct {
+				File  string `json:"file"`
+				Notes string `json:"notes"`
+			} `json:"slides"`
+		} `json:"config"`
+		Slides []struct {
+			File    string `json:"file"`
+			Content string `json:"content"`
+		} `json:"slides"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &deck))
+	require.Equal(t, "Shared", deck.Config.Title)
+	require.Len(t, deck.Config.Slides, 1)
+	require.Empty(t, deck.Config.Slides[0].Notes)
+	require.Len(t, deck.Slides, 1)
+	require.Equal(t, "slide-1.html", deck.Slides[0].File)
+	require.Equal(t, "<html>one</html>", deck.Slides[0].Content)
+
+	ctx.expectRecordLookup(40, "shared")
+	now := time.Now().UTC()
+	ctx.mock.ExpectQuery("SELECT id, record_id, user_id, token_hash, password_hash, expires_at, view_count, last_viewed_at, revoked_at, created_at FROM ppt_shares WHERE record_id = \\? ORDER BY created_at DESC, id DESC").
+		WithArgs(int64(40)).
+		WillReturnRows(sqlmock.NewRows(shareColumns).
+			AddRow(int64(6), int64(40), ctx.userID, "hash-b", nil, now.Add(-time.Minute), 0, nil, nil, now).
+			AddRow(int64(5), int64(40), ctx.userID, shareTokenHash(token), passwordHash, expiresAt, 1, now, nil, now))
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts/40/shares", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotContains(t, rec.Body.String(), "hash-b")
+	require.NotContains(t, rec.Body.String(), "argon2id")
+
+	var listed struct {
+		Items []map[string]any `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed.Items, 2)
+	require.Equal(t, "expired", listed.Items[0]["status"])
+	require.Equal(t, "active", listed.Items[1]["status"])
+	require.Equal(t, float64(1), listed.Items[1]["viewCount"])
+
+	ctx.expectRecordLookup(40, "shared")
+	ctx.mock.ExpectExec("UPDATE ppt_shares SET revoked_at = \\? WHERE id = \\? AND record_id = \\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), int64(5), int64(40)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rec = ctx.do(http.MethodDelete, "/api/v1/ppts/40/shares/5", nil, "")
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	ctx.mock.ExpectQuery(selectShareByTokenQuery).
+		WithArgs(shareTokenHash(token)).
+		WillReturnRows(sqlmock.NewRows(append(append([]string{}, shareColumns...), "uuid")))
+	rec = ctx.openShare(token, "open sesame")
+	require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestShareLinkExpiryAndValidation(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	ctx.expectShareLookup("expired-token", 7, 41, nil, time.Now().UTC().Add(-time.Second))
+	rec := ctx.openShare("expired-token", "")
+	require.Equal(t, http.StatusGone, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), "share_expired")
+
+	past := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/41/shares", []byte(`{"expiresAt":"`+past+`"}`), "application/json")
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	tooFar := time.Now().UTC().Add(400 * 24 * time.Hour).Format(time.RFC3339)
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/41/shares", []byte(`{"expiresAt":"`+tooFar+`"}`), "application/json")
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	ctx.expectRecordLookup(41, "deck")
+	ctx.mock.ExpectExec("UPDATE ppt_shares SET revoked_at").
+		WithArgs(sqlmock.AnyArg(), int64(99), int64(41)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	rec = ctx.do(http.MethodDelete, "/api/v1/ppts/41/shares/99", nil, "")
+	require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), "share_not_found")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestSharePasswordAttemptsAreThrottled(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+	require.NoError(t, ctx.service.ConfigureShareThrottle(newMemoryCache(), records.ShareThrottle{PerShare: 2, PerIP: 3}))
+
+	passwordHash, err := auth.HashPassword("open sesame")
+	require.NoError(t, err)
+	expiresAt := time.Now().UTC().Add(time.Hour)
+
+	for i := 0; i < 2; i++ {
+		ctx.expectShareLookup("guarded", 5, 40, passwordHash, expiresAt)
+		rec := ctx.openShare("guarded", "wrong")
+		require.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+	}
+
+	// 达到单个链接的上限后，正确密码也会被拒绝
+	ctx.expectShareLookup("guarded", 5, 40, passwordHash, expiresAt)
+	rec := ctx.openShare("guarded", "open sesame")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), "rate_limited")
+
+	// 同一 IP 在其他链接上的失败也会累计
+	ctx.expectShareLookup("other", 6, 41, passwordHash, expiresAt)
+	rec = ctx.openShare("other", "wrong")
+	require.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+
+	ctx.expectShareLookup("other", 6, 41, passwordHash, expiresAt)
+	rec = ctx.openShare("other", "open sesame")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code, rec.Body.String())
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestSharePasswordThrottleIgnoresForwardedFor(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+	require.NoError(t, ctx.service.ConfigureShareThrottle(newMemoryCache(), records.ShareThrottle{PerShare: 10, PerIP: 2}))
+
+	passwordHash, err := auth.HashPassword("open sesame")
+	require.NoError(t, err)
+	expiresAt := time.Now().UTC().Add(time.Hour)
+
+	// 未配置可信代理时，伪造的 X-Forwarded-For 不会换出新的 IP 计数
+	attempt := func(token string, shareID int64, password, forwardedFor string) *httptest.ResponseRecorder {
+		ctx.expectShareLookup(token, shareID, 40, passwordHash, expiresAt)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/public/"+token, nil)
+		req.Header.Set("X-Share-Password", password)
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		rec := httptest.NewRecorder()
+		ctx.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := attempt("first", 5, "wrong", "203.0.113.1")
+	require.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+	rec = attempt("second", 6, "wrong", "203.0.113.2")
+	require.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+
+	rec = attempt("third", 7, "open sesame", "203.0.113.3")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code, rec.Body.String())
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func seedSlides(t *testing.T, canonical string, count int) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(canonical, 0o755))
+	for i := 1; i <= count; i++ {
+		name := fmt.Sprintf("slide-%d.html", i)
+		require.NoError(t, os.WriteFile(filepath.Join(canonical, name), []byte(fmt.Sprintf("<p>%d</p>", i)), 0o644))
+	}
+}
+
+func slideIDs(cfg slidesConfigResponse) []string {
+	ids := make([]string, 0, len(cfg.Slides))
+	for _, slide := range cfg.Slides {
+		ids = append(ids, slide.ID)
+	}
+	return ids
+}
+
+func TestSlideOperations(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectRecordLookup(3, "deckone")
+	seedSlides(t, canonical, 3)
+
+	rec := ctx.do(http.MethodPost, "/api/v1/ppts/3/config/slides/slide-3/move", []byte(`{"index":0}`), "application/json")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var cfg slidesConfigResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cfg))
+	require.Equal(t, []string{"slide-3", "slide-1", "slide-2"}, slideIDs(cfg))
+
+	ctx.expectRecordLookup(3, "deckone")
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/3/config/slides/slide-1/duplicate", nil, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cfg))
+	require.Equal(t, []string{"slide-3", "slide-1", "slide-4", "slide-2"}, slideIDs(cfg))
+	require.Equal(t, "slide-4.html", cfg.Slides[2].File)
+	copied, err := os.ReadFile(filepath.Join(canonical, "slide-4.html"))
+	require.NoError(t, err)
+	require.Equal(t, "<p>1</p>", string(copied))
+
+	ctx.expectRecordLookup(3, "deckone")
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/3/config/slides/batch", []byte(`{"action":"hide","slideIds":["slide-2","slide-3"]}`), "application/json")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cfg))
+	for _, slide := range cfg.Slides {
+		hidden := slide.ID == "slide-2" || slide.ID == "slide-3"
+		require.Equal(t, !hidden, slide.Visible, slide.ID)
+	}
+	_, err = os.Stat(filepath.Join(canonical, "slide-2.html"))
+	require.NoError(t, err)
+
+	ctx.expectRecordLookup(3, "deckone")
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/3/config/slides/slide-2/unhide", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cfg))
+	require.True(t, cfg.Slides[3].Visible)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestSlideBatchIsAllOrNothing(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectRecordLookup(3, "deckone")
+	seedSlides(t, canonical, 2)
+
+	rec := ctx.do(http.MethodPost, "/api/v1/ppts/3/config/slides/batch", []byte(`{"action":"duplicate","slideIds":["slide-1","missing"]}`), "application/json")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	_, err := os.Stat(filepath.Join(canonical, "slide-3.html"))
+	require.True(t, os.IsNotExist(err))
+
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/3/config/slides/batch", []byte(`{"action":"explode","slideIds":["slide-1"]}`), "application/json")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
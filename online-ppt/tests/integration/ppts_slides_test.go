@@ -0,0 +1,164 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func (ctx *recordsTestContext) expectRecordLookup(recordID int64, groupName string) string {
+	return ctx.expectVersionedRecordLookup(recordID, groupName, 0)
+}
+
+// expectLiveRecordLookup expects a lookup by id alone, as done once access has
+// been resolved.
+func (ctx *recordsTestContext) expectLiveRecordLookup(recordID int64, groupName string) string {
+	rel := filepath.ToSlash(filepath.Join("presentations", ctx.userUUID, groupName, "slides"))
+	canonical := filepath.Join(ctx.root, ctx.userUUID, groupName, "slides")
+	now := time.Now().UTC()
+
+	ctx.mock.ExpectQuery(selectLiveRecordQuery).
+		WithArgs(recordID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(recordID, ctx.userID, groupName, nil, nil, groupName, rel, canonical, nil, now, now, 0, nil))
+
+	return canonical
+}
+
+func (ctx *recordsTestContext) expectVersionedRecordLookup(recordID int64, groupName string, currentVersion int) string {
+	rel := filepath.ToSlash(filepath.Join("presentations", ctx.userUUID, groupName, "slides"))
+	canonical := filepath.Join(ctx.root, ctx.userUUID, groupName, "slides")
+	now := time.Now().UTC()
+
+	ctx.mock.ExpectQuery(selectRecordQuery).
+		WithArgs(ctx.userID, recordID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(recordID, ctx.userID, groupName, nil, nil, groupName, rel, canonical, nil, now, now, currentVersion, nil))
+
+	return canonical
+}
+
+func (ctx *recordsTestContext) do(method, target string, body []byte, contentType string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	ctx.authorize(req)
+
+	rec := httptest.NewRecorder()
+	ctx.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSlideFileLifecycle(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectRecordLookup(5, "deckone")
+	body, err := json.Marshal(map[string]string{"content": "<section>one</section>"})
+	require.NoError(t, err)
+	rec := ctx.do(http.MethodPost, "/api/v1/ppts/5/slides", body, "application/json")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created struct {
+		Name  string `json:"name"`
+		Index int    `json:"index"`
+		Size  int64  `json:"size"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Equal(t, "slide-1.html", created.Name)
+	require.Equal(t, 1, created.Index)
+
+	ctx.expectRecordLookup(5, "deckone")
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, err := writer.CreateFormFile("file", "intro.html")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("<section>two</section>"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/5/slides", form.Bytes(), writer.FormDataContentType())
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Equal(t, "slide-2.html", created.Name)
+
+	ctx.expectRecordLookup(5, "deckone")
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts/5/slides", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed struct {
+		Items []struct {
+			Name string `json:"name"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed.Items, 2)
+	require.Equal(t, "slide-1.html", listed.Items[0].Name)
+	require.Equal(t, "slide-2.html", listed.Items[1].Name)
+
+	ctx.expectRecordLookup(5, "deckone")
+	ctx.expectSnapshot(5, 1)
+	body, err = json.Marshal(map[string]string{"content": "<section>updated</section>"})
+	require.NoError(t, err)
+	rec = ctx.do(http.MethodPut, "/api/v1/ppts/5/slides/slide-1.html", body, "application/json")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	ctx.expectRecordLookup(5, "deckone")
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts/5/slides/slide-1.html", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "<section>updated</section>", rec.Body.String())
+
+	ctx.expectRecordLookup(5, "deckone")
+	ctx.expectSnapshot(5, 1)
+	rec = ctx.do(http.MethodDelete, "/api/v1/ppts/5/slides/slide-2.html", nil, "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	_, statErr := os.Stat(filepath.Join(canonical, "slide-2.html"))
+	require.True(t, os.IsNotExist(statErr))
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestSlideFileRejectsUnsafeNames(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	ctx.expectRecordLookup(5, "deckone")
+	body, err := json.Marshal(map[string]string{"name": "../../escape.html", "content": "<p>x</p>"})
+	require.NoError(t, err)
+	rec := ctx.do(http.MethodPost, "/api/v1/ppts/5/slides", body, "application/json")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	ctx.expectRecordLookup(5, "deckone")
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts/5/slides/index.html", nil, "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	ctx.expectRecordLookup(5, "deckone")
+	rec = ctx.do(http.MethodPut, "/api/v1/ppts/5/slides/slide-9.html", body, "application/json")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestSlideFileStopsAtHighestIndex(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectRecordLookup(5, "deckone")
+	require.NoError(t, os.MkdirAll(canonical, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(canonical, "slide-999999.html"), []byte("<p>last</p>"), 0o644))
+
+	// 下一个编号超出 slide-N.html 的六位上限，不能再自动命名
+	body, err := json.Marshal(map[string]string{"content": "<p>x</p>"})
+	require.NoError(t, err)
+	rec := ctx.do(http.MethodPost, "/api/v1/ppts/5/slides", body, "application/json")
+	require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), "slide_limit_reached")
+	require.NoFileExists(t, filepath.Join(canonical, "slide-1000000.html"))
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,200 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	trashVisibleFilter       = "\\(\\(user_id = \\? AND workspace_id IS NULL\\) OR workspace_id IN \\(SELECT workspace_id FROM workspace_members WHERE user_id = \\? AND role = 'admin'\\)\\)"
+	selectDeletedRecordQuery = "SELECT id, user_id, name, title, description, group_name, relative_path, canonical_path, tags, created_at, updated_at, current_version, workspace_id, deleted_at, trash_path FROM ppt_records WHERE " + trashVisibleFilter + " AND id = \\? AND deleted_at IS NOT NULL LIMIT 1"
+	restoreRecordQuery       = "UPDATE ppt_records SET deleted_at = NULL, trash_path = NULL, updated_at = NOW\\(\\) WHERE " + trashVisibleFilter + " AND id = \\?"
+)
+
+var trashedRecordColumns = []string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id", "deleted_at", "trash_path"}
+
+func (ctx *recordsTestContext) trashedRow(rows *sqlmock.Rows, recordID int64, groupName string, deletedAt time.Time, trashPath string) *sqlmock.Rows {
+	rel := filepath.ToSlash(filepath.Join("presentations", ctx.userUUID, groupName, "slides"))
+	canonical := filepath.Join(ctx.root, ctx.userUUID, groupName, "slides")
+	return rows.AddRow(recordID, ctx.userID, groupName, nil, nil, groupName, rel, canonical, nil, deletedAt, deletedAt, 0, nil, deletedAt, trashPath)
+}
+
+func TestTrashListAndRestore(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectRecordLookup(9, "deckone")
+	seedSlides(t, canonical, 2)
+	ctx.mock.ExpectExec("UPDATE ppt_records SET deleted_at").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec := ctx.do(http.MethodDelete, "/api/v1/ppts/9", nil, "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	trashed, err := filepath.Glob(filepath.Join(ctx.root, ".trash", ctx.userUUID, "9_deckone_*"))
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+
+	deletedAt := time.Now().UTC().Add(-time.Hour)
+	ctx.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM ppt_records WHERE " + trashVisibleFilter + " AND deleted_at IS NOT NULL").
+		WithArgs(ctx.userID, ctx.userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	ctx.mock.ExpectQuery("SELECT id, .*, deleted_at, trash_path FROM ppt_records WHERE " + trashVisibleFilter + " AND deleted_at IS NOT NULL ORDER BY deleted_at DESC").
+		WithArgs(ctx.userID, ctx.userID, 50, 0).
+		WillReturnRows(ctx.trashedRow(sqlmock.NewRows(trashedRecordColumns), 9, "deckone", deletedAt, trashed[0]))
+
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts/trash", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var listing struct {
+		Total int `json:"total"`
+		Items []struct {
+			ID         int64     `json:"id"`
+			PathStatus string    `json:"pathStatus"`
+			DeletedAt  time.Time `json:"deletedAt"`
+			PurgeAt    time.Time `json:"purgeAt"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listing))
+	require.Equal(t, 1, listing.Total)
+	require.Len(t, listing.Items, 1)
+	require.Equal(t, int64(9), listing.Items[0].ID)
+	require.Equal(t, "trashed", listing.Items[0].PathStatus)
+	require.Equal(t, 30*24*time.Hour, listing.Items[0].PurgeAt.Sub(listing.Items[0].DeletedAt))
+
+	ctx.mock.ExpectQuery(selectDeletedRecordQuery).
+		WithArgs(ctx.userID, ctx.userID, int64(9)).
+		WillReturnRows(ctx.trashedRow(sqlmock.NewRows(trashedRecordColumns), 9, "deckone", deletedAt, trashed[0]))
+	ctx.mock.ExpectExec(restoreRecordQuery).
+		WithArgs(ctx.userID, ctx.userID, int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/9/restore", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var restored struct {
+		PathStatus string `json:"pathStatus"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &restored))
+	require.Equal(t, "valid", restored.PathStatus)
+
+	_, err = os.Stat(filepath.Join(canonical, "slide-2.html"))
+	require.NoError(t, err)
+	_, err = os.Stat(trashed[0])
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestRestoreRefusesOccupiedDeckDirectory(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	trashPath := filepath.Join(ctx.root, ".trash", ctx.userUUID, "9_deckone_20240101T000000Z")
+	seedSlides(t, filepath.Join(trashPath, "slides"), 1)
+	canonical := filepath.Join(ctx.root, ctx.userUUID, "deckone", "slides")
+	require.NoError(t, os.MkdirAll(canonical, 0o755))
+
+	ctx.mock.ExpectQuery(selectDeletedRecordQuery).
+		WithArgs(ctx.userID, ctx.userID, int64(9)).
+		WillReturnRows(ctx.trashedRow(sqlmock.NewRows(trashedRecordColumns), 9, "deckone", time.Now().UTC(), trashPath))
+
+	rec := ctx.do(http.MethodPost, "/api/v1/ppts/9/restore", nil, "")
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	_, err := os.Stat(filepath.Join(trashPath, "slides", "slide-1.html"))
+	require.NoError(t, err)
+
+	ctx.mock.ExpectQuery(selectDeletedRecordQuery).
+		WithArgs(ctx.userID, ctx.userID, int64(10)).
+		WillReturnRows(sqlmock.NewRows(trashedRecordColumns))
+
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/10/restore", nil, "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestWorkspaceAdminRestoresTeammatesDeck(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	// 工作区演示由其他成员创建，当前用户以工作区管理员身份恢复
+	trashPath := filepath.Join(ctx.root, ".trash", testWorkspaceUUID, "31_plan_20240101T000000Z")
+	seedSlides(t, filepath.Join(trashPath, "slides"), 1)
+	rel := filepath.ToSlash(filepath.Join("presentations", "ws", testWorkspaceUUID, "plan", "slides"))
+	canonical := filepath.Join(ctx.root, "ws", testWorkspaceUUID, "plan", "slides")
+	deletedAt := time.Now().UTC().Add(-time.Hour)
+	trashedDeck := func() *sqlmock.Rows {
+		return sqlmock.NewRows(trashedRecordColumns).
+			AddRow(int64(31), ownerUserID, "plan", nil, nil, "plan", rel, canonical, nil, deletedAt, deletedAt, 0, testWorkspaceID, deletedAt, trashPath)
+	}
+
+	ctx.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM ppt_records WHERE " + trashVisibleFilter + " AND deleted_at IS NOT NULL").
+		WithArgs(ctx.userID, ctx.userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	ctx.mock.ExpectQuery("SELECT id, .*, deleted_at, trash_path FROM ppt_records WHERE " + trashVisibleFilter + " AND deleted_at IS NOT NULL ORDER BY deleted_at DESC").
+		WithArgs(ctx.userID, ctx.userID, 50, 0).
+		WillReturnRows(trashedDeck())
+
+	rec := ctx.do(http.MethodGet, "/api/v1/ppts/trash", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var listing struct {
+		Total int `json:"total"`
+		Items []struct {
+			ID int64 `json:"id"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listing))
+	require.Equal(t, 1, listing.Total)
+	require.Len(t, listing.Items, 1)
+	require.Equal(t, int64(31), listing.Items[0].ID)
+
+	ctx.mock.ExpectQuery(selectDeletedRecordQuery).
+		WithArgs(ctx.userID, ctx.userID, int64(31)).
+		WillReturnRows(trashedDeck())
+	ctx.mock.ExpectExec(restoreRecordQuery).
+		WithArgs(ctx.userID, ctx.userID, int64(31)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/31/restore", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	_, err := os.Stat(filepath.Join(canonical, "slide-1.html"))
+	require.NoError(t, err)
+	_, err = os.Stat(trashPath)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestPurgeDeletedRecordsRemovesRowAndDeck(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	trashPath := filepath.Join(ctx.root, ".trash", ctx.userUUID, "9_deckone_20240101T000000Z")
+	seedSlides(t, filepath.Join(trashPath, "slides"), 1)
+	deletedAt := time.Now().UTC().Add(-31 * 24 * time.Hour)
+
+	ctx.mock.ExpectQuery("SELECT .* FROM ppt_records WHERE deleted_at IS NOT NULL AND deleted_at < \\?").
+		WithArgs(sqlmock.AnyArg(), 100).
+		WillReturnRows(ctx.trashedRow(sqlmock.NewRows(trashedRecordColumns), 9, "deckone", deletedAt, trashPath))
+	ctx.mock.ExpectExec("DELETE FROM ppt_records WHERE id = \\? AND deleted_at IS NOT NULL").
+		WithArgs(int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	purged, err := ctx.service.PurgeDeletedRecords(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+
+	_, err = os.Stat(trashPath)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
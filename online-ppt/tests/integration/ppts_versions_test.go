@@ -0,0 +1,178 @@
+package integration
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+var versionColumns = []string{"id", "record_id", "version", "label", "reason", "manifest", "created_at"}
+
+// captureArg matches any argument and remembers its string form.
+type captureArg struct {
+	value *string
+}
+
+func (a captureArg) Match(v driver.Value) bool {
+	if s, ok := v.(string); ok {
+		*a.value = s
+	}
+	return true
+}
+
+// expectSnapshot expects a version insert and returns a pointer that receives
+// the stored manifest once the request runs.
+func (ctx *recordsTestContext) expectSnapshot(recordID int64, version int) *string {
+	manifest := new(string)
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("UPDATE ppt_records SET current_version = current_version WHERE id = \\?").
+		WithArgs(recordID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectQuery("SELECT COALESCE\\(MAX\\(version\\), 0\\) FROM ppt_record_versions WHERE record_id = \\?").
+		WithArgs(recordID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version - 1))
+	ctx.mock.ExpectExec("INSERT INTO ppt_record_versions").
+		WithArgs(recordID, version, sqlmock.AnyArg(), sqlmock.AnyArg(), captureArg{value: manifest}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(int64(version), 1))
+	ctx.mock.ExpectExec("UPDATE ppt_records SET current_version = \\? WHERE id = \\?").
+		WithArgs(version, recordID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectCommit()
+	return manifest
+}
+
+func (ctx *recordsTestContext) expectVersionLookup(recordID int64, version int, reason, manifest string) {
+	rows := sqlmock.NewRows(versionColumns)
+	if manifest != "" {
+		rows.AddRow(int64(version), recordID, version, nil, reason, manifest, time.Now().UTC())
+	}
+	ctx.mock.ExpectQuery("SELECT id, record_id, version, label, reason, manifest, created_at FROM ppt_record_versions WHERE record_id = \\? AND version = \\? LIMIT 1").
+		WithArgs(recordID, version).
+		WillReturnRows(rows)
+}
+
+func TestVersionSaveDiffAndRestore(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectRecordLookup(12, "deckone")
+	seedSlides(t, canonical, 2)
+	first := ctx.expectSnapshot(12, 1)
+
+	rec := ctx.do(http.MethodPost, "/api/v1/ppts/12/versions", []byte(`{"label":"first draft"}`), "application/json")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var saved struct {
+		Version    int    `json:"version"`
+		Label      string `json:"label"`
+		Reason     string `json:"reason"`
+		SlideCount int    `json:"slideCount"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &saved))
+	require.Equal(t, 1, saved.Version)
+	require.Equal(t, "first draft", saved.Label)
+	require.Equal(t, "manual", saved.Reason)
+	require.Equal(t, 2, saved.SlideCount)
+
+	require.NoError(t, os.WriteFile(filepath.Join(canonical, "slide-1.html"), []byte("<p>1</p>\n<p>edited</p>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(canonical, "slide-3.html"), []byte("<p>3</p>"), 0o644))
+
+	ctx.expectVersionedRecordLookup(12, "deckone", 1)
+	second := ctx.expectSnapshot(12, 2)
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/12/versions", nil, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	ctx.expectVersionedRecordLookup(12, "deckone", 2)
+	ctx.expectVersionLookup(12, 1, "manual", *first)
+	ctx.expectVersionLookup(12, 2, "manual", *second)
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts/12/versions/diff?from=1&to=2", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var diff struct {
+		Files []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+			Patch  string `json:"patch"`
+		} `json:"files"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &diff))
+	statuses := map[string]string{}
+	for _, file := range diff.Files {
+		statuses[file.Name] = file.Status
+		if file.Name == "slide-1.html" {
+			require.True(t, strings.Contains(file.Patch, "+<p>edited</p>"), file.Patch)
+		}
+	}
+	require.Equal(t, map[string]string{
+		"slide-1.html": "modified",
+		"slide-2.html": "unchanged",
+		"slide-3.html": "added",
+	}, statuses)
+
+	ctx.expectVersionedRecordLookup(12, "deckone", 2)
+	ctx.expectVersionLookup(12, 1, "manual", *first)
+	ctx.expectVersionLookup(12, 2, "manual", *second)
+	restored := ctx.expectSnapshot(12, 3)
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/12/versions/1/restore", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &saved))
+	require.Equal(t, 3, saved.Version)
+	require.Equal(t, "restore", saved.Reason)
+
+	content, err := os.ReadFile(filepath.Join(canonical, "slide-1.html"))
+	require.NoError(t, err)
+	require.Equal(t, "<p>1</p>", string(content))
+	_, err = os.Stat(filepath.Join(canonical, "slide-3.html"))
+	require.True(t, os.IsNotExist(err))
+
+	// The deck still matches version 3, so deleting a slide does not snapshot again.
+	ctx.expectVersionedRecordLookup(12, "deckone", 3)
+	ctx.expectVersionLookup(12, 3, "restore", *restored)
+	rec = ctx.do(http.MethodDelete, "/api/v1/ppts/12/slides/slide-2.html", nil, "")
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestVersionListAndErrors(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	now := time.Now().UTC()
+	ctx.expectVersionedRecordLookup(12, "deckone", 2)
+	ctx.mock.ExpectQuery("SELECT id, record_id, version, label, reason, manifest, created_at FROM ppt_record_versions WHERE record_id = \\? ORDER BY version DESC").
+		WithArgs(int64(12)).
+		WillReturnRows(sqlmock.NewRows(versionColumns).
+			AddRow(int64(2), int64(12), 2, nil, "auto", `{"config":"","files":[]}`, now).
+			AddRow(int64(1), int64(12), 1, "first", "manual", `{"config":"","files":[]}`, now))
+
+	rec := ctx.do(http.MethodGet, "/api/v1/ppts/12/versions", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var listing struct {
+		CurrentVersion int `json:"currentVersion"`
+		Items          []struct {
+			Version int     `json:"version"`
+			Label   *string `json:"label"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listing))
+	require.Equal(t, 2, listing.CurrentVersion)
+	require.Len(t, listing.Items, 2)
+	require.Nil(t, listing.Items[0].Label)
+	require.Equal(t, "first", *listing.Items[1].Label)
+
+	ctx.expectVersionedRecordLookup(12, "deckone", 2)
+	ctx.expectVersionLookup(12, 9, "", "")
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts/12/versions/9/restore", nil, "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts/12/versions/diff?from=1", nil, "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,266 @@
+package integration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	selectWorkspaceRecordQuery     = "FROM ppt_records r JOIN workspaces w ON w.id = r.workspace_id JOIN workspace_members m ON m.workspace_id = r.workspace_id AND m.user_id = \\?"
+	selectWorkspaceMembershipQuery = "SELECT w.id, w.uuid, w.name, w.created_by, w.created_at, w.updated_at, m.role FROM workspaces w JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = \\? WHERE w.id = \\? LIMIT 1"
+	testWorkspaceID                = int64(3)
+	testWorkspaceUUID              = "5c7d1e2f-3a4b-4c5d-8e9f-0a1b2c3d4e5f"
+)
+
+var workspaceMembershipColumns = []string{"id", "uuid", "name", "created_by", "created_at", "updated_at", "role"}
+
+// expectNonMemberLookup mocks the owner and workspace lookups of a record the
+// test user neither owns nor reaches through a workspace.
+func (ctx *recordsTestContext) expectNonMemberLookup(recordID int64) {
+	ctx.mock.ExpectQuery(selectRecordQuery).
+		WithArgs(ctx.userID, recordID).
+		WillReturnError(sql.ErrNoRows)
+	ctx.mock.ExpectQuery(selectWorkspaceRecordQuery).
+		WithArgs(ctx.userID, recordID).
+		WillReturnError(sql.ErrNoRows)
+}
+
+func (ctx *recordsTestContext) expectWorkspaceMembership(role string) {
+	now := time.Now().UTC()
+	ctx.mock.ExpectQuery(selectWorkspaceMembershipQuery).
+		WithArgs(ctx.userID, testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows(workspaceMembershipColumns).
+			AddRow(testWorkspaceID, testWorkspaceUUID, "Team", ownerUserID, now, now, role))
+}
+
+// expectWorkspaceRecordLookup mocks a workspace deck created by ownerUserID
+// that the test user reaches as a workspace member with the given role.
+func (ctx *recordsTestContext) expectWorkspaceRecordLookup(recordID int64, groupName, role string) string {
+	rel := filepath.ToSlash(filepath.Join("presentations", "ws", testWorkspaceUUID, groupName, "slides"))
+	canonical := filepath.Join(ctx.root, "ws", testWorkspaceUUID, groupName, "slides")
+	now := time.Now().UTC()
+
+	ctx.mock.ExpectQuery(selectRecordQuery).
+		WithArgs(ctx.userID, recordID).
+		WillReturnError(sql.ErrNoRows)
+	ctx.mock.ExpectQuery(selectWorkspaceRecordQuery).
+		WithArgs(ctx.userID, recordID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id", "uuid", "role"}).
+			AddRow(recordID, ownerUserID, groupName, nil, nil, groupName, rel, canonical, nil, now, now, 0, testWorkspaceID, testWorkspaceUUID, role))
+
+	return canonical
+}
+
+func TestWorkspaceMembership(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+	now := time.Now().UTC()
+
+	ctx.mock.ExpectBegin()
+	ctx.mock.ExpectExec("INSERT INTO workspaces").
+		WithArgs(sqlmock.AnyArg(), "Design Team", ctx.userID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(testWorkspaceID, 1))
+	ctx.mock.ExpectExec("INSERT INTO workspace_members").
+		WithArgs(testWorkspaceID, ctx.userID, "admin", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	ctx.mock.ExpectCommit()
+
+	body, err := json.Marshal(map[string]string{"name": " Design Team "})
+	require.NoError(t, err)
+	rec := ctx.do(http.MethodPost, "/api/v1/workspaces", body, "application/json")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created struct {
+		ID   int64  `json:"id"`
+		UUID string `json:"uuid"`
+		Name string `json:"name"`
+		Role string `json:"role"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Equal(t, testWorkspaceID, created.ID)
+	require.Len(t, created.UUID, 36)
+	require.Equal(t, "Design Team", created.Name)
+	require.Equal(t, "admin", created.Role)
+
+	ctx.mock.ExpectQuery("FROM workspaces w JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = \\? ORDER BY w.name ASC").
+		WithArgs(ctx.userID).
+		WillReturnRows(sqlmock.NewRows(workspaceMembershipColumns).
+			AddRow(testWorkspaceID, testWorkspaceUUID, "Design Team", ctx.userID, now, now, "admin"))
+	rec = ctx.do(http.MethodGet, "/api/v1/workspaces", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	ctx.expectWorkspaceMembership("admin")
+	ctx.mock.ExpectQuery("SELECT id FROM user_accounts WHERE email = \\?").
+		WithArgs("bob@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)))
+	ctx.mock.ExpectExec("INSERT INTO workspace_members").
+		WithArgs(testWorkspaceID, int64(7), "member", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	body, err = json.Marshal(map[string]string{"email": "bob@example.com", "role": "member"})
+	require.NoError(t, err)
+	rec = ctx.do(http.MethodPost, "/api/v1/workspaces/3/members", body, "application/json")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	ctx.expectWorkspaceMembership("admin")
+	ctx.mock.ExpectQuery("FROM workspace_members m JOIN user_accounts u ON u.id = m.user_id WHERE m.workspace_id = \\?").
+		WithArgs(testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"workspace_id", "user_id", "email", "role", "created_at", "updated_at"}).
+			AddRow(testWorkspaceID, ctx.userID, "me@example.com", "admin", now, now).
+			AddRow(testWorkspaceID, int64(7), "bob@example.com", "member", now, now))
+	rec = ctx.do(http.MethodGet, "/api/v1/workspaces/3/members", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var members struct {
+		Items []struct {
+			UserID int64  `json:"userId"`
+			Role   string `json:"role"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &members))
+	require.Len(t, members.Items, 2)
+
+	ctx.expectWorkspaceMembership("admin")
+	ctx.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM workspace_members WHERE workspace_id = \\? AND role = 'admin'").
+		WithArgs(testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	body, err = json.Marshal(map[string]string{"role": "member"})
+	require.NoError(t, err)
+	rec = ctx.do(http.MethodPatch, "/api/v1/workspaces/3/members/1", body, "application/json")
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+	require.Equal(t, "last_admin", payload["code"])
+
+	ctx.expectWorkspaceMembership("admin")
+	ctx.mock.ExpectExec("DELETE FROM workspace_members WHERE workspace_id = \\? AND user_id = \\?").
+		WithArgs(testWorkspaceID, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rec = ctx.do(http.MethodDelete, "/api/v1/workspaces/3/members/7", nil, "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	ctx.expectWorkspaceMembership("member")
+	rec = ctx.do(http.MethodDelete, "/api/v1/workspaces/3/members/7", nil, "")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	ctx.mock.ExpectQuery(selectWorkspaceMembershipQuery).
+		WithArgs(ctx.userID, int64(8)).
+		WillReturnError(sql.ErrNoRows)
+	rec = ctx.do(http.MethodGet, "/api/v1/workspaces/8/members", nil, "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestCreateAndListWorkspaceDecks(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+	now := time.Now().UTC()
+
+	rel := filepath.ToSlash(filepath.Join("presentations", "ws", testWorkspaceUUID, "roadmap", "slides"))
+	canonical := filepath.Join(ctx.root, "ws", testWorkspaceUUID, "roadmap", "slides")
+
+	ctx.expectWorkspaceMembership("member")
+	ctx.mock.ExpectExec("INSERT INTO ppt_records").
+		WithArgs(ctx.userID, testWorkspaceID, "Roadmap", sqlmock.AnyArg(), sqlmock.AnyArg(), "roadmap", rel, canonical, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(30, 1))
+	ctx.mock.ExpectQuery(selectRecordQuery).
+		WithArgs(ctx.userID, int64(30)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(int64(30), ctx.userID, "Roadmap", nil, nil, "roadmap", rel, canonical, nil, now, now, 0, testWorkspaceID))
+
+	body, err := json.Marshal(map[string]any{"name": "Roadmap", "workspaceId": testWorkspaceID})
+	require.NoError(t, err)
+	rec := ctx.do(http.MethodPost, "/api/v1/ppts", body, "application/json")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var created struct {
+		WorkspaceID   int64  `json:"workspaceId"`
+		CanonicalPath string `json:"canonicalPath"`
+		Role          string `json:"role"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Equal(t, testWorkspaceID, created.WorkspaceID)
+	require.Equal(t, canonical, created.CanonicalPath)
+	require.Equal(t, "editor", created.Role)
+	info, err := os.Stat(canonical)
+	require.NoError(t, err)
+	require.True(t, info.IsDir())
+
+	ctx.expectWorkspaceMembership("viewer")
+	rec = ctx.do(http.MethodPost, "/api/v1/ppts", body, "application/json")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	ctx.expectWorkspaceMembership("viewer")
+	ctx.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM ppt_records WHERE workspace_id = \\? AND deleted_at IS NULL").
+		WithArgs(testWorkspaceID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	ctx.mock.ExpectQuery("FROM ppt_records WHERE workspace_id = \\? AND deleted_at IS NULL ORDER BY created_at DESC LIMIT \\? OFFSET \\?").
+		WithArgs(testWorkspaceID, 50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(int64(30), ownerUserID, "Roadmap", nil, nil, "roadmap", rel, canonical, nil, now, now, 0, testWorkspaceID))
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts?workspaceId=3", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var listed struct {
+		Items []struct {
+			ID          int64  `json:"id"`
+			WorkspaceID int64  `json:"workspaceId"`
+			Role        string `json:"role"`
+			PathStatus  string `json:"pathStatus"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed.Items, 1)
+	require.Equal(t, "viewer", listed.Items[0].Role)
+	require.Equal(t, "valid", listed.Items[0].PathStatus)
+
+	ctx.mock.ExpectQuery(selectWorkspaceMembershipQuery).
+		WithArgs(ctx.userID, int64(9)).
+		WillReturnError(sql.ErrNoRows)
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts?workspaceId=9", nil, "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestWorkspaceDeckAccessFollowsMembership(t *testing.T) {
+	ctx := newRecordsTestContext(t)
+
+	canonical := ctx.expectWorkspaceRecordLookup(31, "plan", "viewer")
+	seedSlides(t, canonical, 1)
+	rec := ctx.do(http.MethodGet, "/api/v1/ppts/31/slides/slide-1.html", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	ctx.expectWorkspaceRecordLookup(31, "plan", "viewer")
+	ctx.mock.ExpectQuery(selectCollaboratorRecordQuery).
+		WithArgs(ctx.userID, int64(31)).
+		WillReturnError(sql.ErrNoRows)
+	body, err := json.Marshal(map[string]string{"content": "<section>edit</section>"})
+	require.NoError(t, err)
+	rec = ctx.do(http.MethodPut, "/api/v1/ppts/31/slides/slide-1.html", body, "application/json")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	// A creator who has left the workspace keeps no access to its decks.
+	now := time.Now().UTC()
+	rel := filepath.ToSlash(filepath.Join("presentations", "ws", testWorkspaceUUID, "plan", "slides"))
+	ctx.mock.ExpectQuery(selectRecordQuery).
+		WithArgs(ctx.userID, int64(31)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "title", "description", "group_name", "relative_path", "canonical_path", "tags", "created_at", "updated_at", "current_version", "workspace_id"}).
+			AddRow(int64(31), ctx.userID, "plan", nil, nil, "plan", rel, canonical, nil, now, now, 0, testWorkspaceID))
+	ctx.mock.ExpectQuery(selectWorkspaceRecordQuery).
+		WithArgs(ctx.userID, int64(31)).
+		WillReturnError(sql.ErrNoRows)
+	ctx.mock.ExpectQuery(selectCollaboratorRecordQuery).
+		WithArgs(ctx.userID, int64(31)).
+		WillReturnError(sql.ErrNoRows)
+	rec = ctx.do(http.MethodGet, "/api/v1/ppts/31", nil, "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
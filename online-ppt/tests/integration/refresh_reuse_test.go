@@ -0,0 +1,145 @@
+package integration
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/auth"
+)
+
+const (
+	selectSessionByHashQuery = "SELECT id, user_id, parent_id, family_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, ip_address, user_agent, revoked_at, created_at FROM user_sessions WHERE refresh_token_hash = \\? LIMIT 1"
+	childSessionExistsQuery  = "SELECT EXISTS\\(SELECT 1 FROM user_sessions WHERE parent_id = \\?\\)"
+	revokeActiveSessionQuery = "UPDATE user_sessions SET revoked_at = \\? WHERE id = \\? AND revoked_at IS NULL"
+	revokeFamilyQuery        = "UPDATE user_sessions SET revoked_at = \\? WHERE family_id = \\? AND revoked_at IS NULL"
+)
+
+func (ctx *accountTestContext) expectSessionByHash(token string, id int64, family string, revoked bool) {
+	revokedAt := sql.NullTime{}
+	if revoked {
+		revokedAt = sql.NullTime{Time: ctx.now.Add(-time.Minute), Valid: true}
+	}
+	ctx.mock.ExpectQuery(selectSessionByHashQuery).
+		WithArgs(auth.HashRefreshToken(token)).
+		WillReturnRows(sqlmock.NewRows(userSessionColumns).
+			AddRow(id, int64(1), sql.NullInt64{}, family, auth.HashRefreshToken(token), ctx.now.Add(time.Hour), ctx.now,
+				sql.NullString{}, sql.NullString{}, sql.NullString{}, revokedAt, ctx.now))
+}
+
+func TestRefreshRotatesWithinFamily(t *testing.T) {
+	ctx := newAccountTestContext(t, "Password123456")
+
+	ctx.expectSessionByHash("token-a", 5, "family-1", false)
+	ctx.expectUser()
+	ctx.mock.ExpectExec(revokeActiveSessionQuery).
+		WithArgs(sqlmock.AnyArg(), int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx.mock.ExpectExec("INSERT INTO user_sessions").
+		WithArgs(int64(1), sql.NullInt64{Int64: 5, Valid: true}, sql.NullString{String: "family-1", Valid: true},
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(6, 1))
+	ctx.mock.ExpectQuery(selectSessionByIDQuery).
+		WithArgs(int64(6)).
+		WillReturnRows(sqlmock.NewRows(userSessionColumns).
+			AddRow(int64(6), int64(1), sql.NullInt64{Int64: 5, Valid: true}, "family-1", "hash", ctx.now.Add(time.Hour), ctx.now,
+				sql.NullString{}, sql.NullString{}, sql.NullString{}, sql.NullTime{}, ctx.now))
+	ctx.mock.ExpectExec("UPDATE user_accounts SET last_login_at = \\?").
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec := ctx.do(http.MethodPost, "/api/v1/auth/refresh", map[string]string{"refreshToken": "token-a"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestRefreshRejectsLockedAndPendingAccounts(t *testing.T) {
+	ctx := newAccountTestContext(t, "Password123456")
+
+	// 被锁定或待激活的账号不能用旧的刷新令牌续期
+	for _, tc := range []struct {
+		status      string
+		lockedUntil sql.NullTime
+		code        int
+		errCode     string
+	}{
+		{status: "locked", code: http.StatusLocked, errCode: "account_locked"},
+		{status: "locked", lockedUntil: sql.NullTime{Time: ctx.now.Add(time.Hour), Valid: true}, code: http.StatusLocked, errCode: "account_locked"},
+		{status: "pending", code: http.StatusForbidden, errCode: "account_pending"},
+	} {
+		ctx.expectSessionByHash("token-a", 5, "family-1", false)
+		ctx.mock.ExpectQuery(selectUserByIDQuery).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows(userAccountColumns).
+				AddRow(int64(1), "123e4567-e89b-12d3-a456-426614174000", ctx.email, ctx.pwdHash, tc.status, "user", tc.lockedUntil, sql.NullTime{}, ctx.now, ctx.now))
+
+		rec := ctx.do(http.MethodPost, "/api/v1/auth/refresh", map[string]string{"refreshToken": "token-a"})
+		requireAccountError(t, rec, tc.code, tc.errCode)
+	}
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ctx := newAccountTestContext(t, "Password123456")
+
+	// 已轮换的令牌再次出现：吊销整个家族并记录安全审计
+	ctx.expectSessionByHash("token-a", 5, "family-1", true)
+	ctx.mock.ExpectQuery(childSessionExistsQuery).
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	ctx.mock.ExpectExec(revokeFamilyQuery).
+		WithArgs(sqlmock.AnyArg(), "family-1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	rec := ctx.do(http.MethodPost, "/api/v1/auth/refresh", map[string]string{"refreshToken": "token-a"})
+	requireAccountError(t, rec, http.StatusUnauthorized, "refresh_token_reused")
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, "refresh_token", cookies[0].Name)
+	require.Empty(t, cookies[0].Value)
+	require.Contains(t, ctx.audit.String(), `"event":"security.refresh_token_reuse"`)
+	require.Contains(t, ctx.audit.String(), `"revokedSessions":2`)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestRefreshConcurrentRotationCountsAsReuse(t *testing.T) {
+	ctx := newAccountTestContext(t, "Password123456")
+
+	// 查询时仍有效，但吊销时已被其他请求轮换
+	ctx.expectSessionByHash("token-a", 5, "family-1", false)
+	ctx.expectUser()
+	ctx.mock.ExpectExec(revokeActiveSessionQuery).
+		WithArgs(sqlmock.AnyArg(), int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	ctx.mock.ExpectExec(revokeFamilyQuery).
+		WithArgs(sqlmock.AnyArg(), "family-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec := ctx.do(http.MethodPost, "/api/v1/auth/refresh", map[string]string{"refreshToken": "token-a"})
+	requireAccountError(t, rec, http.StatusUnauthorized, "refresh_token_reused")
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestRefreshWithLoggedOutTokenDoesNotRevokeFamily(t *testing.T) {
+	ctx := newAccountTestContext(t, "Password123456")
+
+	ctx.expectSessionByHash("token-a", 5, "family-1", true)
+	ctx.mock.ExpectQuery(childSessionExistsQuery).
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	rec := ctx.do(http.MethodPost, "/api/v1/auth/refresh", map[string]string{"refreshToken": "token-a"})
+	requireAccountError(t, rec, http.StatusUnauthorized, "session_not_found")
+	require.False(t, strings.Contains(ctx.audit.String(), "security.refresh_token_reuse"))
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/auth"
+)
+
+const (
+	listActiveSessionsQuery = "SELECT id, user_id, parent_id, family_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, ip_address, user_agent, revoked_at, created_at FROM user_sessions WHERE user_id = \\? AND revoked_at IS NULL AND expires_at > \\?"
+	selectSessionByIDQuery  = "SELECT id, user_id, parent_id, family_id, refresh_token_hash, expires_at, issued_at, client_fingerprint, ip_address, user_agent, revoked_at, created_at FROM user_sessions WHERE id = \\?"
+	revokeSessionQuery      = "UPDATE user_sessions SET revoked_at = \\? WHERE id = \\?"
+)
+
+// useSessionToken swaps the context's access token for one bound to sessionID.
+func (ctx *accountTestContext) useSessionToken(sessionID int64) {
+	tokenManager, err := auth.NewTokenManager("test-secret", time.Minute*5, time.Hour*24*30)
+	require.NoError(ctx.t, err)
+	token, _, err := tokenManager.IssueSessionAccessToken(1, "123e4567-e89b-12d3-a456-426614174000", auth.RoleUser, sessionID)
+	require.NoError(ctx.t, err)
+	ctx.token = token
+}
+
+func (ctx *accountTestContext) sessionRow(rows *sqlmock.Rows, id, userID int64, ip, userAgent string) *sqlmock.Rows {
+	return rows.AddRow(id, userID, sql.NullInt64{}, "family-1", "hash", ctx.now.Add(time.Hour), ctx.now, sql.NullString{},
+		sql.NullString{String: ip, Valid: ip != ""}, sql.NullString{String: userAgent, Valid: userAgent != ""}, sql.NullTime{}, ctx.now)
+}
+
+func TestListSessions(t *testing.T) {
+	ctx := newAccountTestContext(t, "Password123456")
+	ctx.useSessionToken(7)
+
+	rows := sqlmock.NewRows(userSessionColumns)
+	ctx.sessionRow(rows, 7, 1, "203.0.113.7", "Mozilla/5.0")
+	ctx.sessionRow(rows, 8, 1, "198.51.100.2", "")
+	ctx.mock.ExpectQuery(listActiveSessionsQuery).
+		WithArgs(int64(1), sqlmock.AnyArg()).
+		WillReturnRows(rows)
+
+	rec := ctx.do(http.MethodGet, "/api/v1/auth/sessions", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Sessions []struct {
+			ID        int64   `json:"id"`
+			IPAddress *string `json:"ipAddress"`
+			UserAgent *string `json:"userAgent"`
+			Current   bool    `json:"current"`
+		} `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Sessions, 2)
+	require.Equal(t, int64(7), resp.Sessions[0].ID)
+	require.True(t, resp.Sessions[0].Current)
+	require.Equal(t, "203.0.113.7", *resp.Sessions[0].IPAddress)
+	require.Equal(t, "Mozilla/5.0", *resp.Sessions[0].UserAgent)
+	require.False(t, resp.Sessions[1].Current)
+	require.Nil(t, resp.Sessions[1].UserAgent)
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestRevokeSession(t *testing.T) {
+	ctx := newAccountTestContext(t, "Password123456")
+	ctx.useSessionToken(7)
+
+	// 非法 ID
+	rec := ctx.do(http.MethodDelete, "/api/v1/auth/sessions/abc", nil)
+	requireAccountError(t, rec, http.StatusBadRequest, "invalid_id")
+
+	// 其他用户的会话视为不存在
+	ctx.mock.ExpectQuery(selectSessionByIDQuery).
+		WithArgs(int64(99)).
+		WillReturnRows(ctx.sessionRow(sqlmock.NewRows(userSessionColumns), 99, 2, "", ""))
+	rec = ctx.do(http.MethodDelete, "/api/v1/auth/sessions/99", nil)
+	requireAccountError(t, rec, http.StatusNotFound, "session_not_found")
+
+	// 退出指定设备
+	ctx.mock.ExpectQuery(selectSessionByIDQuery).
+		WithArgs(int64(8)).
+		WillReturnRows(ctx.sessionRow(sqlmock.NewRows(userSessionColumns), 8, 1, "", ""))
+	ctx.mock.ExpectExec(revokeSessionQuery).
+		WithArgs(sqlmock.AnyArg(), int64(8)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rec = ctx.do(http.MethodDelete, "/api/v1/auth/sessions/8", nil)
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+	require.Empty(t, rec.Result().Cookies())
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	ctx := newAccountTestContext(t, "Password123456")
+
+	// 旧令牌不含会话 ID 且未提供刷新令牌时无法识别当前设备
+	rec := ctx.do(http.MethodDelete, "/api/v1/auth/sessions", nil)
+	requireAccountError(t, rec, http.StatusBadRequest, "current_session_unknown")
+
+	ctx.useSessionToken(7)
+	ctx.mock.ExpectExec("UPDATE user_sessions SET revoked_at = \\? WHERE user_id = \\? AND id <> \\? AND revoked_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), int64(1), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	rec = ctx.do(http.MethodDelete, "/api/v1/auth/sessions", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.JSONEq(t, `{"revoked":2}`, rec.Body.String())
+
+	require.NoError(t, ctx.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,147 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"online-ppt/internal/auth"
+	"online-ppt/internal/records"
+	"online-ppt/internal/storage"
+)
+
+// TestSQLiteBackend runs the services against a real SQLite file to cover the
+// dialect-specific SQL: generated ids, unique violations, upserts and JSON tags.
+func TestSQLiteBackend(t *testing.T) {
+	ctx := context.Background()
+
+	db, dialect, err := storage.Open(ctx, storage.DriverSQLite, filepath.Join(t.TempDir(), "ppt.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	applied, err := storage.Migrator{Dialect: dialect}.Apply(ctx, db)
+	require.NoError(t, err)
+	require.NotEmpty(t, applied)
+
+	auditLogger := storage.NewAuditLogger(log.New(&bytes.Buffer{}, "", 0))
+	authRepo, err := auth.NewRepository(db)
+	require.NoError(t, err)
+	authRepo.WithDialect(dialect)
+	tokenManager, err := auth.NewTokenManager("test-secret", time.Minute*5, time.Hour*24)
+	require.NoError(t, err)
+	authService, err := auth.NewService(authRepo, tokenManager, auditLogger, newMemoryCache(), fixedCaptcha{}, newRecordingMailer())
+	require.NoError(t, err)
+
+	recordsRepo, err := records.NewRepository(db)
+	require.NoError(t, err)
+	recordsRepo.WithDialect(dialect)
+	recordsService, err := records.NewService(recordsRepo, t.TempDir(), auditLogger)
+	require.NoError(t, err)
+
+	user, err := authService.Register(ctx, "Sqlite@Example.com", "Password123!")
+	require.NoError(t, err)
+	require.NotZero(t, user.ID)
+	_, err = authService.Register(ctx, "sqlite@example.com", "Password123!")
+	require.ErrorIs(t, err, auth.ErrEmailAlreadyRegistered)
+
+	login, err := authService.Login(ctx, auth.LoginRequest{Email: "sqlite@example.com", Password: "Password123!"}, auth.ClientInfo{})
+	require.NoError(t, err)
+	refreshed, err := authService.Refresh(ctx, login.RefreshToken, auth.ClientInfo{})
+	require.NoError(t, err)
+	sessions, err := authService.ListSessions(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.NotEmpty(t, refreshed.AccessToken)
+
+	// 第二次开始绑定走 upsert 覆盖未确认的密钥
+	first, err := authService.BeginMFAEnrollment(ctx, user.ID, "Password123!")
+	require.NoError(t, err)
+	second, err := authService.BeginMFAEnrollment(ctx, user.ID, "Password123!")
+	require.NoError(t, err)
+	require.NotEqual(t, first.Secret, second.Secret)
+
+	tagged, err := recordsService.CreateRecord(ctx, records.CreateParams{
+		UserID:   user.ID,
+		UserUUID: user.UUID,
+		Name:     "quarterly",
+		Tags:     []string{"finance", "q3"},
+	})
+	require.NoError(t, err)
+	_, err = recordsService.CreateRecord(ctx, records.CreateParams{UserID: user.ID, UserUUID: user.UUID, Name: "roadmap"})
+	require.NoError(t, err)
+	_, err = recordsService.CreateRecord(ctx, records.CreateParams{UserID: user.ID, UserUUID: user.UUID, Name: "Roadmap"})
+	require.ErrorIs(t, err, records.ErrDuplicateRecord)
+
+	// 回收站中的 deck 不占用组名，恢复时与同名 deck 冲突；插入失败不留下目录
+	scratch, err := recordsService.CreateRecord(ctx, records.CreateParams{UserID: user.ID, UserUUID: user.UUID, Name: "scratch"})
+	require.NoError(t, err)
+	require.NoError(t, recordsService.DeleteRecord(ctx, user.ID, scratch.Record.ID))
+	_, err = os.Stat(scratch.Record.CanonicalPath)
+	require.True(t, os.IsNotExist(err))
+	replacement, err := recordsService.CreateRecord(ctx, records.CreateParams{UserID: user.ID, UserUUID: user.UUID, Name: "scratch"})
+	require.NoError(t, err)
+	_, err = recordsService.RestoreRecord(ctx, user.ID, scratch.Record.ID)
+	require.ErrorIs(t, err, records.ErrDeckDirectoryExists)
+	require.NoError(t, os.RemoveAll(filepath.Dir(replacement.Record.CanonicalPath)))
+	_, err = recordsService.RestoreRecord(ctx, user.ID, scratch.Record.ID)
+	require.ErrorIs(t, err, records.ErrDuplicateRecord)
+	require.NoError(t, recordsService.DeleteRecord(ctx, user.ID, replacement.Record.ID))
+	_, err = recordsService.RestoreRecord(ctx, user.ID, scratch.Record.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, os.RemoveAll(filepath.Dir(scratch.Record.CanonicalPath)))
+	_, err = recordsService.CreateRecord(ctx, records.CreateParams{UserID: user.ID, UserUUID: user.UUID, Name: "Scratch"})
+	require.ErrorIs(t, err, records.ErrDuplicateRecord)
+	_, err = os.Stat(scratch.Record.CanonicalPath)
+	require.True(t, os.IsNotExist(err))
+
+	result, err := recordsService.ListRecords(ctx, records.ListParams{UserID: user.ID, Filters: records.ListFilters{Tag: "finance"}})
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Total)
+	require.Equal(t, tagged.Record.ID, result.Records[0].Record.ID)
+
+	result, err = recordsService.ListRecords(ctx, records.ListParams{UserID: user.ID})
+	require.NoError(t, err)
+	require.Equal(t, 3, result.Total)
+
+	// 工作区 deck 在创建者被删除后仍归工作区所有，个人 deck 随账号一起删除
+	teammate, err := authService.Register(ctx, "teammate@example.com", "Password123!")
+	require.NoError(t, err)
+	workspace, err := recordsService.CreateWorkspace(ctx, user.ID, "Team")
+	require.NoError(t, err)
+	_, err = recordsService.AddWorkspaceMember(ctx, user.ID, workspace.Workspace.ID, "teammate@example.com", records.WorkspaceRoleAdmin)
+	require.NoError(t, err)
+	teamDeck, err := recordsService.CreateRecord(ctx, records.CreateParams{
+		UserID:      user.ID,
+		UserUUID:    user.UUID,
+		WorkspaceID: workspace.Workspace.ID,
+		Name:        "roadmap",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, authRepo.DeleteUser(ctx, user.ID))
+
+	view, err := recordsService.GetRecord(ctx, teammate.ID, teamDeck.Record.ID)
+	require.NoError(t, err)
+	require.Zero(t, view.Record.UserID)
+	renamed := "Roadmap2027"
+	view, err = recordsService.UpdateRecord(ctx, records.UpdateParams{UserID: teammate.ID, UserUUID: teammate.UUID, RecordID: teamDeck.Record.ID, Name: &renamed})
+	require.NoError(t, err)
+	require.Equal(t, renamed, view.Record.Name)
+	_, err = recordsService.GetRecord(ctx, teammate.ID, tagged.Record.ID)
+	require.ErrorIs(t, err, records.ErrRecordNotFound)
+	var personal int
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM ppt_records WHERE workspace_id IS NULL`).Scan(&personal))
+	require.Zero(t, personal)
+	require.NoError(t, recordsService.DeleteRecord(ctx, teammate.ID, teamDeck.Record.ID))
+
+	rolledBack, err := storage.Migrator{Dialect: dialect}.Rollback(ctx, db, len(applied))
+	require.NoError(t, err)
+	require.Len(t, rolledBack, len(applied))
+}